@@ -3,9 +3,11 @@ package api
 type GetAllResponsePage[T any] struct {
 	Message      string `json:"message"`
 	StatusCode   int    `json:"status_code"`
-	TotalRecords int    `json:"total_records"`
+	TotalRecords *int   `json:"total_records,omitempty"`
 	Limit        int    `json:"limit"`
 	Offset       int    `json:"offset"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
 	Data         []T    `json:"data"`
 }
 type GetResponsePage[T any] struct {
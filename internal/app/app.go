@@ -1,6 +1,8 @@
 package app
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"os"
 
@@ -8,38 +10,78 @@ import (
 	chimiddle "github.com/go-chi/chi/middleware"
 
 	appAttachment "github.com/nevinmanoj/hostmate/internal/app/attachment"
+	appAudit "github.com/nevinmanoj/hostmate/internal/app/audit"
 	appBooking "github.com/nevinmanoj/hostmate/internal/app/booking"
+	appCoupon "github.com/nevinmanoj/hostmate/internal/app/coupon"
+	appIdempotency "github.com/nevinmanoj/hostmate/internal/app/idempotency"
+	appImports "github.com/nevinmanoj/hostmate/internal/app/imports"
+	appInvitation "github.com/nevinmanoj/hostmate/internal/app/invitation"
+	appInvoice "github.com/nevinmanoj/hostmate/internal/app/invoice"
+	appOrganisation "github.com/nevinmanoj/hostmate/internal/app/organisation"
 	appPayemnt "github.com/nevinmanoj/hostmate/internal/app/payment"
 	appProperty "github.com/nevinmanoj/hostmate/internal/app/property"
+	appReport "github.com/nevinmanoj/hostmate/internal/app/report"
+	appSearch "github.com/nevinmanoj/hostmate/internal/app/search"
+	appTax "github.com/nevinmanoj/hostmate/internal/app/tax"
 	appUser "github.com/nevinmanoj/hostmate/internal/app/user"
+	appWellKnown "github.com/nevinmanoj/hostmate/internal/app/wellknown"
 
 	domainAccess "github.com/nevinmanoj/hostmate/internal/domain/access"
 	domainAttachment "github.com/nevinmanoj/hostmate/internal/domain/attachment"
+	domainAudit "github.com/nevinmanoj/hostmate/internal/domain/audit"
 	domainBooking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	domainCoupon "github.com/nevinmanoj/hostmate/internal/domain/coupon"
+	domainIdempotency "github.com/nevinmanoj/hostmate/internal/domain/idempotency"
+	domainImports "github.com/nevinmanoj/hostmate/internal/domain/imports"
+	domainInvitation "github.com/nevinmanoj/hostmate/internal/domain/invitation"
+	domainInvoice "github.com/nevinmanoj/hostmate/internal/domain/invoice"
+	domainOrganisation "github.com/nevinmanoj/hostmate/internal/domain/organisation"
 	domainPayment "github.com/nevinmanoj/hostmate/internal/domain/payment"
 	domainProperty "github.com/nevinmanoj/hostmate/internal/domain/property"
+	domainReport "github.com/nevinmanoj/hostmate/internal/domain/report"
+	domainSearch "github.com/nevinmanoj/hostmate/internal/domain/search"
+	domainTax "github.com/nevinmanoj/hostmate/internal/domain/tax"
 	domainUser "github.com/nevinmanoj/hostmate/internal/domain/user"
 
+	"github.com/nevinmanoj/hostmate/internal/auth"
 	"github.com/nevinmanoj/hostmate/internal/db/azure"
 	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
 	repoAccess "github.com/nevinmanoj/hostmate/internal/db/postgres/access"
+	repoAudit "github.com/nevinmanoj/hostmate/internal/db/postgres/audit"
 	repoBooking "github.com/nevinmanoj/hostmate/internal/db/postgres/booking"
+	repoCoupon "github.com/nevinmanoj/hostmate/internal/db/postgres/coupon"
+	repoIdempotency "github.com/nevinmanoj/hostmate/internal/db/postgres/idempotency"
+	repoInvitation "github.com/nevinmanoj/hostmate/internal/db/postgres/invitation"
+	repoInvoice "github.com/nevinmanoj/hostmate/internal/db/postgres/invoice"
+	repoOrganisation "github.com/nevinmanoj/hostmate/internal/db/postgres/organisation"
 	repoPayment "github.com/nevinmanoj/hostmate/internal/db/postgres/payment"
 	repoProperty "github.com/nevinmanoj/hostmate/internal/db/postgres/property"
+	repoReport "github.com/nevinmanoj/hostmate/internal/db/postgres/report"
+	repoSearch "github.com/nevinmanoj/hostmate/internal/db/postgres/search"
+	repoTax "github.com/nevinmanoj/hostmate/internal/db/postgres/tax"
 	repoUser "github.com/nevinmanoj/hostmate/internal/db/postgres/user"
 
+	"github.com/nevinmanoj/hostmate/internal/mail"
 	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+	"github.com/nevinmanoj/hostmate/internal/pdf"
 )
 
 func Start() error {
 	//Router and db connection
 	var r *chi.Mux = chi.NewRouter()
 
-	//get connection strings and jwt secret
+	//get connection strings and jwt signing keys
 	dsn := os.Getenv("DATABASE_URL")
 	azurestr := os.Getenv("AZURE_STORAGE_CONNECTION_STRING")
-	jwtSecret := os.Getenv("JWT_SECRET")
-	jwtSecretbyte := []byte(jwtSecret)
+	jwtKeysDir := os.Getenv("JWT_KEYS_DIR")
+	jwtActiveKID := os.Getenv("JWT_ACTIVE_KID")
+	baseURL := os.Getenv("APP_BASE_URL")
+	oidcIssuer := os.Getenv("OIDC_ISSUER")
+	//proxies allowed to forward the client address, comma separated CIDRs
+	trustedProxies, err := middleware.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return err
+	}
 
 	//postgres
 	dbConn := postgres.NewPostgres(dsn)
@@ -50,46 +92,196 @@ func Start() error {
 		return err
 	}
 
+	//JWT keys, rotate by adding a new <kid>.pem and switching JWT_ACTIVE_KID.
+	//An ephemeral key signs out everyone on restart and differs between replicas,
+	//so it is only used when explicitly asked for in development.
+	var jwtKeys *auth.KeySet
+	switch {
+	case jwtKeysDir != "":
+		jwtKeys, err = auth.LoadKeySet(jwtKeysDir, jwtActiveKID)
+	case os.Getenv("JWT_EPHEMERAL_KEYS") == "true":
+		log.Println("JWT_EPHEMERAL_KEYS set, using an ephemeral signing key")
+		jwtKeys, err = auth.NewEphemeralKeySet()
+	default:
+		err = errors.New("JWT_KEYS_DIR is not set, set JWT_EPHEMERAL_KEYS=true to use a throwaway key in development")
+	}
+	if err != nil {
+		return err
+	}
+
 	// Global middleware
 	r.Use(chimiddle.StripSlashes)
-
-	//auth middleware
-	authMiddleware := middleware.Authorization(jwtSecretbyte)
+	r.Use(middleware.RealIP(trustedProxies))
 
 	//Blob storage
 	blobStorage := azure.NewBlobStorage(azureBlobClient)
 
+	//Mail
+	mailer := mail.NewLogMailer()
+
+	//OIDC single sign-on, disabled unless an issuer is configured
+	ssoConfig := domainUser.SSOConfig{AllowedDomain: os.Getenv("OIDC_ALLOWED_DOMAIN")}
+	if oidcIssuer != "" {
+		ssoConfig.Provider = auth.NewOIDCProvider(
+			oidcIssuer,
+			os.Getenv("OIDC_CLIENT_ID"),
+			os.Getenv("OIDC_CLIENT_SECRET"),
+			os.Getenv("OIDC_REDIRECT_URL"),
+		)
+	}
+
 	//Repos
 	userReadRepo := repoUser.NewUserReadRepository(dbConn)
 	userWriteRepo := repoUser.NewUserWriteRepository(dbConn)
+	loginAttemptRepo := repoUser.NewLoginAttemptRepository(dbConn)
+	identityRepo := repoUser.NewIdentityRepository(dbConn)
+	apiKeyRepo := repoUser.NewAPIKeyRepository(dbConn)
 	accessRepo := repoAccess.NewAccessRepository(dbConn)
+	organisationRepo := repoOrganisation.NewOrganisationRepository(dbConn)
+	auditRepo := repoAudit.NewAuditRepository(dbConn)
 	propertyReadRepo := repoProperty.NewPropertyReadRepository(dbConn)
 	propertyWriteRepo := repoProperty.NewPropertyWriteRepository(dbConn)
 	bookingReadRepo := repoBooking.NewBookingReadRepository(dbConn)
 	bookingWriteRepo := repoBooking.NewBookingWriteRepository(dbConn)
 	paymentWriteRepo := repoPayment.NewPaymentWriteRepository(dbConn)
+	paymentReadRepo := repoPayment.NewPaymentReadRepository(dbConn)
+	invitationWriteRepo := repoInvitation.NewInvitationWriteRepository(dbConn)
+	idempotencyRepo := repoIdempotency.NewIdempotencyRepository(dbConn)
+	searchRepo := repoSearch.NewSearchRepository(dbConn)
+	reportRepo := repoReport.NewReportRepository(dbConn)
+	documentRepo := repoInvoice.NewDocumentRepository(dbConn)
+	taxRepo := repoTax.NewTaxRepository(dbConn)
+	couponRepo := repoCoupon.NewCouponRepository(dbConn)
+	transactor := postgres.NewTransactor(dbConn)
 
 	//Services
-	userService := domainUser.NewUserService(userWriteRepo, jwtSecretbyte)
 	accessService := domainAccess.NewAccessService(accessRepo)
-	propertyService := domainProperty.NewPropertyService(propertyWriteRepo, userReadRepo, accessService)
-	bookingService := domainBooking.NewBookingService(bookingWriteRepo, propertyReadRepo, accessService)
-	paymentService := domainPayment.NewPaymentService(paymentWriteRepo, accessService, userReadRepo, bookingReadRepo, propertyReadRepo)
-	attachmentService := domainAttachment.NewAttachmentService(accessService, blobStorage, paymentService, bookingService)
+	auditService := domainAudit.NewAuditService(auditRepo, organisationRepo, accessService)
+	userService := domainUser.NewUserService(userWriteRepo, loginAttemptRepo, identityRepo, apiKeyRepo, accessService, organisationRepo, mailer, jwtKeys, ssoConfig, baseURL)
+	propertyService := domainProperty.NewPropertyService(propertyWriteRepo, accessService, auditService, transactor)
+	taxService := domainTax.NewTaxService(taxRepo, propertyReadRepo, accessService, auditService, transactor)
+	couponService := domainCoupon.NewCouponService(couponRepo, propertyReadRepo, accessService, auditService, transactor)
+	bookingService := domainBooking.NewBookingService(bookingWriteRepo, propertyReadRepo, taxRepo, couponRepo, accessService, auditService, transactor)
+	paymentService := domainPayment.NewPaymentService(paymentWriteRepo, accessService, userReadRepo, bookingReadRepo, propertyReadRepo, auditService, transactor)
+	attachmentService := domainAttachment.NewAttachmentService(accessService, blobStorage, paymentService, bookingService, auditService, transactor)
+	organisationService := domainOrganisation.NewOrganisationService(organisationRepo, jwtKeys)
+	idempotencyService := domainIdempotency.NewIdempotencyService(idempotencyRepo)
+	searchService := domainSearch.NewSearchService(searchRepo)
+	reportService := domainReport.NewReportService(reportRepo)
+	importService := domainImports.NewImportService(transactor, bookingService, paymentService, propertyReadRepo, bookingReadRepo, accessService)
+	invoiceService := domainInvoice.NewInvoiceService(documentRepo, transactor, pdf.NewRenderer(), blobStorage, bookingReadRepo, paymentReadRepo, propertyReadRepo, organisationRepo, taxRepo, accessService, auditService)
+	invitationService := domainInvitation.NewInvitationService(invitationWriteRepo, propertyWriteRepo, userWriteRepo, organisationRepo, accessService, transactor, mailer, jwtKeys, baseURL)
+
+	//auth middleware, accepts session tokens and personal api keys of current organisation members
+	authMiddleware := middleware.Authorization(jwtKeys, userService, userService, accessService)
+	//managing credentials needs a session, an api key cannot mint keys or change two factor settings
+	sessionMiddleware := middleware.AuthorizationForPurpose(jwtKeys, userService, accessService, "")
+	//two factor enrolment also accepts the enrolment token issued when 2FA is mandatory
+	enrolmentMiddleware := middleware.AuthorizationForPurpose(jwtKeys, userService, accessService, "", auth.PurposeTwoFactorEnrolment)
+	//create endpoints replay the first response for a repeated Idempotency-Key, runs after auth
+	idempotencyMiddleware := appIdempotency.Middleware(idempotencyService, 1<<20)
+	importIdempotencyMiddleware := appIdempotency.Middleware(idempotencyService, appImports.MaxUploadSize)
 
 	//Handlers
 	userHandler := appUser.NewUserHandler(userService)
 	propertyHandler := appProperty.NewPropertyHandler(propertyService)
+	taxHandler := appTax.NewTaxHandler(taxService)
 	bookingHandler := appBooking.NewBookingHandler(bookingService)
+	couponHandler := appCoupon.NewCouponHandler(couponService)
 	paymentHandler := appPayemnt.NewPaymentHandler(paymentService)
 	attachmentHandler := appAttachment.NewAttachmentHandler(attachmentService)
+	invitationHandler := appInvitation.NewInvitationHandler(invitationService)
+	organisationHandler := appOrganisation.NewOrganisationHandler(organisationService)
+	auditHandler := appAudit.NewAuditHandler(auditService)
+	searchHandler := appSearch.NewSearchHandler(searchService)
+	reportHandler := appReport.NewReportHandler(reportService)
+	importHandler := appImports.NewImportHandler(importService)
+	invoiceHandler := appInvoice.NewInvoiceHandler(invoiceService)
+	wellKnownHandler := appWellKnown.NewWellKnownHandler(jwtKeys)
+
+	//Public keys for services verifying hostmate tokens
+	r.Get("/.well-known/jwks.json", wellKnownHandler.JWKS)
 
 	//User routes
 	r.Route("/users", func(router chi.Router) {
-		router.Get("/{userId}", userHandler.GetUser)
 		router.Post("/login", userHandler.LoginUser)
 		router.Post("/register", userHandler.CreateUser)
+		router.Post("/login/2fa", userHandler.VerifyTwoFactor)
+		router.Get("/oidc/login", userHandler.BeginSSOLogin)
+		router.Get("/oidc/callback", userHandler.CompleteSSOLogin)
+		router.Get("/email/confirm", userHandler.LookupEmailChange)
+		router.Post("/email/confirm", userHandler.ConfirmEmailChange)
+
+		router.Group(func(router chi.Router) {
+			router.Use(enrolmentMiddleware)
+			router.Post("/me/2fa/enroll", userHandler.BeginTwoFactorEnrolment)
+			router.Post("/me/2fa/enroll/confirm", userHandler.ConfirmTwoFactorEnrolment)
+		})
+		router.Group(func(router chi.Router) {
+			router.Use(sessionMiddleware)
+			router.Put("/me", userHandler.UpdateProfile)
+			router.Post("/me/email", userHandler.RequestEmailChange)
+			router.Post("/me/password", userHandler.ChangePassword)
+			router.Post("/me/deactivate", userHandler.Deactivate)
+			router.Post("/me/2fa/disable", userHandler.DisableTwoFactor)
+			router.Post("/me/2fa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+			router.Get("/me/api-keys", userHandler.GetAPIKeys)
+			router.Post("/me/api-keys", userHandler.CreateAPIKey)
+			router.Post("/me/api-keys/{keyId}/revoke", userHandler.RevokeAPIKey)
+		})
+		router.Group(func(router chi.Router) {
+			router.Use(authMiddleware)
+			router.Get("/", userHandler.SearchUsers)
+			router.Get("/{userId}", userHandler.GetUser)
+			router.Get("/me", userHandler.GetProfile)
+			router.Get("/me/logins", userHandler.GetLoginHistory)
+		})
+	})
+
+	//organisation routes, current is the organisation the session or api key acts in
+	r.Route("/organisations", func(router chi.Router) {
+		router.Group(func(router chi.Router) {
+			router.Use(authMiddleware)
+			router.Get("/", organisationHandler.GetOrganisations)
+			router.Get("/current", organisationHandler.GetCurrentOrganisation)
+			router.Get("/current/members", organisationHandler.GetMembers)
+			router.Get("/current/security-policy", userHandler.GetSecurityPolicy)
+		})
+		//membership changes and switching, which issues a token, need a session
+		router.Group(func(router chi.Router) {
+			router.Use(sessionMiddleware)
+			router.Post("/", organisationHandler.CreateOrganisation)
+			router.Put("/current/security-policy", userHandler.UpdateSecurityPolicy)
+			router.Put("/current/members/{userId}", organisationHandler.UpdateMemberRole)
+			router.Delete("/current/members/{userId}", organisationHandler.RemoveMember)
+			router.Post("/{organisationId}/switch", organisationHandler.SwitchOrganisation)
+		})
+	})
 
+	//audit routes, the full log is for organisation owners and admins
+	r.Route("/audit", func(router chi.Router) {
+		router.Use(authMiddleware)
+		router.Get("/", auditHandler.GetAuditLog)
+		router.Get("/{entityType}/{entityId}", auditHandler.GetEntityHistory)
+	})
+
+	//search routes, results are limited to what the user can see in the lists
+	r.Route("/search", func(router chi.Router) {
+		router.Use(authMiddleware)
+		router.Get("/", searchHandler.Search)
+	})
+
+	//report routes, only properties the user can see the financials of are reported on
+	r.Route("/reports", func(router chi.Router) {
+		router.Use(authMiddleware)
+		router.Get("/performance", reportHandler.GetPerformance)
+		router.Get("/tax", reportHandler.GetTaxSummary)
+	})
+
+	//import routes, rows are created through the booking and payment services
+	r.Route("/imports", func(router chi.Router) {
+		router.Use(authMiddleware)
+		router.With(importIdempotencyMiddleware).Post("/", importHandler.Import)
 	})
 
 	//Property routes
@@ -97,10 +289,28 @@ func Start() error {
 		router.Use(authMiddleware)
 		router.Get("/", propertyHandler.GetProperties)
 		router.Get("/{propertyId}", propertyHandler.GetProperty)
-		router.Post("/", propertyHandler.CreateProperty)
+		router.With(idempotencyMiddleware).Post("/", propertyHandler.CreateProperty)
 		router.Put("/{propertyId}", propertyHandler.UpdateProperty)
+		router.Patch("/{propertyId}", propertyHandler.PatchProperty)
+		router.Delete("/{propertyId}", propertyHandler.DeleteProperty)
+		router.Post("/{propertyId}/restore", propertyHandler.RestoreProperty)
+		router.Get("/{propertyId}/tax", taxHandler.GetTaxConfig)
+		router.Put("/{propertyId}/tax", taxHandler.PutTaxConfig)
+		router.Get("/{propertyId}/members", propertyHandler.GetPropertyMembers)
+		router.Put("/{propertyId}/members/{userId}", propertyHandler.SetPropertyMember)
+		router.Delete("/{propertyId}/members/{userId}", propertyHandler.RemovePropertyMember)
 		router.Get("/{propertyId}/availability", bookingHandler.CheckAvailability)
 		router.Get("/{propertyId}/payments", paymentHandler.GetPaymentsWithPropertyId)
+		router.Get("/{propertyId}/invitations", invitationHandler.GetInvitations)
+		router.Post("/{propertyId}/invitations", invitationHandler.CreateInvitation)
+		router.Post("/{propertyId}/invitations/{invitationId}/resend", invitationHandler.ResendInvitation)
+		router.Post("/{propertyId}/invitations/{invitationId}/revoke", invitationHandler.RevokeInvitation)
+	})
+
+	//invitation routes, accepting may register the invited user so it is public
+	r.Route("/invitations", func(router chi.Router) {
+		router.Get("/accept", invitationHandler.LookupInvitation)
+		router.Post("/accept", invitationHandler.AcceptInvitation)
 	})
 
 	//booking routes
@@ -109,12 +319,31 @@ func Start() error {
 		router.Use(authMiddleware)
 		router.Get("/", bookingHandler.GetBookings)
 		router.Get("/{bookingId}", bookingHandler.GetBooking)
-		router.Post("/", bookingHandler.CreateBooking)
+		router.With(idempotencyMiddleware).Post("/", bookingHandler.CreateBooking)
 		router.Put("/{bookingId}", bookingHandler.UpdateBooking)
+		router.Patch("/{bookingId}", bookingHandler.PatchBooking)
+		router.Delete("/{bookingId}", bookingHandler.DeleteBooking)
+		router.Post("/{bookingId}/restore", bookingHandler.RestoreBooking)
+		router.Get("/{bookingId}/history", bookingHandler.GetBookingHistory)
+		router.Get("/{bookingId}/adjustments", bookingHandler.GetAdjustments)
+		router.With(idempotencyMiddleware).Post("/{bookingId}/adjustments", bookingHandler.AddAdjustment)
+		router.Delete("/{bookingId}/adjustments/{adjustmentId}", bookingHandler.RemoveAdjustment)
+		router.Post("/{bookingId}/invoice", invoiceHandler.IssueInvoice)
+		router.Get("/{bookingId}/documents", invoiceHandler.GetBookingDocuments)
 		router.Get("/{id}/attachments", attachmentHandler.ListForBooking)
 		router.Get("/{bookingId}/payments", paymentHandler.GetPaymentsWithBookingId)
-		router.Post("/{bookingId}/payments", paymentHandler.CreatePayment)
+		router.With(idempotencyMiddleware).Post("/{bookingId}/payments", paymentHandler.CreatePayment)
 		router.Put("/{bookingId}/payments/{paymentId}", paymentHandler.UpdatePayment)
+		router.Patch("/{bookingId}/payments/{paymentId}", paymentHandler.PatchPayment)
+	})
+
+	//coupon routes, codes are organisation wide and applied as booking adjustments
+	r.Route("/coupons", func(router chi.Router) {
+		router.Use(authMiddleware)
+		router.Get("/", couponHandler.GetCoupons)
+		router.With(idempotencyMiddleware).Post("/", couponHandler.CreateCoupon)
+		router.Post("/{couponId}/deactivate", couponHandler.DeactivateCoupon)
+		router.Post("/{couponId}/activate", couponHandler.ActivateCoupon)
 	})
 
 	//Payment routes
@@ -122,10 +351,19 @@ func Start() error {
 		router.Use(authMiddleware)
 		router.Get("/", paymentHandler.GetPayments)
 		router.Get("/{paymentId}", paymentHandler.GetPayment)
+		router.Delete("/{paymentId}", paymentHandler.DeletePayment)
+		router.Post("/{paymentId}/restore", paymentHandler.RestorePayment)
+		router.Post("/{paymentId}/receipt", invoiceHandler.IssueReceipt)
 		router.Get("/{id}/attachments", attachmentHandler.ListForPayment)
 
 	})
 
+	//invoice and receipt pdfs
+	r.Route("/documents", func(router chi.Router) {
+		router.Use(authMiddleware)
+		router.Get("/{documentId}/download", invoiceHandler.DownloadDocument)
+	})
+
 	// attachments routes
 	r.Route("/attachments", func(router chi.Router) {
 		router.Use(authMiddleware)
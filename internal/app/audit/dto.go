@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"time"
+
+	audit "github.com/nevinmanoj/hostmate/internal/domain/audit"
+)
+
+type AuditEntryResponse struct {
+	ID         int64            `json:"id"`
+	ActorID    int64            `json:"actor_id"`
+	EntityType audit.EntityType `json:"entity_type"`
+	EntityID   int64            `json:"entity_id"`
+	Action     audit.Action     `json:"action"`
+	Changes    audit.Changes    `json:"changes"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+func ToAuditEntryResponse(e *audit.Entry) AuditEntryResponse {
+	return AuditEntryResponse{
+		ID:         e.ID,
+		ActorID:    e.ActorID,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     e.Action,
+		Changes:    e.Changes,
+		CreatedAt:  e.CreatedAt,
+	}
+}
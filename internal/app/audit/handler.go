@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	audit "github.com/nevinmanoj/hostmate/internal/domain/audit"
+)
+
+type AuditHandler struct {
+	service audit.AuditService
+}
+
+func NewAuditHandler(s audit.AuditService) *AuditHandler {
+	return &AuditHandler{service: s}
+}
+
+func (h *AuditHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetAuditLog::Fetching audit log")
+	w.Header().Set("Content-Type", "application/json")
+	filter, badRequestError := parseAuditFilter(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	result, total, err := h.service.GetAll(r.Context(), filter)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = toAuditPage(result, total, filter.Limit, filter.Offset)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *AuditHandler) GetEntityHistory(w http.ResponseWriter, r *http.Request) {
+	entityTypeStr := chi.URLParam(r, "entityType")
+	entityIdStr := chi.URLParam(r, "entityId")
+	log.Printf("HandlerGetEntityHistory::Fetching history of %s %s", entityTypeStr, entityIdStr)
+	w.Header().Set("Content-Type", "application/json")
+	entityType, err := parseEntityType(entityTypeStr)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "entityType",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	entityId, err := strconv.ParseInt(entityIdStr, 10, 64)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "entityId",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	limit, offset, badRequestError := parsePagination(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	result, total, err := h.service.GetHistory(r.Context(), entityType, entityId, limit, offset)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = toAuditPage(result, total, limit, offset)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// helpers
+func toAuditPage(entries []audit.Entry, total, limit, offset int) GetAllResponsePage[AuditEntryResponse] {
+	entryResponses := make([]AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		entryResponses = append(entryResponses, ToAuditEntryResponse(&entry))
+	}
+	return GetAllResponsePage[AuditEntryResponse]{
+		StatusCode:   200,
+		Message:      "Audit entries fetched successfully",
+		TotalRecords: &total,
+		Limit:        limit,
+		Offset:       offset,
+		Data:         entryResponses,
+	}
+}
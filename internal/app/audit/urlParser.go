@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
+	audit "github.com/nevinmanoj/hostmate/internal/domain/audit"
+)
+
+func parseAuditFilter(q url.Values) (audit.AuditFilter, *errMap.BadRequestError) {
+	var f audit.AuditFilter
+
+	if v := q.Get("entity_type"); v != "" {
+		entityType, err := parseEntityType(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "entity_type",
+				Reason: err.Error(),
+			}
+		}
+		f.EntityType = &entityType
+	}
+	if v := q.Get("entity_id"); v != "" {
+		entityID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "entity_id",
+				Reason: err.Error(),
+			}
+		}
+		f.EntityID = &entityID
+	}
+	if v := q.Get("actor_id"); v != "" {
+		actorID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "actor_id",
+				Reason: err.Error(),
+			}
+		}
+		f.ActorID = &actorID
+	}
+	if v := q.Get("action"); v != "" {
+		for _, a := range strings.Split(v, ",") {
+			action := audit.Action(strings.ToLower(strings.TrimSpace(a)))
+			if !action.Valid() {
+				return f, &errMap.BadRequestError{
+					Param:  "action",
+					Reason: fmt.Sprintf("invalid action: %s", a),
+				}
+			}
+			f.Action = append(f.Action, action)
+		}
+	}
+	if v := q.Get("from"); v != "" {
+		from, err := httputil.ParseDatePtr(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "from",
+				Reason: "invalid date format, expected YYYY-MM-DD",
+			}
+		}
+		f.From = from
+	}
+	if v := q.Get("to"); v != "" {
+		to, err := httputil.ParseDatePtr(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "to",
+				Reason: "invalid date format, expected YYYY-MM-DD",
+			}
+		}
+		// the whole day is included
+		next := to.AddDate(0, 0, 1)
+		f.To = &next
+	}
+
+	limit, offset, badRequestError := parsePagination(q)
+	if badRequestError != nil {
+		return f, badRequestError
+	}
+	f.Limit = limit
+	f.Offset = offset
+
+	return f, nil
+}
+
+func parsePagination(q url.Values) (int, int, *errMap.BadRequestError) {
+	// Pagination defaults
+	limit := 100
+	offset := 0
+
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, &errMap.BadRequestError{
+				Param:  "limit",
+				Reason: err.Error(),
+			}
+		} else if l > 0 && l < 100 {
+			limit = l
+		}
+	}
+
+	if v := q.Get("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, &errMap.BadRequestError{
+				Param:  "offset",
+				Reason: err.Error(),
+			}
+		} else if o > 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func parseEntityType(v string) (audit.EntityType, error) {
+	entityType := audit.EntityType(strings.ToLower(v))
+	if !entityType.Valid() {
+		return "", fmt.Errorf("invalid entity type: %s", v)
+	}
+	return entityType, nil
+}
@@ -3,6 +3,8 @@ package booking
 import (
 	"time"
 
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
 	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
 )
 
@@ -38,7 +40,52 @@ type UpdateBookingRequest struct {
 	CreatedBy         int64                 `json:"created_by"`
 	UpdatedBy         int64                 `json:"updated_by"`
 	Remarks           string                `json:"remarks"`
+	Reason            string                `json:"reason" validate:"max=500"`
 }
+
+// ToUpdateBookingRequest renders the stored booking as the document a merge patch is applied to
+func ToUpdateBookingRequest(b *booking.Booking) UpdateBookingRequest {
+	return UpdateBookingRequest{
+		ID:                b.ID,
+		PropertyID:        b.PropertyID,
+		ManagerID:         b.ManagerID,
+		GuestPhone:        b.GuestPhone,
+		GuestName:         b.GuestName,
+		BaseRate:          b.BaseRate,
+		MaxGuestsBase:     b.MaxGuestsBase,
+		ExtraRatePerGuest: b.ExtraRatePerGuest,
+		NumGuests:         b.NumGuests,
+		Status:            b.Status,
+		CheckInDate:       b.CheckInDate,
+		CheckOutDate:      b.CheckOutDate,
+		CreatedAt:         b.CreatedAt,
+		UpdatedAt:         b.UpdatedAt,
+		CreatedBy:         b.CreatedBy,
+		UpdatedBy:         b.UpdatedBy,
+		Remarks:           b.Remarks,
+	}
+}
+
+// ToBooking keeps only the fields a client may change, server owned fields are set by the service
+func ToBooking(req *UpdateBookingRequest, version int) booking.Booking {
+	return booking.Booking{
+		ID:                req.ID,
+		PropertyID:        req.PropertyID,
+		ManagerID:         req.ManagerID,
+		GuestPhone:        req.GuestPhone,
+		GuestName:         req.GuestName,
+		BaseRate:          req.BaseRate,
+		MaxGuestsBase:     req.MaxGuestsBase,
+		ExtraRatePerGuest: req.ExtraRatePerGuest,
+		NumGuests:         req.NumGuests,
+		Status:            req.Status,
+		CheckInDate:       req.CheckInDate,
+		CheckOutDate:      req.CheckOutDate,
+		Remarks:           req.Remarks,
+		Version:           version,
+	}
+}
+
 type BookingResponse struct {
 	ID                int64                 `json:"id"`
 	PropertyID        int64                 `json:"property_id"`
@@ -57,6 +104,14 @@ type BookingResponse struct {
 	CreatedBy         int64                 `json:"created_by"`
 	UpdatedBy         int64                 `json:"updated_by"`
 	Remarks           string                `json:"remarks"`
+	DiscountAmount    float64               `json:"discount_amount"`
+	ChargeAmount      float64               `json:"charge_amount"`
+	Tax               TaxResponse           `json:"tax"`
+	TotalAmount       float64               `json:"total_amount"`
+	Revision          int                   `json:"revision"`
+	Version           int                   `json:"version"`
+	DeletedAt         *time.Time            `json:"deleted_at,omitempty"`
+	DeletedBy         *int64                `json:"deleted_by,omitempty"`
 }
 
 func ToBookingResponse(b *booking.Booking) BookingResponse {
@@ -78,5 +133,172 @@ func ToBookingResponse(b *booking.Booking) BookingResponse {
 		CreatedBy:         b.CreatedBy,
 		UpdatedBy:         b.UpdatedBy,
 		Remarks:           b.Remarks,
+		DiscountAmount:    b.DiscountAmount,
+		ChargeAmount:      b.ChargeAmount,
+		Tax: TaxResponse{
+			Rate:          b.TaxRate,
+			Inclusive:     b.TaxInclusive,
+			TaxableAmount: b.TaxableAmount,
+			CGST:          b.CGST,
+			SGST:          b.SGST,
+			IGST:          b.IGST,
+		},
+		TotalAmount: b.TotalAmount,
+		Revision:    b.Revision,
+		Version:     b.Version,
+		DeletedAt:   b.DeletedAt,
+		DeletedBy:   b.DeletedBy,
+	}
+}
+
+// TaxResponse is the GST on the stay, inclusive means it is part of the quoted rates
+type TaxResponse struct {
+	Rate          float64 `json:"rate"`
+	Inclusive     bool    `json:"inclusive"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	CGST          float64 `json:"cgst"`
+	SGST          float64 `json:"sgst"`
+	IGST          float64 `json:"igst"`
+}
+
+type BookingRevisionResponse struct {
+	Revision          int                   `json:"revision"`
+	PropertyID        int64                 `json:"property_id"`
+	ManagerID         int64                 `json:"manager_id"`
+	GuestPhone        string                `json:"guest_phone"`
+	GuestName         string                `json:"guest_name"`
+	BaseRate          float64               `json:"base_rate"`
+	MaxGuestsBase     int                   `json:"max_guests_base"`
+	ExtraRatePerGuest float64               `json:"extra_rate_per_guest"`
+	NumGuests         int                   `json:"num_guests"`
+	Status            booking.BookingStatus `json:"status"`
+	CheckInDate       time.Time             `json:"check_in_date"`
+	CheckOutDate      time.Time             `json:"check_out_date"`
+	Remarks           string                `json:"remarks"`
+	Reason            string                `json:"reason,omitempty"`
+	Changes           audit.Changes         `json:"changes,omitempty"`
+	ChangedBy         int64                 `json:"changed_by"`
+	CreatedAt         time.Time             `json:"created_at"`
+}
+
+func ToBookingRevisionResponse(r *booking.BookingRevision) BookingRevisionResponse {
+	return BookingRevisionResponse{
+		Revision:          r.Revision,
+		PropertyID:        r.PropertyID,
+		ManagerID:         r.ManagerID,
+		GuestPhone:        r.GuestPhone,
+		GuestName:         r.GuestName,
+		BaseRate:          r.BaseRate,
+		MaxGuestsBase:     r.MaxGuestsBase,
+		ExtraRatePerGuest: r.ExtraRatePerGuest,
+		NumGuests:         r.NumGuests,
+		Status:            r.Status,
+		CheckInDate:       r.CheckInDate,
+		CheckOutDate:      r.CheckOutDate,
+		Remarks:           r.Remarks,
+		Reason:            r.Reason,
+		Changes:           r.Changes,
+		ChangedBy:         r.ChangedBy,
+		CreatedAt:         r.CreatedAt,
+	}
+}
+
+var bookingExportHeader = []string{
+	"Booking ID",
+	"Property ID",
+	"Guest Name",
+	"Guest Phone",
+	"Check In",
+	"Check Out",
+	"Guests",
+	"Base Rate",
+	"Base Guests",
+	"Extra Rate Per Guest",
+	"Status",
+	"Remarks",
+	"Discount Amount",
+	"Charge Amount",
+	"Taxable Amount",
+	"GST Rate",
+	"CGST",
+	"SGST",
+	"IGST",
+	"Total Amount",
+	"Created At",
+	"Updated At",
+}
+
+func ToBookingExportRow(b *booking.Booking) []export.Cell {
+	return []export.Cell{
+		export.Int(b.ID),
+		export.Int(b.PropertyID),
+		export.Text(b.GuestName),
+		export.Text(b.GuestPhone),
+		export.Date(b.CheckInDate),
+		export.Date(b.CheckOutDate),
+		export.Int(int64(b.NumGuests)),
+		export.Money(b.BaseRate),
+		export.Int(int64(b.MaxGuestsBase)),
+		export.Money(b.ExtraRatePerGuest),
+		export.Text(string(b.Status)),
+		export.Text(b.Remarks),
+		export.Money(b.DiscountAmount),
+		export.Money(b.ChargeAmount),
+		export.Money(b.TaxableAmount),
+		export.Money(b.TaxRate),
+		export.Money(b.CGST),
+		export.Money(b.SGST),
+		export.Money(b.IGST),
+		export.Money(b.TotalAmount),
+		export.DateTime(b.CreatedAt),
+		export.DateTime(b.UpdatedAt),
+	}
+}
+
+// AdjustmentRequest is either a coupon code, which decides the discount itself,
+// or a kind, basis and value with the reason for it
+type AdjustmentRequest struct {
+	Kind       booking.AdjustmentKind  `json:"kind"`
+	Basis      booking.AdjustmentBasis `json:"basis"`
+	Value      float64                 `json:"value"`
+	CouponCode *string                 `json:"coupon_code"`
+	Reason     string                  `json:"reason" validate:"max=500"`
+}
+
+func ToAdjustment(req *AdjustmentRequest) booking.Adjustment {
+	return booking.Adjustment{
+		Kind:       req.Kind,
+		Basis:      req.Basis,
+		Value:      req.Value,
+		CouponCode: req.CouponCode,
+		Reason:     req.Reason,
+	}
+}
+
+type AdjustmentResponse struct {
+	ID         int64                   `json:"id"`
+	BookingID  int64                   `json:"booking_id"`
+	Kind       booking.AdjustmentKind  `json:"kind"`
+	Basis      booking.AdjustmentBasis `json:"basis"`
+	Value      float64                 `json:"value"`
+	CouponID   *int64                  `json:"coupon_id,omitempty"`
+	CouponCode *string                 `json:"coupon_code,omitempty"`
+	Reason     string                  `json:"reason"`
+	CreatedBy  int64                   `json:"created_by"`
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+func ToAdjustmentResponse(a *booking.Adjustment) AdjustmentResponse {
+	return AdjustmentResponse{
+		ID:         a.ID,
+		BookingID:  a.BookingID,
+		Kind:       a.Kind,
+		Basis:      a.Basis,
+		Value:      a.Value,
+		CouponID:   a.CouponID,
+		CouponCode: a.CouponCode,
+		Reason:     a.Reason,
+		CreatedBy:  a.CreatedBy,
+		CreatedAt:  a.CreatedAt,
 	}
 }
@@ -13,7 +13,10 @@ import (
 
 	. "github.com/nevinmanoj/hostmate/api"
 	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
+	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
 	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 )
 
 type BookingHandler struct {
@@ -36,7 +39,11 @@ func (h *BookingHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, total, err := h.service.GetAll(r.Context(), filter)
+	if format := export.Negotiate(r); format != export.FormatNone {
+		h.exportBookings(w, r, filter, format)
+		return
+	}
+	result, total, cursors, err := h.service.GetAll(r.Context(), filter)
 	w.Header().Set("Content-Type", "application/json")
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
@@ -45,18 +52,45 @@ func (h *BookingHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
 		for _, booking := range result {
 			bookingResponses = append(bookingResponses, ToBookingResponse(&booking))
 		}
+		// the total is left out when the caller skipped counting
+		var totalRecords *int
+		if !filter.SkipCount {
+			totalRecords = &total
+		}
 		resp = GetAllResponsePage[BookingResponse]{
 			StatusCode:   200,
 			Message:      "Bookings fetched successfully",
-			TotalRecords: total,
+			TotalRecords: totalRecords,
 			Limit:        filter.Limit,
 			Offset:       filter.Offset,
+			NextCursor:   cursors.Next,
+			PrevCursor:   cursors.Prev,
 			Data:         bookingResponses,
 		}
 	}
 	json.NewEncoder(w).Encode(resp)
 }
 
+// exportBookings streams every booking matching the filter instead of one page
+func (h *BookingHandler) exportBookings(w http.ResponseWriter, r *http.Request, filter booking.BookingFilter, format export.Format) {
+	log.Println("HandlerExportBookings::Exporting bookings as", format)
+	filter.Limit = export.BatchSize
+	filter.Offset = 0
+	filter.SkipCount = true
+	err := export.Stream(w, format, "bookings", bookingExportHeader, filter.Sort,
+		func(cursor *pagination.Cursor) ([]booking.Booking, pagination.Cursors, error) {
+			filter.Cursor = cursor
+			result, _, cursors, err := h.service.GetAll(r.Context(), filter)
+			return result, cursors, err
+		},
+		ToBookingExportRow,
+	)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+	}
+}
+
 func (h *BookingHandler) GetBooking(w http.ResponseWriter, r *http.Request) {
 
 	idStr := chi.URLParam(r, "bookingId")
@@ -76,6 +110,7 @@ func (h *BookingHandler) GetBooking(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
 	} else {
+		httputil.SetETag(w, result.Version)
 		bookingResponse := ToBookingResponse(result)
 		resp = GetResponsePage[BookingResponse]{
 			StatusCode: 200,
@@ -168,35 +203,26 @@ func (h *BookingHandler) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	bookingToUpdate := booking.Booking{
-		ID:                req.ID,
-		PropertyID:        req.PropertyID,
-		ManagerID:         req.ManagerID,
-		GuestPhone:        req.GuestPhone,
-		GuestName:         req.GuestName,
-		BaseRate:          req.BaseRate,
-		MaxGuestsBase:     req.MaxGuestsBase,
-		ExtraRatePerGuest: req.ExtraRatePerGuest,
-		NumGuests:         req.NumGuests,
-		Status:            req.Status,
-		CheckInDate:       req.CheckInDate,
-		CheckOutDate:      req.CheckOutDate,
-		Remarks:           req.Remarks,
+	version, err := parseIfMatch(r)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(err))
+		return
 	}
-	err = h.service.Update(ctx, &bookingToUpdate)
+	bookingToUpdate := ToBooking(&req, version)
+	err = h.service.Update(ctx, &bookingToUpdate, req.Reason)
 	var resp any
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
 	} else {
+		httputil.SetETag(w, bookingToUpdate.Version)
 		bookingResponse := ToBookingResponse(&bookingToUpdate)
 		resp = PutResponsePage[BookingResponse]{
 			StatusCode: http.StatusOK,
 			Message:    "Property updated successfully",
 			Data:       bookingResponse,
 		}
-		json.NewEncoder(w).Encode(resp)
 	}
-
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (h *BookingHandler) CheckAvailability(w http.ResponseWriter, r *http.Request) {
@@ -243,3 +269,221 @@ func (h *BookingHandler) CheckAvailability(w http.ResponseWriter, r *http.Reques
 func NormalizeDate(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
+
+func (h *BookingHandler) GetBookingHistory(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "bookingId")
+	log.Println("HandlerGetBookingHistory::Fetching history of booking with ID:", idStr)
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		resp = errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "bookingId",
+			Reason: err.Error(),
+		})
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	result, err := h.service.GetHistory(r.Context(), id)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		revisionResponses := make([]BookingRevisionResponse, 0, len(result))
+		for _, revision := range result {
+			revisionResponses = append(revisionResponses, ToBookingRevisionResponse(&revision))
+		}
+		resp = GetResponsePage[[]BookingRevisionResponse]{
+			StatusCode: 200,
+			Message:    "Booking history fetched successfully",
+			Data:       revisionResponses,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *BookingHandler) DeleteBooking(w http.ResponseWriter, r *http.Request) {
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerDeleteBooking::Deleting booking with ID:", bookingId)
+	err := h.service.Delete(r.Context(), bookingId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Booking deleted successfully",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *BookingHandler) RestoreBooking(w http.ResponseWriter, r *http.Request) {
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerRestoreBooking::Restoring booking with ID:", bookingId)
+	result, err := h.service.Restore(r.Context(), bookingId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, result.Version)
+	json.NewEncoder(w).Encode(PutResponsePage[BookingResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Booking restored successfully",
+		Data:       ToBookingResponse(result),
+	})
+}
+
+// PatchBooking applies a JSON merge patch to the stored booking and saves it like UpdateBooking,
+// the reason member of the patch is kept with the new revision
+func (h *BookingHandler) PatchBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerPatchBooking::Patching booking with ID:", bookingId)
+	version, err := parseIfMatch(r)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(err))
+		return
+	}
+	current, err := h.service.GetById(ctx, bookingId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	var req UpdateBookingRequest
+	if err := httputil.DecodeMergePatch(r, ToUpdateBookingRequest(current), &req); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if req.ID != bookingId {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "ID in URL and body do not match or invalid",
+		})
+		return
+	}
+	bookingToUpdate := ToBooking(&req, version)
+	err = h.service.Update(ctx, &bookingToUpdate, req.Reason)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, bookingToUpdate.Version)
+	json.NewEncoder(w).Encode(PutResponsePage[BookingResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Booking updated successfully",
+		Data:       ToBookingResponse(&bookingToUpdate),
+	})
+}
+
+func (h *BookingHandler) GetAdjustments(w http.ResponseWriter, r *http.Request) {
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	log.Println("HandlerGetAdjustments::Fetching adjustments of booking with ID:", bookingId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	result, err := h.service.GetAdjustments(r.Context(), bookingId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	adjustmentResponses := make([]AdjustmentResponse, 0, len(result))
+	for _, adjustment := range result {
+		adjustmentResponses = append(adjustmentResponses, ToAdjustmentResponse(&adjustment))
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[[]AdjustmentResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Booking adjustments fetched successfully",
+		Data:       adjustmentResponses,
+	})
+}
+
+// AddAdjustment adds a discount, coupon or extra charge and returns the booking priced again
+func (h *BookingHandler) AddAdjustment(w http.ResponseWriter, r *http.Request) {
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerAddAdjustment::Adding adjustment to booking with ID:", bookingId)
+	var req AdjustmentRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return
+	}
+	adjustment := ToAdjustment(&req)
+	result, err := h.service.AddAdjustment(r.Context(), bookingId, &adjustment)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, result.Version)
+	json.NewEncoder(w).Encode(PostResponsePage[BookingResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Booking adjustment added successfully",
+		Data:       ToBookingResponse(result),
+	})
+}
+
+func (h *BookingHandler) RemoveAdjustment(w http.ResponseWriter, r *http.Request) {
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	adjustmentId, badRequestError := parseIDParam("adjustmentId", chi.URLParam(r, "adjustmentId"))
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerRemoveAdjustment::Removing adjustment", adjustmentId, "from booking with ID:", bookingId)
+	result, err := h.service.RemoveAdjustment(r.Context(), bookingId, adjustmentId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, result.Version)
+	json.NewEncoder(w).Encode(PutResponsePage[BookingResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Booking adjustment removed successfully",
+		Data:       ToBookingResponse(result),
+	})
+}
@@ -0,0 +1,65 @@
+package coupon
+
+import (
+	"time"
+
+	coupon "github.com/nevinmanoj/hostmate/internal/domain/coupon"
+)
+
+// CreateCouponRequest leaves out property_id for a coupon valid at every property,
+// valid_from, valid_to and max_uses are optional limits
+type CreateCouponRequest struct {
+	Code       string     `json:"code"`
+	Percent    bool       `json:"percent"`
+	Value      float64    `json:"value"`
+	PropertyID *int64     `json:"property_id"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidTo    *time.Time `json:"valid_to"`
+	MaxUses    *int       `json:"max_uses"`
+}
+
+func ToCoupon(req *CreateCouponRequest) coupon.Coupon {
+	return coupon.Coupon{
+		Code:       req.Code,
+		Percent:    req.Percent,
+		Value:      req.Value,
+		PropertyID: req.PropertyID,
+		ValidFrom:  req.ValidFrom,
+		ValidTo:    req.ValidTo,
+		MaxUses:    req.MaxUses,
+	}
+}
+
+type CouponResponse struct {
+	ID         int64      `json:"id"`
+	Code       string     `json:"code"`
+	Percent    bool       `json:"percent"`
+	Value      float64    `json:"value"`
+	PropertyID *int64     `json:"property_id"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidTo    *time.Time `json:"valid_to"`
+	MaxUses    *int       `json:"max_uses"`
+	Uses       int        `json:"uses"`
+	Active     bool       `json:"active"`
+	CreatedBy  int64      `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func ToCouponResponse(c *coupon.Coupon) CouponResponse {
+	return CouponResponse{
+		ID:         c.ID,
+		Code:       c.Code,
+		Percent:    c.Percent,
+		Value:      c.Value,
+		PropertyID: c.PropertyID,
+		ValidFrom:  c.ValidFrom,
+		ValidTo:    c.ValidTo,
+		MaxUses:    c.MaxUses,
+		Uses:       c.Uses,
+		Active:     c.Active,
+		CreatedBy:  c.CreatedBy,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
@@ -0,0 +1,97 @@
+package coupon
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	coupon "github.com/nevinmanoj/hostmate/internal/domain/coupon"
+)
+
+type CouponHandler struct {
+	service coupon.CouponService
+}
+
+func NewCouponHandler(s coupon.CouponService) *CouponHandler {
+	return &CouponHandler{service: s}
+}
+
+func (h *CouponHandler) GetCoupons(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetCoupons::Fetching coupons")
+	w.Header().Set("Content-Type", "application/json")
+	result, err := h.service.GetAll(r.Context())
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	couponResponses := make([]CouponResponse, 0, len(result))
+	for _, c := range result {
+		couponResponses = append(couponResponses, ToCouponResponse(&c))
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[[]CouponResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Coupons fetched successfully",
+		Data:       couponResponses,
+	})
+}
+
+func (h *CouponHandler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerCreateCoupon::Creating coupon")
+	w.Header().Set("Content-Type", "application/json")
+	var req CreateCouponRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	couponToCreate := ToCoupon(&req)
+	if err := h.service.Create(r.Context(), &couponToCreate); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PostResponsePage[CouponResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Coupon created successfully",
+		Data:       ToCouponResponse(&couponToCreate),
+	})
+}
+
+// DeactivateCoupon stops the code from being applied, bookings that used it keep their discount
+func (h *CouponHandler) DeactivateCoupon(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false)
+}
+
+func (h *CouponHandler) ActivateCoupon(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true)
+}
+
+func (h *CouponHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	couponId, badRequestError := parseIDParam("couponId", chi.URLParam(r, "couponId"))
+	log.Println("HandlerSetCouponActive::Setting active to", active, "for coupon ID:", couponId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	result, err := h.service.SetActive(r.Context(), couponId, active)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	message := "Coupon deactivated successfully"
+	if active {
+		message = "Coupon activated successfully"
+	}
+	json.NewEncoder(w).Encode(PutResponsePage[CouponResponse]{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Data:       ToCouponResponse(result),
+	})
+}
@@ -4,10 +4,21 @@ import (
 	"fmt"
 
 	. "github.com/nevinmanoj/hostmate/api"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
 	"github.com/nevinmanoj/hostmate/internal/domain/attachment"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
 	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	coupon "github.com/nevinmanoj/hostmate/internal/domain/coupon"
+	idempotency "github.com/nevinmanoj/hostmate/internal/domain/idempotency"
+	imports "github.com/nevinmanoj/hostmate/internal/domain/imports"
+	invitation "github.com/nevinmanoj/hostmate/internal/domain/invitation"
+	invoice "github.com/nevinmanoj/hostmate/internal/domain/invoice"
+	organisation "github.com/nevinmanoj/hostmate/internal/domain/organisation"
 	"github.com/nevinmanoj/hostmate/internal/domain/payment"
 	property "github.com/nevinmanoj/hostmate/internal/domain/property"
+	report "github.com/nevinmanoj/hostmate/internal/domain/report"
+	search "github.com/nevinmanoj/hostmate/internal/domain/search"
+	tax "github.com/nevinmanoj/hostmate/internal/domain/tax"
 	user "github.com/nevinmanoj/hostmate/internal/domain/user"
 )
 
@@ -29,6 +40,117 @@ func GetDomainErrorResponse(err error) ErrorResponse {
 			StatusCode: 400,
 			Message:    "User already exists",
 		}
+	case user.ErrInvalidCredentials:
+		return ErrorResponse{
+			StatusCode: 401,
+			Message:    "Invalid credentials",
+		}
+	case user.ErrInvalidTwoFactorCode:
+		return ErrorResponse{
+			StatusCode: 401,
+			Message:    "Invalid two factor code",
+		}
+	case user.ErrInvalidChallenge:
+		return ErrorResponse{
+			StatusCode: 401,
+			Message:    "Login challenge is invalid or expired, log in again",
+		}
+	case user.ErrTwoFactorNotEnrolled:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Two factor authentication is not enrolled",
+		}
+	case user.ErrTwoFactorAlreadyEnabled:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "Two factor authentication is already enabled",
+		}
+	case user.ErrTooManyAttempts:
+		return ErrorResponse{
+			StatusCode: 429,
+			Message:    "Too many login attempts, wait before trying again",
+		}
+	case user.ErrAccountLocked:
+		return ErrorResponse{
+			StatusCode: 423,
+			Message:    "Account is temporarily locked after repeated failed logins",
+		}
+	case user.ErrSSONotConfigured:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Single sign-on is not configured",
+		}
+	case user.ErrInvalidSSOState:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Sign-on state is invalid or expired, start the login again",
+		}
+	case user.ErrSSOFailed:
+		return ErrorResponse{
+			StatusCode: 401,
+			Message:    "Sign-on with the identity provider failed",
+		}
+	case user.ErrSSODomainNotAllowed:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Accounts from this domain cannot sign in",
+		}
+	case user.ErrSSOEmailNotVerified:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "The identity provider did not verify this email address",
+		}
+	case user.ErrSSOAccountNotVerified:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "An account with this email exists but its email is not verified, sign in with the password and confirm the email first",
+		}
+	case user.ErrTwoFactorMandatory:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Two factor authentication is mandatory and cannot be disabled",
+		}
+	case user.ErrInvalidAPIKey:
+		return ErrorResponse{
+			StatusCode: 401,
+			Message:    "Invalid or revoked api key",
+		}
+	case user.ErrInvalidAPIKeyScope:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Api key scope must be read or read_write",
+		}
+	case user.ErrAPIKeyNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Api key not found",
+		}
+	case user.ErrInvalidTimezone:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Timezone must be an IANA name such as Asia/Kolkata",
+		}
+	case user.ErrInvalidEmailToken:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Email verification link is invalid or expired",
+		}
+	case user.ErrEmailUnchanged:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "New email is the same as the current email",
+		}
+	case user.ErrAccountDeactivated:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Account is deactivated",
+		}
+	//access errors
+	case access.ErrReadOnlyScope:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Api key is read only",
+		}
 	//property errors
 	case property.ErrUnauthorized:
 		return ErrorResponse{
@@ -45,6 +167,36 @@ func GetDomainErrorResponse(err error) ErrorResponse {
 			StatusCode: 400,
 			Message:    "Managers are not valid",
 		}
+	case property.ErrMemberNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Property member not found",
+		}
+	case property.ErrNotValidMember:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Member must belong to the organisation and not already manage the property",
+		}
+	case property.ErrInvalidCapabilities:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Capabilities are not valid",
+		}
+	case property.ErrSelfGrant:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "You cannot change your own capabilities on a property",
+		}
+	case property.ErrVersionConflict:
+		return ErrorResponse{
+			StatusCode: 412,
+			Message:    "The property was modified by someone else, fetch it again and retry",
+		}
+	case property.ErrRestoreWindowExpired:
+		return ErrorResponse{
+			StatusCode: 410,
+			Message:    "The property was deleted too long ago to be restored",
+		}
 	//booking errors
 	case booking.ErrUnauthorized:
 		return ErrorResponse{
@@ -66,6 +218,36 @@ func GetDomainErrorResponse(err error) ErrorResponse {
 			StatusCode: 409,
 			Message:    "The booking dates conflict with an existing booking",
 		}
+	case booking.ErrVersionConflict:
+		return ErrorResponse{
+			StatusCode: 412,
+			Message:    "The booking was modified by someone else, fetch it again and retry",
+		}
+	case booking.ErrRestoreWindowExpired:
+		return ErrorResponse{
+			StatusCode: 410,
+			Message:    "The booking was deleted too long ago to be restored",
+		}
+	case booking.ErrAdjustmentNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Adjustment not found on this booking",
+		}
+	case booking.ErrInvalidAdjustment:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Adjustment must be a discount or charge, percent or flat, with a positive value and percentages up to 100",
+		}
+	case booking.ErrAdjustmentReasonRequired:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Adjustment needs a reason",
+		}
+	case booking.ErrCouponAlreadyApplied:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "The coupon is already applied to this booking",
+		}
 	//payments
 	case payment.ErrUnauthorized:
 		return ErrorResponse{
@@ -82,12 +264,242 @@ func GetDomainErrorResponse(err error) ErrorResponse {
 			StatusCode: 404,
 			Message:    "Payment not found",
 		}
+	case payment.ErrVersionConflict:
+		return ErrorResponse{
+			StatusCode: 412,
+			Message:    "The payment was modified by someone else, fetch it again and retry",
+		}
+	case payment.ErrRestoreWindowExpired:
+		return ErrorResponse{
+			StatusCode: 410,
+			Message:    "The payment was deleted too long ago to be restored",
+		}
 	//attachments
 	case attachment.ErrInvalidAttachmentParentType:
 		return ErrorResponse{
 			StatusCode: 400,
 			Message:    "Invalid value for attachment parent type",
 		}
+	//invitations
+	case invitation.ErrUnauthorized:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Only the property owner can manage invitations",
+		}
+	case invitation.ErrNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Invitation not found",
+		}
+	case invitation.ErrInvalidToken:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Invitation token is invalid or has been replaced",
+		}
+	case invitation.ErrNotPending:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "Invitation is no longer pending",
+		}
+	case invitation.ErrAlreadyInvited:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "A pending invitation already exists for this email",
+		}
+	case invitation.ErrAlreadyManager:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "User is already a manager of this property",
+		}
+	case invitation.ErrInvalidCredential:
+		return ErrorResponse{
+			StatusCode: 401,
+			Message:    "Invalid credentials for the invited account",
+		}
+	case invitation.ErrMissingSignup:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Name and a password of at least 6 characters are required to register",
+		}
+	//organisations
+	case organisation.ErrNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Organisation not found",
+		}
+	case organisation.ErrUnauthorized:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Unauthorized to manage organisation members",
+		}
+	case organisation.ErrNotMember:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "User is not a member of the organisation",
+		}
+	case organisation.ErrInvalidRole:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Role must be one of owner, admin or member",
+		}
+	case organisation.ErrLastOwner:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "Organisation must keep at least one owner",
+		}
+	case organisation.ErrNoTenant:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "No organisation selected, log in again",
+		}
+	//audit
+	case audit.ErrUnauthorized:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Unauthorized to view audit log",
+		}
+	case audit.ErrInvalidEntityType:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Entity type must be one of property, booking, payment or coupon",
+		}
+	//idempotency
+	case idempotency.ErrInvalidKey:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Idempotency-Key must be between 1 and 255 characters",
+		}
+	case idempotency.ErrKeyReused:
+		return ErrorResponse{
+			StatusCode: 422,
+			Message:    "Idempotency-Key was already used for a different request",
+		}
+	case idempotency.ErrRequestInProgress:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "A request with this Idempotency-Key is still in progress, retry later",
+		}
+	//imports
+	case imports.ErrEmptyImport:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Import files have no rows",
+		}
+	case imports.ErrTooManyRows:
+		return ErrorResponse{
+			StatusCode: 413,
+			Message:    fmt.Sprintf("Import can have at most %d rows, split the files", imports.MaxRows),
+		}
+	//invoices
+	case invoice.ErrNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Document not found",
+		}
+	case invoice.ErrUnauthorized:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Unauthorized to access invoices of this booking",
+		}
+	case invoice.ErrNoStay:
+		return ErrorResponse{
+			StatusCode: 422,
+			Message:    "Booking has no nights to invoice, check the stay dates",
+		}
+	//report
+	case report.ErrInvalidPeriod:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Report period must be one of day, week or month",
+		}
+	case report.ErrInvalidRange:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Report range must end after it starts and not exceed a year of days, three years of weeks or five years of months",
+		}
+	//search
+	case search.ErrInvalidQuery:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    fmt.Sprintf("Search query must be between %d and %d characters", search.MinQueryLength, search.MaxQueryLength),
+		}
+	//tax
+	case tax.ErrNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Property has no tax configuration",
+		}
+	case tax.ErrUnauthorized:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Unauthorized to access tax configuration of this property",
+		}
+	case tax.ErrInvalidGSTIN:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "GSTIN must be a valid 15 character GST identification number",
+		}
+	case tax.ErrInvalidSupply:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Supply must be one of intra_state or inter_state",
+		}
+	case tax.ErrInvalidSlabs:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Tax slabs must have rising up_to limits, rates between 0 and 40 and end with one slab without a limit",
+		}
+	//coupons
+	case coupon.ErrNotFound:
+		return ErrorResponse{
+			StatusCode: 404,
+			Message:    "Coupon not found",
+		}
+	case coupon.ErrUnauthorized:
+		return ErrorResponse{
+			StatusCode: 403,
+			Message:    "Only organisation admins can manage coupons",
+		}
+	case coupon.ErrAlreadyExists:
+		return ErrorResponse{
+			StatusCode: 409,
+			Message:    "A coupon with this code already exists",
+		}
+	case coupon.ErrInvalidCode:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Coupon code must be 3 to 32 characters without spaces",
+		}
+	case coupon.ErrInvalidValue:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Coupon value must be positive, percentages up to 100, and max uses positive",
+		}
+	case coupon.ErrInvalidValidity:
+		return ErrorResponse{
+			StatusCode: 400,
+			Message:    "Coupon valid_to must be after valid_from",
+		}
+	case coupon.ErrInactive:
+		return ErrorResponse{
+			StatusCode: 422,
+			Message:    "The coupon is not active",
+		}
+	case coupon.ErrExpired:
+		return ErrorResponse{
+			StatusCode: 422,
+			Message:    "The coupon is not valid at this time",
+		}
+	case coupon.ErrNotApplicable:
+		return ErrorResponse{
+			StatusCode: 422,
+			Message:    "The coupon does not apply to this property",
+		}
+	case coupon.ErrExhausted:
+		return ErrorResponse{
+			StatusCode: 422,
+			Message:    "The coupon has been used up",
+		}
 	default:
 		return ErrorResponse{
 			StatusCode: 500,
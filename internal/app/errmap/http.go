@@ -15,6 +15,15 @@ func (e BadRequestError) Error() string {
 	return fmt.Sprintf("invalid %s: %s", e.Param, e.Reason)
 }
 
+// PreconditionRequiredError is returned when a conditional request is missing its precondition
+type PreconditionRequiredError struct {
+	Reason string
+}
+
+func (e PreconditionRequiredError) Error() string {
+	return e.Reason
+}
+
 func GetHttpErrorResponse(err any) ErrorResponse {
 	switch e := err.(type) {
 
@@ -24,6 +33,12 @@ func GetHttpErrorResponse(err any) ErrorResponse {
 			Message:    e.Error(),
 		}
 
+	case *PreconditionRequiredError:
+		return ErrorResponse{
+			StatusCode: 428,
+			Message:    e.Error(),
+		}
+
 	default:
 		return ErrorResponse{
 			StatusCode: 500,
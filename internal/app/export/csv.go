@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, header []string) (Writer, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(row []Cell) error {
+	record := make([]string, 0, len(row))
+	for _, cell := range row {
+		record = append(record, csvValue(cell))
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+func csvValue(cell Cell) string {
+	switch cell.kind {
+	case kindInt:
+		return strconv.FormatFloat(cell.number, 'f', 0, 64)
+	case kindMoney:
+		return strconv.FormatFloat(cell.number, 'f', 2, 64)
+	case kindNumber:
+		return strconv.FormatFloat(cell.number, 'f', -1, 64)
+	case kindDate:
+		return cell.time.Format("2006-01-02")
+	case kindDateTime:
+		return cell.time.Format("2006-01-02 15:04:05")
+	default:
+		return escapeFormula(cell.text)
+	}
+}
+
+// escapeFormula keeps spreadsheets from running user entered text as a formula. Every text
+// cell starting with a formula character is prefixed, spreadsheets hide the quote so a
+// phone number like +91 98... still reads the same. Numbers are never text cells.
+func escapeFormula(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"Asha Menon", "Asha Menon"},
+		{"9876543210", "9876543210"},
+		{"=1+1", "'=1+1"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"+", "'+"},
+		{"-", "'-"},
+		{"+91 98765 43210", "'+91 98765 43210"},
+		{"+919876543210", "'+919876543210"},
+		{"-2+3", "'-2+3"},
+		{"- 5", "'- 5"},
+		{"+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"a=1", "a=1"},
+		{"'quoted", "'quoted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := escapeFormula(tt.in); got != tt.want {
+				t.Errorf("escapeFormula(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVWriterOnlyEscapesText(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newCSVWriter(&buf, []string{"name", "phone", "amount", "nights", "rate", "date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)
+	row := []Cell{Text("=cmd"), Text("+91 98765 43210"), Money(-250), Int(-1), Number(-0.125), Date(date)}
+	if err := w.Write(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,phone,amount,nights,rate,date\n'=cmd,'+91 98765 43210,-250.00,-1,-0.125,2026-03-10\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
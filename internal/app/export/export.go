@@ -0,0 +1,124 @@
+package export
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatNone Format = ""
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+const (
+	mimeCSV  = "text/csv"
+	mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// BatchSize is how many rows are fetched at a time while streaming an export
+const BatchSize = 500
+
+// Negotiate picks an export format from the Accept header, the first supported type
+// listed wins and anything else is left to the usual JSON response
+func Negotiate(r *http.Request) Format {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case mimeCSV:
+			return FormatCSV
+		case mimeXLSX:
+			return FormatXLSX
+		}
+	}
+	return FormatNone
+}
+
+type cellKind int
+
+const (
+	kindText cellKind = iota
+	kindInt
+	kindMoney
+	kindNumber
+	kindDate
+	kindDateTime
+)
+
+// Cell is a typed value, each format renders the type its own way
+type Cell struct {
+	kind   cellKind
+	text   string
+	number float64
+	time   time.Time
+}
+
+func Text(v string) Cell {
+	return Cell{kind: kindText, text: v}
+}
+
+func Int(v int64) Cell {
+	return Cell{kind: kindInt, number: float64(v)}
+}
+
+func Money(v float64) Cell {
+	return Cell{kind: kindMoney, number: v}
+}
+
+// Number is written with as many decimals as it needs
+func Number(v float64) Cell {
+	return Cell{kind: kindNumber, number: v}
+}
+
+func Date(t time.Time) Cell {
+	return Cell{kind: kindDate, time: t.UTC()}
+}
+
+func DateTime(t time.Time) Cell {
+	return Cell{kind: kindDateTime, time: t.UTC()}
+}
+
+func Bool(v bool) Cell {
+	if v {
+		return Text("Yes")
+	}
+	return Text("No")
+}
+
+// Writer receives the rows of an export after the header line, Flush sends what is
+// buffered so far and Close finishes the file
+type Writer interface {
+	Write(row []Cell) error
+	Flush() error
+	Close() error
+}
+
+// NewWriter starts the download, name is the file name without extension
+func NewWriter(w http.ResponseWriter, format Format, name string, header []string) (Writer, error) {
+	return NewFileWriter(w, format, fmt.Sprintf("%s_%s", name, time.Now().UTC().Format("2006-01-02")), header)
+}
+
+// NewFileWriter is NewWriter for a download that names its own period, the date of the
+// export is not added to filename
+func NewFileWriter(w http.ResponseWriter, format Format, filename string, header []string) (Writer, error) {
+	filename = fmt.Sprintf("%s.%s", filename, format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	switch format {
+	case FormatCSV:
+		w.Header().Set("Content-Type", mimeCSV+"; charset=utf-8")
+		return newCSVWriter(w, header)
+	case FormatXLSX:
+		w.Header().Set("Content-Type", mimeXLSX)
+		return newXLSXWriter(w, header)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
+)
+
+// Stream writes every row fetch returns, following the next cursor until the last page.
+// The first page is fetched before anything is written so an error there, like a missing
+// permission, can still be answered as JSON, later errors can only cut the file short.
+func Stream[T any](w http.ResponseWriter, format Format, name string, header []string, sort pagination.Sort,
+	fetch func(cursor *pagination.Cursor) ([]T, pagination.Cursors, error), row func(*T) []Cell) error {
+	items, cursors, err := fetch(nil)
+	if err != nil {
+		return err
+	}
+	ew, err := NewWriter(w, format, name, header)
+	if err != nil {
+		return err
+	}
+	flusher, _ := w.(http.Flusher)
+	for {
+		for i := range items {
+			if err := ew.Write(row(&items[i])); err != nil {
+				log.Printf("Export::Error writing %s export: %s", name, err.Error())
+				return nil
+			}
+		}
+		if err := ew.Flush(); err != nil {
+			log.Printf("Export::Error writing %s export: %s", name, err.Error())
+			return nil
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if cursors.Next == "" {
+			break
+		}
+		cursor, err := pagination.DecodeCursor(cursors.Next, sort)
+		if err != nil {
+			log.Printf("Export::Error continuing %s export: %s", name, err.Error())
+			return nil
+		}
+		if items, cursors, err = fetch(cursor); err != nil {
+			log.Printf("Export::Error fetching %s export: %s", name, err.Error())
+			return nil
+		}
+	}
+	if err := ew.Close(); err != nil {
+		log.Printf("Export::Error finishing %s export: %s", name, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the smallest package spreadsheet applications open: a workbook with one sheet and the styles it uses
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="3">` +
+		`<numFmt numFmtId="164" formatCode="yyyy-mm-dd"/>` +
+		`<numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/>` +
+		`<numFmt numFmtId="166" formatCode="#,##0.00"/>` +
+		`</numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="5">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`</cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`},
+}
+
+// cellXfs indexes in styles.xml
+const (
+	styleDefault  = 0
+	styleHeader   = 1
+	styleDate     = 2
+	styleDateTime = 3
+	styleMoney    = 4
+)
+
+// spreadsheet dates count days from the end of 1899
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxWriter streams the sheet straight into the zip, rows are never held in memory
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func newXLSXWriter(w io.Writer, header []string) (Writer, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxWriter{zip: zw, sheet: bufio.NewWriter(f)}
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		"\n" + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	headerRow := make([]Cell, 0, len(header))
+	for _, h := range header {
+		headerRow = append(headerRow, Text(h))
+	}
+	if err := x.writeRow(headerRow, styleHeader); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(row []Cell) error {
+	return x.writeRow(row, styleDefault)
+}
+
+func (x *xlsxWriter) writeRow(row []Cell, textStyle int) error {
+	x.row++
+	rowNum := strconv.Itoa(x.row)
+	x.sheet.WriteString(`<row r="` + rowNum + `">`)
+	for i, cell := range row {
+		ref := columnName(i) + rowNum
+		switch cell.kind {
+		case kindInt:
+			writeNumber(x.sheet, ref, styleDefault, strconv.FormatFloat(cell.number, 'f', 0, 64))
+		case kindMoney:
+			writeNumber(x.sheet, ref, styleMoney, strconv.FormatFloat(cell.number, 'f', 2, 64))
+		case kindNumber:
+			writeNumber(x.sheet, ref, styleDefault, strconv.FormatFloat(cell.number, 'f', -1, 64))
+		case kindDate:
+			writeNumber(x.sheet, ref, styleDate, serialDate(cell.time))
+		case kindDateTime:
+			writeNumber(x.sheet, ref, styleDateTime, serialDate(cell.time))
+		default:
+			x.sheet.WriteString(`<c r="` + ref + `" t="inlineStr" s="` + strconv.Itoa(textStyle) + `"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(x.sheet, []byte(cell.text)); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Flush()
+}
+
+func (x *xlsxWriter) Close() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+func writeNumber(w *bufio.Writer, ref string, style int, value string) {
+	w.WriteString(`<c r="` + ref + `" s="` + strconv.Itoa(style) + `"><v>` + value + `</v></c>`)
+}
+
+func serialDate(t time.Time) string {
+	days := t.Sub(xlsxEpoch).Hours() / 24
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+// columnName turns a zero based index into A, B, ... Z, AA, AB ...
+func columnName(i int) string {
+	var sb strings.Builder
+	for i++; i > 0; i = (i - 1) / 26 {
+		sb.WriteByte(byte('A' + (i-1)%26))
+	}
+	name := []byte(sb.String())
+	for l, r := 0, len(name)-1; l < r; l, r = l+1, r-1 {
+		name[l], name[r] = name[r], name[l]
+	}
+	return string(name)
+}
@@ -0,0 +1,15 @@
+package httputil
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the caller address without the port, RealIP middleware has already applied proxy headers
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
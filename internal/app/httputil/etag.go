@@ -0,0 +1,41 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrMissingIfMatch = errors.New("If-Match header with the ETag of the resource is required")
+	ErrInvalidIfMatch = errors.New("If-Match header is not a valid ETag")
+)
+
+// ETag formats an entity version as a strong entity tag
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// SetETag sets the ETag response header, it has to be called before the body is written
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// ParseIfMatch returns the version the client last saw, weak tags are accepted
+// since the version is the same either way
+func ParseIfMatch(r *http.Request) (int, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" {
+		return 0, ErrMissingIfMatch
+	}
+	v = strings.TrimPrefix(v, "W/")
+	if len(v) < 2 || !strings.HasPrefix(v, `"`) || !strings.HasSuffix(v, `"`) {
+		return 0, ErrInvalidIfMatch
+	}
+	version, err := strconv.Atoi(v[1 : len(v)-1])
+	if err != nil || version < 1 {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
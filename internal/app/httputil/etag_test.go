@@ -0,0 +1,49 @@
+package httputil
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr error
+	}{
+		{`"3"`, 3, nil},
+		{` "12" `, 12, nil},
+		{`W/"3"`, 3, nil},
+		{"", 0, ErrMissingIfMatch},
+		{"3", 0, ErrInvalidIfMatch},
+		{`"3`, 0, ErrInvalidIfMatch},
+		{`""`, 0, ErrInvalidIfMatch},
+		{`"0"`, 0, ErrInvalidIfMatch},
+		{`"-1"`, 0, ErrInvalidIfMatch},
+		{`"abc"`, 0, ErrInvalidIfMatch},
+		{`*`, 0, ErrInvalidIfMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/bookings/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			got, err := ParseIfMatch(r)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ParseIfMatch(%q) = %d, %v, want %d, %v", tt.header, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetETag(w, 42)
+	r := httptest.NewRequest("PUT", "/bookings/1", nil)
+	r.Header.Set("If-Match", w.Header().Get("ETag"))
+	if version, err := ParseIfMatch(r); err != nil || version != 42 {
+		t.Errorf("ParseIfMatch of ETag %s = %d, %v, want 42", w.Header().Get("ETag"), version, err)
+	}
+}
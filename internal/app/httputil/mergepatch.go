@@ -0,0 +1,82 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+var ErrInvalidMergePatch = errors.New("body must be a JSON merge patch object")
+
+// MergePatch applies an RFC 7396 merge patch to the target document,
+// null members remove the field and objects are merged recursively
+func MergePatch(target, patch []byte) ([]byte, error) {
+	patchDoc, err := decodeJSON(patch)
+	if err != nil {
+		return nil, ErrInvalidMergePatch
+	}
+	// a patch replacing the whole resource with a non object is never valid here
+	if _, ok := patchDoc.(map[string]any); !ok {
+		return nil, ErrInvalidMergePatch
+	}
+	targetDoc, err := decodeJSON(target)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(targetDoc, patchDoc))
+}
+
+func mergeValue(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// decodeJSON keeps numbers as json.Number so large ids survive the round trip
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, ErrInvalidMergePatch
+	}
+	return doc, nil
+}
+
+// DecodeMergePatch applies the request body as a merge patch to current and decodes
+// the result into dst, fields dst does not know are rejected like on a full update
+func DecodeMergePatch(r *http.Request, current, dst any) error {
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		return ErrInvalidMergePatch
+	}
+	target, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	merged, err := MergePatch(target, patch)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
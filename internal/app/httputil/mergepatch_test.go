@@ -0,0 +1,80 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the examples of RFC 7396 appendix A, patches that are not objects are rejected here
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch returned %v", err)
+			}
+			var gotDoc, wantDoc any
+			if err := json.Unmarshal(got, &gotDoc); err != nil {
+				t.Fatalf("result %s is not JSON: %v", got, err)
+			}
+			json.Unmarshal([]byte(tt.want), &wantDoc)
+			if !reflect.DeepEqual(gotDoc, wantDoc) {
+				t.Errorf("MergePatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchRejectsNonObjects(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+	}{
+		{`["a","b"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`},
+		{`{"a":"foo"}`, `null`},
+		{`{"a":"foo"}`, `"bar"`},
+		{`{"a":"foo"}`, `{"a":`},
+		{`{"a":"foo"}`, `{"a":1} {"b":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			_, err := MergePatch([]byte(tt.target), []byte(tt.patch))
+			if !errors.Is(err, ErrInvalidMergePatch) {
+				t.Errorf("MergePatch error = %v, want ErrInvalidMergePatch", err)
+			}
+		})
+	}
+}
+
+func TestMergePatchKeepsLargeNumbers(t *testing.T) {
+	got, err := MergePatch([]byte(`{"id":9007199254740993,"rate":1500.25}`), []byte(`{"name":"x"}`))
+	if err != nil {
+		t.Fatalf("MergePatch returned %v", err)
+	}
+	for _, want := range []string{`"id":9007199254740993`, `"rate":1500.25`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("MergePatch = %s, want it to contain %s", got, want)
+		}
+	}
+}
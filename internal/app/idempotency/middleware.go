@@ -0,0 +1,139 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	idempotency "github.com/nevinmanoj/hostmate/internal/domain/idempotency"
+)
+
+const (
+	headerKey      = "Idempotency-Key"
+	headerReplayed = "Idempotent-Replayed"
+)
+
+// headers that belong to the connection rather than the response are never replayed
+var skippedHeaders = map[string]bool{
+	"Content-Length": true,
+	"Date":           true,
+	"Set-Cookie":     true,
+}
+
+// Middleware makes create endpoints safe to retry, a request repeating the Idempotency-Key
+// of an earlier one gets the stored response instead of creating another record.
+// The body is read ahead of the handler to fingerprint the request, so it is capped at maxBody bytes.
+// It needs the authenticated user and tenant, so it has to run after the auth middleware.
+func Middleware(s idempotency.IdempotencyService, maxBody int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(headerKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			w.Header().Set("Content-Type", "application/json")
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+			if err != nil {
+				json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+					Param:  "body",
+					Reason: err.Error(),
+				}))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record, replay, err := s.Begin(ctx, key, fingerprint(r, body))
+			if err != nil {
+				json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+				return
+			}
+			if replay {
+				log.Printf("Idempotency::Replaying response for key %s", key)
+				for name, values := range record.ResponseHeaders {
+					w.Header()[name] = values
+				}
+				w.Header().Set(headerReplayed, "true")
+				w.Write(record.Response)
+				return
+			}
+
+			// the outcome is stored even when the client hangs up, otherwise its retry finds the key reserved
+			done := context.WithoutCancel(ctx)
+			recorder := &responseRecorder{ResponseWriter: w}
+			completed := false
+			// a panicking handler must not leave the key reserved forever
+			defer func() {
+				if !completed {
+					s.Release(done, record)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+
+			if recorder.failed() {
+				// server errors are not results, the client should be able to retry them
+				s.Release(done, record)
+			} else {
+				s.Complete(done, record, responseHeaders(w.Header()), recorder.body.Bytes())
+			}
+			completed = true
+		})
+	}
+}
+
+// fingerprint identifies the request a key was first used for
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseHeaders are what the handler set, kept so a replay looks like the original response
+func responseHeaders(header http.Header) idempotency.Headers {
+	headers := idempotency.Headers{}
+	for name, values := range header {
+		if !skippedHeaders[name] {
+			headers[name] = values
+		}
+	}
+	return headers
+}
+
+// responseRecorder keeps a copy of what the handler wrote
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// failed checks the http status and the status_code every response body carries
+func (rec *responseRecorder) failed() bool {
+	if rec.status >= http.StatusInternalServerError {
+		return true
+	}
+	var resp struct {
+		StatusCode int `json:"status_code"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
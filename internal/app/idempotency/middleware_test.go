@@ -0,0 +1,216 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	idempotency "github.com/nevinmanoj/hostmate/internal/domain/idempotency"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// memoryRepo keeps the records of a single user in memory
+type memoryRepo struct {
+	records map[string]*idempotency.Record
+	nextID  int64
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{records: map[string]*idempotency.Record{}}
+}
+
+func (m *memoryRepo) Create(ctx context.Context, record *idempotency.Record, expiredBefore time.Time) (bool, error) {
+	if existing, ok := m.records[record.Key]; ok && existing.CreatedAt.After(expiredBefore) {
+		return false, nil
+	}
+	m.nextID++
+	record.ID = m.nextID
+	record.CreatedAt = time.Now()
+	stored := *record
+	m.records[record.Key] = &stored
+	return true, nil
+}
+
+func (m *memoryRepo) Get(ctx context.Context, userID int64, key string) (*idempotency.Record, error) {
+	stored := *m.records[key]
+	return &stored, nil
+}
+
+// Complete and Delete fail like the database would once the context is cancelled
+func (m *memoryRepo) Complete(ctx context.Context, id int64, headers idempotency.Headers, response []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, record := range m.records {
+		if record.ID == id {
+			now := time.Now()
+			record.ResponseHeaders = headers
+			record.Response = response
+			record.CompletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memoryRepo) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for key, record := range m.records {
+		if record.ID == id {
+			delete(m.records, key)
+		}
+	}
+	return nil
+}
+
+const maxBody = 1 << 10
+
+// creator counts how often the wrapped create handler actually ran
+type creator struct {
+	calls  int
+	status int
+}
+
+func (c *creator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.calls++
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, c.calls))
+	w.Header().Set("Set-Cookie", "session=abc")
+	status := c.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	json.NewEncoder(w).Encode(map[string]any{"status_code": status, "id": c.calls, "name": string(body)})
+}
+
+func send(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	return sendTo(context.Background(), handler, "/properties", key, body)
+}
+
+func sendTo(ctx context.Context, handler http.Handler, target, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(headerKey, key)
+	}
+	r = r.WithContext(context.WithValue(ctx, middleware.ContextUserKey, int64(7)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func statusCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	var resp struct {
+		StatusCode int `json:"status_code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response %q is not json: %v", w.Body.String(), err)
+	}
+	return resp.StatusCode
+}
+
+func TestReplay(t *testing.T) {
+	next := &creator{}
+	handler := Middleware(idempotency.NewIdempotencyService(newMemoryRepo()), maxBody)(next)
+
+	first := send(handler, "key-1", "Beach house")
+	retry := send(handler, "key-1", "Beach house")
+	if next.calls != 1 {
+		t.Fatalf("handler ran %d times, want once", next.calls)
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("retry got %q, want the first response %q", retry.Body.String(), first.Body.String())
+	}
+	if first.Header().Get(headerReplayed) != "" || retry.Header().Get(headerReplayed) != "true" {
+		t.Errorf("%s headers = %q and %q, want only the retry marked", headerReplayed, first.Header().Get(headerReplayed), retry.Header().Get(headerReplayed))
+	}
+	// headers of the response are replayed, those of the connection are not
+	if etag := retry.Header().Get("ETag"); etag != `"1"` {
+		t.Errorf("replayed ETag = %q, want the first response's \"1\"", etag)
+	}
+	if cookie := retry.Header().Get("Set-Cookie"); cookie != "" {
+		t.Errorf("replayed Set-Cookie %q", cookie)
+	}
+
+	if reused := send(handler, "key-1", "Sea view"); statusCode(t, reused) != http.StatusUnprocessableEntity {
+		t.Errorf("reusing the key for another body got %s", reused.Body.String())
+	}
+	if reused := sendTo(context.Background(), handler, "/properties?copy_from=2", "key-1", "Beach house"); statusCode(t, reused) != http.StatusUnprocessableEntity {
+		t.Errorf("reusing the key for another query got %s", reused.Body.String())
+	}
+	send(handler, "key-2", "Sea view")
+	send(handler, "", "Sea view")
+	if next.calls != 3 {
+		t.Errorf("handler ran %d times, want 3 for a new key and a request without one", next.calls)
+	}
+}
+
+func TestRequestInProgress(t *testing.T) {
+	s := idempotency.NewIdempotencyService(newMemoryRepo())
+	next := &creator{}
+
+	// the first request holds the key until its handler returns, a retry arriving meanwhile must not run
+	var concurrent *httptest.ResponseRecorder
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		concurrent = send(Middleware(s, maxBody)(next), "key-1", "Beach house")
+		next.ServeHTTP(w, r)
+	})
+	send(Middleware(s, maxBody)(slow), "key-1", "Beach house")
+
+	if statusCode(t, concurrent) != http.StatusConflict {
+		t.Errorf("concurrent request got %s, want a conflict", concurrent.Body.String())
+	}
+	if next.calls != 1 {
+		t.Errorf("handler ran %d times, want once", next.calls)
+	}
+}
+
+func TestServerErrorReleasesKey(t *testing.T) {
+	next := &creator{status: http.StatusInternalServerError}
+	handler := Middleware(idempotency.NewIdempotencyService(newMemoryRepo()), maxBody)(next)
+
+	send(handler, "key-1", "Beach house")
+	next.status = http.StatusOK
+	retry := send(handler, "key-1", "Beach house")
+	if next.calls != 2 || statusCode(t, retry) != http.StatusOK {
+		t.Errorf("retry after a server error got %s after %d calls, want it to run again", retry.Body.String(), next.calls)
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	next := &creator{}
+	handler := Middleware(idempotency.NewIdempotencyService(newMemoryRepo()), maxBody)(next)
+
+	if w := send(handler, "key-1", strings.Repeat("x", maxBody+1)); statusCode(t, w) != http.StatusBadRequest {
+		t.Errorf("oversized body got %s, want a bad request", w.Body.String())
+	}
+	if next.calls != 0 {
+		t.Errorf("handler ran %d times for an oversized body", next.calls)
+	}
+}
+
+func TestClientGoneStillCompletes(t *testing.T) {
+	s := idempotency.NewIdempotencyService(newMemoryRepo())
+	next := &creator{}
+
+	// the client hangs up while the handler runs, the record it created must still be stored for the retry
+	ctx, cancel := context.WithCancel(context.Background())
+	gone := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		next.ServeHTTP(w, r)
+	})
+	sendTo(ctx, Middleware(s, maxBody)(gone), "/properties", "key-1", "Beach house")
+
+	retry := send(Middleware(s, maxBody)(next), "key-1", "Beach house")
+	if next.calls != 1 || retry.Header().Get(headerReplayed) != "true" {
+		t.Errorf("retry got %s after %d calls, want the stored response", retry.Body.String(), next.calls)
+	}
+}
@@ -0,0 +1,39 @@
+package imports
+
+import (
+	imports "github.com/nevinmanoj/hostmate/internal/domain/imports"
+)
+
+type ImportReportResponse struct {
+	DryRun    bool               `json:"dry_run"`
+	Committed bool               `json:"committed"`
+	Bookings  int                `json:"bookings"`
+	Payments  int                `json:"payments"`
+	Errors    []RowErrorResponse `json:"errors"`
+}
+
+type RowErrorResponse struct {
+	File    imports.Kind `json:"file"`
+	Line    int          `json:"line"`
+	Column  string       `json:"column,omitempty"`
+	Message string       `json:"message"`
+}
+
+func ToImportReportResponse(r *imports.Report) ImportReportResponse {
+	errorResponses := make([]RowErrorResponse, 0, len(r.Errors))
+	for _, rowErr := range r.Errors {
+		errorResponses = append(errorResponses, RowErrorResponse{
+			File:    rowErr.File,
+			Line:    rowErr.Line,
+			Column:  rowErr.Column,
+			Message: rowErr.Message,
+		})
+	}
+	return ImportReportResponse{
+		DryRun:    r.DryRun,
+		Committed: r.Committed,
+		Bookings:  r.Bookings,
+		Payments:  r.Payments,
+		Errors:    errorResponses,
+	}
+}
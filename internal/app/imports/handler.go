@@ -0,0 +1,116 @@
+package imports
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	imports "github.com/nevinmanoj/hostmate/internal/domain/imports"
+)
+
+const (
+	// MaxUploadSize also caps what the idempotency middleware reads ahead of the handler
+	MaxUploadSize = 20 << 20
+	maxMemory     = 8 << 20
+)
+
+type ImportHandler struct {
+	service imports.ImportService
+}
+
+func NewImportHandler(s imports.ImportService) *ImportHandler {
+	return &ImportHandler{service: s}
+}
+
+// Import takes a multipart form with a bookings and/or a payments csv file, with
+// dry_run=true the rows are checked and reported on but never stored
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerImport::Importing bookings and payments")
+	w.Header().Set("Content-Type", "application/json")
+	dryRun, badRequestError := parseDryRun(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadSize)
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: "expected a multipart form with bookings and payments csv files: " + err.Error(),
+		}))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	req := imports.Request{DryRun: dryRun}
+	found := false
+	if file, _, err := r.FormFile(string(imports.KindBookings)); err == nil {
+		found = true
+		rows, rowErrors, err := imports.ParseBookings(file)
+		file.Close()
+		if err != nil {
+			json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+				Param:  string(imports.KindBookings),
+				Reason: "could not read the csv header: " + err.Error(),
+			}))
+			return
+		}
+		req.Bookings = rows
+		req.Errors = append(req.Errors, rowErrors...)
+	} else if !errors.Is(err, http.ErrMissingFile) {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  string(imports.KindBookings),
+			Reason: err.Error(),
+		}))
+		return
+	}
+	if file, _, err := r.FormFile(string(imports.KindPayments)); err == nil {
+		found = true
+		rows, rowErrors, err := imports.ParsePayments(file)
+		file.Close()
+		if err != nil {
+			json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+				Param:  string(imports.KindPayments),
+				Reason: "could not read the csv header: " + err.Error(),
+			}))
+			return
+		}
+		req.Payments = rows
+		req.Errors = append(req.Errors, rowErrors...)
+	} else if !errors.Is(err, http.ErrMissingFile) {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  string(imports.KindPayments),
+			Reason: err.Error(),
+		}))
+		return
+	}
+	if !found {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: "a bookings or payments csv file is required",
+		}))
+		return
+	}
+
+	var resp any
+	report, err := h.service.Import(r.Context(), req)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		statusCode, message := 200, "Import checked, nothing was stored"
+		if report.Committed {
+			statusCode, message = 201, "Import stored"
+		} else if len(report.Errors) > 0 {
+			statusCode, message = 422, "Import has errors, nothing was stored"
+		}
+		resp = PostResponsePage[ImportReportResponse]{
+			StatusCode: statusCode,
+			Message:    message,
+			Data:       ToImportReportResponse(report),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,23 @@
+package imports
+
+import (
+	"net/url"
+	"strconv"
+
+	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+)
+
+func parseDryRun(q url.Values) (bool, *errMap.BadRequestError) {
+	v := q.Get("dry_run")
+	if v == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &errMap.BadRequestError{
+			Param:  "dry_run",
+			Reason: err.Error(),
+		}
+	}
+	return dryRun, nil
+}
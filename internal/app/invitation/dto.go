@@ -0,0 +1,59 @@
+package invitation
+
+import (
+	"time"
+
+	invitation "github.com/nevinmanoj/hostmate/internal/domain/invitation"
+)
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name"`
+	Password string `json:"password" validate:"required"`
+}
+
+type InvitationResponse struct {
+	ID         int64                       `json:"id"`
+	PropertyID int64                       `json:"property_id"`
+	Email      string                      `json:"email"`
+	Status     invitation.InvitationStatus `json:"status"`
+	InvitedBy  int64                       `json:"invited_by"`
+	AcceptedBy *int64                      `json:"accepted_by,omitempty"`
+	ExpiresAt  time.Time                   `json:"expires_at"`
+	AcceptedAt *time.Time                  `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	UpdatedAt  time.Time                   `json:"updated_at"`
+}
+
+type AcceptInvitationResponse struct {
+	Invitation InvitationResponse `json:"invitation"`
+	UserID     int64              `json:"user_id"`
+	Email      string             `json:"email"`
+	Name       string             `json:"name"`
+}
+
+// InvitationLookupResponse tells the client what to ask the invitee for, existing
+// accounts only confirm with their password
+type InvitationLookupResponse struct {
+	Invitation InvitationResponse `json:"invitation"`
+	HasAccount bool               `json:"has_account"`
+}
+
+func ToInvitationResponse(i *invitation.Invitation) InvitationResponse {
+	return InvitationResponse{
+		ID:         i.ID,
+		PropertyID: i.PropertyID,
+		Email:      i.Email,
+		Status:     i.EffectiveStatus(),
+		InvitedBy:  i.InvitedBy,
+		AcceptedBy: i.AcceptedBy,
+		ExpiresAt:  i.ExpiresAt,
+		AcceptedAt: i.AcceptedAt,
+		CreatedAt:  i.CreatedAt,
+		UpdatedAt:  i.UpdatedAt,
+	}
+}
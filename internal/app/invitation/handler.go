@@ -0,0 +1,233 @@
+package invitation
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	invitation "github.com/nevinmanoj/hostmate/internal/domain/invitation"
+)
+
+type InvitationHandler struct {
+	service   invitation.InvitationService
+	validator *validator.Validate
+}
+
+func NewInvitationHandler(s invitation.InvitationService) *InvitationHandler {
+	return &InvitationHandler{service: s, validator: validator.New()}
+}
+
+func (h *InvitationHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	propertyIdStr := chi.URLParam(r, "propertyId")
+	log.Println("HandlerGetInvitations::Fetching invitations for property ID:", propertyIdStr)
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	propertyId, err := strconv.ParseInt(propertyIdStr, 10, 64)
+	if err != nil {
+		resp = errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "propertyId",
+			Reason: err.Error(),
+		})
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	result, err := h.service.GetByPropertyID(r.Context(), propertyId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		invitationResponses := make([]InvitationResponse, 0, len(result))
+		for _, invitation := range result {
+			invitationResponses = append(invitationResponses, ToInvitationResponse(&invitation))
+		}
+		resp = GetResponsePage[[]InvitationResponse]{
+			StatusCode: 200,
+			Message:    "Invitations fetched successfully",
+			Data:       invitationResponses,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	propertyIdStr := chi.URLParam(r, "propertyId")
+	log.Println("HandlerCreateInvitation::Inviting manager to property ID:", propertyIdStr)
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	propertyId, err := strconv.ParseInt(propertyIdStr, 10, 64)
+	if err != nil {
+		resp = errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "propertyId",
+			Reason: err.Error(),
+		})
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	var req CreateInvitationRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid JSON body",
+		})
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.Invite(ctx, propertyId, req.Email)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[InvitationResponse]{
+			StatusCode: http.StatusCreated,
+			Message:    "Invitation sent successfully",
+			Data:       ToInvitationResponse(result),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *InvitationHandler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	propertyId, invitationId, badRequestError := parseInvitationPath(r)
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	if badRequestError != nil {
+		resp = errmap.GetHttpErrorResponse(badRequestError)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	log.Println("HandlerResendInvitation::Resending invitation with ID:", invitationId)
+	result, err := h.service.Resend(r.Context(), propertyId, invitationId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[InvitationResponse]{
+			StatusCode: http.StatusOK,
+			Message:    "Invitation resent successfully",
+			Data:       ToInvitationResponse(result),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	propertyId, invitationId, badRequestError := parseInvitationPath(r)
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	if badRequestError != nil {
+		resp = errmap.GetHttpErrorResponse(badRequestError)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	log.Println("HandlerRevokeInvitation::Revoking invitation with ID:", invitationId)
+	result, err := h.service.Revoke(r.Context(), propertyId, invitationId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[InvitationResponse]{
+			StatusCode: http.StatusOK,
+			Message:    "Invitation revoked successfully",
+			Data:       ToInvitationResponse(result),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// LookupInvitation serves the link in the invitation mail, accepting is then a POST to the
+// same path with the token and the invitee's password
+func (h *InvitationHandler) LookupInvitation(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerLookupInvitation::Looking up invitation")
+	w.Header().Set("Content-Type", "application/json")
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "token",
+			Reason: "token is required",
+		}))
+		return
+	}
+	result, hasAccount, err := h.service.Lookup(r.Context(), token)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[InvitationLookupResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Invitation fetched successfully",
+		Data: InvitationLookupResponse{
+			Invitation: ToInvitationResponse(result),
+			HasAccount: hasAccount,
+		},
+	})
+}
+
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req AcceptInvitationRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	w.Header().Set("Content-Type", "application/json")
+	if err := dec.Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid JSON body",
+		})
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return
+	}
+
+	var resp any
+	accepted, invitee, err := h.service.Accept(ctx, req.Token, req.Name, req.Password)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[AcceptInvitationResponse]{
+			StatusCode: http.StatusOK,
+			Message:    "Invitation accepted successfully",
+			Data: AcceptInvitationResponse{
+				Invitation: ToInvitationResponse(accepted),
+				UserID:     invitee.ID,
+				Email:      invitee.Email,
+				Name:       invitee.Name,
+			},
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func parseInvitationPath(r *http.Request) (int64, int64, *errmap.BadRequestError) {
+	propertyId, err := strconv.ParseInt(chi.URLParam(r, "propertyId"), 10, 64)
+	if err != nil {
+		return 0, 0, &errmap.BadRequestError{
+			Param:  "propertyId",
+			Reason: err.Error(),
+		}
+	}
+	invitationId, err := strconv.ParseInt(chi.URLParam(r, "invitationId"), 10, 64)
+	if err != nil {
+		return 0, 0, &errmap.BadRequestError{
+			Param:  "invitationId",
+			Reason: err.Error(),
+		}
+	}
+	return propertyId, invitationId, nil
+}
@@ -0,0 +1,39 @@
+package invoice
+
+import (
+	"time"
+
+	invoice "github.com/nevinmanoj/hostmate/internal/domain/invoice"
+)
+
+type DocumentResponse struct {
+	ID              int64                `json:"id"`
+	Type            invoice.DocumentType `json:"type"`
+	Number          string               `json:"number"`
+	PropertyID      int64                `json:"property_id"`
+	BookingID       int64                `json:"booking_id"`
+	PaymentID       *int64               `json:"payment_id,omitempty"`
+	BookingRevision int                  `json:"booking_revision"`
+	Total           float64              `json:"total"`
+	Balance         float64              `json:"balance"`
+	IssuedBy        int64                `json:"issued_by"`
+	IssuedAt        time.Time            `json:"issued_at"`
+	DownloadURL     string               `json:"download_url,omitempty"`
+}
+
+func ToDocumentResponse(d *invoice.Document, downloadURL string) DocumentResponse {
+	return DocumentResponse{
+		ID:              d.ID,
+		Type:            d.Type,
+		Number:          d.Number,
+		PropertyID:      d.PropertyID,
+		BookingID:       d.BookingID,
+		PaymentID:       d.PaymentID,
+		BookingRevision: d.BookingRevision,
+		Total:           d.Total,
+		Balance:         d.Balance,
+		IssuedBy:        d.IssuedBy,
+		IssuedAt:        d.IssuedAt,
+		DownloadURL:     downloadURL,
+	}
+}
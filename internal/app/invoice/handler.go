@@ -0,0 +1,114 @@
+package invoice
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	invoice "github.com/nevinmanoj/hostmate/internal/domain/invoice"
+)
+
+type InvoiceHandler struct {
+	service invoice.InvoiceService
+}
+
+func NewInvoiceHandler(s invoice.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{service: s}
+}
+
+func (h *InvoiceHandler) IssueInvoice(w http.ResponseWriter, r *http.Request) {
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	log.Println("HandlerIssueInvoice::Issuing invoice for booking with ID:", bookingId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	doc, url, err := h.service.IssueInvoice(r.Context(), bookingId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[DocumentResponse]{
+			StatusCode: 201,
+			Message:    "Invoice issued successfully",
+			Data:       ToDocumentResponse(doc, url),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *InvoiceHandler) IssueReceipt(w http.ResponseWriter, r *http.Request) {
+	paymentId, badRequestError := parseIDParam("paymentId", chi.URLParam(r, "paymentId"))
+	log.Println("HandlerIssueReceipt::Issuing receipt for payment with ID:", paymentId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	doc, url, err := h.service.IssueReceipt(r.Context(), paymentId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[DocumentResponse]{
+			StatusCode: 201,
+			Message:    "Receipt issued successfully",
+			Data:       ToDocumentResponse(doc, url),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetBookingDocuments lists the invoices and receipts of a booking, newest first
+func (h *InvoiceHandler) GetBookingDocuments(w http.ResponseWriter, r *http.Request) {
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	log.Println("HandlerGetBookingDocuments::Fetching documents of booking with ID:", bookingId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	result, err := h.service.GetByBooking(r.Context(), bookingId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		documentResponses := make([]DocumentResponse, 0, len(result))
+		for _, doc := range result {
+			documentResponses = append(documentResponses, ToDocumentResponse(&doc, ""))
+		}
+		resp = GetResponsePage[[]DocumentResponse]{
+			StatusCode: 200,
+			Message:    "Documents fetched successfully",
+			Data:       documentResponses,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// DownloadDocument hands out a temporary read url of the stored pdf
+func (h *InvoiceHandler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	documentId, badRequestError := parseIDParam("documentId", chi.URLParam(r, "documentId"))
+	log.Println("HandlerDownloadDocument::Generating download url of document with ID:", documentId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	doc, url, err := h.service.GetDownloadURL(r.Context(), documentId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = GetResponsePage[DocumentResponse]{
+			StatusCode: 200,
+			Message:    "Download url generated successfully",
+			Data:       ToDocumentResponse(doc, url),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,57 @@
+package organisation
+
+import (
+	"time"
+
+	organisation "github.com/nevinmanoj/hostmate/internal/domain/organisation"
+)
+
+type CreateOrganisationRequest struct {
+	Name string `json:"name" validate:"required,max=200"`
+}
+
+type UpdateMemberRoleRequest struct {
+	Role organisation.Role `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type OrganisationResponse struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Role      organisation.Role `json:"role,omitempty"`
+	CreatedBy int64             `json:"created_by"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type MemberResponse struct {
+	UserID    int64             `json:"user_id"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email"`
+	Role      organisation.Role `json:"role"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// SwitchOrganisationResponse carries a session token acting in the selected organisation
+type SwitchOrganisationResponse struct {
+	Token          string `json:"token"`
+	OrganisationID int64  `json:"organisation_id"`
+}
+
+func ToOrganisationResponse(o *organisation.Organisation, role organisation.Role) OrganisationResponse {
+	return OrganisationResponse{
+		ID:        o.ID,
+		Name:      o.Name,
+		Role:      role,
+		CreatedBy: o.CreatedBy,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+func ToMemberResponse(m *organisation.Member) MemberResponse {
+	return MemberResponse{
+		UserID:    m.UserID,
+		Name:      m.Name,
+		Email:     m.Email,
+		Role:      m.Role,
+		CreatedAt: m.CreatedAt,
+	}
+}
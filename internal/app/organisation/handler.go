@@ -0,0 +1,196 @@
+package organisation
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	organisation "github.com/nevinmanoj/hostmate/internal/domain/organisation"
+)
+
+type OrganisationHandler struct {
+	service   organisation.OrganisationService
+	validator *validator.Validate
+}
+
+func NewOrganisationHandler(s organisation.OrganisationService) *OrganisationHandler {
+	return &OrganisationHandler{service: s, validator: validator.New()}
+}
+
+func (h *OrganisationHandler) CreateOrganisation(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerCreateOrganisation::Creating organisation")
+	var req CreateOrganisationRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	var resp any
+	result, err := h.service.Create(r.Context(), req.Name)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[OrganisationResponse]{
+			StatusCode: http.StatusCreated,
+			Message:    "Organisation created successfully",
+			Data:       ToOrganisationResponse(result, organisation.RoleOwner),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *OrganisationHandler) GetOrganisations(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetOrganisations::Fetching organisations of the user")
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	result, err := h.service.GetMine(r.Context())
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		organisationResponses := make([]OrganisationResponse, 0, len(result))
+		for _, membership := range result {
+			organisationResponses = append(organisationResponses, ToOrganisationResponse(&membership.Organisation, membership.Role))
+		}
+		resp = GetResponsePage[[]OrganisationResponse]{
+			StatusCode: 200,
+			Message:    "Organisations fetched successfully",
+			Data:       organisationResponses,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *OrganisationHandler) GetCurrentOrganisation(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetCurrentOrganisation::Fetching current organisation")
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	result, err := h.service.GetCurrent(r.Context())
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = GetResponsePage[OrganisationResponse]{
+			StatusCode: 200,
+			Message:    "Organisation fetched successfully",
+			Data:       ToOrganisationResponse(&result.Organisation, result.Role),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *OrganisationHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetMembers::Fetching members of current organisation")
+	w.Header().Set("Content-Type", "application/json")
+	var resp any
+	result, err := h.service.GetMembers(r.Context())
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		memberResponses := make([]MemberResponse, 0, len(result))
+		for _, member := range result {
+			memberResponses = append(memberResponses, ToMemberResponse(&member))
+		}
+		resp = GetResponsePage[[]MemberResponse]{
+			StatusCode: 200,
+			Message:    "Members fetched successfully",
+			Data:       memberResponses,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *OrganisationHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	userIdStr := chi.URLParam(r, "userId")
+	log.Println("HandlerUpdateMemberRole::Updating role of member with user ID:", userIdStr)
+	w.Header().Set("Content-Type", "application/json")
+	userId, badRequestError := parseIDParam("userId", userIdStr)
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var req UpdateMemberRoleRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	var resp any
+	result, err := h.service.UpdateMemberRole(r.Context(), userId, req.Role)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PutResponsePage[MemberResponse]{
+			StatusCode: 200,
+			Message:    "Member role updated successfully",
+			Data:       ToMemberResponse(result),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *OrganisationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userIdStr := chi.URLParam(r, "userId")
+	log.Println("HandlerRemoveMember::Removing member with user ID:", userIdStr)
+	w.Header().Set("Content-Type", "application/json")
+	userId, badRequestError := parseIDParam("userId", userIdStr)
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	err := h.service.RemoveMember(r.Context(), userId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Member removed successfully",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *OrganisationHandler) SwitchOrganisation(w http.ResponseWriter, r *http.Request) {
+	organisationIdStr := chi.URLParam(r, "organisationId")
+	log.Println("HandlerSwitchOrganisation::Switching to organisation ID:", organisationIdStr)
+	w.Header().Set("Content-Type", "application/json")
+	organisationId, badRequestError := parseIDParam("organisationId", organisationIdStr)
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	token, err := h.service.Switch(r.Context(), organisationId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PostResponsePage[SwitchOrganisationResponse]{
+			StatusCode: http.StatusOK,
+			Message:    "Switched organisation successfully",
+			Data: SwitchOrganisationResponse{
+				Token:          token,
+				OrganisationID: organisationId,
+			},
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// helpers
+func (h *OrganisationHandler) decodeBody(w http.ResponseWriter, r *http.Request, req any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	w.Header().Set("Content-Type", "application/json")
+	if err := dec.Decode(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid JSON body",
+		})
+		return false
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return false
+	}
+	return true
+}
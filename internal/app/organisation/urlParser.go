@@ -0,0 +1,18 @@
+package organisation
+
+import (
+	"strconv"
+
+	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+)
+
+func parseIDParam(param, v string) (int64, *errMap.BadRequestError) {
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &errMap.BadRequestError{
+			Param:  param,
+			Reason: err.Error(),
+		}
+	}
+	return id, nil
+}
@@ -3,6 +3,8 @@ package payment
 import (
 	"time"
 
+	. "github.com/nevinmanoj/hostmate/api"
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
 	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
 )
 
@@ -19,6 +21,32 @@ type UpdatePaymentRequest struct {
 	CreatePaymentRequest
 }
 
+// ToUpdatePaymentRequest renders the stored payment as the document a merge patch is applied to
+func ToUpdatePaymentRequest(p *payment.Payment) UpdatePaymentRequest {
+	return UpdatePaymentRequest{
+		ID: p.ID,
+		CreatePaymentRequest: CreatePaymentRequest{
+			Amount:      p.Amount,
+			Date:        p.Date,
+			PaymentType: p.PaymentType,
+			BookingID:   p.BookingID,
+			Remarks:     p.Remarks,
+		},
+	}
+}
+
+func ToPayment(req *UpdatePaymentRequest, version int) payment.Payment {
+	return payment.Payment{
+		ID:          req.ID,
+		Amount:      req.Amount,
+		Date:        req.Date,
+		PaymentType: req.PaymentType,
+		BookingID:   req.BookingID,
+		Remarks:     req.Remarks,
+		Version:     version,
+	}
+}
+
 type PaymentResponse struct {
 	ID          int64               `json:"id"`
 	Amount      float64             `json:"amount"`
@@ -30,6 +58,9 @@ type PaymentResponse struct {
 	UpdatedAt   time.Time           `json:"updated_at"`
 	CreatedBy   int64               `json:"created_by"`
 	UpdatedBy   int64               `json:"updated_by"`
+	Version     int                 `json:"version"`
+	DeletedAt   *time.Time          `json:"deleted_at,omitempty"`
+	DeletedBy   *int64              `json:"deleted_by,omitempty"`
 }
 
 func ToPaymentResponse(p *payment.Payment) PaymentResponse {
@@ -44,5 +75,66 @@ func ToPaymentResponse(p *payment.Payment) PaymentResponse {
 		UpdatedAt:   p.UpdatedAt,
 		CreatedBy:   p.CreatedBy,
 		UpdatedBy:   p.UpdatedBy,
+		Version:     p.Version,
+		DeletedAt:   p.DeletedAt,
+		DeletedBy:   p.DeletedBy,
+	}
+}
+
+// PaymentPageResponse is a page of payments with the totals of every payment matching the filter
+type PaymentPageResponse struct {
+	GetAllResponsePage[PaymentResponse]
+	Totals *PaymentTotalsResponse `json:"totals,omitempty"`
+}
+
+type PaymentTotalsResponse struct {
+	Count  int                        `json:"count"`
+	Amount float64                    `json:"amount"`
+	ByType []PaymentTypeTotalResponse `json:"by_type"`
+}
+
+type PaymentTypeTotalResponse struct {
+	PaymentType payment.PaymentType `json:"payment_type"`
+	Count       int                 `json:"count"`
+	Amount      float64             `json:"amount"`
+}
+
+func ToPaymentTotalsResponse(t *payment.PaymentTotals) *PaymentTotalsResponse {
+	byType := make([]PaymentTypeTotalResponse, 0, len(t.ByType))
+	for _, typeTotal := range t.ByType {
+		byType = append(byType, PaymentTypeTotalResponse{
+			PaymentType: typeTotal.PaymentType,
+			Count:       typeTotal.Count,
+			Amount:      typeTotal.Amount,
+		})
+	}
+	return &PaymentTotalsResponse{
+		Count:  t.Count,
+		Amount: t.Amount,
+		ByType: byType,
+	}
+}
+
+var paymentExportHeader = []string{
+	"Payment ID",
+	"Booking ID",
+	"Date",
+	"Amount",
+	"Payment Type",
+	"Remarks",
+	"Created At",
+	"Created By",
+}
+
+func ToPaymentExportRow(p *payment.Payment) []export.Cell {
+	return []export.Cell{
+		export.Int(p.ID),
+		export.Int(p.BookingID),
+		export.Date(p.Date),
+		export.Money(p.Amount),
+		export.Text(string(p.PaymentType)),
+		export.Text(p.Remarks),
+		export.DateTime(p.CreatedAt),
+		export.Int(p.CreatedBy),
 	}
 }
@@ -10,6 +10,9 @@ import (
 	"github.com/go-playground/validator/v10"
 	. "github.com/nevinmanoj/hostmate/api"
 	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
+	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
 )
 
@@ -31,7 +34,11 @@ func (h *PaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
-	result, total, err := h.service.GetAll(r.Context(), filter)
+	if format := export.Negotiate(r); format != export.FormatNone {
+		h.exportPayments(w, r, filter, format)
+		return
+	}
+	result, totals, cursors, err := h.service.GetAll(r.Context(), filter)
 	w.Header().Set("Content-Type", "application/json")
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
@@ -40,18 +47,47 @@ func (h *PaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
 		for _, payment := range result {
 			paymentResponses = append(paymentResponses, ToPaymentResponse(&payment))
 		}
-		resp = GetAllResponsePage[PaymentResponse]{
-			StatusCode:   200,
-			Message:      "Payments fetched successfully",
-			TotalRecords: total,
-			Limit:        filter.Limit,
-			Offset:       filter.Offset,
-			Data:         paymentResponses,
+		page := PaymentPageResponse{
+			GetAllResponsePage: GetAllResponsePage[PaymentResponse]{
+				StatusCode: 200,
+				Message:    "Payments fetched successfully",
+				Limit:      filter.Limit,
+				Offset:     filter.Offset,
+				NextCursor: cursors.Next,
+				PrevCursor: cursors.Prev,
+				Data:       paymentResponses,
+			},
 		}
+		// the total and totals are left out when the caller skipped counting
+		if !filter.SkipCount {
+			page.TotalRecords = &totals.Count
+			page.Totals = ToPaymentTotalsResponse(&totals)
+		}
+		resp = page
 	}
 	json.NewEncoder(w).Encode(resp)
 }
 
+// exportPayments streams every payment matching the filter instead of one page
+func (h *PaymentHandler) exportPayments(w http.ResponseWriter, r *http.Request, filter payment.PaymentFilter, format export.Format) {
+	log.Println("HandlerExportPayments::Exporting payments as", format)
+	filter.Limit = export.BatchSize
+	filter.Offset = 0
+	filter.SkipCount = true
+	err := export.Stream(w, format, "payments", paymentExportHeader, filter.Sort,
+		func(cursor *pagination.Cursor) ([]payment.Payment, pagination.Cursors, error) {
+			filter.Cursor = cursor
+			result, _, cursors, err := h.service.GetAll(r.Context(), filter)
+			return result, cursors, err
+		},
+		ToPaymentExportRow,
+	)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+	}
+}
+
 func (h *PaymentHandler) GetPaymentsWithBookingId(w http.ResponseWriter, r *http.Request) {
 	log.Println("handlerGetPaymentWithBookingId::Fetching paymenty with booking id")
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -78,7 +114,7 @@ func (h *PaymentHandler) GetPaymentsWithBookingId(w http.ResponseWriter, r *http
 		resp = GetAllResponsePage[PaymentResponse]{
 			StatusCode:   200,
 			Message:      "Paymnets fetched successfully for Booking ID " + bookingIdstr,
-			TotalRecords: total,
+			TotalRecords: &total,
 			Limit:        limit,
 			Offset:       offset,
 			Data:         paymentResponses,
@@ -112,7 +148,7 @@ func (h *PaymentHandler) GetPaymentsWithPropertyId(w http.ResponseWriter, r *htt
 		resp = GetAllResponsePage[PaymentResponse]{
 			StatusCode:   200,
 			Message:      "Paymnets fetched successfully for Booking ID " + bookingIdstr,
-			TotalRecords: total,
+			TotalRecords: &total,
 			Limit:        limit,
 			Offset:       offset,
 			Data:         paymentResponses,
@@ -137,6 +173,7 @@ func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
 	} else {
+		httputil.SetETag(w, result.Version)
 		paymentResponse := ToPaymentResponse(result)
 		resp = GetResponsePage[PaymentResponse]{
 			StatusCode: 200,
@@ -262,25 +299,131 @@ func (h *PaymentHandler) UpdatePayment(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	paymentToUpdate := payment.Payment{
-		ID:          req.ID,
-		Amount:      req.Amount,
-		Date:        req.Date,
-		PaymentType: req.PaymentType,
-		BookingID:   req.BookingID,
-		Remarks:     req.Remarks,
+	version, err := parseIfMatch(r)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(err))
+		return
 	}
+	paymentToUpdate := ToPayment(&req, version)
 	err = h.service.Update(ctx, &paymentToUpdate)
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
 	} else {
+		httputil.SetETag(w, paymentToUpdate.Version)
 		bookingResponse := ToPaymentResponse(&paymentToUpdate)
 		resp = PutResponsePage[PaymentResponse]{
 			StatusCode: http.StatusOK,
 			Message:    "Payment updated successfully",
 			Data:       bookingResponse,
 		}
-		json.NewEncoder(w).Encode(resp)
 	}
+	json.NewEncoder(w).Encode(resp)
+}
 
+func (h *PaymentHandler) DeletePayment(w http.ResponseWriter, r *http.Request) {
+	paymentId, badRequestError := parseIDParam("paymentId", chi.URLParam(r, "paymentId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerDeletePayment::Deleting payment with ID:", paymentId)
+	err := h.service.Delete(r.Context(), paymentId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Payment deleted successfully",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *PaymentHandler) RestorePayment(w http.ResponseWriter, r *http.Request) {
+	paymentId, badRequestError := parseIDParam("paymentId", chi.URLParam(r, "paymentId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerRestorePayment::Restoring payment with ID:", paymentId)
+	result, err := h.service.Restore(r.Context(), paymentId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, result.Version)
+	json.NewEncoder(w).Encode(PutResponsePage[PaymentResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Payment restored successfully",
+		Data:       ToPaymentResponse(result),
+	})
+}
+
+// PatchPayment applies a JSON merge patch to the stored payment and saves it like UpdatePayment
+func (h *PaymentHandler) PatchPayment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	bookingId, badRequestError := parseIDParam("bookingId", chi.URLParam(r, "bookingId"))
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	paymentId, badRequestError := parseIDParam("paymentId", chi.URLParam(r, "paymentId"))
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerPatchPayment::Patching payment with ID:", paymentId)
+	version, err := parseIfMatch(r)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(err))
+		return
+	}
+	current, err := h.service.GetById(ctx, paymentId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	var req UpdatePaymentRequest
+	if err := httputil.DecodeMergePatch(r, ToUpdatePaymentRequest(current), &req); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if req.ID != paymentId {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "payment ID in URL and body do not match or invalid",
+		})
+		return
+	}
+	if req.BookingID != bookingId {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "booking ID in URL and body do not match or invalid",
+		})
+		return
+	}
+	paymentToUpdate := ToPayment(&req, version)
+	err = h.service.Update(ctx, &paymentToUpdate)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, paymentToUpdate.Version)
+	json.NewEncoder(w).Encode(PutResponsePage[PaymentResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Payment updated successfully",
+		Data:       ToPaymentResponse(&paymentToUpdate),
+	})
 }
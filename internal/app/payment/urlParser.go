@@ -1,13 +1,16 @@
 package payment
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
 	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
 	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
 )
 
@@ -46,6 +49,118 @@ func parsePaymentFilter(q url.Values) (payment.PaymentFilter, *errMap.BadRequest
 		f.ToDate = toDate
 	}
 
+	if v := q.Get("property_id"); v != "" {
+		propertyId, err := httputil.ParseInt64Slice(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "property_id",
+				Reason: err.Error(),
+			}
+		}
+		f.PropertyID = propertyId
+	}
+
+	if v := q.Get("booking_id"); v != "" {
+		bookingId, err := httputil.ParseInt64Slice(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "booking_id",
+				Reason: err.Error(),
+			}
+		}
+		f.BookingID = bookingId
+	}
+
+	if v := q.Get("min_amount"); v != "" {
+		minAmount, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "min_amount",
+				Reason: err.Error(),
+			}
+		}
+		f.MinAmount = &minAmount
+	}
+
+	if v := q.Get("max_amount"); v != "" {
+		maxAmount, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "max_amount",
+				Reason: err.Error(),
+			}
+		}
+		if f.MinAmount != nil && maxAmount < *f.MinAmount {
+			return f, &errMap.BadRequestError{
+				Param:  "max_amount",
+				Reason: "must not be less than min_amount",
+			}
+		}
+		f.MaxAmount = &maxAmount
+	}
+
+	if v := q.Get("created_by"); v != "" {
+		createdBy, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "created_by",
+				Reason: err.Error(),
+			}
+		}
+		f.CreatedBy = &createdBy
+	}
+
+	if v := strings.TrimSpace(q.Get("remarks")); v != "" {
+		f.Remarks = &v
+	}
+
+	if v := q.Get("include_deleted"); v != "" {
+		includeDeleted, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "include_deleted",
+				Reason: err.Error(),
+			}
+		}
+		f.IncludeDeleted = includeDeleted
+	}
+
+	// Sorting, newest first unless asked otherwise
+	f.Sort = pagination.Sort{Field: "created_at", Desc: true}
+	if v := q.Get("sort"); v != "" {
+		sort, err := pagination.ParseSort(v, payment.SortFields)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "sort",
+				Reason: fmt.Sprintf("%s, must be one of %v with an optional - prefix", err.Error(), payment.SortFields),
+			}
+		}
+		f.Sort = sort
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := pagination.DecodeCursor(v, f.Sort)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "cursor",
+				Reason: err.Error(),
+			}
+		}
+		f.Cursor = cursor
+	}
+
+	// counting can be skipped on large tables, the cursors still tell if there are more pages
+	if v := q.Get("count"); v != "" {
+		count, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "count",
+				Reason: err.Error(),
+			}
+		}
+		f.SkipCount = !count
+	}
+
 	// Pagination defaults
 	f.Limit = 100
 	f.Offset = 0
@@ -107,3 +222,29 @@ func parsePaymentTypeSlice(v string) ([]payment.PaymentType, error) {
 
 	return out, nil
 }
+
+// parseIfMatch reads the version the client is updating from the If-Match header
+func parseIfMatch(r *http.Request) (int, error) {
+	version, err := httputil.ParseIfMatch(r)
+	if errors.Is(err, httputil.ErrMissingIfMatch) {
+		return 0, &errMap.PreconditionRequiredError{Reason: err.Error()}
+	}
+	if err != nil {
+		return 0, &errMap.BadRequestError{
+			Param:  "If-Match",
+			Reason: err.Error(),
+		}
+	}
+	return version, nil
+}
+
+func parseIDParam(param, v string) (int64, *errMap.BadRequestError) {
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &errMap.BadRequestError{
+			Param:  param,
+			Reason: err.Error(),
+		}
+	}
+	return id, nil
+}
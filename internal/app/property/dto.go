@@ -1,6 +1,10 @@
 package property
 
 import (
+	"time"
+
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
 	property "github.com/nevinmanoj/hostmate/internal/domain/property"
 )
 
@@ -25,24 +29,64 @@ type UpdatePropertyRequest struct {
 	ExtraRatePerGuest float64  `json:"extra_rate_per_guest" validate:"gte=0"`
 	Managers          []int64  `json:"managers" validate:"required,min=1,dive,gt=0"`
 	Photos            []string `json:"photos" validate:"omitempty"`
-	Active            *bool    `json:"active,omitempty"`
+	Active            *bool    `json:"active,omitempty" validate:"required"`
+}
+
+// ToUpdatePropertyRequest renders the stored property as the document a merge patch is applied to
+func ToUpdatePropertyRequest(p *property.Property) UpdatePropertyRequest {
+	active := p.Active
+	return UpdatePropertyRequest{
+		ID:                p.ID,
+		Name:              p.Name,
+		Address:           p.Address,
+		Type:              string(p.Type),
+		BaseRate:          p.BaseRate,
+		MaxGuestsBase:     p.MaxGuestsBase,
+		ExtraRatePerGuest: p.ExtraRatePerGuest,
+		Managers:          p.Managers,
+		Photos:            p.Photos,
+		Active:            &active,
+	}
+}
+
+func ToProperty(req *UpdatePropertyRequest, version int) property.Property {
+	return property.Property{
+		ID:                req.ID,
+		Name:              req.Name,
+		Address:           req.Address,
+		Type:              property.PropertyType(req.Type),
+		BaseRate:          req.BaseRate,
+		MaxGuestsBase:     req.MaxGuestsBase,
+		ExtraRatePerGuest: req.ExtraRatePerGuest,
+		Managers:          req.Managers,
+		Photos:            req.Photos,
+		Active:            *req.Active,
+		Version:           version,
+	}
+}
+
+type SetPropertyMemberRequest struct {
+	Capabilities []access.Capability `json:"capabilities" validate:"required,min=1,dive,oneof=view_bookings edit_bookings record_payments view_financials manage_property manage_attachments"`
 }
 
 type PropertyResponse struct {
-	ID                int64    `json:"id"`
-	Name              string   `json:"name"`
-	Address           string   `json:"address" `
-	Type              string   `json:"type"`
-	BaseRate          float64  `json:"base_rate"`
-	MaxGuestsBase     int      `json:"max_guests_base"`
-	ExtraRatePerGuest float64  `json:"extra_rate_per_guest"`
-	Managers          []int64  `json:"managers" validate:"required,min=1,dive,gt=0"`
-	Photos            []string `json:"photos" validate:"omitempty"`
-	Active            bool     `json:"active"`
-	CreatedAt         string   `json:"created_at"`
-	UpdatedAt         string   `json:"updated_at"`
-	UpdatedBy         int64    `json:"updated_by"`
-	CreatedBy         int64    `json:"created_by"`
+	ID                int64      `json:"id"`
+	Name              string     `json:"name"`
+	Address           string     `json:"address" `
+	Type              string     `json:"type"`
+	BaseRate          float64    `json:"base_rate"`
+	MaxGuestsBase     int        `json:"max_guests_base"`
+	ExtraRatePerGuest float64    `json:"extra_rate_per_guest"`
+	Managers          []int64    `json:"managers" validate:"required,min=1,dive,gt=0"`
+	Photos            []string   `json:"photos" validate:"omitempty"`
+	Active            bool       `json:"active"`
+	CreatedAt         string     `json:"created_at"`
+	UpdatedAt         string     `json:"updated_at"`
+	UpdatedBy         int64      `json:"updated_by"`
+	CreatedBy         int64      `json:"created_by"`
+	Version           int        `json:"version"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy         *int64     `json:"deleted_by,omitempty"`
 }
 
 func ToPropertyResponse(p *property.Property) PropertyResponse {
@@ -61,5 +105,58 @@ func ToPropertyResponse(p *property.Property) PropertyResponse {
 		UpdatedAt:         p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		CreatedBy:         p.CreatedBy,
 		UpdatedBy:         p.UpdatedBy,
+		Version:           p.Version,
+		DeletedAt:         p.DeletedAt,
+		DeletedBy:         p.DeletedBy,
+	}
+}
+
+type PropertyMemberResponse struct {
+	UserID       int64     `json:"user_id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Capabilities []string  `json:"capabilities"`
+	CreatedBy    int64     `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func ToPropertyMemberResponse(m *property.PropertyMember) PropertyMemberResponse {
+	return PropertyMemberResponse{
+		UserID:       m.UserID,
+		Name:         m.Name,
+		Email:        m.Email,
+		Capabilities: m.Capabilities,
+		CreatedBy:    m.CreatedBy,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
+
+var propertyExportHeader = []string{
+	"Property ID",
+	"Name",
+	"Address",
+	"Type",
+	"Base Rate",
+	"Base Guests",
+	"Extra Rate Per Guest",
+	"Active",
+	"Created At",
+	"Updated At",
+}
+
+func ToPropertyExportRow(p *property.Property) []export.Cell {
+	return []export.Cell{
+		export.Int(p.ID),
+		export.Text(p.Name),
+		export.Text(p.Address),
+		export.Text(string(p.Type)),
+		export.Money(p.BaseRate),
+		export.Int(int64(p.MaxGuestsBase)),
+		export.Money(p.ExtraRatePerGuest),
+		export.Bool(p.Active),
+		export.DateTime(p.CreatedAt),
+		export.DateTime(p.UpdatedAt),
 	}
 }
@@ -12,6 +12,9 @@ import (
 
 	. "github.com/nevinmanoj/hostmate/api"
 	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
+	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	property "github.com/nevinmanoj/hostmate/internal/domain/property"
 )
 
@@ -33,7 +36,11 @@ func (h *PropertyHandler) GetProperties(w http.ResponseWriter, r *http.Request)
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
-	result, total, err := h.service.GetAll(r.Context(), filter)
+	if format := export.Negotiate(r); format != export.FormatNone {
+		h.exportProperties(w, r, filter, format)
+		return
+	}
+	result, total, cursors, err := h.service.GetAll(r.Context(), filter)
 	w.Header().Set("Content-Type", "application/json")
 
 	if err != nil {
@@ -43,17 +50,44 @@ func (h *PropertyHandler) GetProperties(w http.ResponseWriter, r *http.Request)
 		for _, property := range result {
 			propertyResponses = append(propertyResponses, ToPropertyResponse(&property))
 		}
+		// the total is left out when the caller skipped counting
+		var totalRecords *int
+		if !filter.SkipCount {
+			totalRecords = &total
+		}
 		resp = GetAllResponsePage[PropertyResponse]{
 			StatusCode:   200,
 			Message:      "Properties fetched successfully",
-			TotalRecords: total,
+			TotalRecords: totalRecords,
 			Limit:        filter.Limit,
 			Offset:       filter.Offset,
+			NextCursor:   cursors.Next,
+			PrevCursor:   cursors.Prev,
 			Data:         propertyResponses,
 		}
 	}
 	json.NewEncoder(w).Encode(resp)
 }
+
+// exportProperties streams every property matching the filter instead of one page
+func (h *PropertyHandler) exportProperties(w http.ResponseWriter, r *http.Request, filter property.PropertyFilter, format export.Format) {
+	log.Println("HandlerExportProperties::Exporting properties as", format)
+	filter.Limit = export.BatchSize
+	filter.Offset = 0
+	filter.SkipCount = true
+	err := export.Stream(w, format, "properties", propertyExportHeader, filter.Sort,
+		func(cursor *pagination.Cursor) ([]property.Property, pagination.Cursors, error) {
+			filter.Cursor = cursor
+			result, _, cursors, err := h.service.GetAll(r.Context(), filter)
+			return result, cursors, err
+		},
+		ToPropertyExportRow,
+	)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+	}
+}
 func (h *PropertyHandler) GetProperty(w http.ResponseWriter, r *http.Request) {
 
 	idStr := chi.URLParam(r, "propertyId")
@@ -70,6 +104,7 @@ func (h *PropertyHandler) GetProperty(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
 	} else {
+		httputil.SetETag(w, result.Version)
 		propertyResponse := ToPropertyResponse(result)
 		resp = GetResponsePage[PropertyResponse]{
 			StatusCode: 200,
@@ -158,23 +193,18 @@ func (h *PropertyHandler) UpdateProperty(w http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
-	propertyToUpdate := property.Property{
-		ID:                req.ID,
-		Name:              req.Name,
-		Address:           req.Address,
-		Type:              property.PropertyType(req.Type),
-		BaseRate:          req.BaseRate,
-		MaxGuestsBase:     req.MaxGuestsBase,
-		ExtraRatePerGuest: req.ExtraRatePerGuest,
-		Managers:          req.Managers,
-		Photos:            req.Photos,
-		Active:            *req.Active,
+	version, err := parseIfMatch(r)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(err))
+		return
 	}
+	propertyToUpdate := ToProperty(&req, version)
 	err = h.service.Update(ctx, &propertyToUpdate)
 	var resp any
 	if err != nil {
 		resp = errmap.GetDomainErrorResponse(err)
 	} else {
+		httputil.SetETag(w, propertyToUpdate.Version)
 		propertyResponse := ToPropertyResponse(&propertyToUpdate)
 		resp = PutResponsePage[PropertyResponse]{
 			StatusCode: 200,
@@ -184,3 +214,184 @@ func (h *PropertyHandler) UpdateProperty(w http.ResponseWriter, r *http.Request)
 	}
 	json.NewEncoder(w).Encode(resp)
 }
+
+func (h *PropertyHandler) GetPropertyMembers(w http.ResponseWriter, r *http.Request) {
+	propertyIdStr := chi.URLParam(r, "propertyId")
+	log.Println("HandlerGetPropertyMembers::Fetching members of property ID:", propertyIdStr)
+	w.Header().Set("Content-Type", "application/json")
+	propertyId, badRequestError := parseIDParam("propertyId", propertyIdStr)
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	result, err := h.service.GetMembers(r.Context(), propertyId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		memberResponses := make([]PropertyMemberResponse, 0, len(result))
+		for _, member := range result {
+			memberResponses = append(memberResponses, ToPropertyMemberResponse(&member))
+		}
+		resp = GetResponsePage[[]PropertyMemberResponse]{
+			StatusCode: 200,
+			Message:    "Property members fetched successfully",
+			Data:       memberResponses,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *PropertyHandler) SetPropertyMember(w http.ResponseWriter, r *http.Request) {
+	propertyId, userId, badRequestError := parseMemberPath(r)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Printf("HandlerSetPropertyMember::Setting capabilities of user %d on property %d", userId, propertyId)
+	var req SetPropertyMemberRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid JSON body",
+		})
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return
+	}
+	var resp any
+	result, err := h.service.SetMember(r.Context(), propertyId, userId, req.Capabilities)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PutResponsePage[PropertyMemberResponse]{
+			StatusCode: 200,
+			Message:    "Property member saved successfully",
+			Data:       ToPropertyMemberResponse(result),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *PropertyHandler) RemovePropertyMember(w http.ResponseWriter, r *http.Request) {
+	propertyId, userId, badRequestError := parseMemberPath(r)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Printf("HandlerRemovePropertyMember::Removing user %d from property %d", userId, propertyId)
+	err := h.service.RemoveMember(r.Context(), propertyId, userId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Property member removed successfully",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *PropertyHandler) DeleteProperty(w http.ResponseWriter, r *http.Request) {
+	propertyId, badRequestError := parseIDParam("propertyId", chi.URLParam(r, "propertyId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerDeleteProperty::Deleting property with ID:", propertyId)
+	err := h.service.Delete(r.Context(), propertyId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Property deleted successfully",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *PropertyHandler) RestoreProperty(w http.ResponseWriter, r *http.Request) {
+	propertyId, badRequestError := parseIDParam("propertyId", chi.URLParam(r, "propertyId"))
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerRestoreProperty::Restoring property with ID:", propertyId)
+	result, err := h.service.Restore(r.Context(), propertyId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, result.Version)
+	json.NewEncoder(w).Encode(PutResponsePage[PropertyResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Property restored successfully",
+		Data:       ToPropertyResponse(result),
+	})
+}
+
+// PatchProperty applies a JSON merge patch to the stored property and saves it like UpdateProperty
+func (h *PropertyHandler) PatchProperty(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	propertyId, badRequestError := parseIDParam("propertyId", chi.URLParam(r, "propertyId"))
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	log.Println("HandlerPatchProperty::Patching property with ID:", propertyId)
+	version, err := parseIfMatch(r)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(err))
+		return
+	}
+	current, err := h.service.GetById(ctx, propertyId)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	var req UpdatePropertyRequest
+	if err := httputil.DecodeMergePatch(r, ToUpdatePropertyRequest(current), &req); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if req.ID != propertyId {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "ID in URL and body do not match or invalid",
+		})
+		return
+	}
+	propertyToUpdate := ToProperty(&req, version)
+	err = h.service.Update(ctx, &propertyToUpdate)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	httputil.SetETag(w, propertyToUpdate.Version)
+	json.NewEncoder(w).Encode(PutResponsePage[PropertyResponse]{
+		StatusCode: http.StatusOK,
+		Message:    "Property updated successfully",
+		Data:       ToPropertyResponse(&propertyToUpdate),
+	})
+}
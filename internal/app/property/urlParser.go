@@ -1,13 +1,19 @@
 package property
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
+	"github.com/go-chi/chi"
 	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	property "github.com/nevinmanoj/hostmate/internal/domain/property"
+	"github.com/nevinmanoj/hostmate/internal/domain/search"
 )
 
 func parsePropertyFilter(q url.Values) (property.PropertyFilter, *errMap.BadRequestError) {
@@ -35,6 +41,64 @@ func parsePropertyFilter(q url.Values) (property.PropertyFilter, *errMap.BadRequ
 		f.Active = &active
 	}
 
+	if v := q.Get("q"); v != "" {
+		query, err := search.NormalizeQuery(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "q",
+				Reason: fmt.Sprintf("must be between %d and %d characters", search.MinQueryLength, search.MaxQueryLength),
+			}
+		}
+		f.Query = &query
+	}
+
+	if v := q.Get("include_deleted"); v != "" {
+		includeDeleted, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "include_deleted",
+				Reason: err.Error(),
+			}
+		}
+		f.IncludeDeleted = includeDeleted
+	}
+
+	// Sorting, newest first unless asked otherwise
+	f.Sort = pagination.Sort{Field: "created_at", Desc: true}
+	if v := q.Get("sort"); v != "" {
+		sort, err := pagination.ParseSort(v, property.SortFields)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "sort",
+				Reason: fmt.Sprintf("%s, must be one of %v with an optional - prefix", err.Error(), property.SortFields),
+			}
+		}
+		f.Sort = sort
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := pagination.DecodeCursor(v, f.Sort)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "cursor",
+				Reason: err.Error(),
+			}
+		}
+		f.Cursor = cursor
+	}
+
+	// counting can be skipped on large tables, the cursors still tell if there are more pages
+	if v := q.Get("count"); v != "" {
+		count, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "count",
+				Reason: err.Error(),
+			}
+		}
+		f.SkipCount = !count
+	}
+
 	// Pagination defaults
 	f.Limit = 100
 	f.Offset = 0
@@ -93,3 +157,41 @@ func parsePropertyTypeSlice(v string) ([]property.PropertyType, error) {
 
 	return out, nil
 }
+
+func parseIDParam(param, v string) (int64, *errMap.BadRequestError) {
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &errMap.BadRequestError{
+			Param:  param,
+			Reason: err.Error(),
+		}
+	}
+	return id, nil
+}
+
+func parseMemberPath(r *http.Request) (int64, int64, *errMap.BadRequestError) {
+	propertyId, badRequestError := parseIDParam("propertyId", chi.URLParam(r, "propertyId"))
+	if badRequestError != nil {
+		return 0, 0, badRequestError
+	}
+	userId, badRequestError := parseIDParam("userId", chi.URLParam(r, "userId"))
+	if badRequestError != nil {
+		return 0, 0, badRequestError
+	}
+	return propertyId, userId, nil
+}
+
+// parseIfMatch reads the version the client is updating from the If-Match header
+func parseIfMatch(r *http.Request) (int, error) {
+	version, err := httputil.ParseIfMatch(r)
+	if errors.Is(err, httputil.ErrMissingIfMatch) {
+		return 0, &errMap.PreconditionRequiredError{Reason: err.Error()}
+	}
+	if err != nil {
+		return 0, &errMap.BadRequestError{
+			Param:  "If-Match",
+			Reason: err.Error(),
+		}
+	}
+	return version, nil
+}
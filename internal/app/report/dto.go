@@ -0,0 +1,148 @@
+package report
+
+import (
+	"math"
+	"time"
+
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
+	report "github.com/nevinmanoj/hostmate/internal/domain/report"
+)
+
+type PerformanceReportResponse struct {
+	From   time.Time                `json:"from"`
+	To     time.Time                `json:"to"`
+	Period report.Period            `json:"period"`
+	Rows   []PerformanceRowResponse `json:"rows"`
+}
+
+type PerformanceRowResponse struct {
+	PropertyID       int64     `json:"property_id"`
+	PropertyName     string    `json:"property_name"`
+	PeriodStart      time.Time `json:"period_start"`
+	AvailableNights  int       `json:"available_nights"`
+	NightsSold       int       `json:"nights_sold"`
+	OccupancyRate    float64   `json:"occupancy_rate"`
+	ADR              float64   `json:"adr"`
+	RevPAR           float64   `json:"revpar"`
+	RevenueBooked    float64   `json:"revenue_booked"`
+	RevenueCollected float64   `json:"revenue_collected"`
+	Cancellations    int       `json:"cancellations"`
+}
+
+func ToPerformanceRowResponse(r *report.PerformanceRow) PerformanceRowResponse {
+	return PerformanceRowResponse{
+		PropertyID:       r.PropertyID,
+		PropertyName:     r.PropertyName,
+		PeriodStart:      r.PeriodStart,
+		AvailableNights:  r.AvailableNights,
+		NightsSold:       r.NightsSold,
+		OccupancyRate:    r.OccupancyRate,
+		ADR:              r.ADR,
+		RevPAR:           r.RevPAR,
+		RevenueBooked:    r.RevenueBooked,
+		RevenueCollected: r.RevenueCollected,
+		Cancellations:    r.Cancellations,
+	}
+}
+
+var performanceCSVHeader = []string{
+	"property_id",
+	"property_name",
+	"period_start",
+	"available_nights",
+	"nights_sold",
+	"occupancy_rate",
+	"adr",
+	"revpar",
+	"revenue_booked",
+	"revenue_collected",
+	"cancellations",
+}
+
+// ToPerformanceCSVRecord follows performanceCSVHeader, money is rounded to cents
+func ToPerformanceCSVRecord(r *report.PerformanceRow) []export.Cell {
+	return []export.Cell{
+		export.Int(r.PropertyID),
+		export.Text(r.PropertyName),
+		export.Date(r.PeriodStart),
+		export.Int(int64(r.AvailableNights)),
+		export.Int(int64(r.NightsSold)),
+		export.Number(math.Round(r.OccupancyRate*10000) / 10000),
+		export.Money(r.ADR),
+		export.Money(r.RevPAR),
+		export.Money(r.RevenueBooked),
+		export.Money(r.RevenueCollected),
+		export.Int(int64(r.Cancellations)),
+	}
+}
+
+type TaxSummaryResponse struct {
+	From time.Time               `json:"from"`
+	To   time.Time               `json:"to"`
+	Rows []TaxSummaryRowResponse `json:"rows"`
+}
+
+type TaxSummaryRowResponse struct {
+	PropertyID    int64     `json:"property_id"`
+	PropertyName  string    `json:"property_name"`
+	GSTIN         string    `json:"gstin"`
+	Month         time.Time `json:"month"`
+	Rate          float64   `json:"rate"`
+	Bookings      int       `json:"bookings"`
+	TaxableAmount float64   `json:"taxable_amount"`
+	CGST          float64   `json:"cgst"`
+	SGST          float64   `json:"sgst"`
+	IGST          float64   `json:"igst"`
+	TotalTax      float64   `json:"total_tax"`
+	InvoiceValue  float64   `json:"invoice_value"`
+}
+
+func ToTaxSummaryRowResponse(r *report.TaxSummaryRow) TaxSummaryRowResponse {
+	return TaxSummaryRowResponse{
+		PropertyID:    r.PropertyID,
+		PropertyName:  r.PropertyName,
+		GSTIN:         r.GSTIN,
+		Month:         r.Month,
+		Rate:          r.Rate,
+		Bookings:      r.Bookings,
+		TaxableAmount: r.TaxableAmount,
+		CGST:          r.CGST,
+		SGST:          r.SGST,
+		IGST:          r.IGST,
+		TotalTax:      r.TotalTax,
+		InvoiceValue:  r.InvoiceValue,
+	}
+}
+
+var taxSummaryCSVHeader = []string{
+	"property_id",
+	"property_name",
+	"gstin",
+	"month",
+	"rate",
+	"bookings",
+	"taxable_amount",
+	"cgst",
+	"sgst",
+	"igst",
+	"total_tax",
+	"invoice_value",
+}
+
+// ToTaxSummaryCSVRecord follows taxSummaryCSVHeader, the month is written as YYYY-MM
+func ToTaxSummaryCSVRecord(r *report.TaxSummaryRow) []export.Cell {
+	return []export.Cell{
+		export.Int(r.PropertyID),
+		export.Text(r.PropertyName),
+		export.Text(r.GSTIN),
+		export.Text(r.Month.Format("2006-01")),
+		export.Number(r.Rate),
+		export.Int(int64(r.Bookings)),
+		export.Money(r.TaxableAmount),
+		export.Money(r.CGST),
+		export.Money(r.SGST),
+		export.Money(r.IGST),
+		export.Money(r.TotalTax),
+		export.Money(r.InvoiceValue),
+	}
+}
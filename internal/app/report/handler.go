@@ -0,0 +1,122 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	export "github.com/nevinmanoj/hostmate/internal/app/export"
+	report "github.com/nevinmanoj/hostmate/internal/domain/report"
+)
+
+type ReportHandler struct {
+	service report.ReportService
+}
+
+func NewReportHandler(s report.ReportService) *ReportHandler {
+	return &ReportHandler{service: s}
+}
+
+func (h *ReportHandler) GetPerformance(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetPerformance::Fetching performance report")
+	w.Header().Set("Content-Type", "application/json")
+	filter, badRequestError := parseReportFilter(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	format, badRequestError := parseFormat(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	result, err := h.service.GetPerformance(r.Context(), filter)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+
+	if format == formatCSV {
+		filename := fmt.Sprintf("performance_%s_%s", filter.From.Format("2006-01-02"), filter.To.AddDate(0, 0, -1).Format("2006-01-02"))
+		if err := writeCSV(w, filename, performanceCSVHeader, result, ToPerformanceCSVRecord); err != nil {
+			log.Println("HandlerGetPerformance::Error writing csv:", err)
+		}
+		return
+	}
+
+	rowResponses := make([]PerformanceRowResponse, 0, len(result))
+	for _, row := range result {
+		rowResponses = append(rowResponses, ToPerformanceRowResponse(&row))
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[PerformanceReportResponse]{
+		StatusCode: 200,
+		Message:    "Performance report fetched successfully",
+		Data: PerformanceReportResponse{
+			From:   filter.From,
+			To:     filter.To,
+			Period: filter.Period,
+			Rows:   rowResponses,
+		},
+	})
+}
+
+// GetTaxSummary takes the same from, to and property_id parameters as the performance
+// report, stays are counted in the month they check out
+func (h *ReportHandler) GetTaxSummary(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetTaxSummary::Fetching tax summary")
+	w.Header().Set("Content-Type", "application/json")
+	filter, badRequestError := parseReportFilter(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	format, badRequestError := parseFormat(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	result, err := h.service.GetTaxSummary(r.Context(), filter)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+
+	if format == formatCSV {
+		filename := fmt.Sprintf("tax_summary_%s_%s", filter.From.Format("2006-01-02"), filter.To.AddDate(0, 0, -1).Format("2006-01-02"))
+		if err := writeCSV(w, filename, taxSummaryCSVHeader, result, ToTaxSummaryCSVRecord); err != nil {
+			log.Println("HandlerGetTaxSummary::Error writing csv:", err)
+		}
+		return
+	}
+
+	rowResponses := make([]TaxSummaryRowResponse, 0, len(result))
+	for _, row := range result {
+		rowResponses = append(rowResponses, ToTaxSummaryRowResponse(&row))
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[TaxSummaryResponse]{
+		StatusCode: 200,
+		Message:    "Tax summary fetched successfully",
+		Data: TaxSummaryResponse{
+			From: filter.From,
+			To:   filter.To,
+			Rows: rowResponses,
+		},
+	})
+}
+
+// writeCSV sends the report through the export csv writer, so text is escaped like every other download
+func writeCSV[T any](w http.ResponseWriter, filename string, header []string, rows []T, record func(*T) []export.Cell) error {
+	cw, err := export.NewFileWriter(w, export.FormatCSV, filename, header)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := cw.Write(record(&rows[i])); err != nil {
+			return err
+		}
+	}
+	return cw.Close()
+}
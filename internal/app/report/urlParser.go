@@ -0,0 +1,77 @@
+package report
+
+import (
+	"net/url"
+	"strings"
+
+	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
+	report "github.com/nevinmanoj/hostmate/internal/domain/report"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+func parseReportFilter(q url.Values) (report.ReportFilter, *errMap.BadRequestError) {
+	var f report.ReportFilter
+
+	from, err := httputil.ParseDatePtr(q.Get("from"))
+	if err != nil {
+		return f, &errMap.BadRequestError{
+			Param:  "from",
+			Reason: "required, expected YYYY-MM-DD",
+		}
+	}
+	f.From = *from
+
+	to, err := httputil.ParseDatePtr(q.Get("to"))
+	if err != nil {
+		return f, &errMap.BadRequestError{
+			Param:  "to",
+			Reason: "required, expected YYYY-MM-DD",
+		}
+	}
+	// the whole day is included
+	f.To = to.AddDate(0, 0, 1)
+
+	f.Period = report.PeriodMonth
+	if v := q.Get("period"); v != "" {
+		period := report.Period(strings.ToLower(v))
+		if !period.Valid() {
+			return f, &errMap.BadRequestError{
+				Param:  "period",
+				Reason: "Invalid period, must be ['day','week','month']",
+			}
+		}
+		f.Period = period
+	}
+
+	if v := q.Get("property_id"); v != "" {
+		propertyId, err := httputil.ParseInt64Slice(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "property_id",
+				Reason: err.Error(),
+			}
+		}
+		f.PropertyID = propertyId
+	}
+
+	return f, nil
+}
+
+func parseFormat(q url.Values) (string, *errMap.BadRequestError) {
+	switch v := strings.ToLower(q.Get("format")); v {
+	case "", formatJSON:
+		return formatJSON, nil
+	case formatCSV:
+		return formatCSV, nil
+	default:
+		return "", &errMap.BadRequestError{
+			Param:  "format",
+			Reason: "Invalid format, must be ['json','csv']",
+		}
+	}
+}
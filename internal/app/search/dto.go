@@ -0,0 +1,25 @@
+package search
+
+import (
+	search "github.com/nevinmanoj/hostmate/internal/domain/search"
+)
+
+type SearchResultResponse struct {
+	Kind       search.Kind `json:"kind"`
+	ID         int64       `json:"id"`
+	PropertyID int64       `json:"property_id"`
+	Title      string      `json:"title"`
+	Subtitle   string      `json:"subtitle"`
+	Rank       float64     `json:"rank"`
+}
+
+func ToSearchResultResponse(r *search.Result) SearchResultResponse {
+	return SearchResultResponse{
+		Kind:       r.Kind,
+		ID:         r.ID,
+		PropertyID: r.PropertyID,
+		Title:      r.Title,
+		Subtitle:   r.Subtitle,
+		Rank:       r.Rank,
+	}
+}
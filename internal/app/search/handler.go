@@ -0,0 +1,46 @@
+package search
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	search "github.com/nevinmanoj/hostmate/internal/domain/search"
+)
+
+type SearchHandler struct {
+	service search.SearchService
+}
+
+func NewSearchHandler(s search.SearchService) *SearchHandler {
+	return &SearchHandler{service: s}
+}
+
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerSearch::Searching")
+	w.Header().Set("Content-Type", "application/json")
+	filter, badRequestError := parseSearchFilter(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	result, err := h.service.Search(r.Context(), filter)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resultResponses := make([]SearchResultResponse, 0, len(result))
+		for _, res := range result {
+			resultResponses = append(resultResponses, ToSearchResultResponse(&res))
+		}
+		resp = GetAllResponsePage[SearchResultResponse]{
+			StatusCode: 200,
+			Message:    "Search results fetched successfully",
+			Limit:      filter.Limit,
+			Data:       resultResponses,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	search "github.com/nevinmanoj/hostmate/internal/domain/search"
+)
+
+func parseSearchFilter(q url.Values) (search.SearchFilter, *errMap.BadRequestError) {
+	var f search.SearchFilter
+
+	f.Query = q.Get("q")
+	if f.Query == "" {
+		return f, &errMap.BadRequestError{
+			Param:  "q",
+			Reason: "search query is required",
+		}
+	}
+
+	if v := q.Get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			kind := search.Kind(strings.ToLower(strings.TrimSpace(t)))
+			if !kind.Valid() {
+				return f, &errMap.BadRequestError{
+					Param:  "type",
+					Reason: fmt.Sprintf("invalid type: %s, must be ['property','booking','guest']", t),
+				}
+			}
+			f.Kinds = append(f.Kinds, kind)
+		}
+	}
+
+	// a search is a short ranked list, not something to page through
+	f.Limit = 20
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return f, &errMap.BadRequestError{
+				Param:  "limit",
+				Reason: err.Error(),
+			}
+		} else if limit > 0 && limit <= 50 {
+			f.Limit = limit
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,69 @@
+package tax
+
+import (
+	"strings"
+	"time"
+
+	tax "github.com/nevinmanoj/hostmate/internal/domain/tax"
+)
+
+// TaxConfigRequest leaves out slabs to use the current GST rates on accommodation
+type TaxConfigRequest struct {
+	GSTIN     string        `json:"gstin"`
+	Supply    tax.Supply    `json:"supply"`
+	Inclusive bool          `json:"inclusive"`
+	Slabs     []SlabRequest `json:"slabs"`
+}
+
+type SlabRequest struct {
+	UpTo *float64 `json:"up_to"`
+	Rate float64  `json:"rate"`
+}
+
+func ToTaxConfig(req *TaxConfigRequest, propertyID int64) tax.Config {
+	var slabs tax.Slabs
+	if req.Slabs != nil {
+		slabs = make(tax.Slabs, 0, len(req.Slabs))
+		for _, slab := range req.Slabs {
+			slabs = append(slabs, tax.Slab{UpTo: slab.UpTo, Rate: slab.Rate})
+		}
+	}
+	return tax.Config{
+		PropertyID: propertyID,
+		GSTIN:      strings.ToUpper(strings.TrimSpace(req.GSTIN)),
+		Supply:     req.Supply,
+		Inclusive:  req.Inclusive,
+		Slabs:      slabs,
+	}
+}
+
+type TaxConfigResponse struct {
+	PropertyID int64          `json:"property_id"`
+	GSTIN      string         `json:"gstin"`
+	Supply     tax.Supply     `json:"supply"`
+	Inclusive  bool           `json:"inclusive"`
+	Slabs      []SlabResponse `json:"slabs"`
+	UpdatedBy  int64          `json:"updated_by"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+type SlabResponse struct {
+	UpTo *float64 `json:"up_to"`
+	Rate float64  `json:"rate"`
+}
+
+func ToTaxConfigResponse(c *tax.Config) TaxConfigResponse {
+	slabResponses := make([]SlabResponse, 0, len(c.Slabs))
+	for _, slab := range c.Slabs {
+		slabResponses = append(slabResponses, SlabResponse{UpTo: slab.UpTo, Rate: slab.Rate})
+	}
+	return TaxConfigResponse{
+		PropertyID: c.PropertyID,
+		GSTIN:      c.GSTIN,
+		Supply:     c.Supply,
+		Inclusive:  c.Inclusive,
+		Slabs:      slabResponses,
+		UpdatedBy:  c.UpdatedBy,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
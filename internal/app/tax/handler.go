@@ -0,0 +1,76 @@
+package tax
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	. "github.com/nevinmanoj/hostmate/api"
+	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	tax "github.com/nevinmanoj/hostmate/internal/domain/tax"
+)
+
+type TaxHandler struct {
+	service tax.TaxService
+}
+
+func NewTaxHandler(s tax.TaxService) *TaxHandler {
+	return &TaxHandler{service: s}
+}
+
+func (h *TaxHandler) GetTaxConfig(w http.ResponseWriter, r *http.Request) {
+	propertyId, badRequestError := parseIDParam("propertyId", chi.URLParam(r, "propertyId"))
+	log.Println("HandlerGetTaxConfig::Fetching tax configuration of property ID:", propertyId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var resp any
+	result, err := h.service.GetConfig(r.Context(), propertyId)
+	if err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = GetResponsePage[TaxConfigResponse]{
+			StatusCode: 200,
+			Message:    "Tax configuration fetched successfully",
+			Data:       ToTaxConfigResponse(result),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// PutTaxConfig replaces the GST rules of the property, existing bookings are taxed again
+// only when they are next amended
+func (h *TaxHandler) PutTaxConfig(w http.ResponseWriter, r *http.Request) {
+	propertyId, badRequestError := parseIDParam("propertyId", chi.URLParam(r, "propertyId"))
+	log.Println("HandlerPutTaxConfig::Saving tax configuration of property ID:", propertyId)
+	w.Header().Set("Content-Type", "application/json")
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	var req TaxConfigRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "body",
+			Reason: err.Error(),
+		}))
+		return
+	}
+	config := ToTaxConfig(&req, propertyId)
+	var resp any
+	if err := h.service.SaveConfig(r.Context(), &config); err != nil {
+		resp = errmap.GetDomainErrorResponse(err)
+	} else {
+		resp = PutResponsePage[TaxConfigResponse]{
+			StatusCode: 200,
+			Message:    "Tax configuration saved successfully",
+			Data:       ToTaxConfigResponse(&config),
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
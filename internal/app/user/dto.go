@@ -1,6 +1,8 @@
 package user
 
 import (
+	"time"
+
 	user "github.com/nevinmanoj/hostmate/internal/domain/user"
 )
 
@@ -15,28 +17,191 @@ type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required"`
 }
 
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type UpdateSecurityPolicyRequest struct {
+	RequireTwoFactor *bool `json:"require_two_factor" validate:"required"`
+}
+
+type UpdateProfileRequest struct {
+	Name     string  `json:"name" validate:"required,max=100"`
+	Phone    *string `json:"phone" validate:"omitempty,max=32"`
+	Timezone string  `json:"timezone" validate:"required"`
+	Language string  `json:"language" validate:"required,bcp47_language_tag"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type DeactivateRequest struct {
+	Password string `json:"password"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Scope string `json:"scope" validate:"required,oneof=read read_write"`
+}
+
 type LoginUserResponse struct {
 	UserResponse
-	Token string `json:"token"`
+	Token             string `json:"token,omitempty"`
+	OrganisationID    int64  `json:"organisation_id,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	EnrolmentRequired bool   `json:"two_factor_enrolment_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
+type TwoFactorEnrolmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorConfirmResponse struct {
+	Token         string   `json:"token"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type SecurityPolicyResponse struct {
+	OrganisationID   int64     `json:"organisation_id"`
+	RequireTwoFactor bool      `json:"require_two_factor"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	UpdatedBy        *int64    `json:"updated_by,omitempty"`
 }
 
 type UserResponse struct {
+	ID    int64  `json:"id"`
 	Email string `json:"email"`
 	Name  string `json:"name"`
 }
 
+type ProfileResponse struct {
+	ID           int64     `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	Phone        *string   `json:"phone"`
+	Timezone     string    `json:"timezone"`
+	Language     string    `json:"language"`
+	PendingEmail *string   `json:"pending_email,omitempty"`
+	TOTPEnabled  bool      `json:"two_factor_enabled"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// EmailChangeResponse is the address a confirmation link would change the email to
+type EmailChangeResponse struct {
+	Email string `json:"email"`
+}
+
+type SSOLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type LoginAttemptResponse struct {
+	ID        int64             `json:"id"`
+	IP        string            `json:"ip"`
+	UserAgent string            `json:"user_agent"`
+	Outcome   user.LoginOutcome `json:"outcome"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func ToLoginAttemptResponse(a *user.LoginAttempt) LoginAttemptResponse {
+	return LoginAttemptResponse{
+		ID:        a.ID,
+		IP:        a.IP,
+		UserAgent: a.UserAgent,
+		Outcome:   a.Outcome,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+type APIKeyResponse struct {
+	ID             int64      `json:"id"`
+	OrganisationID int64      `json:"organisation_id"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Scope          string     `json:"scope"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse is the only response that contains the plain key
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+func ToAPIKeyResponse(k *user.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:             k.ID,
+		OrganisationID: k.OrganisationID,
+		Name:           k.Name,
+		Prefix:         k.Prefix,
+		Scope:          k.Scope,
+		LastUsedAt:     k.LastUsedAt,
+		RevokedAt:      k.RevokedAt,
+		CreatedAt:      k.CreatedAt,
+	}
+}
+
+func ToProfileResponse(u *user.User) ProfileResponse {
+	return ProfileResponse{
+		ID:           u.ID,
+		Email:        u.Email,
+		Name:         u.Name,
+		Phone:        u.Phone,
+		Timezone:     u.Timezone,
+		Language:     u.Language,
+		PendingEmail: u.PendingEmail,
+		TOTPEnabled:  u.TOTPEnabled,
+		CreatedAt:    u.CreatedAt,
+	}
+}
+
 func ToUserResponse(u *user.User) UserResponse {
 	return UserResponse{
+		ID:    u.ID,
 		Email: u.Email,
 		Name:  u.Name,
 	}
 }
-func ToLoginUserResponse(u *user.User, token string) LoginUserResponse {
+func ToLoginUserResponse(result *user.LoginResult) LoginUserResponse {
 	return LoginUserResponse{
-		UserResponse: UserResponse{
-			Email: u.Email,
-			Name:  u.Name,
-		},
-		Token: token,
+		UserResponse:      ToUserResponse(result.User),
+		Token:             result.Token,
+		OrganisationID:    result.OrganisationID,
+		TwoFactorRequired: result.TwoFactorRequired,
+		EnrolmentRequired: result.EnrolmentRequired,
+		ChallengeToken:    result.ChallengeToken,
+	}
+}
+
+func ToSecurityPolicyResponse(p *user.SecurityPolicy) SecurityPolicyResponse {
+	return SecurityPolicyResponse{
+		OrganisationID:   p.OrganisationID,
+		RequireTwoFactor: p.RequireTwoFactor,
+		UpdatedAt:        p.UpdatedAt,
+		UpdatedBy:        p.UpdatedBy,
 	}
 }
@@ -5,12 +5,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
 	. "github.com/nevinmanoj/hostmate/api"
 	errmap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+	httputil "github.com/nevinmanoj/hostmate/internal/app/httputil"
 	user "github.com/nevinmanoj/hostmate/internal/domain/user"
 )
 
@@ -29,10 +30,9 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	log.Println("HandlerGetUser::Fetching user with ID:", userIdStr)
 	w.Header().Set("Content-Type", "application/json")
 	var resp any
-	userId, err := strconv.ParseInt(userIdStr, 10, 64)
-	if err != nil {
-		resp = errmap.GetDomainErrorResponse(err)
-		json.NewEncoder(w).Encode(resp)
+	userId, badRequestError := parseIDParam("userId", userIdStr)
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
 		return
 	}
 	result, err := h.service.GetUserByID(ctx, userId)
@@ -42,7 +42,7 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		userResponse := ToUserResponse(result)
 		resp = GetResponsePage[UserResponse]{
 			StatusCode: 200,
-			Message:    "Payment fetched successfully",
+			Message:    "User fetched successfully",
 			Data:       userResponse,
 		}
 	}
@@ -50,6 +50,38 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerSearchUsers::Searching users")
+	w.Header().Set("Content-Type", "application/json")
+	limit, offset, badRequestError := parsePagination(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	filter := user.UserFilter{
+		Query:  r.URL.Query().Get("q"),
+		Limit:  limit,
+		Offset: offset,
+	}
+	result, total, err := h.service.SearchUsers(r.Context(), filter)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	userResponses := make([]UserResponse, 0, len(result))
+	for _, u := range result {
+		userResponses = append(userResponses, ToUserResponse(&u))
+	}
+	json.NewEncoder(w).Encode(GetAllResponsePage[UserResponse]{
+		StatusCode:   200,
+		Message:      "Users fetched successfully",
+		TotalRecords: &total,
+		Limit:        limit,
+		Offset:       offset,
+		Data:         userResponses,
+	})
+}
+
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req CreateUserRequest
@@ -113,13 +145,13 @@ func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var email string = req.Email
 	var password string = req.Password
 
-	token, user, err := h.service.LoginUser(ctx, email, password)
+	result, err := h.service.LoginUser(ctx, email, password, loginClient(r))
 	if err != nil {
 		resp := errmap.GetDomainErrorResponse(err)
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
-	logingResponse := ToLoginUserResponse(user, token)
+	logingResponse := ToLoginUserResponse(result)
 
 	json.NewEncoder(w).Encode(PostResponsePage[LoginUserResponse]{
 		Message:    "User created successfully",
@@ -127,3 +159,448 @@ func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		StatusCode: http.StatusCreated,
 	})
 }
+
+func (h *UserHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req VerifyTwoFactorRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	result, err := h.service.VerifyTwoFactor(ctx, req.ChallengeToken, req.Code, loginClient(r))
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PostResponsePage[LoginUserResponse]{
+		Message:    "User logged in successfully",
+		Data:       ToLoginUserResponse(result),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) BeginTwoFactorEnrolment(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerBeginTwoFactorEnrolment::Starting two factor enrolment")
+	w.Header().Set("Content-Type", "application/json")
+	enrolment, err := h.service.BeginTwoFactorEnrolment(r.Context())
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PostResponsePage[TwoFactorEnrolmentResponse]{
+		Message: "Scan the provisioning uri with an authenticator app and confirm with a code",
+		Data: TwoFactorEnrolmentResponse{
+			Secret:          enrolment.Secret,
+			ProvisioningURI: enrolment.ProvisioningURI,
+		},
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) ConfirmTwoFactorEnrolment(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorCodeRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	result, codes, err := h.service.ConfirmTwoFactorEnrolment(r.Context(), req.Code)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PostResponsePage[TwoFactorConfirmResponse]{
+		Message: "Two factor authentication enabled, store the recovery codes safely",
+		Data: TwoFactorConfirmResponse{
+			Token:         result.Token,
+			RecoveryCodes: codes,
+		},
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorCodeRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	err := h.service.DisableTwoFactor(r.Context(), req.Code)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Two factor authentication disabled",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorCodeRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), req.Code)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PostResponsePage[RecoveryCodesResponse]{
+		Message:    "Recovery codes regenerated, previous codes no longer work",
+		Data:       RecoveryCodesResponse{RecoveryCodes: codes},
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) GetSecurityPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	policy, err := h.service.GetSecurityPolicy(r.Context())
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[SecurityPolicyResponse]{
+		Message:    "Security policy fetched successfully",
+		Data:       ToSecurityPolicyResponse(policy),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) UpdateSecurityPolicy(w http.ResponseWriter, r *http.Request) {
+	var req UpdateSecurityPolicyRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	policy := user.SecurityPolicy{RequireTwoFactor: *req.RequireTwoFactor}
+	err := h.service.UpdateSecurityPolicy(r.Context(), &policy)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PutResponsePage[SecurityPolicyResponse]{
+		Message:    "Security policy updated successfully",
+		Data:       ToSecurityPolicyResponse(&policy),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetLoginHistory::Fetching login history")
+	w.Header().Set("Content-Type", "application/json")
+	limit, offset, badRequestError := parsePagination(r.URL.Query())
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	result, total, err := h.service.GetLoginHistory(r.Context(), limit, offset)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	attemptResponses := make([]LoginAttemptResponse, 0, len(result))
+	for _, attempt := range result {
+		attemptResponses = append(attemptResponses, ToLoginAttemptResponse(&attempt))
+	}
+	json.NewEncoder(w).Encode(GetAllResponsePage[LoginAttemptResponse]{
+		StatusCode:   200,
+		Message:      "Login history fetched successfully",
+		TotalRecords: &total,
+		Limit:        limit,
+		Offset:       offset,
+		Data:         attemptResponses,
+	})
+}
+
+// ssoStateCookie binds a sign-on to the browser that began it
+const ssoStateCookie = "hostmate_sso_state"
+
+func (h *UserHandler) BeginSSOLogin(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerBeginSSOLogin::Starting single sign-on")
+	w.Header().Set("Content-Type", "application/json")
+	authURL, state, err := h.service.BeginSSOLogin(r.Context())
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	// lax so the cookie comes along on the redirect back from the identity provider
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     "/users/oidc",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	json.NewEncoder(w).Encode(GetResponsePage[SSOLoginResponse]{
+		Message:    "Redirect the user to the authorization url",
+		Data:       SSOLoginResponse{AuthorizationURL: authURL},
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) CompleteSSOLogin(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerCompleteSSOLogin::Completing single sign-on")
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+	if v := q.Get("error"); v != "" {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusUnauthorized,
+			Message:    "Sign-on was not completed: " + v,
+		})
+		return
+	}
+	state := q.Get("state")
+	code := q.Get("code")
+	if state == "" || code == "" {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "code",
+			Reason: "state and code are required",
+		}))
+		return
+	}
+	var browserState string
+	if cookie, err := r.Cookie(ssoStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+	// the state is single use, the cookie goes with it
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Path:     "/users/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	result, err := h.service.CompleteSSOLogin(r.Context(), state, browserState, code, loginClient(r))
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PostResponsePage[LoginUserResponse]{
+		Message:    "User logged in successfully",
+		Data:       ToLoginUserResponse(result),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetProfile::Fetching profile")
+	w.Header().Set("Content-Type", "application/json")
+	result, err := h.service.GetProfile(r.Context())
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[ProfileResponse]{
+		Message:    "Profile fetched successfully",
+		Data:       ToProfileResponse(result),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerUpdateProfile::Updating profile")
+	var req UpdateProfileRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	result, err := h.service.UpdateProfile(r.Context(), &user.ProfileUpdate{
+		Name:     req.Name,
+		Phone:    req.Phone,
+		Timezone: req.Timezone,
+		Language: req.Language,
+	})
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PutResponsePage[ProfileResponse]{
+		Message:    "Profile updated successfully",
+		Data:       ToProfileResponse(result),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerRequestEmailChange::Requesting email change")
+	var req ChangeEmailRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	err := h.service.RequestEmailChange(r.Context(), req.Email, req.Password)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Verification mail sent, the email changes once the new address is confirmed",
+		StatusCode: http.StatusAccepted,
+	})
+}
+
+// LookupEmailChange serves the link in the mail, it changes nothing as mail scanners follow
+// links too. Confirming is then a POST to the same path with the token.
+func (h *UserHandler) LookupEmailChange(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerLookupEmailChange::Looking up email change")
+	w.Header().Set("Content-Type", "application/json")
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(&errmap.BadRequestError{
+			Param:  "token",
+			Reason: "token is required",
+		}))
+		return
+	}
+	email, err := h.service.LookupEmailChange(r.Context(), token)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(GetResponsePage[EmailChangeResponse]{
+		Message:    "Email change fetched successfully, confirm it to change the email",
+		Data:       EmailChangeResponse{Email: email},
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerConfirmEmailChange::Confirming email change")
+	var req ConfirmEmailRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	result, err := h.service.ConfirmEmailChange(r.Context(), req.Token)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PutResponsePage[ProfileResponse]{
+		Message:    "Email changed successfully",
+		Data:       ToProfileResponse(result),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerChangePassword::Changing password")
+	var req ChangePasswordRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	err := h.service.ChangePassword(r.Context(), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Password changed successfully, sign in again with the new password",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerDeactivate::Deactivating account")
+	var req DeactivateRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	err := h.service.Deactivate(r.Context(), req.Password)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Account deactivated",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (h *UserHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerCreateAPIKey::Creating api key")
+	var req CreateAPIKeyRequest
+	if !h.decodeBody(w, r, &req) {
+		return
+	}
+	key, plain, err := h.service.CreateAPIKey(r.Context(), req.Name, req.Scope)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(PostResponsePage[CreatedAPIKeyResponse]{
+		Message: "Api key created, store the key now as it will not be shown again",
+		Data: CreatedAPIKeyResponse{
+			APIKeyResponse: ToAPIKeyResponse(key),
+			Key:            plain,
+		},
+		StatusCode: http.StatusCreated,
+	})
+}
+
+func (h *UserHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerGetAPIKeys::Fetching api keys")
+	w.Header().Set("Content-Type", "application/json")
+	keys, err := h.service.GetAPIKeys(r.Context())
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	keyResponses := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		keyResponses = append(keyResponses, ToAPIKeyResponse(&key))
+	}
+	total := len(keyResponses)
+	json.NewEncoder(w).Encode(GetAllResponsePage[APIKeyResponse]{
+		StatusCode:   200,
+		Message:      "Api keys fetched successfully",
+		TotalRecords: &total,
+		Limit:        len(keyResponses),
+		Offset:       0,
+		Data:         keyResponses,
+	})
+}
+
+func (h *UserHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	log.Println("HandlerRevokeAPIKey::Revoking api key")
+	w.Header().Set("Content-Type", "application/json")
+	keyID, badRequestError := parseIDParam("keyId", chi.URLParam(r, "keyId"))
+	if badRequestError != nil {
+		json.NewEncoder(w).Encode(errmap.GetHttpErrorResponse(badRequestError))
+		return
+	}
+	err := h.service.RevokeAPIKey(r.Context(), keyID)
+	if err != nil {
+		json.NewEncoder(w).Encode(errmap.GetDomainErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(DeleteResponsePage{
+		Message:    "Api key revoked",
+		StatusCode: http.StatusOK,
+	})
+}
+
+func loginClient(r *http.Request) user.LoginClient {
+	return user.LoginClient{
+		IP:        httputil.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// decodeBody decodes and validates a JSON request body, writing the error response itself
+func (h *UserHandler) decodeBody(w http.ResponseWriter, r *http.Request, req any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	w.Header().Set("Content-Type", "application/json")
+	if err := dec.Decode(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid JSON body",
+		})
+		return false
+	}
+	if err := h.validator.Struct(req); err != nil {
+		json.NewEncoder(w).Encode(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"net/url"
+	"strconv"
+
+	errMap "github.com/nevinmanoj/hostmate/internal/app/errmap"
+)
+
+func parsePagination(q url.Values) (int, int, *errMap.BadRequestError) {
+	// Pagination defaults
+	limit := 100
+	offset := 0
+
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, &errMap.BadRequestError{
+				Param:  "limit",
+				Reason: err.Error(),
+			}
+		} else if l > 0 && l < 100 {
+			limit = l
+		}
+	}
+
+	if v := q.Get("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, &errMap.BadRequestError{
+				Param:  "offset",
+				Reason: err.Error(),
+			}
+		} else if o > 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func parseIDParam(param, v string) (int64, *errMap.BadRequestError) {
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &errMap.BadRequestError{
+			Param:  param,
+			Reason: err.Error(),
+		}
+	}
+	return id, nil
+}
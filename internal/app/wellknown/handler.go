@@ -0,0 +1,23 @@
+package wellknown
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+)
+
+type WellKnownHandler struct {
+	keys *auth.KeySet
+}
+
+func NewWellKnownHandler(keys *auth.KeySet) *WellKnownHandler {
+	return &WellKnownHandler{keys: keys}
+}
+
+// JWKS is served in the standard RFC 7517 shape so off the shelf JWT libraries can consume it
+func (h *WellKnownHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.keys.JWKS())
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// API keys are opaque random strings, only their sha256 is stored
+const (
+	APIKeyPrefix = "hm_"
+
+	ScopeRead      = "read"
+	ScopeReadWrite = "read_write"
+
+	// number of leading characters kept in clear so users can tell keys apart
+	apiKeyDisplayLength = 11
+)
+
+// GenerateAPIKey returns a new key and the prefix that is safe to display
+func GenerateAPIKey() (string, string, error) {
+	secret, err := RandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	key := APIKeyPrefix + secret
+	return key, key[:apiKeyDisplayLength], nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+func ValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeReadWrite
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIKey(key) {
+		t.Errorf("key %q does not start with %q", key, APIKeyPrefix)
+	}
+	if !strings.HasPrefix(key, prefix) || len(prefix) != apiKeyDisplayLength {
+		t.Errorf("prefix %q is not the first %d characters of the key", prefix, apiKeyDisplayLength)
+	}
+	other, _, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HashAPIKey(key) == HashAPIKey(other) {
+		t.Error("two keys hash the same")
+	}
+	if HashAPIKey(key) != HashAPIKey(key) || strings.Contains(HashAPIKey(key), key) {
+		t.Error("the hash is not a stable digest of the key")
+	}
+}
+
+func TestIsAPIKey(t *testing.T) {
+	keys := testKeySet(t)
+	session, err := GenerateToken(7, "owner@example.com", 3, 0, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsAPIKey(session) {
+		t.Error("a session token is taken for an API key")
+	}
+	if IsAPIKey("") {
+		t.Error("an empty token is taken for an API key")
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	for scope, want := range map[string]bool{
+		ScopeRead:      true,
+		ScopeReadWrite: true,
+		"write":        false,
+		"":             false,
+		"READ":         false,
+	} {
+		if got := ValidScope(scope); got != want {
+			t.Errorf("ValidScope(%q) = %v, want %v", scope, got, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type InvitationClaims struct {
+	Type           string `json:"typ"`
+	InvitationID   int64  `json:"invitation_id"`
+	OrganisationID int64  `json:"org_id"`
+	Email          string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// GenerateInvitationToken signs an invitation token, tokenID is stored on the
+// invitation so that resending invalidates previously issued tokens
+func GenerateInvitationToken(invitationID, organisationID int64, email, tokenID string, expiresAt time.Time, keys *KeySet) (string, error) {
+	claims := InvitationClaims{
+		Type:           TokenTypeInvitation,
+		InvitationID:   invitationID,
+		OrganisationID: organisationID,
+		Email:          email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    Issuer,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return keys.Sign(claims)
+}
+
+func ParseInvitationToken(tokenStr string, keys *KeySet) (*InvitationClaims, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenStr,
+		&InvitationClaims{},
+		keys.Keyfunc,
+		keys.ParserOptions()...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*InvitationClaims)
+	if !ok || !token.Valid || claims.Type != TokenTypeInvitation || claims.ID == "" || claims.OrganisationID == 0 {
+		return nil, fmt.Errorf("invalid invitation token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testKeySet(t *testing.T) *KeySet {
+	t.Helper()
+	keys, err := NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestInvitationTokenRoundTrip(t *testing.T) {
+	keys := testKeySet(t)
+	expiresAt := time.Now().Add(time.Hour)
+	token, err := GenerateInvitationToken(42, 3, "guest@example.com", "token-1", expiresAt, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseInvitationToken(token, keys)
+	if err != nil {
+		t.Fatalf("ParseInvitationToken returned %v", err)
+	}
+	if claims.InvitationID != 42 || claims.OrganisationID != 3 || claims.Email != "guest@example.com" || claims.ID != "token-1" {
+		t.Errorf("claims = %+v, want invitation 42 of organisation 3 for guest@example.com with token id token-1", claims)
+	}
+}
+
+func TestInvitationTokenRejected(t *testing.T) {
+	keys := testKeySet(t)
+	valid := func() string {
+		token, _ := GenerateInvitationToken(42, 3, "guest@example.com", "token-1", time.Now().Add(time.Hour), keys)
+		return token
+	}
+	expired, _ := GenerateInvitationToken(42, 3, "guest@example.com", "token-1", time.Now().Add(-time.Minute), keys)
+	withoutID, _ := GenerateInvitationToken(42, 3, "guest@example.com", "", time.Now().Add(time.Hour), keys)
+	withoutOrganisation, _ := GenerateInvitationToken(42, 0, "guest@example.com", "token-1", time.Now().Add(time.Hour), keys)
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, InvitationClaims{
+		InvitationID:     42,
+		RegisteredClaims: jwt.RegisteredClaims{ID: "token-1", Issuer: Issuer},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name  string
+		token string
+		keys  *KeySet
+	}{
+		{"expired", expired, keys},
+		{"signed with another key", valid(), testKeySet(t)},
+		{"without a token id", withoutID, keys},
+		{"without an organisation", withoutOrganisation, keys},
+		{"unsigned", unsigned, keys},
+		{"tampered", valid() + "x", keys},
+		{"not a token", "invitation", keys},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseInvitationToken(tt.token, tt.keys); err == nil {
+				t.Error("ParseInvitationToken accepted the token")
+			}
+		})
+	}
+}
@@ -7,40 +7,79 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token types, every token this service signs says what it is so that one kind
+// is never accepted in place of another, e.g. an invitation as a session
+const (
+	TokenTypeSession    = "session"
+	TokenTypePurpose    = "purpose"
+	TokenTypeInvitation = "invitation"
+)
+
+// Token purposes, an empty purpose is a regular session token
+const (
+	PurposeTwoFactorChallenge = "2fa_challenge"
+	PurposeTwoFactorEnrolment = "2fa_enrolment"
+	PurposeEmailChange        = "email_change"
+)
+
 type Claims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
+	Type    string `json:"typ"`
+	UserID  int64  `json:"user_id"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose,omitempty"`
+	// OrganisationID is the tenant a session acts in, purpose tokens carry none
+	OrganisationID int64 `json:"org_id,omitempty"`
+	// Generation is the user's session generation when the token was issued, changing the
+	// password or deactivating the account moves it on and so revokes every earlier token
+	Generation int `json:"gen"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int64, email string, secret []byte) (string, error) {
+func GenerateToken(userID int64, email string, organisationID int64, generation int, keys *KeySet) (string, error) {
 
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		Type:           TokenTypeSession,
+		UserID:         userID,
+		Email:          email,
+		OrganisationID: organisationID,
+		Generation:     generation,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	return keys.Sign(claims)
+}
+
+// GeneratePurposeToken issues a short lived token that is only accepted for the given purpose
+func GeneratePurposeToken(userID int64, email, purpose string, generation int, ttl time.Duration, keys *KeySet) (string, error) {
+
+	claims := Claims{
+		Type:       TokenTypePurpose,
+		UserID:     userID,
+		Email:      email,
+		Purpose:    purpose,
+		Generation: generation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return keys.Sign(claims)
 }
 
-func ParseToken(tokenStr string, secret []byte) (*Claims, error) {
+// ParseToken only accepts session and purpose tokens of a user, a purpose token
+// always names its purpose and a session token never does
+func ParseToken(tokenStr string, keys *KeySet) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenStr,
 		&Claims{},
-		func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf(
-					"unexpected signing method: %v",
-					token.Header["alg"],
-				)
-			}
-			return secret, nil
-		},
+		keys.Keyfunc,
+		keys.ParserOptions()...,
 	)
 
 	if err != nil {
@@ -48,7 +87,19 @@ func ParseToken(tokenStr string, secret []byte) (*Claims, error) {
 	}
 
 	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.UserID <= 0 {
+		return nil, fmt.Errorf("invalid token")
+	}
+	switch claims.Type {
+	case TokenTypeSession:
+		if claims.Purpose != "" {
+			return nil, fmt.Errorf("invalid token")
+		}
+	case TokenTypePurpose:
+		if claims.Purpose == "" {
+			return nil, fmt.Errorf("invalid token")
+		}
+	default:
 		return nil, fmt.Errorf("invalid token")
 	}
 
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writePrivateKey(t *testing.T, dir, kid string, key any) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, dir, kid string, key any) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "PUBLIC KEY", der)
+}
+
+// rotatedKeys holds a retired RSA key, of which only the public half is left, and an active Ed25519 key
+func rotatedKeys(t *testing.T) (dir string, retired *rsa.PrivateKey) {
+	t.Helper()
+	dir = t.TempDir()
+	retired, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, active, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePublicKey(t, dir, "2025-01", &retired.PublicKey)
+	writePrivateKey(t, dir, "2026-01", active)
+	return dir, retired
+}
+
+func sessionClaims() Claims {
+	return Claims{
+		Type:   TokenTypeSession,
+		UserID: 7,
+		Email:  "owner@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir, retired := rotatedKeys(t)
+	keys, err := LoadKeySet(dir, "2026-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := keys.Sign(sessionClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "2026-01" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("token signed with kid %v using %s, want 2026-01 using EdDSA", parsed.Header["kid"], parsed.Method.Alg())
+	}
+	if claims, err := ParseToken(token, keys); err != nil || claims.UserID != 7 {
+		t.Errorf("ParseToken = %+v, %v, want user 7", claims, err)
+	}
+
+	// tokens signed before the rotation stay valid until they expire
+	old := jwt.NewWithClaims(jwt.SigningMethodRS256, sessionClaims())
+	old.Header["kid"] = "2025-01"
+	oldToken, err := old.SignedString(retired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(oldToken, keys); err != nil {
+		t.Errorf("ParseToken rejected a token of the retired key: %v", err)
+	}
+}
+
+func TestParseTokenRejectsForeignTokens(t *testing.T) {
+	dir, retired := rotatedKeys(t)
+	keys, err := LoadKeySet(dir, "2026-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(method jwt.SigningMethod, kid string, claims Claims, key any) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	otherIssuer := sessionClaims()
+	otherIssuer.Issuer = "someone-else"
+	untyped := sessionClaims()
+	untyped.Type = ""
+	withPurpose := sessionClaims()
+	withPurpose.Purpose = PurposeTwoFactorChallenge
+	withoutPurpose := sessionClaims()
+	withoutPurpose.Type = TokenTypePurpose
+	publicDER, _ := x509.MarshalPKIXPublicKey(&retired.PublicKey)
+	invitation, err := GenerateInvitationToken(42, 3, "guest@example.com", "token-1", time.Now().Add(time.Hour), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"unknown key id", sign(jwt.SigningMethodRS256, "2024-01", sessionClaims(), retired)},
+		{"algorithm of another key", sign(jwt.SigningMethodRS256, "2026-01", sessionClaims(), retired)},
+		{"HMAC keyed with the public key", sign(jwt.SigningMethodHS256, "2025-01", sessionClaims(), publicDER)},
+		{"another issuer", sign(jwt.SigningMethodRS256, "2025-01", otherIssuer, retired)},
+		{"without a type", sign(jwt.SigningMethodRS256, "2025-01", untyped, retired)},
+		{"session naming a purpose", sign(jwt.SigningMethodRS256, "2025-01", withPurpose, retired)},
+		{"purpose token without a purpose", sign(jwt.SigningMethodRS256, "2025-01", withoutPurpose, retired)},
+		{"invitation", invitation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseToken(tt.token, keys); err == nil {
+				t.Error("ParseToken accepted the token")
+			}
+		})
+	}
+}
+
+func TestLoadKeySetErrors(t *testing.T) {
+	dir, _ := rotatedKeys(t)
+	if _, err := LoadKeySet(dir, "2025-01"); err == nil {
+		t.Error("LoadKeySet accepted a public key as the active key")
+	}
+	if _, err := LoadKeySet(dir, "2027-01"); err == nil {
+		t.Error("LoadKeySet accepted a missing active key")
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePrivateKey(t, dir, "weak", weak)
+	if _, err := LoadKeySet(dir, "2026-01"); err == nil {
+		t.Error("LoadKeySet accepted a 1024 bit RSA key")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	dir, retired := rotatedKeys(t)
+	keys, err := LoadKeySet(dir, "2026-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := keys.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want the retired and the active key", len(set.Keys))
+	}
+	rsaKey, edKey := set.Keys[0], set.Keys[1]
+	if rsaKey.Kid != "2025-01" || rsaKey.Kty != "RSA" || rsaKey.Alg != "RS256" || rsaKey.E != "AQAB" || rsaKey.N == "" {
+		t.Errorf("retired key = %+v, want an RS256 RSA key with exponent AQAB", rsaKey)
+	}
+	if len(rsaKey.N) != len(retired.N.Bytes())*4/3+1 {
+		t.Errorf("modulus %q does not encode %d bytes", rsaKey.N, len(retired.N.Bytes()))
+	}
+	if edKey.Kid != "2026-01" || edKey.Kty != "OKP" || edKey.Crv != "Ed25519" || edKey.Alg != "EdDSA" || edKey.X == "" {
+		t.Errorf("active key = %+v, want an Ed25519 OKP key", edKey)
+	}
+	for _, key := range set.Keys {
+		if key.Use != "sig" {
+			t.Errorf("key %s has use %q, want sig", key.Kid, key.Use)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIssuer serves discovery and the keys of an ephemeral key set
+func testIssuer(t *testing.T) (*httptest.Server, *KeySet) {
+	t.Helper()
+	keys := testKeySet(t)
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                server.URL,
+			AuthorizationEndpoint: server.URL + "/authorize",
+			TokenEndpoint:         server.URL + "/token",
+			JWKSURI:               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(keys.JWKS())
+	})
+	return server, keys
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	server, keys := testIssuer(t)
+	provider := NewOIDCProvider(server.URL+"/", "hostmate", "", "http://localhost/callback")
+
+	claims := func(edit func(*idTokenClaims)) idTokenClaims {
+		c := idTokenClaims{
+			Email:         "guest@example.com",
+			EmailVerified: true,
+			Name:          "Asha Menon",
+			Nonce:         "nonce-1",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    server.URL,
+				Subject:   "subject-1",
+				Audience:  jwt.ClaimStrings{"hostmate"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		if edit != nil {
+			edit(&c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name         string
+		claims       idTokenClaims
+		nonce        string
+		wantErr      bool
+		wantVerified bool
+		wantMFA      bool
+	}{
+		{name: "valid", claims: claims(nil), nonce: "nonce-1", wantVerified: true},
+		{name: "email_verified sent as a string", claims: claims(func(c *idTokenClaims) { c.EmailVerified = "true" }), nonce: "nonce-1", wantVerified: true},
+		{name: "second factor asserted", claims: claims(func(c *idTokenClaims) { c.AMR = []string{"pwd", "otp", "mfa"} }), nonce: "nonce-1", wantVerified: true, wantMFA: true},
+		{name: "password only", claims: claims(func(c *idTokenClaims) { c.AMR = []string{"pwd"} }), nonce: "nonce-1", wantVerified: true},
+		{name: "unverified email", claims: claims(func(c *idTokenClaims) { c.EmailVerified = false }), nonce: "nonce-1"},
+		{name: "nonce of another login", claims: claims(nil), nonce: "nonce-2", wantErr: true},
+		{name: "no nonce", claims: claims(func(c *idTokenClaims) { c.Nonce = "" }), nonce: "", wantErr: true},
+		{name: "another audience", claims: claims(func(c *idTokenClaims) { c.Audience = jwt.ClaimStrings{"other"} }), nonce: "nonce-1", wantErr: true},
+		{name: "another issuer", claims: claims(func(c *idTokenClaims) { c.Issuer = "https://evil.example.com" }), nonce: "nonce-1", wantErr: true},
+		{name: "expired", claims: claims(func(c *idTokenClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour)) }), nonce: "nonce-1", wantErr: true},
+		{name: "no expiry", claims: claims(func(c *idTokenClaims) { c.ExpiresAt = nil }), nonce: "nonce-1", wantErr: true},
+		{name: "no subject", claims: claims(func(c *idTokenClaims) { c.Subject = "" }), nonce: "nonce-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := keys.Sign(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			identity, err := provider.VerifyIDToken(context.Background(), token, tt.nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("VerifyIDToken accepted the token as %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken returned %v", err)
+			}
+			if identity.Issuer != server.URL || identity.Subject != "subject-1" || identity.Email != "guest@example.com" {
+				t.Errorf("identity = %+v", identity)
+			}
+			if identity.EmailVerified != tt.wantVerified {
+				t.Errorf("EmailVerified = %v, want %v", identity.EmailVerified, tt.wantVerified)
+			}
+			if identity.MFA != tt.wantMFA {
+				t.Errorf("MFA = %v, want %v", identity.MFA, tt.wantMFA)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsUnknownKeys(t *testing.T) {
+	server, _ := testIssuer(t)
+	provider := NewOIDCProvider(server.URL, "hostmate", "", "http://localhost/callback")
+	token, err := testKeySet(t).Sign(idTokenClaims{
+		Nonce: "nonce-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    server.URL,
+			Subject:   "subject-1",
+			Audience:  jwt.ClaimStrings{"hostmate"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyIDToken(context.Background(), token, "nonce-1"); err == nil {
+		t.Error("VerifyIDToken accepted a token signed with a key the issuer does not publish")
+	}
+}
+
+func TestPKCEVerifier(t *testing.T) {
+	verifier, challenge, err := NewPKCEVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RFC 7636 requires 43 to 128 characters
+	if len(verifier) < 43 || len(verifier) > 128 {
+		t.Errorf("verifier has %d characters", len(verifier))
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if want := base64.RawURLEncoding.EncodeToString(sum[:]); challenge != want {
+		t.Errorf("challenge = %q, want the S256 of the verifier %q", challenge, want)
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// the SHA1 secret of the RFC 6238 appendix B test vectors, base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     int64
+		want   bool
+		step   int64
+	}{
+		{"RFC 6238 at 59", rfcSecret, "287082", 59, true, 1},
+		{"RFC 6238 at 1111111109", rfcSecret, "081804", 1111111109, true, 37037036},
+		{"RFC 6238 at 1234567890", rfcSecret, "005924", 1234567890, true, 41152263},
+		{"lower case secret", strings.ToLower(rfcSecret), "005924", 1234567890, true, 41152263},
+		{"surrounding spaces", rfcSecret, " 005924 ", 1234567890, true, 41152263},
+		{"one step early", rfcSecret, "005924", 1234567890 - 30, true, 41152263},
+		{"one step late", rfcSecret, "005924", 1234567890 + 30, true, 41152263},
+		{"two steps late", rfcSecret, "005924", 1234567890 + 60, false, 0},
+		{"wrong code", rfcSecret, "005925", 1234567890, false, 0},
+		{"too short", rfcSecret, "05924", 1234567890, false, 0},
+		{"eight digits", rfcSecret, "89005924", 1234567890, false, 0},
+		{"secret not base32", "not a secret!", "005924", 1234567890, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the step is the one the code was generated in, not the one it is checked in
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.at, 0))
+			if ok != tt.want || step != tt.step {
+				t.Errorf("ValidateTOTP(%q) at %d = %d, %v, want %d, %v", tt.code, tt.at, step, ok, tt.step, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratedSecretValidates(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	now := time.Now()
+	code := hotp(key, uint64(now.Unix()/totpPeriod))
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Errorf("ValidateTOTP rejected the current code %q", code)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 9 || code[4] != '-' {
+			t.Errorf("recovery code %q is not of the form xxxx-xxxx", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) != 10 {
+		t.Errorf("got %d distinct codes, want 10", len(seen))
+	}
+	// codes are accepted however they are typed back
+	want := HashRecoveryCode(codes[0])
+	for _, typed := range []string{strings.ToUpper(codes[0]), strings.ReplaceAll(codes[0], "-", ""), " " + codes[0] + " "} {
+		if got := HashRecoveryCode(typed); got != want {
+			t.Errorf("HashRecoveryCode(%q) differs from the hash of %q", typed, codes[0])
+		}
+	}
+}
@@ -105,3 +105,15 @@ func (a *azureBlobClient) VerifyBlobSize(ctx context.Context, blobName string) e
 	}
 	return nil
 }
+
+func (a *azureBlobClient) Upload(ctx context.Context, blobName, contentType string, data []byte) error {
+	_, err := a.client.UploadBuffer(ctx, containerName, blobName, data, &azblob.UploadBufferOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &contentType},
+	})
+	return err
+}
+
+func (a *azureBlobClient) Delete(ctx context.Context, blobName string) error {
+	_, err := a.client.DeleteBlob(ctx, containerName, blobName, nil)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
 	"github.com/nevinmanoj/hostmate/internal/domain/access"
 )
 
@@ -15,26 +16,48 @@ func NewAccessRepository(db *sqlx.DB) access.AccessRepository {
 	return &accessRepository{db: db}
 }
 
-func (r *accessRepository) HasManagerByPropertyID(ctx context.Context, propertyID, userID int64) (bool, error) {
+// grantCondition is true when $2 manages the property pr or is a member of it holding
+// capability $3, an empty capability matches any grant
+const grantCondition = `(
+	$2 = ANY(pr.managers)
+	OR EXISTS (
+		SELECT 1
+		FROM property_members pm
+		WHERE pm.property_id = pr.id
+		  AND pm.user_id = $2
+		  AND ($3 = '' OR $3 = ANY(pm.capabilities))
+	)
+)`
+
+func (r *accessRepository) HasCapabilityByPropertyID(ctx context.Context, propertyID, userID int64, capability access.Capability) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
 
 	const q = `
 		SELECT EXISTS (
 			SELECT 1
 			FROM properties pr
-			WHERE id = $1
-			  AND $2 = ANY(pr.managers)
+			WHERE pr.id = $1
+			  AND ` + grantCondition + `
+			  AND pr.organisation_id = $4
 		)
 	`
 
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, q, propertyID, userID)
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &exists, q, propertyID, userID, string(capability), tenantID)
 	if err != nil {
 		return false, err
 	}
 
 	return exists, nil
 }
-func (r *accessRepository) HasManagerByBookingID(ctx context.Context, bookingID, userID int64) (bool, error) {
+func (r *accessRepository) HasCapabilityByBookingID(ctx context.Context, bookingID, userID int64, capability access.Capability) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
 
 	const q = `
 		SELECT EXISTS (
@@ -42,19 +65,25 @@ func (r *accessRepository) HasManagerByBookingID(ctx context.Context, bookingID,
 			FROM bookings b
 			JOIN properties pr ON pr.id = b.property_id
 			WHERE b.id = $1
-			  AND $2 = ANY(pr.managers)
+			  AND ` + grantCondition + `
+			  AND b.organisation_id = $4
+			  AND pr.organisation_id = $4
 		)
 	`
 
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, q, bookingID, userID)
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &exists, q, bookingID, userID, string(capability), tenantID)
 	if err != nil {
 		return false, err
 	}
 
 	return exists, nil
 }
-func (r *accessRepository) HasManagerByPaymentID(ctx context.Context, paymentID, userID int64) (bool, error) {
+func (r *accessRepository) HasCapabilityByPaymentID(ctx context.Context, paymentID, userID int64, capability access.Capability) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
 
 	const q = `
 		SELECT EXISTS (
@@ -63,12 +92,91 @@ func (r *accessRepository) HasManagerByPaymentID(ctx context.Context, paymentID,
 			JOIN bookings b ON b.id = p.booking_id
 			JOIN properties pr ON pr.id = b.property_id
 			WHERE p.id = $1
+			  AND ` + grantCondition + `
+			  AND p.organisation_id = $4
+			  AND pr.organisation_id = $4
+		)
+	`
+
+	var exists bool
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &exists, q, paymentID, userID, string(capability), tenantID)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+func (r *accessRepository) SharesPropertyWithUser(ctx context.Context, otherUserID, userID int64) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	const q = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM properties pr
+			WHERE $1 = ANY(pr.managers)
 			  AND $2 = ANY(pr.managers)
+			  AND pr.organisation_id = $3
+		)
+	`
+
+	var exists bool
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &exists, q, otherUserID, userID, tenantID)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *accessRepository) HasMember(ctx context.Context, userID int64) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	const q = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM organisation_members m
+			JOIN users u ON u.id = m.user_id
+			WHERE m.organisation_id = $1
+			  AND m.user_id = $2
+			  AND u.deactivated_at IS NULL
+		)
+	`
+
+	var exists bool
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &exists, q, tenantID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *accessRepository) HasAdminRole(ctx context.Context, userID int64) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	const q = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM organisation_members m
+			JOIN users u ON u.id = m.user_id
+			WHERE m.organisation_id = $1
+			  AND m.user_id = $2
+			  AND m.role IN ('owner', 'admin')
+			  AND u.deactivated_at IS NULL
 		)
 	`
 
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, q, paymentID, userID)
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &exists, q, tenantID, userID)
 	if err != nil {
 		return false, err
 	}
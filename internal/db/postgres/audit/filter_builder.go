@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	audit "github.com/nevinmanoj/hostmate/internal/domain/audit"
+)
+
+func buildAuditQuery(baseQuery string, f audit.AuditFilter, tenantID int64, isCount bool) (string, []any, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	conditions = append(conditions, "organisation_id = ?")
+	args = append(args, tenantID)
+
+	if f.EntityType != nil {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, *f.EntityType)
+	}
+	if f.EntityID != nil {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, *f.EntityID)
+	}
+	if f.ActorID != nil {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, *f.ActorID)
+	}
+	if len(f.Action) > 0 {
+		conditions = append(conditions, "action IN (?)")
+		args = append(args, f.Action)
+	}
+	if f.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *f.To)
+	}
+
+	// Apply WHERE
+	if len(conditions) > 0 {
+		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if !isCount {
+		// Ordering (always deterministic)
+		baseQuery += " ORDER BY created_at DESC, id DESC"
+
+		// Pagination
+		if f.Limit > 0 {
+			baseQuery += " LIMIT ?"
+			args = append(args, f.Limit)
+		}
+		if f.Offset > 0 {
+			baseQuery += " OFFSET ?"
+			args = append(args, f.Offset)
+		}
+	}
+
+	// Expand IN clauses
+	query, finalArgs, err := sqlx.In(baseQuery, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Rebind for postgres ($1, $2...)
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	return query, finalArgs, nil
+}
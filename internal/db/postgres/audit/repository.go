@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	audit "github.com/nevinmanoj/hostmate/internal/domain/audit"
+)
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) audit.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *audit.Entry) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	entry.OrganisationID = tenantID
+
+	query := `
+		INSERT INTO audit_log (
+			organisation_id,
+			actor_id,
+			entity_type,
+			entity_id,
+			action,
+			changes
+		)
+		VALUES (
+			:organisation_id,
+			:actor_id,
+			:entity_type,
+			:entity_id,
+			:action,
+			:changes
+		)
+		RETURNING id, created_at
+	`
+
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, entry)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return rows.Scan(&entry.ID, &entry.CreatedAt)
+	}
+	return rows.Err()
+}
+
+func (r *auditRepository) GetAll(ctx context.Context, filter audit.AuditFilter) ([]audit.Entry, int, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	countQuery, countArgs, err := buildAuditQuery(`SELECT COUNT(*) FROM audit_log`, filter, tenantID, true)
+	if err != nil {
+		log.Println("Error during building audit query:", err.Error())
+		return nil, 0, err
+	}
+	var total int
+	if err := postgres.Conn(ctx, r.db).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []audit.Entry{}, 0, nil
+	}
+
+	query, args, err := buildAuditQuery(`SELECT * FROM audit_log`, filter, tenantID, false)
+	if err != nil {
+		log.Println("Error during building audit query:", err.Error())
+		return nil, 0, err
+	}
+	entries := []audit.Entry{}
+	err = postgres.Conn(ctx, r.db).SelectContext(ctx, &entries, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -4,19 +4,33 @@ import (
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
 	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 )
 
-func buildBookingQuery(baseQuery string, f booking.BookingFilter, isCount bool) (string, []any, error) {
+func buildBookingQuery(baseQuery string, f booking.BookingFilter, tenantID int64, isCount bool) (string, []any, error) {
 	var (
 		conditions []string
 		args       []any
 	)
 
+	conditions = append(conditions, "b.organisation_id = ?")
+	args = append(args, tenantID)
+
+	if !f.IncludeDeleted {
+		//bookings of an archived property are archived with it
+		conditions = append(conditions, "b.deleted_at IS NULL AND p.deleted_at IS NULL")
+	}
+
 	if f.UserID != nil {
-		//check if user is a manager for property,if user is nil -> admin access
-		conditions = append(conditions, "? = ANY(p.managers)")
-		args = append(args, *f.UserID)
+		//check if user is a manager for property or may view its bookings,if user is nil -> admin access
+		conditions = append(conditions, `(? = ANY(p.managers) OR EXISTS (
+			SELECT 1 FROM property_members pm
+			WHERE pm.property_id = p.id AND pm.user_id = ? AND ? = ANY(pm.capabilities)
+		))`)
+		args = append(args, *f.UserID, *f.UserID, string(access.CapViewBookings))
 	}
 	if len(f.PropertyID) > 0 {
 		conditions = append(conditions, "b.property_id IN (?)")
@@ -56,6 +70,19 @@ func buildBookingQuery(baseQuery string, f booking.BookingFilter, isCount bool)
 		args = append(args, *f.GuestPhone)
 	}
 
+	if f.Query != nil {
+		condition, queryArgs := postgres.BookingMatch("b.", *f.Query)
+		conditions = append(conditions, condition)
+		args = append(args, queryArgs...)
+	}
+
+	// the total counts every page, only the page itself continues from the cursor
+	keyset := bookingKeyset(f)
+	if condition, keysetArgs := keyset.Condition(); condition != "" && !isCount {
+		conditions = append(conditions, condition)
+		args = append(args, keysetArgs...)
+	}
+
 	// Apply WHERE
 	if len(conditions) > 0 {
 		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
@@ -63,18 +90,17 @@ func buildBookingQuery(baseQuery string, f booking.BookingFilter, isCount bool)
 
 	// Ordering (always deterministic)
 	if !isCount {
-		baseQuery += " ORDER BY created_at DESC"
-	}
-
-	// Pagination
-	if f.Limit > 0 {
-		baseQuery += " LIMIT ?"
-		args = append(args, f.Limit)
-	}
-
-	if f.Offset > 0 {
-		baseQuery += " OFFSET ?"
-		args = append(args, f.Offset)
+		baseQuery += keyset.OrderBy()
+		// Pagination, one extra row tells whether there is a next page
+		if f.Limit > 0 {
+			baseQuery += " LIMIT ?"
+			args = append(args, f.Limit+1)
+		}
+
+		if f.Offset > 0 && f.Cursor == nil {
+			baseQuery += " OFFSET ?"
+			args = append(args, f.Offset)
+		}
 	}
 
 	// Expand IN clauses
@@ -88,3 +114,40 @@ func buildBookingQuery(baseQuery string, f booking.BookingFilter, isCount bool)
 
 	return query, finalArgs, nil
 }
+
+var bookingSortColumns = map[string]string{
+	"created_at":     "b.created_at",
+	"check_in_date":  "b.check_in_date",
+	"check_out_date": "b.check_out_date",
+	"guest_name":     "b.guest_name",
+	"base_rate":      "b.base_rate",
+}
+
+// bookingKeyset defaults to the newest bookings first
+func bookingKeyset(f booking.BookingFilter) postgres.Keyset {
+	sort := f.Sort
+	column, ok := bookingSortColumns[sort.Field]
+	if !ok {
+		sort = pagination.Sort{Field: "created_at", Desc: true}
+		column = bookingSortColumns[sort.Field]
+	}
+	return postgres.Keyset{Column: column, IDColumn: "b.id", Sort: sort, Cursor: f.Cursor}
+}
+
+// bookingSortKey is the cursor value of a booking for the sort field
+func bookingSortKey(field string) func(booking.Booking) (string, int64) {
+	return func(b booking.Booking) (string, int64) {
+		switch field {
+		case "check_in_date":
+			return postgres.TimeKey(b.CheckInDate), b.ID
+		case "check_out_date":
+			return postgres.TimeKey(b.CheckOutDate), b.ID
+		case "guest_name":
+			return b.GuestName, b.ID
+		case "base_rate":
+			return postgres.FloatKey(b.BaseRate), b.ID
+		default:
+			return postgres.TimeKey(b.CreatedAt), b.ID
+		}
+	}
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
 	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 )
 
 type bookingRepository struct {
@@ -22,45 +24,67 @@ func NewBookingWriteRepository(db *sqlx.DB) booking.BookingWriteRepository {
 	return &bookingRepository{db: db}
 }
 
-func (r *bookingRepository) GetAll(ctx context.Context, filter booking.BookingFilter) ([]booking.Booking, int, error) {
-	baseCountQuery := `SELECT COUNT(b.*)
-		FROM bookings b
-		JOIN properties p ON p.id = b.property_id`
-	finalCountQuery, finalCountArgs, err := buildBookingQuery(baseCountQuery, filter, true)
-	var total int
-	if err := r.db.QueryRowContext(
-		ctx,
-		finalCountQuery, finalCountArgs...,
-	).Scan(&total); err != nil {
-		return nil, 0, err
+func (r *bookingRepository) GetAll(ctx context.Context, filter booking.BookingFilter) ([]booking.Booking, int, pagination.Cursors, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, 0, pagination.Cursors{}, booking.ErrInternal
 	}
-	if total == 0 {
-		return []booking.Booking{}, 0, nil
+	var total int
+	if !filter.SkipCount {
+		baseCountQuery := `SELECT COUNT(b.*)
+			FROM bookings b
+			JOIN properties p ON p.id = b.property_id`
+		finalCountQuery, finalCountArgs, err := buildBookingQuery(baseCountQuery, filter, tenantID, true)
+		if err != nil {
+			return nil, 0, pagination.Cursors{}, err
+		}
+		if err := postgres.Conn(ctx, r.db).QueryRowContext(
+			ctx,
+			finalCountQuery, finalCountArgs...,
+		).Scan(&total); err != nil {
+			return nil, 0, pagination.Cursors{}, err
+		}
+		if total == 0 {
+			return []booking.Booking{}, 0, pagination.Cursors{}, nil
+		}
 	}
 	baseQuery := `SELECT b.*
 		FROM bookings b
 		JOIN properties p ON p.id = b.property_id`
-	finalQuery, finalArgs, err := buildBookingQuery(baseQuery, filter, false)
+	finalQuery, finalArgs, err := buildBookingQuery(baseQuery, filter, tenantID, false)
+	if err != nil {
+		return nil, 0, pagination.Cursors{}, err
+	}
 	bookings := []booking.Booking{}
-	err = r.db.SelectContext(
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&bookings,
 		finalQuery, finalArgs...,
 	)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, pagination.Cursors{}, err
 	}
-	return bookings, total, nil
+	keyset := bookingKeyset(filter)
+	bookings, cursors := postgres.Page(bookings, filter.Limit, keyset, bookingSortKey(keyset.Sort.Field))
+	return bookings, total, cursors, nil
 }
 
 func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*booking.Booking, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, booking.ErrInternal
+	}
 	var count int64
-	if err := r.db.QueryRowContext(
+	if err := postgres.Conn(ctx, r.db).QueryRowContext(
 		ctx,
 		`SELECT COUNT (*)
 		 FROM bookings
-		 WHERE id = $1`,
-		id,
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL`,
+		id, tenantID,
 	).Scan(&count); err != nil {
 		log.Println("Error checking booking existence:", err)
 		return nil, booking.ErrInternal
@@ -69,12 +93,14 @@ func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*booking.Boo
 		return nil, booking.ErrNotFound
 	}
 	bookings := []booking.Booking{}
-	err := r.db.SelectContext(
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&bookings,
 		`SELECT * FROM bookings
-		 WHERE id = $1`,
-		id,
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL`,
+		id, tenantID,
 	)
 	if err != nil {
 		log.Println("Error fetching booking by ID:", err)
@@ -85,9 +111,16 @@ func (r *bookingRepository) GetByID(ctx context.Context, id int64) (*booking.Boo
 }
 
 func (r *bookingRepository) Create(ctx context.Context, bookingToCreate *booking.Booking) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return booking.ErrInternal
+	}
+	bookingToCreate.OrganisationID = tenantID
 
 	query := `
 		INSERT INTO bookings (
+			organisation_id,
 			property_id,
 			manager_id,
 			guest_phone,
@@ -100,10 +133,21 @@ func (r *bookingRepository) Create(ctx context.Context, bookingToCreate *booking
 			check_in_date,
 			check_out_date,
 			id_proofs, 
+			remarks,
+			discount_amount,
+			charge_amount,
+			tax_rate,
+			tax_inclusive,
+			taxable_amount,
+			cgst,
+			sgst,
+			igst,
+			total_amount,
 			created_by,
 			updated_by	
 		)
 		VALUES (
+			:organisation_id,
 			:property_id,
 			:manager_id,
 			:guest_phone,
@@ -116,13 +160,30 @@ func (r *bookingRepository) Create(ctx context.Context, bookingToCreate *booking
 			:check_in_date,
 			:check_out_date,
 			:id_proofs,
+			:remarks,
+			:discount_amount,
+			:charge_amount,
+			:tax_rate,
+			:tax_inclusive,
+			:taxable_amount,
+			:cgst,
+			:sgst,
+			:igst,
+			:total_amount,
 			:created_by,
 			:updated_by	
 		)
-		RETURNING id, created_at, updated_at
+		RETURNING id, created_at, updated_at, revision, version
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, bookingToCreate)
+	tx, err := postgres.BeginTx(ctx, r.db)
+	if err != nil {
+		log.Println("Error starting booking transaction:", err)
+		return booking.ErrInternal
+	}
+	defer tx.Rollback()
+
+	rows, err := sqlx.NamedQueryContext(ctx, tx, query, bookingToCreate)
 	if err != nil {
 		log.Println("Error creating booking:", err)
 
@@ -137,17 +198,31 @@ func (r *bookingRepository) Create(ctx context.Context, bookingToCreate *booking
 
 		return booking.ErrInternal
 	}
-	defer rows.Close()
-
-	if rows.Next() {
-		rows.Scan(&bookingToCreate.ID, &bookingToCreate.CreatedAt, &bookingToCreate.UpdatedAt)
-		return nil
+	if !rows.Next() {
+		rows.Close()
+		return booking.ErrInternal
 	}
+	rows.Scan(&bookingToCreate.ID, &bookingToCreate.CreatedAt, &bookingToCreate.UpdatedAt, &bookingToCreate.Revision, &bookingToCreate.Version)
+	rows.Close()
 
-	return booking.ErrInternal
+	if err := insertRevision(ctx, tx.Tx, bookingToCreate.ID, tenantID, ""); err != nil {
+		log.Println("Error storing booking revision:", err)
+		return booking.ErrInternal
+	}
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing booking:", err)
+		return booking.ErrInternal
+	}
+	return nil
 }
 
-func (r *bookingRepository) Update(ctx context.Context, bookingToUpdate *booking.Booking) error {
+func (r *bookingRepository) Update(ctx context.Context, bookingToUpdate *booking.Booking, reason string) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return booking.ErrInternal
+	}
+	bookingToUpdate.OrganisationID = tenantID
 	query := `
 		UPDATE bookings
 		SET
@@ -163,42 +238,255 @@ func (r *bookingRepository) Update(ctx context.Context, bookingToUpdate *booking
 			check_in_date = :check_in_date,
 			check_out_date  = :check_out_date,
 			id_proofs = :id_proofs, 
+			remarks = :remarks,
+			discount_amount = :discount_amount,
+			charge_amount = :charge_amount,
+			tax_rate = :tax_rate,
+			tax_inclusive = :tax_inclusive,
+			taxable_amount = :taxable_amount,
+			cgst = :cgst,
+			sgst = :sgst,
+			igst = :igst,
+			total_amount = :total_amount,
 			updated_at = NOW(),
-			updated_by = :updated_by
+			updated_by = :updated_by,
+			revision = revision + 1,
+			version = version + 1
 		WHERE id = :id
-		RETURNING updated_at
+		  AND organisation_id = :organisation_id
+		  AND version = :version
+		  AND deleted_at IS NULL
+		RETURNING updated_at, revision, version
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, bookingToUpdate)
+	tx, err := postgres.BeginTx(ctx, r.db)
+	if err != nil {
+		log.Println("Error starting booking transaction:", err)
+		return booking.ErrInternal
+	}
+	defer tx.Rollback()
+
+	rows, err := sqlx.NamedQueryContext(ctx, tx, query, bookingToUpdate)
 	if err != nil {
 		log.Println("Error updating booking:", err)
 		return booking.ErrInternal
 	}
-	defer rows.Close()
-	if rows.Next() {
-		rows.Scan(&bookingToUpdate.UpdatedAt)
-		return nil
+	if !rows.Next() {
+		rows.Close()
+		// the booking was read before updating, so a miss means someone else saved first
+		return booking.ErrVersionConflict
+	}
+	rows.Scan(&bookingToUpdate.UpdatedAt, &bookingToUpdate.Revision, &bookingToUpdate.Version)
+	rows.Close()
+
+	if err := insertRevision(ctx, tx.Tx, bookingToUpdate.ID, tenantID, reason); err != nil {
+		log.Println("Error storing booking revision:", err)
+		return booking.ErrInternal
+	}
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing booking update:", err)
+		return booking.ErrInternal
+	}
+	return nil
+}
+
+func (r *bookingRepository) GetRevisions(ctx context.Context, bookingID int64) ([]booking.BookingRevision, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, booking.ErrInternal
+	}
+	revisions := []booking.BookingRevision{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&revisions,
+		`SELECT * FROM booking_revisions
+		 WHERE booking_id = $1
+		   AND organisation_id = $2
+		 ORDER BY revision`,
+		bookingID, tenantID,
+	)
+	if err != nil {
+		log.Println("Error fetching booking revisions:", err)
+		return nil, booking.ErrInternal
+	}
+	return revisions, nil
+}
+
+// insertRevision copies the stored booking into booking_revisions, so the revision
+// always matches what was written rather than what the caller sent
+func insertRevision(ctx context.Context, tx *sqlx.Tx, bookingID, tenantID int64, reason string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO booking_revisions (
+			booking_id,
+			organisation_id,
+			revision,
+			property_id,
+			manager_id,
+			guest_phone,
+			guest_name,
+			base_rate,
+			max_guests_base,
+			extra_rate_per_guest,
+			num_guests,
+			status,
+			check_in_date,
+			check_out_date,
+			remarks,
+			reason,
+			changed_by
+		)
+		SELECT
+			id,
+			organisation_id,
+			revision,
+			property_id,
+			manager_id,
+			guest_phone,
+			guest_name,
+			base_rate,
+			max_guests_base,
+			extra_rate_per_guest,
+			num_guests,
+			status,
+			check_in_date,
+			check_out_date,
+			remarks,
+			$2,
+			updated_by
+		FROM bookings
+		WHERE id = $1
+		  AND organisation_id = $3`,
+		bookingID, reason, tenantID,
+	)
+	return err
+}
+
+// GetDeletedByID finds a soft deleted booking, GetByID never returns one
+func (r *bookingRepository) GetDeletedByID(ctx context.Context, id int64) (*booking.Booking, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, booking.ErrInternal
+	}
+	bookings := []booking.Booking{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&bookings,
+		`SELECT * FROM bookings
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NOT NULL`,
+		id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error fetching deleted booking by ID:", err)
+		return nil, booking.ErrInternal
+	}
+	if len(bookings) == 0 {
+		return nil, booking.ErrNotFound
+	}
+	return &bookings[0], nil
+}
+
+func (r *bookingRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return booking.ErrInternal
+	}
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE bookings
+		SET deleted_at = NOW(),
+			deleted_by = $1,
+			version = version + 1
+		WHERE id = $2
+		  AND organisation_id = $3
+		  AND deleted_at IS NULL`,
+		deletedBy, id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error deleting booking:", err)
+		return booking.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return booking.ErrInternal
+	}
+	if rows == 0 {
+		return booking.ErrNotFound
+	}
+	return nil
+}
+
+func (r *bookingRepository) Restore(ctx context.Context, id, restoredBy int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return booking.ErrInternal
+	}
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE bookings
+		SET deleted_at = NULL,
+			deleted_by = NULL,
+			updated_at = NOW(),
+			updated_by = $1,
+			version = version + 1
+		WHERE id = $2
+		  AND organisation_id = $3
+		  AND deleted_at IS NOT NULL`,
+		restoredBy, id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error restoring booking:", err)
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			// the dates were booked again while this booking was deleted
+			if pqErr.Code == "23P01" &&
+				pqErr.Constraint == "no_overlapping_bookings" {
+				return booking.ErrBookingConflict
+			}
+		}
+
+		return booking.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return booking.ErrInternal
+	}
+	if rows == 0 {
+		return booking.ErrNotFound
 	}
 	return nil
 }
 
 func (r *bookingRepository) CheckAvailability(ctx context.Context, propertyID int64, checkInDate, checkOutDate time.Time) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return false, booking.ErrInternal
+	}
 	available := false
 	query := `
 		SELECT NOT EXISTS (
     		SELECT 1
    			FROM bookings
     		WHERE property_id = $1
+			AND organisation_id = $4
+			AND deleted_at IS NULL
 			AND status IN ('booked', 'checkedIn')
       		AND daterange(check_in_date, check_out_date, '[)') &&
           	daterange($2::date, $3::date, '[)')
 		)`
-	err := r.db.QueryRowContext(
+	err = postgres.Conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		propertyID,
 		checkInDate,
 		checkOutDate,
+		tenantID,
 	).Scan(&available)
 	if err != nil {
 		log.Println("Error checking booking availability:", err)
@@ -208,15 +496,21 @@ func (r *bookingRepository) CheckAvailability(ctx context.Context, propertyID in
 	return available, nil
 }
 func (r *bookingRepository) AppendBlobs(ctx context.Context, bookingID int64, blobName string) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return booking.ErrInternal
+	}
 
 	query := `
 		UPDATE bookings
 		SET blobs = array_append(blobs, $1)
 		WHERE id = $2
+		  AND organisation_id = $3
 		  AND NOT ($1 = ANY(blobs))
 	`
 
-	res, err := r.db.ExecContext(ctx, query, blobName, bookingID)
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, query, blobName, bookingID, tenantID)
 	if err != nil {
 		log.Println("Error updating blob array in booking:", err)
 		return booking.ErrInternal
@@ -229,9 +523,9 @@ func (r *bookingRepository) AppendBlobs(ctx context.Context, bookingID int64, bl
 
 	if rows == 0 {
 		var exists bool
-		err := r.db.GetContext(ctx, &exists,
-			`SELECT EXISTS(SELECT 1 FROM bookings WHERE id = $1)`,
-			bookingID,
+		err := postgres.Conn(ctx, r.db).GetContext(ctx, &exists,
+			`SELECT EXISTS(SELECT 1 FROM bookings WHERE id = $1 AND organisation_id = $2)`,
+			bookingID, tenantID,
 		)
 		if err != nil {
 			return booking.ErrInternal
@@ -248,14 +542,182 @@ func (r *bookingRepository) AppendBlobs(ctx context.Context, bookingID int64, bl
 	return nil
 }
 func (r *bookingRepository) GetBlobs(ctx context.Context, bookingID int64) ([]string, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, booking.ErrInternal
+	}
 
 	var blobs []string
 
-	err := r.db.GetContext(ctx, pq.Array(&blobs), `
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, pq.Array(&blobs), `
         SELECT blobs
         FROM bookings
         WHERE id = $1
-    `, bookingID)
+          AND organisation_id = $2
+    `, bookingID, tenantID)
 
 	return blobs, err
 }
+
+func (r *bookingRepository) GetAdjustments(ctx context.Context, bookingID int64) ([]booking.Adjustment, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, booking.ErrInternal
+	}
+	adjustments := []booking.Adjustment{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&adjustments,
+		`SELECT * FROM booking_adjustments
+		 WHERE booking_id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL
+		 ORDER BY id`,
+		bookingID, tenantID,
+	)
+	if err != nil {
+		log.Println("Error fetching booking adjustments:", err)
+		return nil, booking.ErrInternal
+	}
+	return adjustments, nil
+}
+
+func (r *bookingRepository) AddAdjustment(ctx context.Context, adjustment *booking.Adjustment, priced *booking.Booking) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return booking.ErrInternal
+	}
+	adjustment.OrganisationID = tenantID
+
+	tx, err := postgres.BeginTx(ctx, r.db)
+	if err != nil {
+		log.Println("Error starting booking transaction:", err)
+		return booking.ErrInternal
+	}
+	defer tx.Rollback()
+
+	rows, err := sqlx.NamedQueryContext(ctx, tx, `
+		INSERT INTO booking_adjustments (
+			organisation_id,
+			booking_id,
+			kind,
+			basis,
+			value,
+			coupon_id,
+			coupon_code,
+			reason,
+			created_by
+		)
+		VALUES (
+			:organisation_id,
+			:booking_id,
+			:kind,
+			:basis,
+			:value,
+			:coupon_id,
+			:coupon_code,
+			:reason,
+			:created_by
+		)
+		RETURNING id, created_at`, adjustment)
+	if err != nil {
+		log.Println("Error creating booking adjustment:", err)
+		return booking.ErrInternal
+	}
+	if !rows.Next() {
+		rows.Close()
+		return booking.ErrInternal
+	}
+	rows.Scan(&adjustment.ID, &adjustment.CreatedAt)
+	rows.Close()
+
+	if err := updatePricing(ctx, tx.Tx, priced, tenantID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing booking adjustment:", err)
+		return booking.ErrInternal
+	}
+	return nil
+}
+
+func (r *bookingRepository) RemoveAdjustment(ctx context.Context, adjustmentID, removedBy int64, priced *booking.Booking) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return booking.ErrInternal
+	}
+
+	tx, err := postgres.BeginTx(ctx, r.db)
+	if err != nil {
+		log.Println("Error starting booking transaction:", err)
+		return booking.ErrInternal
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE booking_adjustments
+		SET deleted_at = NOW(),
+			deleted_by = $1
+		WHERE id = $2
+		  AND booking_id = $3
+		  AND organisation_id = $4
+		  AND deleted_at IS NULL`,
+		removedBy, adjustmentID, priced.ID, tenantID,
+	)
+	if err != nil {
+		log.Println("Error removing booking adjustment:", err)
+		return booking.ErrInternal
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return booking.ErrAdjustmentNotFound
+	}
+
+	if err := updatePricing(ctx, tx.Tx, priced, tenantID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing booking adjustment:", err)
+		return booking.ErrInternal
+	}
+	return nil
+}
+
+// updatePricing stores the totals of a booking priced again after an adjustment, the terms
+// are unchanged so no revision is kept but the version moves on
+func updatePricing(ctx context.Context, tx *sqlx.Tx, priced *booking.Booking, tenantID int64) error {
+	priced.OrganisationID = tenantID
+	rows, err := sqlx.NamedQueryContext(ctx, tx, `
+		UPDATE bookings
+		SET
+			discount_amount = :discount_amount,
+			charge_amount = :charge_amount,
+			tax_rate = :tax_rate,
+			tax_inclusive = :tax_inclusive,
+			taxable_amount = :taxable_amount,
+			cgst = :cgst,
+			sgst = :sgst,
+			igst = :igst,
+			total_amount = :total_amount,
+			updated_at = NOW(),
+			updated_by = :updated_by,
+			version = version + 1
+		WHERE id = :id
+		  AND organisation_id = :organisation_id
+		  AND version = :version
+		  AND deleted_at IS NULL
+		RETURNING updated_at, version`, priced)
+	if err != nil {
+		log.Println("Error updating booking pricing:", err)
+		return booking.ErrInternal
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		// the booking was read before pricing, so a miss means someone else saved first
+		return booking.ErrVersionConflict
+	}
+	return rows.Scan(&priced.UpdatedAt, &priced.Version)
+}
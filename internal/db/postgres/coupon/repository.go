@@ -0,0 +1,169 @@
+package coupon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	coupon "github.com/nevinmanoj/hostmate/internal/domain/coupon"
+)
+
+type couponRepository struct {
+	db *sqlx.DB
+}
+
+func NewCouponRepository(db *sqlx.DB) coupon.CouponRepository {
+	return &couponRepository{db: db}
+}
+
+// a coupon is used by every live adjustment redeeming it, removing the adjustment or
+// deleting its booking frees the use
+const selectCoupon = `
+	SELECT c.*, (
+		SELECT COUNT(*) FROM booking_adjustments ba
+		JOIN bookings b ON b.id = ba.booking_id
+		WHERE ba.coupon_id = c.id AND ba.deleted_at IS NULL AND b.deleted_at IS NULL
+	) AS uses
+	FROM coupons c`
+
+func (r *couponRepository) GetAll(ctx context.Context) ([]coupon.Coupon, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, coupon.ErrInternal
+	}
+	coupons := []coupon.Coupon{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&coupons,
+		selectCoupon+`
+		 WHERE c.organisation_id = $1
+		 ORDER BY c.active DESC, c.created_at DESC`,
+		tenantID,
+	)
+	if err != nil {
+		log.Println("Error fetching coupons:", err)
+		return nil, coupon.ErrInternal
+	}
+	return coupons, nil
+}
+
+func (r *couponRepository) GetByID(ctx context.Context, id int64) (*coupon.Coupon, error) {
+	return r.get(ctx, "c.id = $1", id, "")
+}
+
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*coupon.Coupon, error) {
+	return r.get(ctx, "c.code = $1", code, "")
+}
+
+// GetByCodeForUpdate locks only the coupon row, the uses counted with it cannot grow
+// while the lock is held since every redemption takes the same lock first
+func (r *couponRepository) GetByCodeForUpdate(ctx context.Context, code string) (*coupon.Coupon, error) {
+	return r.get(ctx, "c.code = $1", code, " FOR UPDATE OF c")
+}
+
+func (r *couponRepository) get(ctx context.Context, condition string, arg any, lock string) (*coupon.Coupon, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, coupon.ErrInternal
+	}
+	var c coupon.Coupon
+	err = postgres.Conn(ctx, r.db).GetContext(
+		ctx,
+		&c,
+		selectCoupon+`
+		 WHERE `+condition+`
+		   AND c.organisation_id = $2`+lock,
+		arg, tenantID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, coupon.ErrNotFound
+	}
+	if err != nil {
+		log.Println("Error fetching coupon:", err)
+		return nil, coupon.ErrInternal
+	}
+	return &c, nil
+}
+
+func (r *couponRepository) Create(ctx context.Context, couponToCreate *coupon.Coupon) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return coupon.ErrInternal
+	}
+	couponToCreate.OrganisationID = tenantID
+
+	query := `
+		INSERT INTO coupons (
+			organisation_id,
+			code,
+			percent,
+			value,
+			property_id,
+			valid_from,
+			valid_to,
+			max_uses,
+			active,
+			created_by
+		)
+		VALUES (
+			:organisation_id,
+			:code,
+			:percent,
+			:value,
+			:property_id,
+			:valid_from,
+			:valid_to,
+			:max_uses,
+			:active,
+			:created_by
+		)
+		RETURNING id, created_at, updated_at
+	`
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, couponToCreate)
+	if err != nil {
+		log.Println("Error creating coupon:", err)
+		// codes are unique within an organisation
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return coupon.ErrAlreadyExists
+		}
+		return coupon.ErrInternal
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return rows.Scan(&couponToCreate.ID, &couponToCreate.CreatedAt, &couponToCreate.UpdatedAt)
+	}
+	return coupon.ErrInternal
+}
+
+func (r *couponRepository) SetActive(ctx context.Context, id int64, active bool) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return coupon.ErrInternal
+	}
+	result, err := postgres.Conn(ctx, r.db).ExecContext(
+		ctx,
+		`UPDATE coupons
+		 SET active = $1,
+			 updated_at = NOW()
+		 WHERE id = $2
+		   AND organisation_id = $3`,
+		active, id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error updating coupon:", err)
+		return coupon.ErrInternal
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return coupon.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	idempotency "github.com/nevinmanoj/hostmate/internal/domain/idempotency"
+)
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) idempotency.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Create(ctx context.Context, record *idempotency.Record, expiredBefore time.Time) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
+	record.OrganisationID = tenantID
+
+	// an expired key is taken over by the new request, a live one is left untouched
+	query := `
+		INSERT INTO idempotency_keys (
+			organisation_id,
+			user_id,
+			idempotency_key,
+			fingerprint
+		)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organisation_id, user_id, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			response = NULL,
+			response_headers = NULL,
+			created_at = NOW(),
+			completed_at = NULL
+		WHERE idempotency_keys.created_at < $5
+		RETURNING id, created_at
+	`
+	err = r.db.QueryRowContext(
+		ctx,
+		query,
+		tenantID, record.UserID, record.Key, record.Fingerprint, expiredBefore,
+	).Scan(&record.ID, &record.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userID int64, key string) (*idempotency.Record, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var record idempotency.Record
+	err = r.db.GetContext(
+		ctx,
+		&record,
+		`SELECT * FROM idempotency_keys
+		 WHERE organisation_id = $1
+		   AND user_id = $2
+		   AND idempotency_key = $3`,
+		tenantID, userID, key,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, id int64, headers idempotency.Headers, response []byte) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`UPDATE idempotency_keys
+		 SET response = $1,
+			 response_headers = $2,
+			 completed_at = NOW()
+		 WHERE id = $3
+		   AND organisation_id = $4`,
+		response, headers, id, tenantID,
+	)
+	return err
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, id int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys
+		 WHERE id = $1
+		   AND organisation_id = $2`,
+		id, tenantID,
+	)
+	return err
+}
@@ -0,0 +1,176 @@
+package invitation
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	invitation "github.com/nevinmanoj/hostmate/internal/domain/invitation"
+)
+
+type invitationRepository struct {
+	db *sqlx.DB
+}
+
+func NewInvitationReadRepository(db *sqlx.DB) invitation.InvitationReadRepository {
+	return &invitationRepository{db: db}
+}
+func NewInvitationWriteRepository(db *sqlx.DB) invitation.InvitationWriteRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, id int64) (*invitation.Invitation, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, invitation.ErrInternal
+	}
+	invitations := []invitation.Invitation{}
+	err = r.db.SelectContext(
+		ctx,
+		&invitations,
+		`SELECT * FROM property_invitations
+		 WHERE id = $1
+		   AND organisation_id = $2`,
+		id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error fetching invitation by ID:", err)
+		return nil, invitation.ErrInternal
+	}
+	if len(invitations) == 0 {
+		return nil, invitation.ErrNotFound
+	}
+	invitation := invitations[0]
+	return &invitation, nil
+}
+
+func (r *invitationRepository) GetByPropertyID(ctx context.Context, propertyID int64) ([]invitation.Invitation, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, invitation.ErrInternal
+	}
+	invitations := []invitation.Invitation{}
+	err = r.db.SelectContext(
+		ctx,
+		&invitations,
+		`SELECT * FROM property_invitations
+		 WHERE property_id = $1
+		   AND organisation_id = $2
+		 ORDER BY created_at DESC`,
+		propertyID, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *invitationRepository) GetPendingByEmail(ctx context.Context, propertyID int64, email string) (*invitation.Invitation, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, invitation.ErrInternal
+	}
+	invitations := []invitation.Invitation{}
+	err = r.db.SelectContext(
+		ctx,
+		&invitations,
+		`SELECT * FROM property_invitations
+		 WHERE property_id = $1
+		   AND email = $2
+		   AND organisation_id = $3
+		   AND status = 'pending'
+		 ORDER BY created_at DESC
+		 LIMIT 1`,
+		propertyID, email, tenantID,
+	)
+	if err != nil {
+		log.Println("Error fetching pending invitation:", err)
+		return nil, invitation.ErrInternal
+	}
+	if len(invitations) == 0 {
+		return nil, invitation.ErrNotFound
+	}
+	invitation := invitations[0]
+	return &invitation, nil
+}
+
+func (r *invitationRepository) Create(ctx context.Context, invitationToCreate *invitation.Invitation) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return invitation.ErrInternal
+	}
+	invitationToCreate.OrganisationID = tenantID
+
+	query := `
+		INSERT INTO property_invitations (
+			organisation_id,
+			property_id,
+			email,
+			token_id,
+			status,
+			invited_by,
+			expires_at
+		)
+		VALUES (
+			:organisation_id,
+			:property_id,
+			:email,
+			:token_id,
+			:status,
+			:invited_by,
+			:expires_at
+		)
+		RETURNING id, created_at, updated_at
+	`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, invitationToCreate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		rows.Scan(&invitationToCreate.ID, &invitationToCreate.CreatedAt, &invitationToCreate.UpdatedAt)
+		return nil
+	}
+
+	return invitation.ErrInternal
+}
+
+func (r *invitationRepository) Update(ctx context.Context, invitationToUpdate *invitation.Invitation) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return invitation.ErrInternal
+	}
+	invitationToUpdate.OrganisationID = tenantID
+	query := `
+		UPDATE property_invitations
+		SET
+			token_id = :token_id,
+			status = :status,
+			expires_at = :expires_at,
+			accepted_by = :accepted_by,
+			accepted_at = :accepted_at,
+			updated_at = NOW()
+		WHERE id = :id
+		  AND organisation_id = :organisation_id
+		RETURNING updated_at
+	`
+
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, invitationToUpdate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		rows.Scan(&invitationToUpdate.UpdatedAt)
+		return nil
+	}
+	return invitation.ErrNotFound
+}
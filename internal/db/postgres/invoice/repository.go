@@ -0,0 +1,173 @@
+package invoice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	invoice "github.com/nevinmanoj/hostmate/internal/domain/invoice"
+)
+
+type documentRepository struct {
+	db *sqlx.DB
+}
+
+func NewDocumentRepository(db *sqlx.DB) invoice.DocumentRepository {
+	return &documentRepository{db: db}
+}
+
+// NextSequence bumps the counter row of the series, document_sequences is keyed by
+// (organisation_id, property_id, document_type). The row stays locked until the
+// transaction ends so concurrent issues queue up instead of taking the same number.
+func (r *documentRepository) NextSequence(ctx context.Context, propertyID int64, docType invoice.DocumentType) (int64, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return 0, invoice.ErrInternal
+	}
+	var sequence int64
+	err = postgres.Conn(ctx, r.db).QueryRowContext(
+		ctx,
+		`INSERT INTO document_sequences (organisation_id, property_id, document_type, last_sequence)
+		 VALUES ($1, $2, $3, 1)
+		 ON CONFLICT (organisation_id, property_id, document_type) DO UPDATE
+		 SET last_sequence = document_sequences.last_sequence + 1
+		 RETURNING last_sequence`,
+		tenantID, propertyID, docType,
+	).Scan(&sequence)
+	if err != nil {
+		log.Println("Error taking document sequence:", err)
+		return 0, invoice.ErrInternal
+	}
+	return sequence, nil
+}
+
+func (r *documentRepository) Create(ctx context.Context, doc *invoice.Document) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return invoice.ErrInternal
+	}
+	doc.OrganisationID = tenantID
+
+	query := `
+		INSERT INTO documents (
+			organisation_id,
+			property_id,
+			booking_id,
+			payment_id,
+			document_type,
+			sequence,
+			number,
+			booking_revision,
+			total,
+			balance,
+			blob_name,
+			issued_by,
+			issued_at
+		)
+		VALUES (
+			:organisation_id,
+			:property_id,
+			:booking_id,
+			:payment_id,
+			:document_type,
+			:sequence,
+			:number,
+			:booking_revision,
+			:total,
+			:balance,
+			:blob_name,
+			:issued_by,
+			:issued_at
+		)
+		RETURNING id
+	`
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, doc)
+	if err != nil {
+		log.Println("Error creating document:", err)
+		return invoice.ErrInternal
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return rows.Scan(&doc.ID)
+	}
+	return invoice.ErrInternal
+}
+
+func (r *documentRepository) GetByID(ctx context.Context, id int64) (*invoice.Document, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, invoice.ErrInternal
+	}
+	var doc invoice.Document
+	err = postgres.Conn(ctx, r.db).GetContext(
+		ctx,
+		&doc,
+		`SELECT * FROM documents
+		 WHERE id = $1
+		   AND organisation_id = $2`,
+		id, tenantID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, invoice.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *documentRepository) GetByBooking(ctx context.Context, bookingID int64) ([]invoice.Document, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, invoice.ErrInternal
+	}
+	docs := []invoice.Document{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&docs,
+		`SELECT * FROM documents
+		 WHERE booking_id = $1
+		   AND organisation_id = $2
+		 ORDER BY issued_at DESC, id DESC`,
+		bookingID, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *documentRepository) GetLatest(ctx context.Context, docType invoice.DocumentType, bookingID int64, paymentID *int64) (*invoice.Document, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, invoice.ErrInternal
+	}
+	var doc invoice.Document
+	err = postgres.Conn(ctx, r.db).GetContext(
+		ctx,
+		&doc,
+		`SELECT * FROM documents
+		 WHERE document_type = $1
+		   AND booking_id = $2
+		   AND payment_id IS NOT DISTINCT FROM $3
+		   AND organisation_id = $4
+		 ORDER BY sequence DESC
+		 LIMIT 1`,
+		docType, bookingID, paymentID, tenantID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, invoice.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
+)
+
+// Keyset pages a list ordered by Column then IDColumn, continuing from Cursor when set
+type Keyset struct {
+	Column   string
+	IDColumn string
+	Sort     pagination.Sort
+	Cursor   *pagination.Cursor
+}
+
+func (k Keyset) backward() bool {
+	return k.Cursor != nil && k.Cursor.Backward
+}
+
+// Condition is the WHERE clause selecting the rows past the cursor, empty on the first page
+func (k Keyset) Condition() (string, []any) {
+	if k.Cursor == nil {
+		return "", nil
+	}
+	op := ">"
+	if k.Sort.Desc != k.backward() {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s, %s) %s (?, ?)", k.Column, k.IDColumn, op), []any{k.Cursor.Value, k.Cursor.ID}
+}
+
+// OrderBy walks backwards from a backward cursor, Page puts the rows back in order
+func (k Keyset) OrderBy() string {
+	dir := "ASC"
+	if k.Sort.Desc != k.backward() {
+		dir = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", k.Column, dir, k.IDColumn, dir)
+}
+
+// Page takes rows fetched with a limit one above the page size, trims the extra row
+// and builds the cursors around the page, key returns the sort value and id of a row
+func Page[T any](rows []T, limit int, k Keyset, key func(T) (string, int64)) ([]T, pagination.Cursors) {
+	var cursors pagination.Cursors
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if k.backward() {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, cursors
+	}
+	// going forward there is a previous page once a cursor was followed, going back there always is a next one
+	hasNext, hasPrev := hasMore, k.Cursor != nil
+	if k.backward() {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		value, id := key(rows[len(rows)-1])
+		cursors.Next = pagination.Cursor{Sort: k.Sort.String(), Value: value, ID: id}.Encode()
+	}
+	if hasPrev {
+		value, id := key(rows[0])
+		cursors.Prev = pagination.Cursor{Sort: k.Sort.String(), Value: value, ID: id, Backward: true}.Encode()
+	}
+	return rows, cursors
+}
+
+// TimeKey formats a timestamp sort value without losing precision
+func TimeKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func FloatKey(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
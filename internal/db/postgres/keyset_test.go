@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
+)
+
+type row struct {
+	ID   int64
+	Name string
+}
+
+func rowKey(r row) (string, int64) {
+	return r.Name, r.ID
+}
+
+// rows makes rows with the given ids, named after them so the sort value follows the id
+func rows(ids ...int64) []row {
+	out := []row{}
+	for _, id := range ids {
+		out = append(out, row{ID: id, Name: "name " + strconv.FormatInt(id, 10)})
+	}
+	return out
+}
+
+func ids(rows []row) []int64 {
+	out := []int64{}
+	for _, r := range rows {
+		out = append(out, r.ID)
+	}
+	return out
+}
+
+func TestPage(t *testing.T) {
+	byName := pagination.Sort{Field: "name"}
+	forward := &pagination.Cursor{Sort: "name", Value: "name 3", ID: 3}
+	backward := &pagination.Cursor{Sort: "name", Value: "name 7", ID: 7, Backward: true}
+
+	tests := []struct {
+		name     string
+		fetched  []row
+		cursor   *pagination.Cursor
+		wantIDs  []int64
+		wantNext int64
+		wantPrev int64
+	}{
+		{
+			name:    "empty list",
+			fetched: rows(),
+			wantIDs: []int64{},
+		},
+		{
+			name:    "single page",
+			fetched: rows(1, 2),
+			wantIDs: []int64{1, 2},
+		},
+		{
+			name:    "exactly one page has no next page",
+			fetched: rows(1, 2, 3),
+			wantIDs: []int64{1, 2, 3},
+		},
+		{
+			name:     "first of several pages",
+			fetched:  rows(1, 2, 3, 4),
+			wantIDs:  []int64{1, 2, 3},
+			wantNext: 3,
+		},
+		{
+			name:     "middle page going forward",
+			fetched:  rows(4, 5, 6, 7),
+			cursor:   forward,
+			wantIDs:  []int64{4, 5, 6},
+			wantNext: 6,
+			wantPrev: 4,
+		},
+		{
+			name:     "last page going forward",
+			fetched:  rows(4, 5),
+			cursor:   forward,
+			wantIDs:  []int64{4, 5},
+			wantPrev: 4,
+		},
+		{
+			name:    "past the end going forward",
+			fetched: rows(),
+			cursor:  forward,
+			wantIDs: []int64{},
+		},
+		{
+			name:     "middle page going back is put back in order",
+			fetched:  rows(6, 5, 4, 3),
+			cursor:   backward,
+			wantIDs:  []int64{4, 5, 6},
+			wantNext: 6,
+			wantPrev: 4,
+		},
+		{
+			name:     "first page reached going back",
+			fetched:  rows(6, 5),
+			cursor:   backward,
+			wantIDs:  []int64{5, 6},
+			wantNext: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := Keyset{Column: "name", IDColumn: "id", Sort: byName, Cursor: tt.cursor}
+			page, cursors := Page(tt.fetched, 3, k, rowKey)
+			if got := ids(page); !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("page = %v, want %v", got, tt.wantIDs)
+			}
+			checkCursor(t, "next", cursors.Next, tt.wantNext, false)
+			checkCursor(t, "prev", cursors.Prev, tt.wantPrev, true)
+		})
+	}
+}
+
+func checkCursor(t *testing.T, which, encoded string, wantID int64, backward bool) {
+	t.Helper()
+	if wantID == 0 {
+		if encoded != "" {
+			t.Errorf("%s cursor = %q, want none", which, encoded)
+		}
+		return
+	}
+	c, err := pagination.DecodeCursor(encoded, pagination.Sort{Field: "name"})
+	if err != nil {
+		t.Fatalf("%s cursor %q does not decode: %v", which, encoded, err)
+	}
+	want := pagination.Cursor{Sort: "name", Value: "name " + strconv.FormatInt(wantID, 10), ID: wantID, Backward: backward}
+	if *c != want {
+		t.Errorf("%s cursor = %+v, want %+v", which, *c, want)
+	}
+}
+
+func TestKeysetQuery(t *testing.T) {
+	cursor := func(backward bool) *pagination.Cursor {
+		return &pagination.Cursor{Value: "v", ID: 1, Backward: backward}
+	}
+	tests := []struct {
+		name          string
+		desc          bool
+		cursor        *pagination.Cursor
+		wantCondition string
+		wantOrderBy   string
+	}{
+		{"first page ascending", false, nil, "", " ORDER BY name ASC, id ASC"},
+		{"first page descending", true, nil, "", " ORDER BY name DESC, id DESC"},
+		{"forward ascending", false, cursor(false), "(name, id) > (?, ?)", " ORDER BY name ASC, id ASC"},
+		{"forward descending", true, cursor(false), "(name, id) < (?, ?)", " ORDER BY name DESC, id DESC"},
+		{"backward ascending", false, cursor(true), "(name, id) < (?, ?)", " ORDER BY name DESC, id DESC"},
+		{"backward descending", true, cursor(true), "(name, id) > (?, ?)", " ORDER BY name ASC, id ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := Keyset{Column: "name", IDColumn: "id", Sort: pagination.Sort{Field: "name", Desc: tt.desc}, Cursor: tt.cursor}
+			condition, args := k.Condition()
+			if condition != tt.wantCondition {
+				t.Errorf("Condition() = %q, want %q", condition, tt.wantCondition)
+			}
+			if tt.cursor != nil && (len(args) != 2 || args[0] != "v" || args[1] != int64(1)) {
+				t.Errorf("Condition() args = %v, want [v 1]", args)
+			}
+			if got := k.OrderBy(); got != tt.wantOrderBy {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.wantOrderBy)
+			}
+		})
+	}
+}
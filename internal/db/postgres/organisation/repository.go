@@ -0,0 +1,261 @@
+package organisation
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	organisation "github.com/nevinmanoj/hostmate/internal/domain/organisation"
+)
+
+type organisationRepository struct {
+	db *sqlx.DB
+}
+
+func NewOrganisationRepository(db *sqlx.DB) organisation.OrganisationRepository {
+	return &organisationRepository{db: db}
+}
+
+func (r *organisationRepository) Create(ctx context.Context, org *organisation.Organisation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Println("Error starting organisation transaction:", err)
+		return organisation.ErrInternal
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowxContext(
+		ctx,
+		`INSERT INTO organisations (name, created_by)
+		 VALUES ($1, $2)
+		 RETURNING id, created_at`,
+		org.Name, org.CreatedBy,
+	).Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		log.Println("Error inserting organisation:", err)
+		return organisation.ErrInternal
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO organisation_members (organisation_id, user_id, role)
+		 VALUES ($1, $2, $3)`,
+		org.ID, org.CreatedBy, organisation.RoleOwner,
+	)
+	if err != nil {
+		log.Println("Error inserting organisation owner:", err)
+		return organisation.ErrInternal
+	}
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing organisation:", err)
+		return organisation.ErrInternal
+	}
+	return nil
+}
+
+func (r *organisationRepository) GetByID(ctx context.Context, id int64) (*organisation.Organisation, error) {
+	orgs := []organisation.Organisation{}
+	err := r.db.SelectContext(ctx, &orgs, `SELECT * FROM organisations WHERE id = $1`, id)
+	if err != nil {
+		log.Println("Error fetching organisation by ID:", err)
+		return nil, organisation.ErrInternal
+	}
+	if len(orgs) == 0 {
+		return nil, organisation.ErrNotFound
+	}
+	org := orgs[0]
+	return &org, nil
+}
+
+// GetForUser lists the user's organisations, oldest membership first
+func (r *organisationRepository) GetForUser(ctx context.Context, userID int64) ([]organisation.Membership, error) {
+	memberships := []organisation.Membership{}
+	err := r.db.SelectContext(
+		ctx,
+		&memberships,
+		`SELECT o.*, m.role
+		 FROM organisation_members m
+		 JOIN organisations o ON o.id = m.organisation_id
+		 WHERE m.user_id = $1
+		 ORDER BY m.created_at, o.id`,
+		userID,
+	)
+	if err != nil {
+		log.Println("Error fetching organisations for user:", err)
+		return nil, organisation.ErrInternal
+	}
+	return memberships, nil
+}
+
+const memberColumns = `m.organisation_id, m.user_id, m.role, m.created_at, u.name, u.email, u.session_generation`
+
+func (r *organisationRepository) GetMember(ctx context.Context, organisationID, userID int64) (*organisation.Member, error) {
+	members := []organisation.Member{}
+	err := r.db.SelectContext(
+		ctx,
+		&members,
+		`SELECT `+memberColumns+`
+		 FROM organisation_members m
+		 JOIN users u ON u.id = m.user_id
+		 WHERE m.organisation_id = $1
+		   AND m.user_id = $2`,
+		organisationID, userID,
+	)
+	if err != nil {
+		log.Println("Error fetching organisation member:", err)
+		return nil, organisation.ErrInternal
+	}
+	if len(members) == 0 {
+		return nil, organisation.ErrNotMember
+	}
+	member := members[0]
+	return &member, nil
+}
+
+func (r *organisationRepository) GetMembers(ctx context.Context, organisationID int64) ([]organisation.Member, error) {
+	members := []organisation.Member{}
+	err := r.db.SelectContext(
+		ctx,
+		&members,
+		`SELECT `+memberColumns+`
+		 FROM organisation_members m
+		 JOIN users u ON u.id = m.user_id
+		 WHERE m.organisation_id = $1
+		 ORDER BY u.name, u.id`,
+		organisationID,
+	)
+	if err != nil {
+		log.Println("Error fetching organisation members:", err)
+		return nil, organisation.ErrInternal
+	}
+	return members, nil
+}
+
+// AddMember is idempotent, an existing member keeps their role
+func (r *organisationRepository) AddMember(ctx context.Context, organisationID, userID int64, role organisation.Role) error {
+	_, err := postgres.Conn(ctx, r.db).ExecContext(
+		ctx,
+		`INSERT INTO organisation_members (organisation_id, user_id, role)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (organisation_id, user_id) DO NOTHING`,
+		organisationID, userID, role,
+	)
+	if err != nil {
+		log.Println("Error adding organisation member:", err)
+		return organisation.ErrInternal
+	}
+	return nil
+}
+
+func (r *organisationRepository) UpdateMemberRole(ctx context.Context, organisationID, userID int64, role organisation.Role) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE organisation_members
+		 SET role = $1
+		 WHERE organisation_id = $2
+		   AND user_id = $3`,
+		role, organisationID, userID,
+	)
+	if err != nil {
+		log.Println("Error updating organisation member role:", err)
+		return organisation.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return organisation.ErrInternal
+	}
+	if rows == 0 {
+		return organisation.ErrNotMember
+	}
+	return nil
+}
+
+func (r *organisationRepository) RemoveMember(ctx context.Context, organisationID, userID int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Println("Error starting member removal transaction:", err)
+		return organisation.ErrInternal
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM organisation_members
+		 WHERE organisation_id = $1
+		   AND user_id = $2`,
+		organisationID, userID,
+	)
+	if err != nil {
+		log.Println("Error removing organisation member:", err)
+		return organisation.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return organisation.ErrInternal
+	}
+	if rows == 0 {
+		return organisation.ErrNotMember
+	}
+	// property access is granted through managers and property members, so it has to go with the membership
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE properties
+		 SET managers = array_remove(managers, $1),
+		     updated_at = NOW()
+		 WHERE organisation_id = $2
+		   AND $1 = ANY(managers)`,
+		userID, organisationID,
+	)
+	if err != nil {
+		log.Println("Error removing member from property managers:", err)
+		return organisation.ErrInternal
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM property_members
+		 WHERE organisation_id = $1
+		   AND user_id = $2`,
+		organisationID, userID,
+	)
+	if err != nil {
+		log.Println("Error removing member property grants:", err)
+		return organisation.ErrInternal
+	}
+	// personal api keys act in a single organisation and stop working with the membership
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE api_keys
+		 SET revoked_at = NOW()
+		 WHERE organisation_id = $1
+		   AND user_id = $2
+		   AND revoked_at IS NULL`,
+		organisationID, userID,
+	)
+	if err != nil {
+		log.Println("Error revoking member api keys:", err)
+		return organisation.ErrInternal
+	}
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing member removal:", err)
+		return organisation.ErrInternal
+	}
+	return nil
+}
+
+func (r *organisationRepository) CountOwners(ctx context.Context, organisationID int64) (int, error) {
+	var owners int
+	err := r.db.GetContext(
+		ctx,
+		&owners,
+		`SELECT COUNT(*)
+		 FROM organisation_members
+		 WHERE organisation_id = $1
+		   AND role = $2`,
+		organisationID, organisation.RoleOwner,
+	)
+	if err != nil {
+		log.Println("Error counting organisation owners:", err)
+		return 0, organisation.ErrInternal
+	}
+	return owners, nil
+}
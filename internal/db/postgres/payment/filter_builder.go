@@ -4,24 +4,63 @@ import (
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
 )
 
-func buildPaymentQuery(baseQuery string, f payment.PaymentFilter, isCount bool) (string, []any, error) {
+func buildPaymentQuery(baseQuery string, f payment.PaymentFilter, tenantID int64, isCount bool) (string, []any, error) {
 	var (
 		conditions []string
 		args       []any
 	)
 
+	conditions = append(conditions, "p.organisation_id = ?")
+	args = append(args, tenantID)
+
+	if !f.IncludeDeleted {
+		//payments of an archived booking or property are archived with it
+		conditions = append(conditions, "p.deleted_at IS NULL AND b.deleted_at IS NULL AND pr.deleted_at IS NULL")
+	}
+
 	if f.UserID != nil {
-		conditions = append(conditions, "? = ANY(pr.managers)")
-		args = append(args, *f.UserID)
+		//payments are financials, members need the view financials grant
+		conditions = append(conditions, `(? = ANY(pr.managers) OR EXISTS (
+			SELECT 1 FROM property_members pm
+			WHERE pm.property_id = pr.id AND pm.user_id = ? AND ? = ANY(pm.capabilities)
+		))`)
+		args = append(args, *f.UserID, *f.UserID, string(access.CapViewFinancials))
 	}
 
 	if len(f.PaymentType) > 0 {
 		conditions = append(conditions, "p.payment_type IN (?)")
 		args = append(args, f.PaymentType)
 	}
+	if len(f.PropertyID) > 0 {
+		conditions = append(conditions, "b.property_id IN (?)")
+		args = append(args, f.PropertyID)
+	}
+	if len(f.BookingID) > 0 {
+		conditions = append(conditions, "p.booking_id IN (?)")
+		args = append(args, f.BookingID)
+	}
+	if f.MinAmount != nil {
+		conditions = append(conditions, "p.amount >= ?")
+		args = append(args, *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		conditions = append(conditions, "p.amount <= ?")
+		args = append(args, *f.MaxAmount)
+	}
+	if f.CreatedBy != nil {
+		conditions = append(conditions, "p.created_by = ?")
+		args = append(args, *f.CreatedBy)
+	}
+	if f.Remarks != nil {
+		conditions = append(conditions, "p.remarks ILIKE ?")
+		args = append(args, postgres.LikePattern(*f.Remarks))
+	}
 	if f.FromDate != nil {
 		conditions = append(conditions, "p.created_at > ?")
 		args = append(args, *f.FromDate)
@@ -32,6 +71,13 @@ func buildPaymentQuery(baseQuery string, f payment.PaymentFilter, isCount bool)
 		args = append(args, *f.ToDate)
 	}
 
+	// the total counts every page, only the page itself continues from the cursor
+	keyset := paymentKeyset(f)
+	if condition, keysetArgs := keyset.Condition(); condition != "" && !isCount {
+		conditions = append(conditions, condition)
+		args = append(args, keysetArgs...)
+	}
+
 	// Apply WHERE
 	if len(conditions) > 0 {
 		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
@@ -39,18 +85,17 @@ func buildPaymentQuery(baseQuery string, f payment.PaymentFilter, isCount bool)
 
 	// Ordering (always deterministic)
 	if !isCount {
-		baseQuery += " ORDER BY created_at DESC"
-	}
-
-	// Pagination
-	if f.Limit > 0 {
-		baseQuery += " LIMIT ?"
-		args = append(args, f.Limit)
-	}
+		baseQuery += keyset.OrderBy()
+		// Pagination, one extra row tells whether there is a next page
+		if f.Limit > 0 {
+			baseQuery += " LIMIT ?"
+			args = append(args, f.Limit+1)
+		}
 
-	if f.Offset > 0 {
-		baseQuery += " OFFSET ?"
-		args = append(args, f.Offset)
+		if f.Offset > 0 && f.Cursor == nil {
+			baseQuery += " OFFSET ?"
+			args = append(args, f.Offset)
+		}
 	}
 
 	// Expand IN clauses
@@ -64,3 +109,34 @@ func buildPaymentQuery(baseQuery string, f payment.PaymentFilter, isCount bool)
 
 	return query, finalArgs, nil
 }
+
+var paymentSortColumns = map[string]string{
+	"created_at": "p.created_at",
+	"date":       "p.date",
+	"amount":     "p.amount",
+}
+
+// paymentKeyset defaults to the newest payments first
+func paymentKeyset(f payment.PaymentFilter) postgres.Keyset {
+	sort := f.Sort
+	column, ok := paymentSortColumns[sort.Field]
+	if !ok {
+		sort = pagination.Sort{Field: "created_at", Desc: true}
+		column = paymentSortColumns[sort.Field]
+	}
+	return postgres.Keyset{Column: column, IDColumn: "p.id", Sort: sort, Cursor: f.Cursor}
+}
+
+// paymentSortKey is the cursor value of a payment for the sort field
+func paymentSortKey(field string) func(payment.Payment) (string, int64) {
+	return func(p payment.Payment) (string, int64) {
+		switch field {
+		case "date":
+			return postgres.TimeKey(p.Date), p.ID
+		case "amount":
+			return postgres.FloatKey(p.Amount), p.ID
+		default:
+			return postgres.TimeKey(p.CreatedAt), p.ID
+		}
+	}
+}
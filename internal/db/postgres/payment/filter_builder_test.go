@@ -0,0 +1,105 @@
+package payment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
+	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
+)
+
+func TestBuildPaymentQuery(t *testing.T) {
+	userID := int64(7)
+	minAmount, maxAmount := 100.0, 500.0
+	remarks := "advance_50%"
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &pagination.Cursor{Sort: "amount", Value: "250", ID: 12}
+
+	tests := []struct {
+		name     string
+		filter   payment.PaymentFilter
+		isCount  bool
+		contains []string
+		absent   []string
+		args     []any
+	}{
+		{
+			name:     "defaults",
+			filter:   payment.PaymentFilter{Limit: 20},
+			contains: []string{"p.organisation_id = $1", "p.deleted_at IS NULL", "ORDER BY p.created_at DESC, p.id DESC", "LIMIT $2"},
+			args:     []any{int64(3), 21},
+		},
+		{
+			name: "every filter",
+			filter: payment.PaymentFilter{
+				UserID:         &userID,
+				PaymentType:    []payment.PaymentType{payment.PaymentUPI, payment.PaymentCash},
+				PropertyID:     []int64{1, 2},
+				MinAmount:      &minAmount,
+				MaxAmount:      &maxAmount,
+				Remarks:        &remarks,
+				FromDate:       &from,
+				IncludeDeleted: true,
+			},
+			contains: []string{
+				"$2 = ANY(pr.managers)",
+				"p.payment_type IN ($5, $6)",
+				"b.property_id IN ($7, $8)",
+				"p.amount >= $9",
+				"p.amount <= $10",
+				"p.remarks ILIKE $11",
+				"p.created_at > $12",
+			},
+			absent: []string{"deleted_at IS NULL", "LIMIT"},
+			args: []any{
+				int64(3), userID, userID, "view_financials",
+				payment.PaymentUPI, payment.PaymentCash, int64(1), int64(2),
+				minAmount, maxAmount, `%advance\_50\%%`, from,
+			},
+		},
+		{
+			name:     "page after a cursor",
+			filter:   payment.PaymentFilter{Sort: pagination.Sort{Field: "amount"}, Cursor: cursor, Limit: 10, Offset: 30},
+			contains: []string{"(p.amount, p.id) > ($2, $3)", "ORDER BY p.amount ASC, p.id ASC", "LIMIT $4"},
+			absent:   []string{"OFFSET"},
+			args:     []any{int64(3), "250", int64(12), 11},
+		},
+		{
+			name:     "count ignores the cursor",
+			filter:   payment.PaymentFilter{Sort: pagination.Sort{Field: "amount"}, Cursor: cursor, Limit: 10},
+			isCount:  true,
+			contains: []string{"p.organisation_id = $1"},
+			absent:   []string{"(p.amount, p.id)", "ORDER BY", "LIMIT"},
+			args:     []any{int64(3)},
+		},
+		{
+			name:     "unknown sort falls back to the newest first",
+			filter:   payment.PaymentFilter{Sort: pagination.Sort{Field: "guest_name"}},
+			contains: []string{"ORDER BY p.created_at DESC, p.id DESC"},
+			args:     []any{int64(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := buildPaymentQuery("SELECT p.* FROM payments p", tt.filter, 3, tt.isCount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(query, part) {
+					t.Errorf("query %q does not contain %q", query, part)
+				}
+			}
+			for _, part := range tt.absent {
+				if strings.Contains(query, part) {
+					t.Errorf("query %q contains %q", query, part)
+				}
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 
 	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
 )
 
@@ -19,48 +21,78 @@ func NewPaymentWriteRepository(db *sqlx.DB) payment.PaymentWriteRepository {
 	return &paymentRepository{db: db}
 }
 
-func (r *paymentRepository) GetAll(ctx context.Context, filter payment.PaymentFilter) ([]payment.Payment, int, error) {
-
-	var total int
-	baseCountQuery := `SELECT COUNT(*) FROM payments p
-	JOIN bookings b ON b.id = p.booking_id
-	JOIN properties pr ON pr.id = b.property_id`
-	finalCountQuery, finalArgs, err := buildPaymentQuery(baseCountQuery, filter, true)
-	if err := r.db.QueryRowContext(
-		ctx,
-		finalCountQuery,
-		finalArgs...,
-	).Scan(&total); err != nil {
-		return nil, 0, err
+func (r *paymentRepository) GetAll(ctx context.Context, filter payment.PaymentFilter) ([]payment.Payment, payment.PaymentTotals, pagination.Cursors, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, payment.PaymentTotals{}, pagination.Cursors{}, payment.ErrInternal
 	}
 
-	if total == 0 {
-		return []payment.Payment{}, 0, nil
+	// the totals per payment type double as the count
+	var totals payment.PaymentTotals
+	if !filter.SkipCount {
+		baseTotalsQuery := `SELECT p.payment_type, COUNT(*) AS count, COALESCE(SUM(p.amount), 0) AS amount
+		FROM payments p
+		JOIN bookings b ON b.id = p.booking_id
+		JOIN properties pr ON pr.id = b.property_id`
+		finalTotalsQuery, finalArgs, err := buildPaymentQuery(baseTotalsQuery, filter, tenantID, true)
+		if err != nil {
+			return nil, payment.PaymentTotals{}, pagination.Cursors{}, err
+		}
+		totals.ByType = []payment.PaymentTypeTotal{}
+		if err := postgres.Conn(ctx, r.db).SelectContext(
+			ctx,
+			&totals.ByType,
+			finalTotalsQuery+" GROUP BY p.payment_type ORDER BY p.payment_type",
+			finalArgs...,
+		); err != nil {
+			return nil, payment.PaymentTotals{}, pagination.Cursors{}, err
+		}
+		for _, typeTotal := range totals.ByType {
+			totals.Count += typeTotal.Count
+			totals.Amount += typeTotal.Amount
+		}
+
+		if totals.Count == 0 {
+			return []payment.Payment{}, totals, pagination.Cursors{}, nil
+		}
 	}
 
 	payments := []payment.Payment{}
-	baseQuery := `SELECT p.* FROM payments
+	baseQuery := `SELECT p.* FROM payments p
 	JOIN bookings b ON b.id = p.booking_id
 	JOIN properties pr ON pr.id = b.property_id`
-	finalQuery, finalArgs, err := buildPaymentQuery(baseQuery, filter, true)
-	err = r.db.SelectContext(
+	finalQuery, finalArgs, err := buildPaymentQuery(baseQuery, filter, tenantID, false)
+	if err != nil {
+		return nil, payment.PaymentTotals{}, pagination.Cursors{}, err
+	}
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&payments,
 		finalQuery, finalArgs...,
 	)
 	if err != nil {
-		return nil, 0, err
+		return nil, payment.PaymentTotals{}, pagination.Cursors{}, err
 	}
-	return payments, total, nil
+	keyset := paymentKeyset(filter)
+	payments, cursors := postgres.Page(payments, filter.Limit, keyset, paymentSortKey(keyset.Sort.Field))
+	return payments, totals, cursors, nil
 }
 
 func (r *paymentRepository) GetByBookingId(ctx context.Context, bookingID int64, limit, offset int) ([]payment.Payment, int, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, 0, payment.ErrInternal
+	}
 	var total int
-	if err := r.db.QueryRowContext(
+	if err := postgres.Conn(ctx, r.db).QueryRowContext(
 		ctx,
 		`SELECT COUNT(*) FROM payments 
-		 WHERE booking_id = $1`,
-		bookingID,
+		 WHERE booking_id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL`,
+		bookingID, tenantID,
 	).Scan(&total); err != nil {
 		return nil, 0, err
 	}
@@ -70,14 +102,16 @@ func (r *paymentRepository) GetByBookingId(ctx context.Context, bookingID int64,
 	}
 
 	properties := []payment.Payment{}
-	err := r.db.SelectContext(
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&properties,
 		`SELECT * FROM payments
 		 WHERE booking_id = $1
+		   AND organisation_id = $4
+		   AND deleted_at IS NULL
 		 ORDER BY id
 		 LIMIT $2 OFFSET $3`,
-		bookingID, limit, offset,
+		bookingID, limit, offset, tenantID,
 	)
 	if err != nil {
 		return nil, 0, err
@@ -86,14 +120,22 @@ func (r *paymentRepository) GetByBookingId(ctx context.Context, bookingID int64,
 	return properties, total, nil
 }
 func (r *paymentRepository) GetByPropertyId(ctx context.Context, propertyID int64, limit, offset int) ([]payment.Payment, int, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, 0, payment.ErrInternal
+	}
 	var total int
-	if err := r.db.QueryRowContext(
+	if err := postgres.Conn(ctx, r.db).QueryRowContext(
 		ctx,
 		`SELECT COUNT(*)
 		FROM payments p
 		JOIN bookings b ON b.id = p.booking_id
-		WHERE b.property_id = $1`,
-		propertyID,
+		WHERE b.property_id = $1
+		  AND p.organisation_id = $2
+		  AND p.deleted_at IS NULL
+		  AND b.deleted_at IS NULL`,
+		propertyID, tenantID,
 	).Scan(&total); err != nil {
 		return nil, 0, err
 	}
@@ -103,16 +145,19 @@ func (r *paymentRepository) GetByPropertyId(ctx context.Context, propertyID int6
 	}
 
 	properties := []payment.Payment{}
-	err := r.db.SelectContext(
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&properties,
 		`SELECT p.* 
 		FROM payments p
 		JOIN bookings b ON b.id = p.booking_id
 		WHERE b.property_id = $1
+		  AND p.organisation_id = $4
+		  AND p.deleted_at IS NULL
+		  AND b.deleted_at IS NULL
 		ORDER BY id
 		LIMIT $2 OFFSET $3`,
-		propertyID, limit, offset,
+		propertyID, limit, offset, tenantID,
 	)
 	if err != nil {
 		return nil, 0, err
@@ -122,13 +167,20 @@ func (r *paymentRepository) GetByPropertyId(ctx context.Context, propertyID int6
 }
 
 func (r *paymentRepository) GetByID(ctx context.Context, id int64) (*payment.Payment, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, payment.ErrInternal
+	}
 	var count int64
-	if err := r.db.QueryRowContext(
+	if err := postgres.Conn(ctx, r.db).QueryRowContext(
 		ctx,
 		`SELECT COUNT (*)
 		 FROM payments
-		 WHERE id = $1`,
-		id,
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL`,
+		id, tenantID,
 	).Scan(&count); err != nil {
 		log.Println("Error checking payment existence:", err)
 		return nil, payment.ErrInternal
@@ -137,12 +189,14 @@ func (r *paymentRepository) GetByID(ctx context.Context, id int64) (*payment.Pay
 		return nil, payment.ErrNotFound
 	}
 	payments := []payment.Payment{}
-	err := r.db.SelectContext(
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&payments,
 		`SELECT * FROM payments
-		 WHERE id = $1`,
-		id,
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL`,
+		id, tenantID,
 	)
 	if err != nil {
 		log.Println("Error fetching payment by ID:", err)
@@ -153,9 +207,16 @@ func (r *paymentRepository) GetByID(ctx context.Context, id int64) (*payment.Pay
 }
 
 func (r *paymentRepository) Create(ctx context.Context, paymentToCreate *payment.Payment) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return payment.ErrInternal
+	}
+	paymentToCreate.OrganisationID = tenantID
 
 	query := `
 		INSERT INTO	payments (
+			organisation_id,
 			booking_id,
 			amount,
 			payment_type,
@@ -166,6 +227,7 @@ func (r *paymentRepository) Create(ctx context.Context, paymentToCreate *payment
 			updated_by
 		)
 		VALUES (
+			:organisation_id,
 			:booking_id,
 			:amount,
 			:payment_type,
@@ -175,10 +237,10 @@ func (r *paymentRepository) Create(ctx context.Context, paymentToCreate *payment
 			:created_by,
 			:updated_by
 		)
-		RETURNING id, created_at, updated_at
+		RETURNING id, created_at, updated_at, version
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, paymentToCreate)
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, paymentToCreate)
 	if err != nil {
 		log.Println("Error creating payment:", err)
 		return payment.ErrInternal
@@ -186,7 +248,7 @@ func (r *paymentRepository) Create(ctx context.Context, paymentToCreate *payment
 	defer rows.Close()
 
 	if rows.Next() {
-		rows.Scan(&paymentToCreate.ID, &paymentToCreate.CreatedAt, &paymentToCreate.UpdatedAt)
+		rows.Scan(&paymentToCreate.ID, &paymentToCreate.CreatedAt, &paymentToCreate.UpdatedAt, &paymentToCreate.Version)
 		return nil
 	}
 
@@ -194,6 +256,12 @@ func (r *paymentRepository) Create(ctx context.Context, paymentToCreate *payment
 }
 
 func (r *paymentRepository) Update(ctx context.Context, paymentToUpdate *payment.Payment) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return payment.ErrInternal
+	}
+	paymentToUpdate.OrganisationID = tenantID
 	query := `
 		UPDATE payments
 		SET
@@ -202,33 +270,134 @@ func (r *paymentRepository) Update(ctx context.Context, paymentToUpdate *payment
 			proof_images=:proof_images,
 			date=:date,
 			remarks=:remarks,
-			updated_by=:updated_by
+			updated_by=:updated_by,
+			version=version+1
 		WHERE id = :id
-		RETURNING updated_at
+		  AND organisation_id = :organisation_id
+		  AND version = :version
+		  AND deleted_at IS NULL
+		RETURNING updated_at, version
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, paymentToUpdate)
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, paymentToUpdate)
 	if err != nil {
 		log.Println("Error updating payment:", err)
 		return payment.ErrInternal
 	}
 	defer rows.Close()
 	if rows.Next() {
-		rows.Scan(&paymentToUpdate.UpdatedAt)
+		rows.Scan(&paymentToUpdate.UpdatedAt, &paymentToUpdate.Version)
 		return nil
 	}
+	// the payment was read before updating, so a miss means someone else saved first
+	return payment.ErrVersionConflict
+}
+
+// GetDeletedByID finds a soft deleted payment, GetByID never returns one
+func (r *paymentRepository) GetDeletedByID(ctx context.Context, id int64) (*payment.Payment, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, payment.ErrInternal
+	}
+	payments := []payment.Payment{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&payments,
+		`SELECT * FROM payments
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NOT NULL`,
+		id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error fetching deleted payment by ID:", err)
+		return nil, payment.ErrInternal
+	}
+	if len(payments) == 0 {
+		return nil, payment.ErrNotFound
+	}
+	return &payments[0], nil
+}
+
+func (r *paymentRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return payment.ErrInternal
+	}
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE payments
+		SET deleted_at = NOW(),
+			deleted_by = $1,
+			version = version + 1
+		WHERE id = $2
+		  AND organisation_id = $3
+		  AND deleted_at IS NULL`,
+		deletedBy, id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error deleting payment:", err)
+		return payment.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return payment.ErrInternal
+	}
+	if rows == 0 {
+		return payment.ErrNotFound
+	}
+	return nil
+}
+
+func (r *paymentRepository) Restore(ctx context.Context, id, restoredBy int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return payment.ErrInternal
+	}
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE payments
+		SET deleted_at = NULL,
+			deleted_by = NULL,
+			updated_at = NOW(),
+			updated_by = $1,
+			version = version + 1
+		WHERE id = $2
+		  AND organisation_id = $3
+		  AND deleted_at IS NOT NULL`,
+		restoredBy, id, tenantID,
+	)
+	if err != nil {
+		log.Println("Error restoring payment:", err)
+		return payment.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return payment.ErrInternal
+	}
+	if rows == 0 {
+		return payment.ErrNotFound
+	}
 	return nil
 }
+
 func (r *paymentRepository) AppendBlobs(ctx context.Context, paymentID int64, blobName string) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return payment.ErrInternal
+	}
 
 	query := `
 		UPDATE payments
 		SET blobs = array_append(blobs, $1)
 		WHERE id = $2
+		  AND organisation_id = $3
 		  AND NOT ($1 = ANY(blobs))
 	`
 
-	res, err := r.db.ExecContext(ctx, query, blobName, paymentID)
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, query, blobName, paymentID, tenantID)
 	if err != nil {
 		log.Println("Error updating blob array in payment:", err)
 		return payment.ErrInternal
@@ -241,9 +410,9 @@ func (r *paymentRepository) AppendBlobs(ctx context.Context, paymentID int64, bl
 
 	if rows == 0 {
 		var exists bool
-		err := r.db.GetContext(ctx, &exists,
-			`SELECT EXISTS(SELECT 1 FROM payments WHERE id = $1)`,
-			paymentID,
+		err := postgres.Conn(ctx, r.db).GetContext(ctx, &exists,
+			`SELECT EXISTS(SELECT 1 FROM payments WHERE id = $1 AND organisation_id = $2)`,
+			paymentID, tenantID,
 		)
 		if err != nil {
 			return payment.ErrInternal
@@ -260,14 +429,20 @@ func (r *paymentRepository) AppendBlobs(ctx context.Context, paymentID int64, bl
 	return nil
 }
 func (r *paymentRepository) GetBlobs(ctx context.Context, paymentID int64) ([]string, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, payment.ErrInternal
+	}
 
 	var blobs []string
 
-	err := r.db.GetContext(ctx, &blobs, `
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &blobs, `
 		SELECT blobs
 		FROM payments
 		WHERE id = $1
-	`, paymentID)
+		  AND organisation_id = $2
+	`, paymentID, tenantID)
 
 	return blobs, err
 }
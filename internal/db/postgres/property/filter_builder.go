@@ -4,19 +4,32 @@ import (
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	property "github.com/nevinmanoj/hostmate/internal/domain/property"
 )
 
-func buildPropertyQuery(baseQuery string, f property.PropertyFilter, isCount bool) (string, []any, error) {
+func buildPropertyQuery(baseQuery string, f property.PropertyFilter, tenantID int64, isCount bool) (string, []any, error) {
 	var (
 		conditions []string
 		args       []any
 	)
 
-	// if f.UserID != nil {
-	// 	conditions = append(conditions, "user_id = ?")
-	// 	args = append(args, *f.UserID)
-	// }
+	conditions = append(conditions, "organisation_id = ?")
+	args = append(args, tenantID)
+
+	if !f.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if f.UserID != nil {
+		//managers and members with any grant can see the property
+		conditions = append(conditions, `(? = ANY(managers) OR EXISTS (
+			SELECT 1 FROM property_members pm
+			WHERE pm.property_id = properties.id AND pm.user_id = ?
+		))`)
+		args = append(args, *f.UserID, *f.UserID)
+	}
 
 	if len(f.Type) > 0 {
 		conditions = append(conditions, "type IN (?)")
@@ -33,6 +46,19 @@ func buildPropertyQuery(baseQuery string, f property.PropertyFilter, isCount boo
 		args = append(args, *f.Active)
 	}
 
+	if f.Query != nil {
+		condition, queryArgs := postgres.PropertyMatch("", *f.Query)
+		conditions = append(conditions, condition)
+		args = append(args, queryArgs...)
+	}
+
+	// the total counts every page, only the page itself continues from the cursor
+	keyset := propertyKeyset(f)
+	if condition, keysetArgs := keyset.Condition(); condition != "" && !isCount {
+		conditions = append(conditions, condition)
+		args = append(args, keysetArgs...)
+	}
+
 	// Apply WHERE
 	if len(conditions) > 0 {
 		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
@@ -40,14 +66,14 @@ func buildPropertyQuery(baseQuery string, f property.PropertyFilter, isCount boo
 
 	// Ordering (always deterministic)
 	if !isCount {
-		baseQuery += " ORDER BY created_at DESC"
-		// Pagination
+		baseQuery += keyset.OrderBy()
+		// Pagination, one extra row tells whether there is a next page
 		if f.Limit > 0 {
 			baseQuery += " LIMIT ?"
-			args = append(args, f.Limit)
+			args = append(args, f.Limit+1)
 		}
 
-		if f.Offset > 0 {
+		if f.Offset > 0 && f.Cursor == nil {
 			baseQuery += " OFFSET ?"
 			args = append(args, f.Offset)
 		}
@@ -64,3 +90,34 @@ func buildPropertyQuery(baseQuery string, f property.PropertyFilter, isCount boo
 
 	return query, finalArgs, nil
 }
+
+var propertySortColumns = map[string]string{
+	"created_at": "created_at",
+	"name":       "name",
+	"base_rate":  "base_rate",
+}
+
+// propertyKeyset defaults to the newest properties first
+func propertyKeyset(f property.PropertyFilter) postgres.Keyset {
+	sort := f.Sort
+	column, ok := propertySortColumns[sort.Field]
+	if !ok {
+		sort = pagination.Sort{Field: "created_at", Desc: true}
+		column = propertySortColumns[sort.Field]
+	}
+	return postgres.Keyset{Column: column, IDColumn: "id", Sort: sort, Cursor: f.Cursor}
+}
+
+// propertySortKey is the cursor value of a property for the sort field
+func propertySortKey(field string) func(property.Property) (string, int64) {
+	return func(p property.Property) (string, int64) {
+		switch field {
+		case "name":
+			return p.Name, p.ID
+		case "base_rate":
+			return postgres.FloatKey(p.BaseRate), p.ID
+		default:
+			return postgres.TimeKey(p.CreatedAt), p.ID
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 
 	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	property "github.com/nevinmanoj/hostmate/internal/domain/property"
 )
 
@@ -19,48 +21,66 @@ func NewPropertyWriteRepository(db *sqlx.DB) property.PropertyWriteRepository {
 func NewPropertyReadRepository(db *sqlx.DB) property.PropertyReadRepository {
 	return &propertyRepository{db: db}
 }
-func (r *propertyRepository) GetAll(ctx context.Context, filter property.PropertyFilter) ([]property.Property, int, error) {
-
-	baseCountQuery := `SELECT COUNT(*) FROM properties`
-	finalCountQuery, finalCountArgs, err := buildPropertyQuery(baseCountQuery, filter, true)
+func (r *propertyRepository) GetAll(ctx context.Context, filter property.PropertyFilter) ([]property.Property, int, pagination.Cursors, error) {
+	tenantID, err := postgres.TenantID(ctx)
 	if err != nil {
-		log.Println("Error during building properties query:", err.Error())
-		return nil, 0, err
+		return nil, 0, pagination.Cursors{}, err
 	}
 
 	var total int
-	if err := r.db.QueryRowContext(
-		ctx,
-		finalCountQuery, finalCountArgs...,
-	).Scan(&total); err != nil {
-		return nil, 0, err
-	}
+	if !filter.SkipCount {
+		baseCountQuery := `SELECT COUNT(*) FROM properties`
+		finalCountQuery, finalCountArgs, err := buildPropertyQuery(baseCountQuery, filter, tenantID, true)
+		if err != nil {
+			log.Println("Error during building properties query:", err.Error())
+			return nil, 0, pagination.Cursors{}, err
+		}
+
+		if err := postgres.Conn(ctx, r.db).QueryRowContext(
+			ctx,
+			finalCountQuery, finalCountArgs...,
+		).Scan(&total); err != nil {
+			return nil, 0, pagination.Cursors{}, err
+		}
 
-	if total == 0 {
-		return []property.Property{}, 0, nil
+		if total == 0 {
+			return []property.Property{}, 0, pagination.Cursors{}, nil
+		}
 	}
 	baseQuery := `SELECT * FROM properties`
-	finalQuery, finalArgs, err := buildPropertyQuery(baseQuery, filter, false)
+	finalQuery, finalArgs, err := buildPropertyQuery(baseQuery, filter, tenantID, false)
+	if err != nil {
+		log.Println("Error during building properties query:", err.Error())
+		return nil, 0, pagination.Cursors{}, err
+	}
 	properties := []property.Property{}
-	err = r.db.SelectContext(
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&properties,
 		finalQuery, finalArgs...,
 	)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, pagination.Cursors{}, err
 	}
 
-	return properties, total, nil
+	keyset := propertyKeyset(filter)
+	properties, cursors := postgres.Page(properties, filter.Limit, keyset, propertySortKey(keyset.Sort.Field))
+	return properties, total, cursors, nil
 }
 func (r *propertyRepository) GetByID(ctx context.Context, id int64) (*property.Property, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var count int64
-	if err := r.db.QueryRowContext(
+	if err := postgres.Conn(ctx, r.db).QueryRowContext(
 		ctx,
 		`SELECT COUNT (*) 
 		 FROM properties 
-		 WHERE id = $1`,
-		id,
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL`,
+		id, tenantID,
 	).Scan(&count); err != nil {
 		return nil, err
 	}
@@ -68,12 +88,14 @@ func (r *propertyRepository) GetByID(ctx context.Context, id int64) (*property.P
 		return nil, property.ErrNotFound
 	}
 	properties := []property.Property{}
-	err := r.db.SelectContext(
+	err = postgres.Conn(ctx, r.db).SelectContext(
 		ctx,
 		&properties,
 		`SELECT * FROM properties
-		 WHERE id = $1`,
-		id,
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NULL`,
+		id, tenantID,
 	)
 	if err != nil {
 		return nil, err
@@ -82,9 +104,15 @@ func (r *propertyRepository) GetByID(ctx context.Context, id int64) (*property.P
 	return &property, nil
 }
 func (r *propertyRepository) Create(ctx context.Context, propertyToCreate *property.Property) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	propertyToCreate.OrganisationID = tenantID
 
 	query := `
 		INSERT INTO properties (
+			organisation_id,
 			name,
 			address,
 			type,
@@ -98,6 +126,7 @@ func (r *propertyRepository) Create(ctx context.Context, propertyToCreate *prope
 			updated_by
 		)
 		VALUES (
+			:organisation_id,
 			:name,
 			:address,
 			:type,
@@ -110,23 +139,28 @@ func (r *propertyRepository) Create(ctx context.Context, propertyToCreate *prope
 			:created_by,
 			:updated_by
 		)
-		RETURNING id, created_at, updated_at
+		RETURNING id, created_at, updated_at, version
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, propertyToCreate)
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, propertyToCreate)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
 	if rows.Next() {
-		rows.Scan(&propertyToCreate.ID, &propertyToCreate.CreatedAt, &propertyToCreate.UpdatedAt)
+		rows.Scan(&propertyToCreate.ID, &propertyToCreate.CreatedAt, &propertyToCreate.UpdatedAt, &propertyToCreate.Version)
 		return nil
 	}
 
 	return sql.ErrNoRows
 }
 func (r *propertyRepository) Update(ctx context.Context, propertyToUpdate *property.Property) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	propertyToUpdate.OrganisationID = tenantID
 	query := `
 		UPDATE properties
 		SET
@@ -140,25 +174,117 @@ func (r *propertyRepository) Update(ctx context.Context, propertyToUpdate *prope
 			photos = :photos,
 			active = :active,
 			updated_at = NOW(),
-			updated_by = :updated_by
+			updated_by = :updated_by,
+			version = version + 1
 		WHERE id = :id
-		RETURNING updated_at
+		  AND organisation_id = :organisation_id
+		  AND version = :version
+		  AND deleted_at IS NULL
+		RETURNING updated_at, version
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, propertyToUpdate)
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, propertyToUpdate)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 	if rows.Next() {
-		rows.Scan(&propertyToUpdate.UpdatedAt)
+		rows.Scan(&propertyToUpdate.UpdatedAt, &propertyToUpdate.Version)
 		return nil
 	}
+	// the property was read before updating, so a miss means someone else saved first
+	return property.ErrVersionConflict
+}
+
+// GetDeletedByID finds a soft deleted property, GetByID never returns one
+func (r *propertyRepository) GetDeletedByID(ctx context.Context, id int64) (*property.Property, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	properties := []property.Property{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&properties,
+		`SELECT * FROM properties
+		 WHERE id = $1
+		   AND organisation_id = $2
+		   AND deleted_at IS NOT NULL`,
+		id, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		return nil, property.ErrNotFound
+	}
+	return &properties[0], nil
+}
+
+func (r *propertyRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE properties
+		SET deleted_at = NOW(),
+			deleted_by = $1,
+			version = version + 1
+		WHERE id = $2
+		  AND organisation_id = $3
+		  AND deleted_at IS NULL`,
+		deletedBy, id, tenantID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return property.ErrNotFound
+	}
+	return nil
+}
+
+func (r *propertyRepository) Restore(ctx context.Context, id, restoredBy int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE properties
+		SET deleted_at = NULL,
+			deleted_by = NULL,
+			updated_at = NOW(),
+			updated_by = $1,
+			version = version + 1
+		WHERE id = $2
+		  AND organisation_id = $3
+		  AND deleted_at IS NOT NULL`,
+		restoredBy, id, tenantID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return property.ErrNotFound
+	}
 	return nil
 }
 
 func (r *propertyRepository) HasManager(ctx context.Context, propertyID, userID int64,
 ) (bool, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return false, err
+	}
 
 	const q = `
 		SELECT EXISTS (
@@ -166,14 +292,181 @@ func (r *propertyRepository) HasManager(ctx context.Context, propertyID, userID
 			FROM properties
 			WHERE id = $1
 			  AND $2 = ANY(managers)
+			  AND organisation_id = $3
 		)
 	`
 
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, q, propertyID, userID)
+	err = postgres.Conn(ctx, r.db).GetContext(ctx, &exists, q, propertyID, userID, tenantID)
 	if err != nil {
 		return false, err
 	}
 
 	return exists, nil
 }
+
+func (r *propertyRepository) AddManager(ctx context.Context, propertyID, userID int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE properties
+		SET managers = array_append(managers, $1),
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = $2
+		  AND organisation_id = $3
+		  AND NOT ($1 = ANY(managers))
+	`
+
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, query, userID, propertyID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		var exists bool
+		err := postgres.Conn(ctx, r.db).GetContext(ctx, &exists,
+			`SELECT EXISTS(SELECT 1 FROM properties WHERE id = $1 AND organisation_id = $2)`,
+			propertyID, tenantID,
+		)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return property.ErrNotFound
+		}
+
+		// user already in managers → idempotent success
+		return nil
+	}
+
+	return nil
+}
+
+const memberColumns = `pm.property_id, pm.user_id, pm.organisation_id, pm.capabilities,
+	pm.created_by, pm.created_at, pm.updated_at, u.name, u.email`
+
+func (r *propertyRepository) GetMembers(ctx context.Context, propertyID int64) ([]property.PropertyMember, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := []property.PropertyMember{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&members,
+		`SELECT `+memberColumns+`
+		 FROM property_members pm
+		 JOIN users u ON u.id = pm.user_id
+		 WHERE pm.property_id = $1
+		   AND pm.organisation_id = $2
+		 ORDER BY u.name, u.id`,
+		propertyID, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *propertyRepository) GetMember(ctx context.Context, propertyID, userID int64) (*property.PropertyMember, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := []property.PropertyMember{}
+	err = postgres.Conn(ctx, r.db).SelectContext(
+		ctx,
+		&members,
+		`SELECT `+memberColumns+`
+		 FROM property_members pm
+		 JOIN users u ON u.id = pm.user_id
+		 WHERE pm.property_id = $1
+		   AND pm.user_id = $2
+		   AND pm.organisation_id = $3`,
+		propertyID, userID, tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, property.ErrMemberNotFound
+	}
+	member := members[0]
+	return &member, nil
+}
+
+// SetMember inserts the grant or replaces the capabilities of an existing one
+func (r *propertyRepository) SetMember(ctx context.Context, member *property.PropertyMember) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	member.OrganisationID = tenantID
+
+	query := `
+		INSERT INTO property_members (
+			property_id,
+			user_id,
+			organisation_id,
+			capabilities,
+			created_by
+		)
+		SELECT :property_id, :user_id, :organisation_id, :capabilities, :created_by
+		WHERE EXISTS (
+			SELECT 1 FROM properties
+			WHERE id = :property_id
+			  AND organisation_id = :organisation_id
+		)
+		ON CONFLICT (property_id, user_id) DO UPDATE
+		SET capabilities = EXCLUDED.capabilities,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, member)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		rows.Scan(&member.CreatedAt, &member.UpdatedAt)
+		return nil
+	}
+	return property.ErrNotFound
+}
+
+func (r *propertyRepository) RemoveMember(ctx context.Context, propertyID, userID int64) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := postgres.Conn(ctx, r.db).ExecContext(
+		ctx,
+		`DELETE FROM property_members
+		 WHERE property_id = $1
+		   AND user_id = $2
+		   AND organisation_id = $3`,
+		propertyID, userID, tenantID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return property.ErrMemberNotFound
+	}
+	return nil
+}
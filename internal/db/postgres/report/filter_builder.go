@@ -0,0 +1,97 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	report "github.com/nevinmanoj/hostmate/internal/domain/report"
+)
+
+func buildPerformanceQuery(f report.ReportFilter, tenantID int64) (string, []any, error) {
+	var (
+		conditions []string
+		gridArgs   []any
+	)
+	step := "1 " + string(f.Period)
+
+	conditions = append(conditions, "pr.organisation_id = ?", "pr.deleted_at IS NULL")
+	gridArgs = append(gridArgs, tenantID)
+
+	if f.UserID != nil {
+		//revenue is financials, members need the view financials grant
+		conditions = append(conditions, `(? = ANY(pr.managers) OR EXISTS (
+			SELECT 1 FROM property_members pm
+			WHERE pm.property_id = pr.id AND pm.user_id = ? AND ? = ANY(pm.capabilities)
+		))`)
+		gridArgs = append(gridArgs, *f.UserID, *f.UserID, string(access.CapViewFinancials))
+	}
+
+	if len(f.PropertyID) > 0 {
+		conditions = append(conditions, "pr.id IN (?)")
+		gridArgs = append(gridArgs, f.PropertyID)
+	}
+
+	query := fmt.Sprintf(performanceQuery, "WHERE "+strings.Join(conditions, " AND "))
+
+	// arguments in the order their placeholders appear
+	args := []any{step, string(f.Period), f.From, f.To, step, f.From, f.To}
+	args = append(args, gridArgs...)
+	args = append(args, booking.BookingCancelled, booking.BookingCancelled)
+
+	// Expand IN clauses
+	query, finalArgs, err := sqlx.In(query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Rebind for postgres ($1, $2...)
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	return query, finalArgs, nil
+}
+
+func buildTaxSummaryQuery(f report.ReportFilter, tenantID int64) (string, []any, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	conditions = append(conditions,
+		"b.organisation_id = ?",
+		"b.deleted_at IS NULL",
+		"b.status <> ?",
+		"b.check_out_date >= ?",
+		"b.check_out_date < ?",
+	)
+	args = append(args, tenantID, booking.BookingCancelled, f.From, f.To)
+
+	if f.UserID != nil {
+		//tax is financials, members need the view financials grant
+		conditions = append(conditions, `(? = ANY(pr.managers) OR EXISTS (
+			SELECT 1 FROM property_members pm
+			WHERE pm.property_id = pr.id AND pm.user_id = ? AND ? = ANY(pm.capabilities)
+		))`)
+		args = append(args, *f.UserID, *f.UserID, string(access.CapViewFinancials))
+	}
+
+	if len(f.PropertyID) > 0 {
+		conditions = append(conditions, "pr.id IN (?)")
+		args = append(args, f.PropertyID)
+	}
+
+	query := fmt.Sprintf(taxSummaryQuery, "WHERE "+strings.Join(conditions, " AND "))
+
+	// Expand IN clauses
+	query, finalArgs, err := sqlx.In(query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Rebind for postgres ($1, $2...)
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	return query, finalArgs, nil
+}
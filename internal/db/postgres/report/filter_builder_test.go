@@ -0,0 +1,67 @@
+package report
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	report "github.com/nevinmanoj/hostmate/internal/domain/report"
+)
+
+func TestBuildPerformanceQuery(t *testing.T) {
+	userID := int64(7)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		filter   report.ReportFilter
+		contains []string
+		args     []any
+	}{
+		{
+			name:     "organisation wide",
+			filter:   report.ReportFilter{From: from, To: to, Period: report.PeriodMonth},
+			contains: []string{"pr.organisation_id = $8", "pr.deleted_at IS NULL"},
+			args: []any{
+				"1 month", "month", from, to, "1 month", from, to,
+				int64(3),
+				booking.BookingCancelled, booking.BookingCancelled,
+			},
+		},
+		{
+			name:     "properties the user can see the financials of",
+			filter:   report.ReportFilter{From: from, To: to, Period: report.PeriodWeek, PropertyID: []int64{4, 5}, UserID: &userID},
+			contains: []string{"$9 = ANY(pr.managers)", "pr.id IN ($12, $13)"},
+			args: []any{
+				"1 week", "week", from, to, "1 week", from, to,
+				int64(3), userID, userID, "view_financials", int64(4), int64(5),
+				booking.BookingCancelled, booking.BookingCancelled,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := buildPerformanceQuery(tt.filter, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(query, part) {
+					t.Errorf("query does not contain %q:\n%s", part, query)
+				}
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+			// every argument is bound and none is left over
+			last := fmt.Sprintf("$%d", len(args))
+			if !strings.Contains(query, last) || strings.Contains(query, fmt.Sprintf("$%d", len(args)+1)) || strings.Contains(query, "?") {
+				t.Errorf("query does not bind exactly %d args:\n%s", len(args), query)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	report "github.com/nevinmanoj/hostmate/internal/domain/report"
+)
+
+type reportRepository struct {
+	db *sqlx.DB
+}
+
+func NewReportRepository(db *sqlx.DB) report.ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// performanceQuery builds a grid of every property and period, clipped to the requested range,
+// and adds up what happened in each cell:
+//   - nights sold and revenue booked count the nights of live bookings that fall in the cell,
+//     a night is an equal share of the booking's taxable amount, so revenue is net of GST and
+//     includes discounts and extra charges. Bookings never priced fall back to the base rate
+//     plus the extra rate for each guest over the base count
+//   - revenue collected is the payments dated in the cell
+//   - cancellations are the cancelled bookings that were due to check in during the cell
+//
+// The grid CTE is completed by buildPerformanceQuery with the property conditions.
+const performanceQuery = `
+	WITH periods AS (
+		SELECT gs AS period_start, gs + CAST(? AS interval) AS period_end
+		FROM generate_series(
+			date_trunc(?, CAST(? AS timestamp)),
+			CAST(? AS timestamp) - interval '1 microsecond',
+			CAST(? AS interval)
+		) gs
+	),
+	grid AS (
+		SELECT pr.id AS property_id, pr.name AS property_name, pe.period_start,
+			GREATEST(pe.period_start, CAST(? AS timestamp))::date AS window_start,
+			LEAST(pe.period_end, CAST(? AS timestamp))::date AS window_end
+		FROM properties pr
+		CROSS JOIN periods pe
+		%s
+	),
+	stays AS (
+		SELECT g.property_id, g.period_start,
+			SUM(n.nights) AS nights_sold,
+			SUM(n.nights * CASE
+				WHEN b.total_amount = 0 AND b.discount_amount = 0
+					THEN b.base_rate + GREATEST(b.num_guests - b.max_guests_base, 0) * b.extra_rate_per_guest
+				ELSE b.taxable_amount / NULLIF(b.check_out_date::date - b.check_in_date::date, 0)
+			END) AS revenue_booked
+		FROM grid g
+		JOIN bookings b ON b.property_id = g.property_id
+			AND b.deleted_at IS NULL
+			AND b.status <> ?
+			AND b.check_in_date::date < g.window_end
+			AND b.check_out_date::date > g.window_start
+		CROSS JOIN LATERAL (
+			SELECT LEAST(b.check_out_date::date, g.window_end) - GREATEST(b.check_in_date::date, g.window_start) AS nights
+		) n
+		GROUP BY g.property_id, g.period_start
+	),
+	collections AS (
+		SELECT g.property_id, g.period_start, SUM(pa.amount) AS revenue_collected
+		FROM grid g
+		JOIN bookings b ON b.property_id = g.property_id AND b.deleted_at IS NULL
+		JOIN payments pa ON pa.booking_id = b.id
+			AND pa.deleted_at IS NULL
+			AND pa.date::date >= g.window_start
+			AND pa.date::date < g.window_end
+		GROUP BY g.property_id, g.period_start
+	),
+	cancellations AS (
+		SELECT g.property_id, g.period_start, COUNT(*) AS cancellations
+		FROM grid g
+		JOIN bookings b ON b.property_id = g.property_id
+			AND b.deleted_at IS NULL
+			AND b.status = ?
+			AND b.check_in_date::date >= g.window_start
+			AND b.check_in_date::date < g.window_end
+		GROUP BY g.property_id, g.period_start
+	)
+	SELECT g.property_id, g.property_name, g.period_start,
+		g.window_end - g.window_start AS available_nights,
+		COALESCE(s.nights_sold, 0) AS nights_sold,
+		COALESCE(COALESCE(s.nights_sold, 0)::float8 / NULLIF(g.window_end - g.window_start, 0), 0) AS occupancy_rate,
+		COALESCE(s.revenue_booked::float8 / NULLIF(s.nights_sold, 0), 0) AS adr,
+		COALESCE(COALESCE(s.revenue_booked, 0)::float8 / NULLIF(g.window_end - g.window_start, 0), 0) AS revpar,
+		COALESCE(s.revenue_booked, 0)::float8 AS revenue_booked,
+		COALESCE(c.revenue_collected, 0)::float8 AS revenue_collected,
+		COALESCE(x.cancellations, 0) AS cancellations
+	FROM grid g
+	LEFT JOIN stays s ON s.property_id = g.property_id AND s.period_start = g.period_start
+	LEFT JOIN collections c ON c.property_id = g.property_id AND c.period_start = g.period_start
+	LEFT JOIN cancellations x ON x.property_id = g.property_id AND x.period_start = g.period_start
+	ORDER BY g.period_start, g.property_name, g.property_id`
+
+func (r *reportRepository) GetPerformance(ctx context.Context, filter report.ReportFilter) ([]report.PerformanceRow, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := buildPerformanceQuery(filter, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	rows := []report.PerformanceRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// taxSummaryQuery adds up the tax each booking was saved with, so a change to the rules of
+// a property never rewrites the tax of stays already priced. Cancelled stays owe no tax.
+const taxSummaryQuery = `
+	SELECT pr.id AS property_id, pr.name AS property_name, COALESCE(tc.gstin, '') AS gstin,
+		date_trunc('month', b.check_out_date) AS month,
+		b.tax_rate,
+		COUNT(*) AS bookings,
+		SUM(b.taxable_amount)::float8 AS taxable_amount,
+		SUM(b.cgst)::float8 AS cgst,
+		SUM(b.sgst)::float8 AS sgst,
+		SUM(b.igst)::float8 AS igst,
+		SUM(b.cgst + b.sgst + b.igst)::float8 AS total_tax,
+		SUM(b.total_amount)::float8 AS invoice_value
+	FROM bookings b
+	JOIN properties pr ON pr.id = b.property_id
+	LEFT JOIN property_tax_configs tc ON tc.property_id = pr.id
+	%s
+	GROUP BY pr.id, pr.name, tc.gstin, date_trunc('month', b.check_out_date), b.tax_rate
+	ORDER BY month, pr.name, pr.id, b.tax_rate`
+
+func (r *reportRepository) GetTaxSummary(ctx context.Context, filter report.ReportFilter) ([]report.TaxSummaryRow, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := buildTaxSummaryQuery(filter, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	rows := []report.TaxSummaryRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
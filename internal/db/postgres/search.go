@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/search"
+)
+
+// Text search runs on full text and trigram matching, the expressions below are kept
+// identical to the indexes of migrations/0014_search.sql that serve them
+
+func bookingDocument(prefix string) string {
+	return fmt.Sprintf("to_tsvector('simple', %sguest_name || ' ' || %sremarks)", prefix, prefix)
+}
+
+func propertyDocument(prefix string) string {
+	return fmt.Sprintf("to_tsvector('simple', %sname || ' ' || %saddress)", prefix, prefix)
+}
+
+// BookingMatch matches words of the guest name or remarks, a fragment of the guest name
+// or the digits of the guest phone, prefix is the table alias with its dot
+func BookingMatch(prefix, q string) (string, []any) {
+	condition := fmt.Sprintf("(%s @@ plainto_tsquery('simple', ?) OR %sguest_name ILIKE ?", bookingDocument(prefix), prefix)
+	args := []any{q, LikePattern(q)}
+	if digits := search.PhoneFragment(q); digits != "" {
+		condition += fmt.Sprintf(` OR regexp_replace(%sguest_phone, '\D', '', 'g') LIKE ?`, prefix)
+		args = append(args, LikePattern(digits))
+	}
+	return condition + ")", args
+}
+
+// BookingRank scores a booking match, whole words count on top of how close the name is
+func BookingRank(prefix, q string) (string, []any) {
+	return fmt.Sprintf("ts_rank(%s, plainto_tsquery('simple', ?)) + similarity(%sguest_name, ?)", bookingDocument(prefix), prefix), []any{q, q}
+}
+
+// PropertyMatch matches words or fragments of the property name and address
+func PropertyMatch(prefix, q string) (string, []any) {
+	pattern := LikePattern(q)
+	return fmt.Sprintf("(%s @@ plainto_tsquery('simple', ?) OR %sname ILIKE ? OR %saddress ILIKE ?)", propertyDocument(prefix), prefix, prefix), []any{q, pattern, pattern}
+}
+
+func PropertyRank(prefix, q string) (string, []any) {
+	return fmt.Sprintf("ts_rank(%s, plainto_tsquery('simple', ?)) + similarity(%sname, ?)", propertyDocument(prefix), prefix), []any{q, q}
+}
+
+// LikePattern matches the term anywhere, wildcards typed by the user are taken literally
+func LikePattern(term string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + escaper.Replace(term) + "%"
+}
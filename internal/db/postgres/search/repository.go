@@ -0,0 +1,128 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	search "github.com/nevinmanoj/hostmate/internal/domain/search"
+)
+
+type searchRepository struct {
+	db *sqlx.DB
+}
+
+func NewSearchRepository(db *sqlx.DB) search.SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// Search runs one query per kind and merges them by rank, every query is limited on its own
+// so the merged list still holds the best matches
+func (r *searchRepository) Search(ctx context.Context, filter search.SearchFilter) ([]search.Result, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := []search.Result{}
+	for _, kind := range filter.Kinds {
+		var (
+			query string
+			args  []any
+		)
+		switch kind {
+		case search.KindProperty:
+			query, args = propertySearchQuery(filter, tenantID)
+		case search.KindBooking:
+			query, args = bookingSearchQuery(filter, tenantID)
+		case search.KindGuest:
+			query, args = guestSearchQuery(filter, tenantID)
+		default:
+			continue
+		}
+		var matches []search.Result
+		if err := r.db.SelectContext(ctx, &matches, sqlx.Rebind(sqlx.DOLLAR, query), args...); err != nil {
+			return nil, err
+		}
+		results = append(results, matches...)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+func propertySearchQuery(f search.SearchFilter, tenantID int64) (string, []any) {
+	rank, args := postgres.PropertyRank("p.", f.Query)
+	match, matchArgs := postgres.PropertyMatch("p.", f.Query)
+	query := fmt.Sprintf(`SELECT 'property' AS kind, p.id, p.id AS property_id, p.name AS title, p.address AS subtitle, %s AS rank
+		FROM properties p
+		WHERE p.organisation_id = ? AND p.deleted_at IS NULL AND %s`, rank, match)
+	args = append(args, tenantID)
+	args = append(args, matchArgs...)
+	if f.UserID != nil {
+		//same visibility as the property list
+		query += ` AND (? = ANY(p.managers) OR EXISTS (
+			SELECT 1 FROM property_members pm
+			WHERE pm.property_id = p.id AND pm.user_id = ?
+		))`
+		args = append(args, *f.UserID, *f.UserID)
+	}
+	query += " ORDER BY rank DESC, p.id DESC LIMIT ?"
+	args = append(args, f.Limit)
+	return query, args
+}
+
+func bookingSearchQuery(f search.SearchFilter, tenantID int64) (string, []any) {
+	rank, args := postgres.BookingRank("b.", f.Query)
+	where, whereArgs := bookingSearchWhere(f, tenantID)
+	query := fmt.Sprintf(`SELECT 'booking' AS kind, b.id, b.property_id, b.guest_name AS title,
+			p.name || ' ' || to_char(b.check_in_date, 'YYYY-MM-DD') AS subtitle, %s AS rank
+		FROM bookings b
+		JOIN properties p ON p.id = b.property_id
+		%s
+		ORDER BY rank DESC, b.id DESC LIMIT ?`, rank, where)
+	args = append(args, whereArgs...)
+	args = append(args, f.Limit)
+	return query, args
+}
+
+// guestSearchQuery groups matching bookings by phone, the guest shows under their latest name
+func guestSearchQuery(f search.SearchFilter, tenantID int64) (string, []any) {
+	rank, args := postgres.BookingRank("b.", f.Query)
+	where, whereArgs := bookingSearchWhere(f, tenantID)
+	query := fmt.Sprintf(`SELECT 'guest' AS kind,
+			(array_agg(b.id ORDER BY b.created_at DESC, b.id DESC))[1] AS id,
+			(array_agg(b.property_id ORDER BY b.created_at DESC, b.id DESC))[1] AS property_id,
+			(array_agg(b.guest_name ORDER BY b.created_at DESC, b.id DESC))[1] AS title,
+			b.guest_phone AS subtitle,
+			MAX(%s) AS rank
+		FROM bookings b
+		JOIN properties p ON p.id = b.property_id
+		%s
+		GROUP BY b.guest_phone
+		ORDER BY rank DESC, id DESC LIMIT ?`, rank, where)
+	args = append(args, whereArgs...)
+	args = append(args, f.Limit)
+	return query, args
+}
+
+func bookingSearchWhere(f search.SearchFilter, tenantID int64) (string, []any) {
+	match, matchArgs := postgres.BookingMatch("b.", f.Query)
+	where := "WHERE b.organisation_id = ? AND b.deleted_at IS NULL AND p.deleted_at IS NULL AND " + match
+	args := append([]any{tenantID}, matchArgs...)
+	if f.UserID != nil {
+		//same visibility as the booking list
+		where += ` AND (? = ANY(p.managers) OR EXISTS (
+			SELECT 1 FROM property_members pm
+			WHERE pm.property_id = p.id AND pm.user_id = ? AND ? = ANY(pm.capabilities)
+		))`
+		args = append(args, *f.UserID, *f.UserID, string(access.CapViewBookings))
+	}
+	return where, args
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBookingMatch(t *testing.T) {
+	condition, args := BookingMatch("b.", "asha")
+	if strings.Contains(condition, "guest_phone") {
+		t.Errorf("a query without digits matches phone numbers: %s", condition)
+	}
+	if want := []any{"asha", "%asha%"}; !slices.Equal(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	condition, args = BookingMatch("b.", "98470 123")
+	if !strings.Contains(condition, `regexp_replace(b.guest_phone, '\D', '', 'g') LIKE ?`) {
+		t.Errorf("condition %s does not match phone digits", condition)
+	}
+	if want := []any{"98470 123", "%98470 123%", "%98470123%"}; !slices.Equal(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+	if strings.Count(condition, "?") != len(args) {
+		t.Errorf("condition %s has %d placeholders for %d args", condition, strings.Count(condition, "?"), len(args))
+	}
+}
+
+func TestPropertyMatch(t *testing.T) {
+	condition, args := PropertyMatch("", "sea view")
+	if strings.Count(condition, "?") != len(args) {
+		t.Errorf("condition %s has %d placeholders for %d args", condition, strings.Count(condition, "?"), len(args))
+	}
+	if !strings.Contains(condition, "to_tsvector('simple', name || ' ' || address)") {
+		t.Errorf("condition %s does not use the indexed document", condition)
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	if got, want := LikePattern(`50%_off\`), `%50\%\_off\\%`; got != want {
+		t.Errorf("LikePattern = %q, want %q", got, want)
+	}
+}
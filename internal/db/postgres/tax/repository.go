@@ -0,0 +1,94 @@
+package tax
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
+	tax "github.com/nevinmanoj/hostmate/internal/domain/tax"
+)
+
+type taxRepository struct {
+	db *sqlx.DB
+}
+
+func NewTaxRepository(db *sqlx.DB) tax.TaxRepository {
+	return &taxRepository{db: db}
+}
+
+func (r *taxRepository) GetConfig(ctx context.Context, propertyID int64) (*tax.Config, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, tax.ErrInternal
+	}
+	var config tax.Config
+	err = postgres.Conn(ctx, r.db).GetContext(
+		ctx,
+		&config,
+		`SELECT * FROM property_tax_configs
+		 WHERE property_id = $1
+		   AND organisation_id = $2`,
+		propertyID, tenantID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, tax.ErrNotFound
+	}
+	if err != nil {
+		log.Println("Error fetching tax config:", err)
+		return nil, tax.ErrInternal
+	}
+	return &config, nil
+}
+
+func (r *taxRepository) SaveConfig(ctx context.Context, config *tax.Config) error {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return tax.ErrInternal
+	}
+	config.OrganisationID = tenantID
+
+	query := `
+		INSERT INTO property_tax_configs (
+			property_id,
+			organisation_id,
+			gstin,
+			supply,
+			inclusive,
+			slabs,
+			updated_by
+		)
+		VALUES (
+			:property_id,
+			:organisation_id,
+			:gstin,
+			:supply,
+			:inclusive,
+			:slabs,
+			:updated_by
+		)
+		ON CONFLICT (property_id) DO UPDATE
+		SET gstin = EXCLUDED.gstin,
+			supply = EXCLUDED.supply,
+			inclusive = EXCLUDED.inclusive,
+			slabs = EXCLUDED.slabs,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		WHERE property_tax_configs.organisation_id = EXCLUDED.organisation_id
+		RETURNING updated_at
+	`
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, config)
+	if err != nil {
+		log.Println("Error saving tax config:", err)
+		return tax.ErrInternal
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return rows.Scan(&config.UpdatedAt)
+	}
+	return tax.ErrInternal
+}
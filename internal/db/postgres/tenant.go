@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+var ErrNoTenant = errors.New("no organisation in request context")
+
+// TenantID returns the organisation a repository query must be scoped to.
+// Users, credentials and login history are global, everything a business owns is scoped.
+func TenantID(ctx context.Context) (int64, error) {
+	tenantID, ok := middleware.TenantFromContext(ctx)
+	if !ok {
+		return 0, ErrNoTenant
+	}
+	return tenantID, nil
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+// txState is the transaction running in a context, savepoints numbers its nested transactions
+type txState struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+// Queryer is what both a *sqlx.DB and a *sqlx.Tx can run
+type Queryer interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error)
+}
+
+// Conn is the transaction running in ctx, or db when there is none, repositories query
+// through it so a caller can group their writes in one transaction
+func Conn(ctx context.Context, db *sqlx.DB) Queryer {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
+
+// Tx is a new transaction, or a savepoint when ctx already runs one. Committing a
+// savepoint keeps its work in the outer transaction, rolling it back only undoes its own.
+type Tx struct {
+	*sqlx.Tx
+	state     *txState
+	savepoint string
+	done      bool
+}
+
+func BeginTx(ctx context.Context, db *sqlx.DB) (*Tx, error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.savepoints++
+		savepoint := fmt.Sprintf("sp_%d", state.savepoints)
+		if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+			return nil, err
+		}
+		return &Tx{Tx: state.tx, state: state, savepoint: savepoint}, nil
+	}
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, state: &txState{tx: tx}}, nil
+}
+
+func (t *Tx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	_, err := t.Tx.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
+
+// Rollback after Commit does nothing, so it can always be deferred
+func (t *Tx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	_, err := t.Tx.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint)
+	return err
+}
+
+// Context carries the transaction so repositories called with it join in
+func (t *Tx) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, t.state)
+}
+
+type Transactor struct {
+	db *sqlx.DB
+}
+
+func NewTransactor(db *sqlx.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx runs fn in one transaction, committed when fn returns nil. Nested calls run
+// in a savepoint, so an inner failure can be undone while the outer transaction goes on.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := BeginTx(ctx, t.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx.Context(ctx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,138 @@
+package user
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+)
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) user.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *user.APIKey) error {
+	query := `
+		INSERT INTO api_keys (
+			user_id,
+			organisation_id,
+			name,
+			prefix,
+			key_hash,
+			scope
+		)
+		VALUES (
+			:user_id,
+			:organisation_id,
+			:name,
+			:prefix,
+			:key_hash,
+			:scope
+		)
+		RETURNING id, created_at
+	`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, key)
+	if err != nil {
+		log.Println("Error inserting api key:", err)
+		return user.ErrInternal
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		rows.Scan(&key.ID, &key.CreatedAt)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*user.APIKey, error) {
+	keys := []user.APIKey{}
+	err := r.db.SelectContext(ctx, &keys, `SELECT * FROM api_keys WHERE key_hash = $1`, keyHash)
+	if err != nil {
+		log.Println("Error fetching api key:", err)
+		return nil, user.ErrInternal
+	}
+	if len(keys) == 0 {
+		return nil, user.ErrAPIKeyNotFound
+	}
+	key := keys[0]
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByUser(ctx context.Context, userID int64) ([]user.APIKey, error) {
+	keys := []user.APIKey{}
+	err := r.db.SelectContext(
+		ctx,
+		&keys,
+		`SELECT * FROM api_keys
+		 WHERE user_id = $1
+		 ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		log.Println("Error fetching api keys:", err)
+		return nil, user.ErrInternal
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, id int64) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE api_keys
+		 SET revoked_at = NOW()
+		 WHERE id = $1
+		   AND user_id = $2
+		   AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		log.Println("Error revoking api key:", err)
+		return user.ErrInternal
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return user.ErrInternal
+	}
+	if affected == 0 {
+		return user.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) RevokeAllForUser(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE api_keys
+		 SET revoked_at = NOW()
+		 WHERE user_id = $1
+		   AND revoked_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		log.Println("Error revoking api keys:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	// written at most once a minute so busy integrations do not update the row on every request
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE api_keys
+		 SET last_used_at = NOW()
+		 WHERE id = $1
+		   AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
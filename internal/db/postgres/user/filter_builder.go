@@ -0,0 +1,77 @@
+package user
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func buildUserQuery(baseQuery string, f user.UserFilter, tenantID int64, isCount bool) (string, []any, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	// users are global, only members of the request's organisation are listed
+	conditions = append(conditions, `EXISTS (
+		SELECT 1
+		FROM organisation_members m
+		WHERE m.user_id = users.id
+		  AND m.organisation_id = ?
+	)`)
+	args = append(args, tenantID)
+
+	// deactivated accounts cannot be picked as managers
+	conditions = append(conditions, "deactivated_at IS NULL")
+
+	if q := strings.TrimSpace(f.Query); q != "" {
+		pattern := "%" + likeEscaper.Replace(q) + "%"
+		conditions = append(conditions, "(name ILIKE ? OR email ILIKE ?)")
+		args = append(args, pattern, pattern)
+	}
+
+	if f.VisibleTo != nil {
+		conditions = append(conditions, `(id = ? OR EXISTS (
+			SELECT 1
+			FROM properties pr
+			WHERE ? = ANY(pr.managers)
+			  AND users.id = ANY(pr.managers)
+			  AND pr.organisation_id = ?
+		))`)
+		args = append(args, *f.VisibleTo, *f.VisibleTo, tenantID)
+	}
+
+	// Apply WHERE
+	if len(conditions) > 0 {
+		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Ordering (always deterministic)
+	if !isCount {
+		baseQuery += " ORDER BY name ASC, id ASC"
+		// Pagination
+		if f.Limit > 0 {
+			baseQuery += " LIMIT ?"
+			args = append(args, f.Limit)
+		}
+
+		if f.Offset > 0 {
+			baseQuery += " OFFSET ?"
+			args = append(args, f.Offset)
+		}
+	}
+
+	// Expand IN clauses
+	query, finalArgs, err := sqlx.In(baseQuery, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Rebind for postgres ($1, $2...)
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	return query, finalArgs, nil
+}
@@ -0,0 +1,70 @@
+package user
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+)
+
+func TestBuildUserQuery(t *testing.T) {
+	viewer := int64(7)
+	tests := []struct {
+		name     string
+		filter   user.UserFilter
+		isCount  bool
+		contains []string
+		absent   []string
+		args     []any
+	}{
+		{
+			name:     "everyone active",
+			filter:   user.UserFilter{},
+			contains: []string{"m.organisation_id = $1", "deactivated_at IS NULL", "ORDER BY name ASC, id ASC"},
+			absent:   []string{"ILIKE", "LIMIT", "OFFSET"},
+			args:     []any{int64(3)},
+		},
+		{
+			name:     "wildcards in the query are matched literally",
+			filter:   user.UserFilter{Query: " 50%_off\\ "},
+			contains: []string{"(name ILIKE $2 OR email ILIKE $3)"},
+			args:     []any{int64(3), `%50\%\_off\\%`, `%50\%\_off\\%`},
+		},
+		{
+			name:     "visible to a manager",
+			filter:   user.UserFilter{VisibleTo: &viewer, Limit: 20, Offset: 40},
+			contains: []string{"(id = $2 OR EXISTS", "$3 = ANY(pr.managers)", "pr.organisation_id = $4", "LIMIT $5", "OFFSET $6"},
+			args:     []any{int64(3), viewer, viewer, int64(3), 20, 40},
+		},
+		{
+			name:     "count skips ordering and pagination",
+			filter:   user.UserFilter{Query: "asha", Limit: 20, Offset: 40},
+			isCount:  true,
+			contains: []string{"ILIKE $2"},
+			absent:   []string{"ORDER BY", "LIMIT", "OFFSET"},
+			args:     []any{int64(3), "%asha%", "%asha%"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := buildUserQuery("SELECT * FROM users", tt.filter, 3, tt.isCount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(query, part) {
+					t.Errorf("query %q does not contain %q", query, part)
+				}
+			}
+			for _, part := range tt.absent {
+				if strings.Contains(query, part) {
+					t.Errorf("query %q contains %q", query, part)
+				}
+			}
+			if !slices.Equal(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
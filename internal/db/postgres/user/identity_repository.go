@@ -0,0 +1,180 @@
+package user
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+)
+
+type identityRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdentityRepository(db *sqlx.DB) user.IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) SaveLoginState(ctx context.Context, state *user.OIDCLoginState) error {
+	// expired states are swept on every new login so the table stays small
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`)
+	if err != nil {
+		log.Println("Error sweeping sign-on states:", err)
+	}
+
+	query := `
+		INSERT INTO oidc_login_states (
+			state,
+			nonce,
+			code_verifier,
+			expires_at
+		)
+		VALUES (
+			:state,
+			:nonce,
+			:code_verifier,
+			:expires_at
+		)
+	`
+	_, err = r.db.NamedExecContext(ctx, query, state)
+	if err != nil {
+		log.Println("Error saving sign-on state:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *identityRepository) ConsumeLoginState(ctx context.Context, state string) (*user.OIDCLoginState, error) {
+	states := []user.OIDCLoginState{}
+	err := r.db.SelectContext(
+		ctx,
+		&states,
+		`DELETE FROM oidc_login_states
+		 WHERE state = $1
+		 RETURNING *`,
+		state,
+	)
+	if err != nil {
+		log.Println("Error consuming sign-on state:", err)
+		return nil, user.ErrInternal
+	}
+	if len(states) == 0 {
+		return nil, user.ErrInvalidSSOState
+	}
+	loginState := states[0]
+	if loginState.ExpiresAt.Before(time.Now()) {
+		return nil, user.ErrInvalidSSOState
+	}
+	return &loginState, nil
+}
+
+func (r *identityRepository) GetUserByIdentity(ctx context.Context, issuer, subject string) (*user.User, error) {
+	users := []user.User{}
+	err := r.db.SelectContext(
+		ctx,
+		&users,
+		`SELECT u.* FROM users u
+		 JOIN user_identities i ON i.user_id = u.id
+		 WHERE i.issuer = $1
+		   AND i.subject = $2`,
+		issuer, subject,
+	)
+	if err != nil {
+		log.Println("Error fetching user by identity:", err)
+		return nil, user.ErrInternal
+	}
+	if len(users) == 0 {
+		return nil, user.ErrNotFound
+	}
+	user := users[0]
+	return &user, nil
+}
+
+func (r *identityRepository) LinkIdentity(ctx context.Context, identity *user.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (
+			user_id,
+			issuer,
+			subject,
+			email,
+			last_login_at
+		)
+		VALUES (
+			:user_id,
+			:issuer,
+			:subject,
+			:email,
+			NOW()
+		)
+		RETURNING id, created_at
+	`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, identity)
+	if err != nil {
+		log.Println("Error linking identity:", err)
+		return user.ErrInternal
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		rows.Scan(&identity.ID, &identity.CreatedAt)
+	}
+	return nil
+}
+
+func (r *identityRepository) TouchIdentity(ctx context.Context, issuer, subject string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE user_identities
+		 SET last_login_at = NOW()
+		 WHERE issuer = $1
+		   AND subject = $2`,
+		issuer, subject,
+	)
+	if err != nil {
+		log.Println("Error updating identity login time:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+// CreateExternalUser creates an account without a password, it can only sign in through its identity provider,
+// which has verified the email
+func (r *identityRepository) CreateExternalUser(ctx context.Context, email, name string) (*user.User, error) {
+	userToCreate := &user.User{
+		Email: email,
+		Name:  name,
+	}
+
+	query := `
+		INSERT INTO users (
+			name,
+			email,
+			password_hash,
+			email_verified_at
+		)
+		VALUES (
+			:name,
+			:email,
+			'',
+			NOW()
+		)
+		RETURNING id, created_at
+	`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, userToCreate)
+	if err != nil {
+		log.Println("Error inserting external user:", err)
+		return nil, user.ErrInternal
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		rows.Scan(&userToCreate.ID, &userToCreate.CreatedAt)
+		return userToCreate, nil
+	}
+
+	return nil, user.ErrInternal
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+)
+
+type loginAttemptRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) user.LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Record(ctx context.Context, attempt *user.LoginAttempt) error {
+	query := `
+		INSERT INTO login_attempts (
+			user_id,
+			email,
+			ip,
+			user_agent,
+			outcome
+		)
+		VALUES (
+			:user_id,
+			:email,
+			:ip,
+			:user_agent,
+			:outcome
+		)
+		RETURNING id, created_at
+	`
+
+	rows, err := r.db.NamedQueryContext(ctx, query, attempt)
+	if err != nil {
+		log.Println("Error recording login attempt:", err)
+		return user.ErrInternal
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		rows.Scan(&attempt.ID, &attempt.CreatedAt)
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) GetFailureStats(ctx context.Context, email string, since time.Time) (*user.LoginFailureStats, error) {
+	// only real credential failures count, attempts rejected while throttled do not extend the lock
+	const q = `
+		SELECT COUNT(*) AS failures, MAX(created_at) AS last_failure
+		FROM login_attempts
+		WHERE email = $1
+		  AND outcome IN ('invalid_password', 'invalid_two_factor')
+		  AND created_at > $2
+		  AND created_at > COALESCE((
+			SELECT MAX(created_at)
+			FROM login_attempts
+			WHERE email = $1
+			  AND outcome = 'success'
+		  ), '-infinity')
+	`
+
+	var stats user.LoginFailureStats
+	err := r.db.GetContext(ctx, &stats, q, email, since)
+	if err != nil {
+		log.Println("Error fetching login failure stats:", err)
+		return nil, user.ErrInternal
+	}
+	return &stats, nil
+}
+
+func (r *loginAttemptRepository) CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int, error) {
+	const q = `
+		SELECT COUNT(*)
+		FROM login_attempts
+		WHERE ip = $1
+		  AND outcome IN ('invalid_password', 'invalid_two_factor')
+		  AND created_at > $2
+	`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, q, ip, since)
+	if err != nil {
+		log.Println("Error counting login failures by ip:", err)
+		return 0, user.ErrInternal
+	}
+	return count, nil
+}
+
+func (r *loginAttemptRepository) GetByUser(ctx context.Context, userID int64, email string, limit, offset int) ([]user.LoginAttempt, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM login_attempts
+		 WHERE user_id = $1 OR email = $2`,
+		userID, email,
+	).Scan(&total); err != nil {
+		log.Println("Error counting login attempts:", err)
+		return nil, 0, user.ErrInternal
+	}
+
+	if total == 0 {
+		return []user.LoginAttempt{}, 0, nil
+	}
+
+	attempts := []user.LoginAttempt{}
+	err := r.db.SelectContext(
+		ctx,
+		&attempts,
+		`SELECT * FROM login_attempts
+		 WHERE user_id = $1 OR email = $2
+		 ORDER BY created_at DESC
+		 LIMIT $3 OFFSET $4`,
+		userID, email, limit, offset,
+	)
+	if err != nil {
+		log.Println("Error fetching login attempts:", err)
+		return nil, 0, user.ErrInternal
+	}
+	return attempts, total, nil
+}
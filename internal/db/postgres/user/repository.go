@@ -6,6 +6,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/nevinmanoj/hostmate/internal/auth"
+	postgres "github.com/nevinmanoj/hostmate/internal/db/postgres"
 	user "github.com/nevinmanoj/hostmate/internal/domain/user"
 )
 
@@ -23,7 +24,7 @@ func NewUserReadRepository(db *sqlx.DB) user.UserReadRepository {
 func (r *userRepository) CreateUser(ctx context.Context, email, password, name string) (*user.User, error) {
 	//check if email already exists
 	var exists bool
-	err := r.db.QueryRowContext(
+	err := postgres.Conn(ctx, r.db).QueryRowContext(
 		ctx,
 		`SELECT EXISTS (
 		SELECT 1
@@ -66,7 +67,7 @@ func (r *userRepository) CreateUser(ctx context.Context, email, password, name s
 		RETURNING id, created_at
 	`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, userToCreate)
+	rows, err := sqlx.NamedQueryContext(ctx, postgres.Conn(ctx, r.db), query, userToCreate)
 	if err != nil {
 		log.Println("Error inserting user:", err)
 		return nil, user.ErrInternal
@@ -81,6 +82,51 @@ func (r *userRepository) CreateUser(ctx context.Context, email, password, name s
 	return nil, user.ErrInternal
 }
 
+func (r *userRepository) GetAll(ctx context.Context, filter user.UserFilter) ([]user.User, int, error) {
+	tenantID, err := postgres.TenantID(ctx)
+	if err != nil {
+		log.Println("Error resolving tenant:", err)
+		return nil, 0, user.ErrInternal
+	}
+
+	baseCountQuery := `SELECT COUNT(*) FROM users`
+	finalCountQuery, finalCountArgs, err := buildUserQuery(baseCountQuery, filter, tenantID, true)
+	if err != nil {
+		log.Println("Error during building users query:", err.Error())
+		return nil, 0, user.ErrInternal
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(
+		ctx,
+		finalCountQuery, finalCountArgs...,
+	).Scan(&total); err != nil {
+		log.Println("Error counting users:", err)
+		return nil, 0, user.ErrInternal
+	}
+
+	if total == 0 {
+		return []user.User{}, 0, nil
+	}
+	baseQuery := `SELECT * FROM users`
+	finalQuery, finalArgs, err := buildUserQuery(baseQuery, filter, tenantID, false)
+	if err != nil {
+		log.Println("Error during building users query:", err.Error())
+		return nil, 0, user.ErrInternal
+	}
+	users := []user.User{}
+	err = r.db.SelectContext(
+		ctx,
+		&users,
+		finalQuery, finalArgs...,
+	)
+	if err != nil {
+		log.Println("Error searching users:", err)
+		return nil, 0, user.ErrInternal
+	}
+	return users, total, nil
+}
+
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
 	users := []user.User{}
 	err := r.db.SelectContext(
@@ -122,3 +168,275 @@ func (r *userRepository) GetUserByID(ctx context.Context, id int64) (*user.User,
 	return &user, nil
 
 }
+
+func (r *userRepository) UpdateTwoFactor(ctx context.Context, userID int64, secret *string, enabled bool) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET totp_secret = $1,
+		     totp_enabled = $2
+		 WHERE id = $3`,
+		secret, enabled, userID,
+	)
+	if err != nil {
+		log.Println("Error updating two factor settings:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Println("Error starting recovery code transaction:", err)
+		return user.ErrInternal
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		log.Println("Error deleting recovery codes:", err)
+		return user.ErrInternal
+	}
+	for _, codeHash := range codeHashes {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, codeHash,
+		)
+		if err != nil {
+			log.Println("Error inserting recovery code:", err)
+			return user.ErrInternal
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Println("Error committing recovery codes:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE user_recovery_codes
+		 SET used_at = NOW()
+		 WHERE user_id = $1
+		   AND code_hash = $2
+		   AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		log.Println("Error consuming recovery code:", err)
+		return false, user.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, user.ErrInternal
+	}
+	return rows == 1, nil
+}
+
+// UseTOTPStep records the step of an accepted code, false means the step or a later one was
+// already used. The conditional update keeps two requests from both using one code.
+func (r *userRepository) UseTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET totp_last_step = $2
+		 WHERE id = $1
+		   AND (totp_last_step IS NULL OR totp_last_step < $2)`,
+		userID, step,
+	)
+	if err != nil {
+		log.Println("Error recording totp step:", err)
+		return false, user.ErrInternal
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Println("Error recording totp step:", err)
+		return false, user.ErrInternal
+	}
+	return rows == 1, nil
+}
+
+func (r *userRepository) GetSecurityPolicy(ctx context.Context, organisationID int64) (*user.SecurityPolicy, error) {
+	policies := []user.SecurityPolicy{}
+	err := r.db.SelectContext(
+		ctx,
+		&policies,
+		`SELECT organisation_id, require_two_factor, updated_at, updated_by
+		 FROM organisation_security_policies
+		 WHERE organisation_id = $1`,
+		organisationID,
+	)
+	if err != nil {
+		log.Println("Error fetching security policy:", err)
+		return nil, user.ErrInternal
+	}
+	// no row yet means nothing has been enforced by an owner
+	if len(policies) == 0 {
+		return &user.SecurityPolicy{OrganisationID: organisationID}, nil
+	}
+	policy := policies[0]
+	return &policy, nil
+}
+
+// RequiresTwoFactor is true when any organisation the user belongs to enforces two factor
+func (r *userRepository) RequiresTwoFactor(ctx context.Context, userID int64) (bool, error) {
+	var required bool
+	err := r.db.GetContext(
+		ctx,
+		&required,
+		`SELECT EXISTS (
+			SELECT 1
+			FROM organisation_members m
+			JOIN organisation_security_policies p ON p.organisation_id = m.organisation_id
+			WHERE m.user_id = $1
+			  AND p.require_two_factor
+		)`,
+		userID,
+	)
+	if err != nil {
+		log.Println("Error checking two factor policies:", err)
+		return false, user.ErrInternal
+	}
+	return required, nil
+}
+
+func (r *userRepository) UpdateSecurityPolicy(ctx context.Context, policy *user.SecurityPolicy) error {
+	err := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO organisation_security_policies (organisation_id, require_two_factor, updated_by, updated_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (organisation_id) DO UPDATE
+		 SET require_two_factor = EXCLUDED.require_two_factor,
+		     updated_by = EXCLUDED.updated_by,
+		     updated_at = NOW()
+		 RETURNING updated_at`,
+		policy.OrganisationID, policy.RequireTwoFactor, policy.UpdatedBy,
+	).Scan(&policy.UpdatedAt)
+	if err != nil {
+		log.Println("Error updating security policy:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, userID int64, profile *user.ProfileUpdate) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET name = $1,
+		     phone = $2,
+		     timezone = $3,
+		     language = $4
+		 WHERE id = $5`,
+		profile.Name, profile.Phone, profile.Timezone, profile.Language, userID,
+	)
+	if err != nil {
+		log.Println("Error updating profile:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) SetPendingEmail(ctx context.Context, userID int64, email *string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET pending_email = $1 WHERE id = $2`,
+		email, userID,
+	)
+	if err != nil {
+		log.Println("Error setting pending email:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateEmail(ctx context.Context, userID int64, email string) error {
+	// the address may have been registered by someone else since the change was requested
+	var exists bool
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+		SELECT 1
+		FROM users
+		WHERE email = $1
+		  AND id <> $2
+	)`,
+		email, userID,
+	).Scan(&exists)
+	if err != nil {
+		log.Println("Error checking if email exists:", err)
+		return user.ErrInternal
+	}
+	if exists {
+		return user.ErrAlreadyExists
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET email = $1,
+		     pending_email = NULL,
+		     email_verified_at = NOW()
+		 WHERE id = $2`,
+		email, userID,
+	)
+	if err != nil {
+		log.Println("Error updating email:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, userID int64) error {
+	_, err := postgres.Conn(ctx, r.db).ExecContext(
+		ctx,
+		`UPDATE users
+		 SET email_verified_at = NOW()
+		 WHERE id = $1
+		   AND email_verified_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		log.Println("Error marking email verified:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET password_hash = $1,
+		     session_generation = session_generation + 1
+		 WHERE id = $2`,
+		passwordHash, userID,
+	)
+	if err != nil {
+		log.Println("Error updating password:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
+
+func (r *userRepository) Deactivate(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users
+		 SET deactivated_at = NOW(),
+		     pending_email = NULL,
+		     session_generation = session_generation + 1
+		 WHERE id = $1
+		   AND deactivated_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		log.Println("Error deactivating user:", err)
+		return user.ErrInternal
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package access
+
+// Capability is a single permission on a property. Managers of a property hold
+// every capability, property members only the ones they were granted.
+type Capability string
+
+const (
+	CapViewBookings      Capability = "view_bookings"
+	CapEditBookings      Capability = "edit_bookings"
+	CapRecordPayments    Capability = "record_payments"
+	CapViewFinancials    Capability = "view_financials"
+	CapManageProperty    Capability = "manage_property"
+	CapManageAttachments Capability = "manage_attachments"
+)
+
+var Capabilities = []Capability{
+	CapViewBookings,
+	CapEditBookings,
+	CapRecordPayments,
+	CapViewFinancials,
+	CapManageProperty,
+	CapManageAttachments,
+}
+
+func (c Capability) Valid() bool {
+	for _, capability := range Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
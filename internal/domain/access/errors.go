@@ -0,0 +1,9 @@
+package access
+
+import (
+	"errors"
+)
+
+var (
+	ErrReadOnlyScope = errors.New("read only api key cannot modify data")
+)
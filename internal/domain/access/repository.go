@@ -5,7 +5,11 @@ import (
 )
 
 type AccessRepository interface {
-	HasManagerByPropertyID(ctx context.Context, propertyID, userID int64) (bool, error)
-	HasManagerByBookingID(ctx context.Context, bookingID, userID int64) (bool, error)
-	HasManagerByPaymentID(ctx context.Context, paymentID, userID int64) (bool, error)
+	// an empty capability matches any grant on the property
+	HasCapabilityByPropertyID(ctx context.Context, propertyID, userID int64, capability Capability) (bool, error)
+	HasCapabilityByBookingID(ctx context.Context, bookingID, userID int64, capability Capability) (bool, error)
+	HasCapabilityByPaymentID(ctx context.Context, paymentID, userID int64, capability Capability) (bool, error)
+	SharesPropertyWithUser(ctx context.Context, otherUserID, userID int64) (bool, error)
+	HasMember(ctx context.Context, userID int64) (bool, error)
+	HasAdminRole(ctx context.Context, userID int64) (bool, error)
 }
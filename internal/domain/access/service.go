@@ -2,12 +2,20 @@ package access
 
 import (
 	"context"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
 )
 
 type AccessService interface {
-	CanAccessPayment(ctx context.Context, paymentID, userID int64) (bool, error)
-	CanAccessBooking(ctx context.Context, bookingID, userID int64) (bool, error)
-	CanAccessProperty(ctx context.Context, propertyID, userID int64) (bool, error)
+	CanViewProperty(ctx context.Context, propertyID, userID int64) (bool, error)
+	HasPropertyCapability(ctx context.Context, propertyID, userID int64, capability Capability) (bool, error)
+	HasBookingCapability(ctx context.Context, bookingID, userID int64, capability Capability) (bool, error)
+	HasPaymentCapability(ctx context.Context, paymentID, userID int64, capability Capability) (bool, error)
+	CanAccessUser(ctx context.Context, otherUserID, userID int64) (bool, error)
+	IsMember(ctx context.Context, userID int64) (bool, error)
+	IsAdmin(ctx context.Context, userID int64) (bool, error)
+	CanWrite(ctx context.Context) error
 }
 
 type accessService struct {
@@ -18,24 +26,71 @@ func NewAccessService(repo AccessRepository) AccessService {
 	return &accessService{repo: repo}
 }
 
-func (s *accessService) CanAccessPayment(ctx context.Context, paymentID, userID int64) (bool, error) {
-	canAccess, err := s.repo.HasManagerByPaymentID(ctx, paymentID, userID)
+// CanViewProperty is true for managers and for members holding any capability on the property
+func (s *accessService) CanViewProperty(ctx context.Context, propertyID, userID int64) (bool, error) {
+	canView, err := s.repo.HasCapabilityByPropertyID(ctx, propertyID, userID, "")
 	if err != nil {
 		return false, err
 	}
-	return canAccess, nil
+	return canView, nil
 }
-func (s *accessService) CanAccessBooking(ctx context.Context, bookingID, userID int64) (bool, error) {
-	canAccess, err := s.repo.HasManagerByBookingID(ctx, bookingID, userID)
+func (s *accessService) HasPropertyCapability(ctx context.Context, propertyID, userID int64, capability Capability) (bool, error) {
+	hasCapability, err := s.repo.HasCapabilityByPropertyID(ctx, propertyID, userID, capability)
 	if err != nil {
 		return false, err
 	}
-	return canAccess, nil
+	return hasCapability, nil
+}
+func (s *accessService) HasBookingCapability(ctx context.Context, bookingID, userID int64, capability Capability) (bool, error) {
+	hasCapability, err := s.repo.HasCapabilityByBookingID(ctx, bookingID, userID, capability)
+	if err != nil {
+		return false, err
+	}
+	return hasCapability, nil
 }
-func (s *accessService) CanAccessProperty(ctx context.Context, propertyID, userID int64) (bool, error) {
-	canAccess, err := s.repo.HasManagerByPropertyID(ctx, propertyID, userID)
+func (s *accessService) HasPaymentCapability(ctx context.Context, paymentID, userID int64, capability Capability) (bool, error) {
+	hasCapability, err := s.repo.HasCapabilityByPaymentID(ctx, paymentID, userID, capability)
+	if err != nil {
+		return false, err
+	}
+	return hasCapability, nil
+}
+
+// CanAccessUser allows managers to see the other managers of the properties they manage
+func (s *accessService) CanAccessUser(ctx context.Context, otherUserID, userID int64) (bool, error) {
+	if otherUserID == userID {
+		return true, nil
+	}
+	canAccess, err := s.repo.SharesPropertyWithUser(ctx, otherUserID, userID)
 	if err != nil {
 		return false, err
 	}
 	return canAccess, nil
 }
+
+// IsMember reports whether the user is an active member of the request's organisation
+func (s *accessService) IsMember(ctx context.Context, userID int64) (bool, error) {
+	isMember, err := s.repo.HasMember(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return isMember, nil
+}
+
+// IsAdmin reports whether the user owns or administers the request's organisation
+func (s *accessService) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	isAdmin, err := s.repo.HasAdminRole(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return isAdmin, nil
+}
+
+// CanWrite rejects requests authenticated with a read only API key, sessions can always write
+func (s *accessService) CanWrite(ctx context.Context) error {
+	scope, ok := ctx.Value(middleware.ContextScopeKey).(string)
+	if ok && scope != auth.ScopeReadWrite {
+		return ErrReadOnlyScope
+	}
+	return nil
+}
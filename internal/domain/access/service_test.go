@@ -0,0 +1,40 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+func TestCanWrite(t *testing.T) {
+	s := &accessService{}
+	session := context.Background()
+	readOnly := context.WithValue(session, middleware.ContextScopeKey, auth.ScopeRead)
+	readWrite := context.WithValue(session, middleware.ContextScopeKey, auth.ScopeReadWrite)
+
+	if err := s.CanWrite(session); err != nil {
+		t.Errorf("CanWrite for a session = %v, want nil", err)
+	}
+	if err := s.CanWrite(readWrite); err != nil {
+		t.Errorf("CanWrite for a read_write key = %v, want nil", err)
+	}
+	if err := s.CanWrite(readOnly); !errors.Is(err, ErrReadOnlyScope) {
+		t.Errorf("CanWrite for a read key = %v, want ErrReadOnlyScope", err)
+	}
+}
+
+func TestCapabilityValid(t *testing.T) {
+	for _, capability := range Capabilities {
+		if !capability.Valid() {
+			t.Errorf("%s is not valid", capability)
+		}
+	}
+	for _, capability := range []Capability{"", "manage", "VIEW_BOOKINGS", "delete_everything"} {
+		if capability.Valid() {
+			t.Errorf("%q is valid", capability)
+		}
+	}
+}
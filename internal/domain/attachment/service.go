@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
 	"github.com/nevinmanoj/hostmate/internal/domain/booking"
 	"github.com/nevinmanoj/hostmate/internal/domain/payment"
 	"github.com/nevinmanoj/hostmate/internal/middleware"
@@ -22,11 +23,18 @@ type AttachmentService interface {
 	GetAttachments(ctx context.Context, parentType AttachmentParentType, parentID int64) ([]string, error)
 }
 
+// Transactor runs fn in one transaction that the repositories called with its context join
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type attachmentService struct {
 	accessService  access.AccessService
 	blobStorage    BlobStorage
 	paymentService payment.PaymentService
 	bookingService booking.BookingService
+	auditService   audit.AuditService
+	transactor     Transactor
 }
 
 func NewAttachmentService(
@@ -34,17 +42,23 @@ func NewAttachmentService(
 	blobStorage BlobStorage,
 	paymentService payment.PaymentService,
 	bookingService booking.BookingService,
-
+	auditService audit.AuditService,
+	transactor Transactor,
 ) AttachmentService {
 	return &attachmentService{
 		accessService:  accessService,
 		blobStorage:    blobStorage,
 		paymentService: paymentService,
 		bookingService: bookingService,
+		auditService:   auditService,
+		transactor:     transactor,
 	}
 }
 
 func (s *attachmentService) RequestUploadURL(ctx context.Context, parentType AttachmentParentType, parentID int64, fileName string) (string, string, string, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return "", "", "", err
+	}
 	//check parent access
 	err := checkParentAccess(parentType, parentID, s.accessService, ctx)
 	if err != nil {
@@ -66,6 +80,9 @@ func (s *attachmentService) RequestUploadURL(ctx context.Context, parentType Att
 	return blobName, uploadURL, expiresAtStr, err
 }
 func (s *attachmentService) ConfirmUpload(ctx context.Context, blobName string) (bool, string, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return false, "", err
+	}
 	//extract parentType and parentID from blobname
 	parentType, parentID, err := ParseBlobName(blobName)
 	if err != nil {
@@ -78,15 +95,27 @@ func (s *attachmentService) ConfirmUpload(ctx context.Context, blobName string)
 		return false, "", err
 	}
 
-	//update new blobName in parent blobs array
-	switch parentType {
-	case AttachmentParentBooking:
-		err = s.bookingService.ConfirmBlobsUpload(ctx, parentID, blobName)
-	case AttachmentParentPayment:
-		err = s.paymentService.ConfirmBlobsUpload(ctx, parentID, blobName)
-	}
+	//update new blobName in parent blobs array, attachments are audited on their parent
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var entityType audit.EntityType
+		var err error
+		switch parentType {
+		case AttachmentParentBooking:
+			entityType = audit.EntityBooking
+			err = s.bookingService.ConfirmBlobsUpload(ctx, parentID, blobName)
+		case AttachmentParentPayment:
+			entityType = audit.EntityPayment
+			err = s.paymentService.ConfirmBlobsUpload(ctx, parentID, blobName)
+		}
+		if err != nil {
+			log.Printf("Failed to update parent blobs: %v", err)
+			return err
+		}
+		return s.auditService.RecordChanges(ctx, entityType, parentID, audit.ActionAttach, audit.Changes{
+			"blobs": {After: blobName},
+		})
+	})
 	if err != nil {
-		log.Printf("Failed to update parent blobs: %v", err)
 		return false, "", err
 	}
 	// Generate temporary read URL (valid for 7 days)
@@ -132,7 +161,7 @@ func checkParentAccess(parentType AttachmentParentType, parentID int64, accessSe
 	}
 	switch parentType {
 	case AttachmentParentPayment:
-		hasAccess, err = accessService.CanAccessPayment(ctx, parentID, userID)
+		hasAccess, err = accessService.HasPaymentCapability(ctx, parentID, userID, access.CapManageAttachments)
 		if err != nil {
 			return err
 		}
@@ -140,7 +169,7 @@ func checkParentAccess(parentType AttachmentParentType, parentID int64, accessSe
 			return payment.ErrUnauthorized
 		}
 	case AttachmentParentBooking:
-		hasAccess, err = accessService.CanAccessBooking(ctx, parentID, userID)
+		hasAccess, err = accessService.HasBookingCapability(ctx, parentID, userID, access.CapManageAttachments)
 		if err != nil {
 			return err
 		}
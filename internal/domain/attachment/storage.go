@@ -10,4 +10,8 @@ type BlobStorage interface {
 	GenerateReadURL(blobName string) (string, error)
 	VerifyBlobExists(ctx context.Context, blobName string) error
 	VerifyBlobSize(ctx context.Context, blobName string) error
+	// Upload stores a file generated by the server, clients upload through GenerateUploadURL
+	Upload(ctx context.Context, blobName, contentType string, data []byte) error
+	// Delete removes a file the server uploaded but could not keep
+	Delete(ctx context.Context, blobName string) error
 }
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"reflect"
+)
+
+// fields that change on every write and carry no information of their own
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+	"updated_by": true,
+	"version":    true,
+	"deleted_at": true,
+	"deleted_by": true,
+}
+
+// Diff compares two values of the same struct type field by field using their db tags.
+// Pass nil as before for a create and nil as after for a delete.
+func Diff(before, after any) Changes {
+	changes := Changes{}
+	beforeFields := fieldsByColumn(before)
+	afterFields := fieldsByColumn(after)
+	for column, afterValue := range afterFields {
+		beforeValue, ok := beforeFields[column]
+		if ok && reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes[column] = Change{Before: beforeValue, After: afterValue}
+	}
+	for column, beforeValue := range beforeFields {
+		if _, ok := afterFields[column]; !ok {
+			changes[column] = Change{Before: beforeValue}
+		}
+	}
+	return changes
+}
+
+func fieldsByColumn(v any) map[string]any {
+	fields := map[string]any{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return fields
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fields
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		column := field.Tag.Get("db")
+		if !field.IsExported() || column == "" || column == "-" || ignoredFields[column] {
+			continue
+		}
+		fields[column] = rv.Field(i).Interface()
+	}
+	return fields
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type record struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Rate      float64   `db:"rate"`
+	Tags      []string  `db:"tags"`
+	Notes     *string   `db:"notes"`
+	UpdatedAt time.Time `db:"updated_at"`
+	UpdatedBy int64     `db:"updated_by"`
+	Version   int       `db:"version"`
+	Computed  int       `db:"-"`
+	Untagged  string
+	internal  string
+}
+
+func TestDiff(t *testing.T) {
+	note := "late arrival"
+	before := record{ID: 1, Name: "Beach house", Rate: 100, Tags: []string{"sea"}, UpdatedAt: time.Unix(1, 0), UpdatedBy: 2}
+
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		want   Changes
+	}{
+		{
+			name:   "nothing changed",
+			before: before,
+			after:  before,
+			want:   Changes{},
+		},
+		{
+			name:   "bookkeeping fields are ignored",
+			before: before,
+			after:  record{ID: 1, Name: "Beach house", Rate: 100, Tags: []string{"sea"}, UpdatedAt: time.Unix(2, 0), UpdatedBy: 3, Version: 2, Computed: 4, Untagged: "x", internal: "y"},
+			want:   Changes{},
+		},
+		{
+			name:   "changed fields only",
+			before: &before,
+			after:  &record{ID: 1, Name: "Sea view", Rate: 100, Tags: []string{"sea", "pool"}, Notes: &note},
+			want: Changes{
+				"name":  {Before: "Beach house", After: "Sea view"},
+				"tags":  {Before: []string{"sea"}, After: []string{"sea", "pool"}},
+				"notes": {Before: (*string)(nil), After: &note},
+			},
+		},
+		{
+			name:   "create",
+			before: nil,
+			after:  &record{ID: 1, Name: "Beach house"},
+			want: Changes{
+				"id":    {After: int64(1)},
+				"name":  {After: "Beach house"},
+				"rate":  {After: float64(0)},
+				"tags":  {After: []string(nil)},
+				"notes": {After: (*string)(nil)},
+			},
+		},
+		{
+			name:   "delete",
+			before: &record{ID: 1, Name: "Beach house"},
+			after:  (*record)(nil),
+			want: Changes{
+				"id":    {Before: int64(1)},
+				"name":  {Before: "Beach house"},
+				"rate":  {Before: float64(0)},
+				"tags":  {Before: []string(nil)},
+				"notes": {Before: (*string)(nil)},
+			},
+		},
+		{
+			name:   "not a struct",
+			before: "before",
+			after:  42,
+			want:   Changes{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangesScan(t *testing.T) {
+	var changes Changes
+	if err := changes.Scan([]byte(`{"name":{"before":"a","after":"b"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Changes{"name": {Before: "a", After: "b"}}); !reflect.DeepEqual(changes, want) {
+		t.Errorf("Scan = %v, want %v", changes, want)
+	}
+	if err := changes.Scan(nil); err != nil || len(changes) != 0 {
+		t.Errorf("Scan(nil) = %v, %v, want no changes", changes, err)
+	}
+	if err := changes.Scan(42); err == nil {
+		t.Error("Scan accepted an int")
+	}
+	value, err := Changes(nil).Value()
+	if err != nil || string(value.([]byte)) != "{}" {
+		t.Errorf("Value of nil changes = %s, %v, want {}", value, err)
+	}
+}
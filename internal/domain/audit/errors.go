@@ -0,0 +1,11 @@
+package audit
+
+import (
+	"errors"
+)
+
+var (
+	ErrInternal          = errors.New("internal error")
+	ErrUnauthorized      = errors.New("unauthorized to view audit log")
+	ErrInvalidEntityType = errors.New("invalid audit entity type")
+)
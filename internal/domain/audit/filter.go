@@ -0,0 +1,14 @@
+package audit
+
+import "time"
+
+type AuditFilter struct {
+	EntityType *EntityType
+	EntityID   *int64
+	ActorID    *int64
+	Action     []Action
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type EntityType string
+
+const (
+	EntityProperty EntityType = "property"
+	EntityBooking  EntityType = "booking"
+	EntityPayment  EntityType = "payment"
+	EntityCoupon   EntityType = "coupon"
+)
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionAttach  Action = "attach"
+	ActionRestore Action = "restore"
+	ActionIssue   Action = "issue"
+)
+
+// Change is the value of a single field before and after a mutation,
+// Before is nil for creates and After is nil for deletes
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Changes is keyed by the db column of the changed field
+type Changes map[string]Change
+
+// Entry is one append-only audit record, entries are never updated or deleted
+type Entry struct {
+	ID             int64      `db:"id"`
+	OrganisationID int64      `db:"organisation_id"`
+	ActorID        int64      `db:"actor_id"`
+	EntityType     EntityType `db:"entity_type"`
+	EntityID       int64      `db:"entity_id"`
+	Action         Action     `db:"action"`
+	Changes        Changes    `db:"changes"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+func (e EntityType) Valid() bool {
+	return e == EntityProperty || e == EntityBooking || e == EntityPayment || e == EntityCoupon
+}
+
+func (a Action) Valid() bool {
+	return a == ActionCreate || a == ActionUpdate || a == ActionDelete || a == ActionAttach || a == ActionRestore || a == ActionIssue
+}
+
+// Value stores changes as jsonb
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *Changes) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case nil:
+		*c = Changes{}
+		return nil
+	default:
+		return errors.New("unsupported type for audit changes")
+	}
+}
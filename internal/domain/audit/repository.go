@@ -0,0 +1,11 @@
+package audit
+
+import (
+	"context"
+)
+
+// AuditRepository only appends, there is deliberately no update or delete
+type AuditRepository interface {
+	Create(ctx context.Context, entry *Entry) error
+	GetAll(ctx context.Context, filter AuditFilter) ([]Entry, int, error)
+}
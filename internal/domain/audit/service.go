@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/organisation"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+type AuditService interface {
+	// Record and RecordChanges write into the transaction in ctx, the caller rolls its
+	// mutation back when they fail so nothing is stored without its entry
+	Record(ctx context.Context, entityType EntityType, entityID int64, action Action, before, after any) error
+	RecordChanges(ctx context.Context, entityType EntityType, entityID int64, action Action, changes Changes) error
+	GetAll(ctx context.Context, filter AuditFilter) ([]Entry, int, error)
+	GetHistory(ctx context.Context, entityType EntityType, entityID int64, limit, offset int) ([]Entry, int, error)
+}
+
+type auditService struct {
+	repo          AuditRepository
+	orgRepo       organisation.OrganisationRepository
+	accessService access.AccessService
+}
+
+func NewAuditService(repo AuditRepository, orgRepo organisation.OrganisationRepository, accessService access.AccessService) AuditService {
+	return &auditService{repo: repo, orgRepo: orgRepo, accessService: accessService}
+}
+
+// Record diffs before and after and appends an entry, updates that change nothing are skipped
+func (s *auditService) Record(ctx context.Context, entityType EntityType, entityID int64, action Action, before, after any) error {
+	return s.RecordChanges(ctx, entityType, entityID, action, Diff(before, after))
+}
+
+// RecordChanges runs in the transaction storing the mutation, an entry that cannot be
+// written fails the whole write
+func (s *auditService) RecordChanges(ctx context.Context, entityType EntityType, entityID int64, action Action, changes Changes) error {
+	if action == ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	actorID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		log.Printf("Audit entry for %s %d has no actor", entityType, entityID)
+		return ErrInternal
+	}
+	entry := &Entry{
+		ActorID:    actorID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    changes,
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		log.Printf("Error recording audit entry for %s %d: %s", entityType, entityID, err.Error())
+		return ErrInternal
+	}
+	return nil
+}
+
+// GetAll is the organisation wide log, only owners and admins can read it
+func (s *auditService) GetAll(ctx context.Context, filter AuditFilter) ([]Entry, int, error) {
+	canManage, err := s.canManageOrganisation(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !canManage {
+		return nil, 0, ErrUnauthorized
+	}
+	return s.getAll(ctx, filter)
+}
+
+// GetHistory is the log of a single entity, visible to anyone who can view the entity
+func (s *auditService) GetHistory(ctx context.Context, entityType EntityType, entityID int64, limit, offset int) ([]Entry, int, error) {
+	if !entityType.Valid() {
+		return nil, 0, ErrInvalidEntityType
+	}
+	canView, err := s.canViewEntity(ctx, entityType, entityID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !canView {
+		return nil, 0, ErrUnauthorized
+	}
+	return s.getAll(ctx, AuditFilter{
+		EntityType: &entityType,
+		EntityID:   &entityID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+}
+
+// helpers
+func (s *auditService) getAll(ctx context.Context, filter AuditFilter) ([]Entry, int, error) {
+	entries, total, err := s.repo.GetAll(ctx, filter)
+	if err != nil {
+		log.Println("Error fetching audit entries:", err)
+		return nil, 0, ErrInternal
+	}
+	return entries, total, nil
+}
+
+func (s *auditService) canManageOrganisation(ctx context.Context) (bool, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	tenantID, ok := middleware.TenantFromContext(ctx)
+	if !ok {
+		return false, organisation.ErrNoTenant
+	}
+	member, err := s.orgRepo.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, organisation.ErrNotMember) {
+			return false, nil
+		}
+		return false, ErrInternal
+	}
+	return member.Role.CanManageMembers(), nil
+}
+
+func (s *auditService) canViewEntity(ctx context.Context, entityType EntityType, entityID int64) (bool, error) {
+	canManage, err := s.canManageOrganisation(ctx)
+	if err != nil || canManage {
+		return canManage, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	var canView bool
+	switch entityType {
+	case EntityProperty:
+		canView, err = s.accessService.CanViewProperty(ctx, entityID, userID)
+	case EntityBooking:
+		canView, err = s.accessService.HasBookingCapability(ctx, entityID, userID, access.CapViewBookings)
+	case EntityPayment:
+		canView, err = s.accessService.HasPaymentCapability(ctx, entityID, userID, access.CapViewFinancials)
+	}
+	if err != nil {
+		log.Printf("Error checking access to %s %d: %s", entityType, entityID, err.Error())
+		return false, ErrInternal
+	}
+	return canView, nil
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+type fakeAuditRepo struct {
+	AuditRepository
+	entries []Entry
+	err     error
+}
+
+func (r *fakeAuditRepo) Create(ctx context.Context, entry *Entry) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func TestRecordChanges(t *testing.T) {
+	actor := context.WithValue(context.Background(), middleware.ContextUserKey, int64(7))
+	changes := Changes{"name": {Before: "Beach house", After: "Sea view"}}
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		action  Action
+		changes Changes
+		repoErr error
+		want    error
+		stored  int
+	}{
+		{"update", actor, ActionUpdate, changes, nil, nil, 1},
+		{"update that changed nothing", actor, ActionUpdate, Changes{}, nil, nil, 0},
+		{"restore without changes", actor, ActionRestore, nil, nil, nil, 1},
+		// the caller has to roll its write back, so failures are reported rather than logged away
+		{"no actor", context.Background(), ActionUpdate, changes, nil, ErrInternal, 0},
+		{"entry not stored", actor, ActionUpdate, changes, errors.New("connection reset"), ErrInternal, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAuditRepo{err: tt.repoErr}
+			s := &auditService{repo: repo}
+			if err := s.RecordChanges(tt.ctx, EntityProperty, 1, tt.action, tt.changes); !errors.Is(err, tt.want) {
+				t.Fatalf("RecordChanges = %v, want %v", err, tt.want)
+			}
+			if len(repo.entries) != tt.stored {
+				t.Fatalf("stored %d entries, want %d", len(repo.entries), tt.stored)
+			}
+			if tt.stored > 0 && repo.entries[0].ActorID != 7 {
+				t.Errorf("entry by %d, want the user of the request", repo.entries[0].ActorID)
+			}
+		})
+	}
+}
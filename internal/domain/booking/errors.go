@@ -5,9 +5,16 @@ import (
 )
 
 var (
-	ErrNotFound         = errors.New("Not found")
-	ErrInternal         = errors.New("internal error")
-	ErrUnauthorized     = errors.New("unauthorized")
-	ErrInvalidDateRange = errors.New("invalid date range")
-	ErrBookingConflict  = errors.New("booking conflict")
+	ErrNotFound             = errors.New("Not found")
+	ErrInternal             = errors.New("internal error")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrInvalidDateRange     = errors.New("invalid date range")
+	ErrBookingConflict      = errors.New("booking conflict")
+	ErrVersionConflict      = errors.New("booking was modified since it was read")
+	ErrRestoreWindowExpired = errors.New("booking was deleted too long ago to be restored")
+
+	ErrAdjustmentNotFound       = errors.New("adjustment not found")
+	ErrInvalidAdjustment        = errors.New("invalid adjustment")
+	ErrAdjustmentReasonRequired = errors.New("adjustment needs a reason")
+	ErrCouponAlreadyApplied     = errors.New("coupon is already applied to the booking")
 )
@@ -1,18 +1,30 @@
 package booking
 
-import "time"
+import (
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
+)
+
+// SortFields are the fields a booking list can be sorted on
+var SortFields = []string{"created_at", "check_in_date", "check_out_date", "guest_name", "base_rate"}
 
 type BookingFilter struct {
-	Id         *int64
-	UserID     *int64
-	PropertyID []int64
-	Status     []BookingStatus
-	ManagerID  *int64
-	BookedFrom *time.Time
-	BookedTo   *time.Time
-	StayFrom   *time.Time
-	StayTo     *time.Time
-	GuestPhone *string
-	Limit      int
-	Offset     int
+	Id             *int64
+	UserID         *int64
+	PropertyID     []int64
+	Status         []BookingStatus
+	ManagerID      *int64
+	BookedFrom     *time.Time
+	BookedTo       *time.Time
+	StayFrom       *time.Time
+	StayTo         *time.Time
+	GuestPhone     *string
+	Query          *string
+	IncludeDeleted bool
+	Sort           pagination.Sort
+	Cursor         *pagination.Cursor
+	SkipCount      bool
+	Limit          int
+	Offset         int
 }
@@ -1,7 +1,11 @@
 package booking
 
 import (
+	"math"
 	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	"github.com/nevinmanoj/hostmate/internal/domain/tax"
 )
 
 type BookingStatus string
@@ -15,6 +19,7 @@ const (
 
 type Booking struct {
 	ID                int64         `db:"id"`
+	OrganisationID    int64         `db:"organisation_id"`
 	PropertyID        int64         `db:"property_id"`
 	ManagerID         int64         `db:"manager_id"`
 	GuestPhone        string        `db:"guest_phone"`
@@ -32,4 +37,162 @@ type Booking struct {
 	CreatedBy         int64         `db:"created_by"`
 	UpdatedBy         int64         `db:"updated_by"`
 	Remarks           string        `db:"remarks"`
+	DiscountAmount    float64       `db:"discount_amount"`
+	ChargeAmount      float64       `db:"charge_amount"`
+	TaxRate           float64       `db:"tax_rate"`
+	TaxInclusive      bool          `db:"tax_inclusive"`
+	TaxableAmount     float64       `db:"taxable_amount"`
+	CGST              float64       `db:"cgst"`
+	SGST              float64       `db:"sgst"`
+	IGST              float64       `db:"igst"`
+	TotalAmount       float64       `db:"total_amount"`
+	Revision          int           `db:"revision"`
+	Version           int           `db:"version"`
+	DeletedAt         *time.Time    `db:"deleted_at"`
+	DeletedBy         *int64        `db:"deleted_by"`
+}
+
+// Nights counts calendar nights, so the check in and check out times do not matter
+func (b *Booking) Nights() int {
+	checkIn := time.Date(b.CheckInDate.Year(), b.CheckInDate.Month(), b.CheckInDate.Day(), 0, 0, 0, 0, time.UTC)
+	checkOut := time.Date(b.CheckOutDate.Year(), b.CheckOutDate.Month(), b.CheckOutDate.Day(), 0, 0, 0, 0, time.UTC)
+	return int(checkOut.Sub(checkIn).Hours() / 24)
+}
+
+// NightlyTariff is the base rate plus the extra rate for every guest over the base count
+func (b *Booking) NightlyTariff() float64 {
+	tariff := b.BaseRate
+	if extraGuests := b.NumGuests - b.MaxGuestsBase; extraGuests > 0 {
+		tariff += float64(extraGuests) * b.ExtraRatePerGuest
+	}
+	return tariff
+}
+
+// Price works out what the guest owes, the adjustments change the stay before GST and
+// discounts never take it below zero. The GST slab goes by the discounted nightly tariff.
+func (b *Booking) Price(adjustments []Adjustment, config *tax.Config) {
+	nights := b.Nights()
+	tariff := b.NightlyTariff()
+	stay := float64(nights) * tariff
+	discount, charge := 0.0, 0.0
+	for _, adjustment := range adjustments {
+		if adjustment.Kind == AdjustmentDiscount {
+			discount += adjustment.AmountOn(stay)
+		} else {
+			charge += adjustment.AmountOn(stay)
+		}
+	}
+	b.DiscountAmount = round(math.Min(discount, stay))
+	b.ChargeAmount = round(charge)
+	if nights > 0 {
+		tariff = (stay - b.DiscountAmount) / float64(nights)
+	}
+	b.SetTax(config.Apply(tariff, stay-b.DiscountAmount+b.ChargeAmount))
+}
+
+// SetTax keeps the GST worked out for the booking, TotalAmount is what the guest owes
+func (b *Booking) SetTax(breakdown tax.Breakdown) {
+	b.TaxRate = breakdown.Rate
+	b.TaxInclusive = breakdown.Inclusive
+	b.TaxableAmount = breakdown.Taxable
+	b.CGST = breakdown.CGST
+	b.SGST = breakdown.SGST
+	b.IGST = breakdown.IGST
+	b.TotalAmount = breakdown.Total
+}
+
+// BookingTerms are what was agreed with the guest, every revision keeps a copy
+type BookingTerms struct {
+	PropertyID        int64         `db:"property_id"`
+	ManagerID         int64         `db:"manager_id"`
+	GuestPhone        string        `db:"guest_phone"`
+	GuestName         string        `db:"guest_name"`
+	BaseRate          float64       `db:"base_rate"`
+	MaxGuestsBase     int           `db:"max_guests_base"`
+	ExtraRatePerGuest float64       `db:"extra_rate_per_guest"`
+	NumGuests         int           `db:"num_guests"`
+	Status            BookingStatus `db:"status"`
+	CheckInDate       time.Time     `db:"check_in_date"`
+	CheckOutDate      time.Time     `db:"check_out_date"`
+	Remarks           string        `db:"remarks"`
+}
+
+// BookingRevision is the booking as it stood after a create or amendment,
+// Changes is filled in against the previous revision when history is read
+type BookingRevision struct {
+	ID             int64 `db:"id"`
+	BookingID      int64 `db:"booking_id"`
+	OrganisationID int64 `db:"organisation_id"`
+	Revision       int   `db:"revision"`
+	BookingTerms
+	Reason    string        `db:"reason"`
+	ChangedBy int64         `db:"changed_by"`
+	CreatedAt time.Time     `db:"created_at"`
+	Changes   audit.Changes `db:"-"`
+}
+
+type AdjustmentKind string
+
+const (
+	AdjustmentDiscount AdjustmentKind = "discount"
+	AdjustmentCharge   AdjustmentKind = "charge"
+)
+
+func (k AdjustmentKind) Valid() bool {
+	return k == AdjustmentDiscount || k == AdjustmentCharge
+}
+
+type AdjustmentBasis string
+
+const (
+	AdjustmentPercent AdjustmentBasis = "percent"
+	AdjustmentFlat    AdjustmentBasis = "flat"
+)
+
+func (b AdjustmentBasis) Valid() bool {
+	return b == AdjustmentPercent || b == AdjustmentFlat
+}
+
+// Adjustment is a discount or an extra charge on a booking, like a negotiated rate, a coupon,
+// a late checkout or damages. Percentages are of the stay, so they follow changes to it.
+type Adjustment struct {
+	ID             int64           `db:"id"`
+	OrganisationID int64           `db:"organisation_id"`
+	BookingID      int64           `db:"booking_id"`
+	Kind           AdjustmentKind  `db:"kind"`
+	Basis          AdjustmentBasis `db:"basis"`
+	Value          float64         `db:"value"`
+	CouponID       *int64          `db:"coupon_id"`
+	CouponCode     *string         `db:"coupon_code"`
+	Reason         string          `db:"reason"`
+	CreatedBy      int64           `db:"created_by"`
+	CreatedAt      time.Time       `db:"created_at"`
+	DeletedAt      *time.Time      `db:"deleted_at"`
+	DeletedBy      *int64          `db:"deleted_by"`
+}
+
+// AmountOn is the adjustment in money on a stay priced at stay
+func (a *Adjustment) AmountOn(stay float64) float64 {
+	if a.Basis == AdjustmentPercent {
+		return round(stay * a.Value / 100)
+	}
+	return round(a.Value)
+}
+
+func (a *Adjustment) Validate() error {
+	if !a.Kind.Valid() || !a.Basis.Valid() {
+		return ErrInvalidAdjustment
+	}
+	if a.Value <= 0 || (a.Basis == AdjustmentPercent && a.Value > 100) {
+		return ErrInvalidAdjustment
+	}
+	if a.Reason == "" {
+		return ErrAdjustmentReasonRequired
+	}
+	return nil
+}
+
+// round keeps amounts to the paisa
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
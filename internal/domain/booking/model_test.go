@@ -0,0 +1,150 @@
+package booking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/tax"
+)
+
+func stay(nights int, baseRate float64) *Booking {
+	checkIn := time.Date(2026, time.March, 10, 14, 0, 0, 0, time.UTC)
+	return &Booking{
+		BaseRate:      baseRate,
+		MaxGuestsBase: 2,
+		NumGuests:     2,
+		CheckInDate:   checkIn,
+		CheckOutDate:  checkIn.AddDate(0, 0, nights).Add(-3 * time.Hour),
+	}
+}
+
+func TestPrice(t *testing.T) {
+	gst := &tax.Config{Supply: tax.SupplyIntraState, Slabs: tax.DefaultSlabs()}
+	withExtraGuests := stay(3, 2000)
+	withExtraGuests.NumGuests = 4
+	withExtraGuests.ExtraRatePerGuest = 500
+
+	tests := []struct {
+		name        string
+		booking     *Booking
+		adjustments []Adjustment
+		config      *tax.Config
+		discount    float64
+		charge      float64
+		rate        float64
+		taxable     float64
+		cgst        float64
+		total       float64
+	}{
+		{
+			name:    "plain stay without GST",
+			booking: stay(2, 3000),
+			taxable: 6000,
+			total:   6000,
+		},
+		{
+			name:    "extra guests are charged every night",
+			booking: withExtraGuests,
+			taxable: 9000,
+			total:   9000,
+		},
+		{
+			name:    "same day stay costs nothing",
+			booking: stay(0, 3000),
+			config:  gst,
+			rate:    5,
+		},
+		{
+			name:        "percent discount comes off before GST",
+			booking:     stay(2, 5000),
+			adjustments: []Adjustment{{Kind: AdjustmentDiscount, Basis: AdjustmentPercent, Value: 10}},
+			config:      gst,
+			discount:    1000,
+			rate:        5,
+			taxable:     9000,
+			cgst:        225,
+			total:       9450,
+		},
+		{
+			name:        "discount moves the stay into a lower slab",
+			booking:     stay(2, 8000),
+			adjustments: []Adjustment{{Kind: AdjustmentDiscount, Basis: AdjustmentFlat, Value: 2000}},
+			config:      gst,
+			discount:    2000,
+			rate:        5,
+			taxable:     14000,
+			cgst:        350,
+			total:       14700,
+		},
+		{
+			name:        "discount never takes the stay below zero",
+			booking:     stay(2, 1000),
+			adjustments: []Adjustment{{Kind: AdjustmentDiscount, Basis: AdjustmentFlat, Value: 5000}},
+			discount:    2000,
+		},
+		{
+			name:        "charges are taxed but do not pick the slab",
+			booking:     stay(1, 7500),
+			adjustments: []Adjustment{{Kind: AdjustmentCharge, Basis: AdjustmentFlat, Value: 1000}},
+			config:      gst,
+			charge:      1000,
+			rate:        5,
+			taxable:     8500,
+			cgst:        212.5,
+			total:       8925,
+		},
+		{
+			name:    "percent adjustments are taken on the stay only",
+			booking: stay(4, 2500),
+			adjustments: []Adjustment{
+				{Kind: AdjustmentCharge, Basis: AdjustmentFlat, Value: 400},
+				{Kind: AdjustmentDiscount, Basis: AdjustmentPercent, Value: 25},
+				{Kind: AdjustmentCharge, Basis: AdjustmentPercent, Value: 10},
+			},
+			discount: 2500,
+			charge:   1400,
+			taxable:  8900,
+			total:    8900,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.booking
+			b.Price(tt.adjustments, tt.config)
+			if b.DiscountAmount != tt.discount || b.ChargeAmount != tt.charge {
+				t.Errorf("discount, charge = %v, %v, want %v, %v", b.DiscountAmount, b.ChargeAmount, tt.discount, tt.charge)
+			}
+			if b.TaxRate != tt.rate || b.TaxableAmount != tt.taxable || b.CGST != tt.cgst || b.SGST != tt.cgst || b.IGST != 0 {
+				t.Errorf("rate, taxable, cgst, sgst, igst = %v, %v, %v, %v, %v, want %v, %v, %v, %v, 0",
+					b.TaxRate, b.TaxableAmount, b.CGST, b.SGST, b.IGST, tt.rate, tt.taxable, tt.cgst, tt.cgst)
+			}
+			if b.TotalAmount != tt.total {
+				t.Errorf("total = %v, want %v", b.TotalAmount, tt.total)
+			}
+		})
+	}
+}
+
+func TestNights(t *testing.T) {
+	b := stay(2, 1000)
+	if got := b.Nights(); got != 2 {
+		t.Errorf("Nights = %d, want 2", got)
+	}
+	// a late check out still counts the calendar nights
+	b.CheckOutDate = b.CheckOutDate.Add(12 * time.Hour)
+	if got := b.Nights(); got != 2 {
+		t.Errorf("Nights with a late check out = %d, want 2", got)
+	}
+}
+
+func TestNightlyTariff(t *testing.T) {
+	b := stay(1, 2000)
+	b.ExtraRatePerGuest = 500
+	if got := b.NightlyTariff(); got != 2000 {
+		t.Errorf("NightlyTariff within the base count = %v, want 2000", got)
+	}
+	b.NumGuests = 4
+	if got := b.NightlyTariff(); got != 3000 {
+		t.Errorf("NightlyTariff with 2 extra guests = %v, want 3000", got)
+	}
+}
@@ -3,17 +3,33 @@ package booking
 import (
 	"context"
 	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 )
 
 type BookingReadRepository interface {
-	GetAll(ctx context.Context, filter BookingFilter) ([]Booking, int, error)
+	GetAll(ctx context.Context, filter BookingFilter) ([]Booking, int, pagination.Cursors, error)
 	GetByID(ctx context.Context, id int64) (*Booking, error)
 	CheckAvailability(ctx context.Context, propertyID int64, checkInDate, checkOutDate time.Time) (bool, error)
 	GetBlobs(ctx context.Context, bookingID int64) ([]string, error)
+	GetRevisions(ctx context.Context, bookingID int64) ([]BookingRevision, error)
+	GetDeletedByID(ctx context.Context, id int64) (*Booking, error)
+	GetAdjustments(ctx context.Context, bookingID int64) ([]Adjustment, error)
 }
 type BookingWriteRepository interface {
 	BookingReadRepository
+	// Create and Update store a revision of the booking in the same transaction
 	Create(ctx context.Context, booking *Booking) error
-	Update(ctx context.Context, booking *Booking) error
+	Update(ctx context.Context, booking *Booking, reason string) error
 	AppendBlobs(ctx context.Context, bookingID int64, blobName string) error
+	Delete(ctx context.Context, id, deletedBy int64) error
+	Restore(ctx context.Context, id, restoredBy int64) error
+	// AddAdjustment and RemoveAdjustment store the booking priced with the change in the same transaction
+	AddAdjustment(ctx context.Context, adjustment *Adjustment, priced *Booking) error
+	RemoveAdjustment(ctx context.Context, adjustmentID, removedBy int64, priced *Booking) error
+}
+
+// Transactor runs fn in one transaction, repositories called with the ctx it gets join in
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
@@ -2,46 +2,73 @@ package booking
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	"github.com/nevinmanoj/hostmate/internal/domain/coupon"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	"github.com/nevinmanoj/hostmate/internal/domain/property"
+	"github.com/nevinmanoj/hostmate/internal/domain/tax"
 	"github.com/nevinmanoj/hostmate/internal/middleware"
 )
 
 type BookingService interface {
-	GetAll(ctx context.Context, filter BookingFilter) ([]Booking, int, error)
+	GetAll(ctx context.Context, filter BookingFilter) ([]Booking, int, pagination.Cursors, error)
 	GetById(ctx context.Context, id int64) (*Booking, error)
 	Create(ctx context.Context, booking *Booking) error
-	Update(ctx context.Context, booking *Booking) error
+	Update(ctx context.Context, booking *Booking, reason string) error
 	CheckAvailability(ctx context.Context, propertyID int64, startDate, endDate time.Time) (bool, error)
 	ConfirmBlobsUpload(ctx context.Context, bookingID int64, blobName string) error
 	GetBlobs(ctx context.Context, bookingID int64) ([]string, error)
+	GetHistory(ctx context.Context, bookingID int64) ([]BookingRevision, error)
+	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64) (*Booking, error)
+	GetAdjustments(ctx context.Context, bookingID int64) ([]Adjustment, error)
+	AddAdjustment(ctx context.Context, bookingID int64, adjustment *Adjustment) (*Booking, error)
+	RemoveAdjustment(ctx context.Context, bookingID, adjustmentID int64) (*Booking, error)
 }
 
+// deleted bookings can be restored for this long, afterwards they stay archived
+const restoreWindow = 30 * 24 * time.Hour
+
 type bookingService struct {
 	repo          BookingWriteRepository
 	propertyRepo  property.PropertyReadRepository
+	taxRepo       tax.TaxRepository
+	couponRepo    coupon.CouponRepository
 	accessService access.AccessService
+	auditService  audit.AuditService
+	transactor    Transactor
 }
 
-func NewBookingService(repo BookingWriteRepository, propertyRepo property.PropertyReadRepository, accessService access.AccessService) BookingService {
-	return &bookingService{repo: repo, propertyRepo: propertyRepo, accessService: accessService}
+func NewBookingService(repo BookingWriteRepository, propertyRepo property.PropertyReadRepository, taxRepo tax.TaxRepository, couponRepo coupon.CouponRepository, accessService access.AccessService, auditService audit.AuditService, transactor Transactor) BookingService {
+	return &bookingService{repo: repo, propertyRepo: propertyRepo, taxRepo: taxRepo, couponRepo: couponRepo, accessService: accessService, auditService: auditService, transactor: transactor}
 }
-func (s *bookingService) GetAll(ctx context.Context, filter BookingFilter) ([]Booking, int, error) {
+func (s *bookingService) GetAll(ctx context.Context, filter BookingFilter) ([]Booking, int, pagination.Cursors, error) {
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	if filter.IncludeDeleted {
+		isAdmin, err := s.accessService.IsAdmin(ctx, userID)
+		if err != nil {
+			return nil, 0, pagination.Cursors{}, ErrInternal
+		}
+		if !isAdmin {
+			return nil, 0, pagination.Cursors{}, ErrUnauthorized
+		}
+	}
 	// TODO setup admin bypass
 	filter.UserID = &userID
-	data, total, err := s.repo.GetAll(ctx, filter)
+	data, total, cursors, err := s.repo.GetAll(ctx, filter)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, pagination.Cursors{}, err
 	}
-	return data, total, nil
+	return data, total, cursors, nil
 }
 
 func (s *bookingService) GetById(ctx context.Context, id int64) (*Booking, error) {
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessBooking(ctx, id, userID)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, id, userID, access.CapViewBookings)
 	if err != nil {
 		return nil, err
 	}
@@ -57,38 +84,53 @@ func (s *bookingService) GetById(ctx context.Context, id int64) (*Booking, error
 }
 
 func (s *bookingService) Create(ctx context.Context, booking *Booking) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 	// Validate property fields as needed, managers
 	createdBy, ok := ctx.Value(middleware.ContextUserKey).(int64)
 	if !ok {
 		return ErrInternal
 	}
-	hasAccess, err := s.accessService.CanAccessProperty(ctx, booking.PropertyID, createdBy)
+	hasAccess, err := s.accessService.HasPropertyCapability(ctx, booking.PropertyID, createdBy, access.CapEditBookings)
 	if err != nil {
 		return err
 	}
 	if !hasAccess {
 		return ErrUnauthorized
 	}
+	// deleted properties take no new bookings
+	if _, err := s.propertyRepo.GetByID(ctx, booking.PropertyID); err != nil {
+		return err
+	}
 
 	//check if booking dates are valid
 	if !booking.CheckInDate.Before(booking.CheckOutDate) {
 		return ErrInvalidDateRange
 	}
+	if err := s.price(ctx, booking, nil); err != nil {
+		return err
+	}
 	//check property availability for the booking dates can be added here
 	booking.CreatedBy = createdBy
 	booking.UpdatedBy = createdBy
 	booking.ManagerID = createdBy
-	err = s.repo.Create(ctx, booking)
-	if err != nil {
-		return err
-	}
-	return nil
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, booking); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, audit.EntityBooking, booking.ID, audit.ActionCreate, nil, booking)
+	})
 }
 
-func (s *bookingService) Update(ctx context.Context, booking *Booking) error {
+// Update amends the booking, the reason is kept with the new revision
+func (s *bookingService) Update(ctx context.Context, booking *Booking, reason string) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 	// Validate booking fields as needed, managers,images should exist
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessBooking(ctx, booking.ID, userID)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, booking.ID, userID, access.CapEditBookings)
 	if err != nil {
 		return err
 	}
@@ -101,24 +143,49 @@ func (s *bookingService) Update(ctx context.Context, booking *Booking) error {
 		//no such booking
 		return err
 	}
+	// the client must have seen the latest version of the booking
+	if booking.Version != bookingFromDb.Version {
+		return ErrVersionConflict
+	}
+	// moving the booking to another property needs the same grant there as creating it would
+	if booking.PropertyID != bookingFromDb.PropertyID {
+		hasAccess, err := s.accessService.HasPropertyCapability(ctx, booking.PropertyID, userID, access.CapEditBookings)
+		if err != nil {
+			return err
+		}
+		if !hasAccess {
+			return ErrUnauthorized
+		}
+		if _, err := s.propertyRepo.GetByID(ctx, booking.PropertyID); err != nil {
+			return err
+		}
+	}
 
 	//check if booking dates are valid
 	if !booking.CheckInDate.Before(booking.CheckOutDate) {
 		return ErrInvalidDateRange
 	}
-	booking.CreatedBy = bookingFromDb.CreatedBy
-	booking.CreatedAt = bookingFromDb.CreatedAt
-	booking.UpdatedBy = userID
-	err = s.repo.Update(ctx, booking)
+	adjustments, err := s.repo.GetAdjustments(ctx, booking.ID)
 	if err != nil {
 		return err
 	}
-	return nil
+	if err := s.price(ctx, booking, adjustments); err != nil {
+		return err
+	}
+	booking.CreatedBy = bookingFromDb.CreatedBy
+	booking.CreatedAt = bookingFromDb.CreatedAt
+	booking.UpdatedBy = userID
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, booking, strings.TrimSpace(reason)); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, audit.EntityBooking, booking.ID, audit.ActionUpdate, bookingFromDb, booking)
+	})
 }
 
 func (s *bookingService) CheckAvailability(ctx context.Context, propertyID int64, startDate, endDate time.Time) (bool, error) {
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessProperty(ctx, propertyID, userID)
+	hasAccess, err := s.accessService.HasPropertyCapability(ctx, propertyID, userID, access.CapViewBookings)
 	if err != nil {
 		return false, err
 	}
@@ -131,8 +198,11 @@ func (s *bookingService) CheckAvailability(ctx context.Context, propertyID int64
 }
 
 func (s *bookingService) ConfirmBlobsUpload(ctx context.Context, bookingID int64, blobName string) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessBooking(ctx, bookingID, userID)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapManageAttachments)
 	if err != nil {
 		return err
 	}
@@ -148,7 +218,7 @@ func (s *bookingService) ConfirmBlobsUpload(ctx context.Context, bookingID int64
 
 func (s *bookingService) GetBlobs(ctx context.Context, bookingID int64) ([]string, error) {
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessBooking(ctx, bookingID, userID)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapViewBookings)
 	if err != nil {
 		return nil, err
 	}
@@ -161,3 +231,249 @@ func (s *bookingService) GetBlobs(ctx context.Context, bookingID int64) ([]strin
 	}
 	return blobs, nil
 }
+
+// GetHistory lists every revision of the booking, oldest first, with the changes each one made
+func (s *bookingService) GetHistory(ctx context.Context, bookingID int64) ([]BookingRevision, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapViewBookings)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, ErrUnauthorized
+	}
+	revisions, err := s.repo.GetRevisions(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(revisions); i++ {
+		revisions[i].Changes = audit.Diff(revisions[i-1].BookingTerms, revisions[i].BookingTerms)
+	}
+	return revisions, nil
+}
+
+// Delete archives the booking, it can be restored within the restore window
+func (s *bookingService) Delete(ctx context.Context, id int64) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, id, userID, access.CapEditBookings)
+	if err != nil {
+		return err
+	}
+	if !hasAccess {
+		return ErrUnauthorized
+	}
+	bookingFromDb, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, audit.EntityBooking, id, audit.ActionDelete, bookingFromDb, nil)
+	})
+}
+
+func (s *bookingService) Restore(ctx context.Context, id int64) (*Booking, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, id, userID, access.CapEditBookings)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, ErrUnauthorized
+	}
+	deleted, err := s.repo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(*deleted.DeletedAt) > restoreWindow {
+		return nil, ErrRestoreWindowExpired
+	}
+	// the property has to be restored first
+	if _, err := s.propertyRepo.GetByID(ctx, deleted.PropertyID); err != nil {
+		return nil, err
+	}
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.auditService.RecordChanges(ctx, audit.EntityBooking, id, audit.ActionRestore, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *bookingService) GetAdjustments(ctx context.Context, bookingID int64) ([]Adjustment, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapViewBookings)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, ErrUnauthorized
+	}
+	return s.repo.GetAdjustments(ctx, bookingID)
+}
+
+// AddAdjustment prices the booking again with the adjustment added. With a coupon code the
+// coupon decides the discount, the reason defaults to the code.
+func (s *bookingService) AddAdjustment(ctx context.Context, bookingID int64, adjustment *Adjustment) (*Booking, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapEditBookings)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, ErrUnauthorized
+	}
+	adjustment.Reason = strings.TrimSpace(adjustment.Reason)
+	adjustment.BookingID = bookingID
+	adjustment.CreatedBy = userID
+
+	var bookingFromDb *Booking
+	var priced Booking
+	// the coupon row stays locked until the adjustment and its audit entry are stored,
+	// so two bookings cannot both take the last use of a coupon
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		bookingFromDb, err = s.repo.GetByID(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		adjustments, err := s.repo.GetAdjustments(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		if adjustment.CouponCode != nil {
+			if err := s.applyCoupon(ctx, bookingFromDb, adjustments, adjustment); err != nil {
+				return err
+			}
+		}
+		if err := adjustment.Validate(); err != nil {
+			return err
+		}
+		priced = *bookingFromDb
+		if err := s.price(ctx, &priced, append(adjustments, *adjustment)); err != nil {
+			return err
+		}
+		priced.UpdatedBy = userID
+		if err := s.repo.AddAdjustment(ctx, adjustment, &priced); err != nil {
+			return err
+		}
+		changes := audit.Diff(bookingFromDb, &priced)
+		changes["adjustment"] = audit.Change{After: adjustment}
+		return s.auditService.RecordChanges(ctx, audit.EntityBooking, bookingID, audit.ActionUpdate, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &priced, nil
+}
+
+// applyCoupon turns the adjustment into the discount of its coupon code, the coupon is
+// locked for the rest of the transaction in ctx so its uses cannot change underneath
+func (s *bookingService) applyCoupon(ctx context.Context, bookingFromDb *Booking, adjustments []Adjustment, adjustment *Adjustment) error {
+	code := coupon.NormalizeCode(*adjustment.CouponCode)
+	c, err := s.couponRepo.GetByCodeForUpdate(ctx, code)
+	if err != nil {
+		return err
+	}
+	if err := c.Redeemable(bookingFromDb.PropertyID, time.Now()); err != nil {
+		return err
+	}
+	for _, existing := range adjustments {
+		if existing.CouponID != nil && *existing.CouponID == c.ID {
+			return ErrCouponAlreadyApplied
+		}
+	}
+	adjustment.Kind = AdjustmentDiscount
+	adjustment.Basis = AdjustmentFlat
+	if c.Percent {
+		adjustment.Basis = AdjustmentPercent
+	}
+	adjustment.Value = c.Value
+	adjustment.CouponID = &c.ID
+	adjustment.CouponCode = &c.Code
+	if adjustment.Reason == "" {
+		adjustment.Reason = "Coupon " + c.Code
+	}
+	return nil
+}
+
+// RemoveAdjustment takes the adjustment off the booking, it is kept as deleted for the record
+func (s *bookingService) RemoveAdjustment(ctx context.Context, bookingID, adjustmentID int64) (*Booking, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapEditBookings)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, ErrUnauthorized
+	}
+	bookingFromDb, err := s.repo.GetByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := s.repo.GetAdjustments(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	var removed *Adjustment
+	remaining := []Adjustment{}
+	for i := range adjustments {
+		if adjustments[i].ID == adjustmentID {
+			removed = &adjustments[i]
+			continue
+		}
+		remaining = append(remaining, adjustments[i])
+	}
+	if removed == nil {
+		return nil, ErrAdjustmentNotFound
+	}
+
+	priced := *bookingFromDb
+	if err := s.price(ctx, &priced, remaining); err != nil {
+		return nil, err
+	}
+	priced.UpdatedBy = userID
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.RemoveAdjustment(ctx, adjustmentID, userID, &priced); err != nil {
+			return err
+		}
+		changes := audit.Diff(bookingFromDb, &priced)
+		changes["adjustment"] = audit.Change{Before: removed}
+		return s.auditService.RecordChanges(ctx, audit.EntityBooking, bookingID, audit.ActionUpdate, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &priced, nil
+}
+
+// price works out the booking total with the GST rules the property has now, a property
+// without rules charges no tax
+func (s *bookingService) price(ctx context.Context, booking *Booking, adjustments []Adjustment) error {
+	config, err := s.taxRepo.GetConfig(ctx, booking.PropertyID)
+	if err == tax.ErrNotFound {
+		config = nil
+	} else if err != nil {
+		return err
+	}
+	booking.Price(adjustments, config)
+	return nil
+}
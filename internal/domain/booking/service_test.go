@@ -0,0 +1,263 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	"github.com/nevinmanoj/hostmate/internal/domain/coupon"
+	property "github.com/nevinmanoj/hostmate/internal/domain/property"
+	"github.com/nevinmanoj/hostmate/internal/domain/tax"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// fakeAccess lets the listed users view every booking, editors holds the users with
+// edit_bookings on each property
+type fakeAccess struct {
+	access.AccessService
+	viewers []int64
+	editors map[int64][]int64
+}
+
+func (a *fakeAccess) CanWrite(ctx context.Context) error {
+	return nil
+}
+
+func (a *fakeAccess) HasPropertyCapability(ctx context.Context, propertyID, userID int64, capability access.Capability) (bool, error) {
+	return slices.Contains(a.editors[propertyID], userID), nil
+}
+
+func (a *fakeAccess) HasBookingCapability(ctx context.Context, bookingID, userID int64, capability access.Capability) (bool, error) {
+	for _, viewer := range a.viewers {
+		if viewer == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeBookingRepo holds the revisions of a single booking, methods the tests do not need are left to the nil interface
+type fakeBookingRepo struct {
+	BookingWriteRepository
+	booking     *Booking
+	revisions   []BookingRevision
+	adjustments []Adjustment
+}
+
+func (r *fakeBookingRepo) GetAdjustments(ctx context.Context, bookingID int64) ([]Adjustment, error) {
+	return r.adjustments, nil
+}
+
+func (r *fakeBookingRepo) AddAdjustment(ctx context.Context, adjustment *Adjustment, priced *Booking) error {
+	r.adjustments = append(r.adjustments, *adjustment)
+	return nil
+}
+
+// fakePropertyRepo knows no property
+type fakePropertyRepo struct {
+	property.PropertyReadRepository
+}
+
+func (r *fakePropertyRepo) GetByID(ctx context.Context, id int64) (*property.Property, error) {
+	return nil, property.ErrNotFound
+}
+
+func (r *fakeBookingRepo) GetByID(ctx context.Context, id int64) (*Booking, error) {
+	if r.booking == nil || r.booking.ID != id {
+		return nil, ErrNotFound
+	}
+	b := *r.booking
+	return &b, nil
+}
+
+func (r *fakeBookingRepo) GetRevisions(ctx context.Context, bookingID int64) ([]BookingRevision, error) {
+	return r.revisions, nil
+}
+
+// txKey marks the context a fakeTransactor runs its function with
+type txKey struct{}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+// fakeCouponRepo holds a single coupon and fails a lock taken outside a transaction
+type fakeCouponRepo struct {
+	coupon.CouponRepository
+	coupon coupon.Coupon
+}
+
+func (r *fakeCouponRepo) GetByCodeForUpdate(ctx context.Context, code string) (*coupon.Coupon, error) {
+	if ctx.Value(txKey{}) == nil {
+		return nil, errors.New("coupon locked outside a transaction")
+	}
+	if code != r.coupon.Code {
+		return nil, coupon.ErrNotFound
+	}
+	c := r.coupon
+	return &c, nil
+}
+
+// noTax is a property without GST registration
+type noTax struct {
+	tax.TaxRepository
+}
+
+func (noTax) GetConfig(ctx context.Context, propertyID int64) (*tax.Config, error) {
+	return nil, tax.ErrNotFound
+}
+
+type fakeAudit struct {
+	audit.AuditService
+}
+
+func (fakeAudit) RecordChanges(ctx context.Context, entityType audit.EntityType, entityID int64, action audit.Action, changes audit.Changes) error {
+	return nil
+}
+
+func userContext(userID int64) context.Context {
+	return context.WithValue(context.Background(), middleware.ContextUserKey, userID)
+}
+
+func TestGetHistory(t *testing.T) {
+	checkIn := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	terms := BookingTerms{
+		PropertyID:   1,
+		GuestName:    "Asha Menon",
+		BaseRate:     100,
+		NumGuests:    2,
+		Status:       BookingBooked,
+		CheckInDate:  checkIn,
+		CheckOutDate: checkIn.AddDate(0, 0, 3),
+	}
+	amended := terms
+	amended.NumGuests = 3
+	amended.CheckOutDate = checkIn.AddDate(0, 0, 4)
+	cancelled := amended
+	cancelled.Status = BookingCancelled
+
+	repo := &fakeBookingRepo{revisions: []BookingRevision{
+		{Revision: 1, BookingTerms: terms},
+		{Revision: 2, BookingTerms: amended, Reason: "guest stays a night longer"},
+		{Revision: 3, BookingTerms: cancelled, Reason: "flight cancelled"},
+	}}
+	s := &bookingService{repo: repo, accessService: &fakeAccess{viewers: []int64{7}}}
+
+	if _, err := s.GetHistory(userContext(8), 1); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("GetHistory without access = %v, want %v", err, ErrUnauthorized)
+	}
+
+	revisions, err := s.GetHistory(userContext(7), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("got %d revisions, want 3", len(revisions))
+	}
+	want := []audit.Changes{
+		nil,
+		{
+			"num_guests":     {Before: 2, After: 3},
+			"check_out_date": {Before: terms.CheckOutDate, After: amended.CheckOutDate},
+		},
+		{
+			"status": {Before: BookingBooked, After: BookingCancelled},
+		},
+	}
+	for i, revision := range revisions {
+		if !reflect.DeepEqual(revision.Changes, want[i]) {
+			t.Errorf("revision %d changes = %v, want %v", revision.Revision, revision.Changes, want[i])
+		}
+	}
+}
+
+func TestUpdateMovingProperty(t *testing.T) {
+	repo := &fakeBookingRepo{booking: &Booking{ID: 1, Version: 1, PropertyID: 1}}
+	s := &bookingService{
+		repo:          repo,
+		propertyRepo:  &fakePropertyRepo{},
+		accessService: &fakeAccess{viewers: []int64{7}, editors: map[int64][]int64{1: {7}, 3: {7}}},
+	}
+	tests := []struct {
+		name       string
+		propertyID int64
+		want       error
+	}{
+		// edit_bookings on the booking is not enough to move it to a property the user has no grant on
+		{"to a property without a grant", 2, ErrUnauthorized},
+		{"to a granted property that does not exist", 3, property.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moved := &Booking{ID: 1, Version: 1, PropertyID: tt.propertyID}
+			if err := s.Update(userContext(7), moved, ""); !errors.Is(err, tt.want) {
+				t.Errorf("Update = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddAdjustmentWithCoupon(t *testing.T) {
+	checkIn := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	maxUses := 5
+	festive := coupon.Coupon{ID: 4, Code: "FESTIVE", Percent: true, Value: 10, MaxUses: &maxUses, Active: true}
+	exhausted := festive
+	exhausted.Uses = 5
+	couponID := festive.ID
+
+	tests := []struct {
+		name     string
+		coupon   coupon.Coupon
+		existing []Adjustment
+		code     string
+		want     error
+		discount float64
+	}{
+		{"percent coupon", festive, nil, " festive ", nil, 600},
+		{"every use taken", exhausted, nil, "FESTIVE", coupon.ErrExhausted, 0},
+		{"applied twice", festive, []Adjustment{{CouponID: &couponID}}, "FESTIVE", ErrCouponAlreadyApplied, 0},
+		{"unknown code", festive, nil, "SUMMER", coupon.ErrNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeBookingRepo{
+				booking: &Booking{ID: 1, PropertyID: 1, BaseRate: 2000, NumGuests: 2, MaxGuestsBase: 2,
+					CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 3)},
+				adjustments: tt.existing,
+			}
+			s := &bookingService{
+				repo:          repo,
+				taxRepo:       noTax{},
+				couponRepo:    &fakeCouponRepo{coupon: tt.coupon},
+				accessService: &fakeAccess{viewers: []int64{7}},
+				auditService:  fakeAudit{},
+				transactor:    fakeTransactor{},
+			}
+			code := tt.code
+			priced, err := s.AddAdjustment(userContext(7), 1, &Adjustment{CouponCode: &code})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("AddAdjustment = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if len(repo.adjustments) != len(tt.existing) {
+					t.Error("the adjustment was stored")
+				}
+				return
+			}
+			if priced.DiscountAmount != tt.discount {
+				t.Errorf("discount = %v, want %v", priced.DiscountAmount, tt.discount)
+			}
+			added := repo.adjustments[len(repo.adjustments)-1]
+			if added.CouponID == nil || *added.CouponID != festive.ID || added.Reason != "Coupon FESTIVE" {
+				t.Errorf("stored adjustment %+v, want the discount of coupon %d", added, festive.ID)
+			}
+		})
+	}
+}
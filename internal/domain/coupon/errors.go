@@ -0,0 +1,17 @@
+package coupon
+
+import "errors"
+
+var (
+	ErrNotFound        = errors.New("coupon not found")
+	ErrInternal        = errors.New("Internal error")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrAlreadyExists   = errors.New("coupon code already exists")
+	ErrInvalidCode     = errors.New("invalid coupon code")
+	ErrInvalidValue    = errors.New("invalid coupon value")
+	ErrInvalidValidity = errors.New("invalid coupon validity")
+	ErrInactive        = errors.New("coupon is not active")
+	ErrExpired         = errors.New("coupon is not valid at this time")
+	ErrNotApplicable   = errors.New("coupon does not apply to this property")
+	ErrExhausted       = errors.New("coupon has been used up")
+)
@@ -0,0 +1,64 @@
+package coupon
+
+import (
+	"strings"
+	"time"
+)
+
+// Coupon is a discount code of the organisation, percentage or flat, optionally limited to
+// one property, a validity window and a number of redemptions
+type Coupon struct {
+	ID             int64      `db:"id"`
+	OrganisationID int64      `db:"organisation_id"`
+	Code           string     `db:"code"`
+	Percent        bool       `db:"percent"`
+	Value          float64    `db:"value"`
+	PropertyID     *int64     `db:"property_id"`
+	ValidFrom      *time.Time `db:"valid_from"`
+	ValidTo        *time.Time `db:"valid_to"`
+	MaxUses        *int       `db:"max_uses"`
+	Uses           int        `db:"uses"`
+	Active         bool       `db:"active"`
+	CreatedBy      int64      `db:"created_by"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// NormalizeCode makes codes case insensitive, they are stored upper case
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (c *Coupon) Validate() error {
+	if len(c.Code) < 3 || len(c.Code) > 32 || strings.ContainsAny(c.Code, " \t\n") {
+		return ErrInvalidCode
+	}
+	if c.Value <= 0 || (c.Percent && c.Value > 100) {
+		return ErrInvalidValue
+	}
+	if c.ValidFrom != nil && c.ValidTo != nil && !c.ValidTo.After(*c.ValidFrom) {
+		return ErrInvalidValidity
+	}
+	if c.MaxUses != nil && *c.MaxUses <= 0 {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+// Redeemable checks the coupon can be applied now to a booking of the property,
+// Uses is expected to be up to date
+func (c *Coupon) Redeemable(propertyID int64, at time.Time) error {
+	if !c.Active {
+		return ErrInactive
+	}
+	if c.PropertyID != nil && *c.PropertyID != propertyID {
+		return ErrNotApplicable
+	}
+	if (c.ValidFrom != nil && at.Before(*c.ValidFrom)) || (c.ValidTo != nil && !at.Before(*c.ValidTo)) {
+		return ErrExpired
+	}
+	if c.MaxUses != nil && c.Uses >= *c.MaxUses {
+		return ErrExhausted
+	}
+	return nil
+}
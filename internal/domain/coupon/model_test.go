@@ -0,0 +1,37 @@
+package coupon
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRedeemable(t *testing.T) {
+	now := time.Date(2026, 12, 20, 12, 0, 0, 0, time.UTC)
+	from, to := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+	propertyID, maxUses := int64(2), 3
+
+	tests := []struct {
+		name       string
+		coupon     Coupon
+		propertyID int64
+		want       error
+	}{
+		{"unlimited", Coupon{Active: true}, 1, nil},
+		{"inactive", Coupon{Active: false}, 1, ErrInactive},
+		{"other property", Coupon{Active: true, PropertyID: &propertyID}, 1, ErrNotApplicable},
+		{"its property", Coupon{Active: true, PropertyID: &propertyID}, 2, nil},
+		{"not yet valid", Coupon{Active: true, ValidFrom: &to}, 1, ErrExpired},
+		{"past its validity", Coupon{Active: true, ValidTo: &from}, 1, ErrExpired},
+		{"within its validity", Coupon{Active: true, ValidFrom: &from, ValidTo: &to}, 1, nil},
+		{"a use left", Coupon{Active: true, MaxUses: &maxUses, Uses: 2}, 1, nil},
+		{"every use taken", Coupon{Active: true, MaxUses: &maxUses, Uses: 3}, 1, ErrExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.coupon.Redeemable(tt.propertyID, now); !errors.Is(err, tt.want) {
+				t.Errorf("Redeemable = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package coupon
+
+import (
+	"context"
+)
+
+type CouponRepository interface {
+	GetAll(ctx context.Context) ([]Coupon, error)
+	GetByID(ctx context.Context, id int64) (*Coupon, error)
+	// GetByCode fills Uses with the live booking adjustments redeeming the coupon
+	GetByCode(ctx context.Context, code string) (*Coupon, error)
+	// GetByCodeForUpdate also locks the coupon until the transaction in ctx ends
+	GetByCodeForUpdate(ctx context.Context, code string) (*Coupon, error)
+	Create(ctx context.Context, coupon *Coupon) error
+	SetActive(ctx context.Context, id int64, active bool) error
+}
+
+// Transactor runs fn in one transaction, repositories called with the ctx it gets join in
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
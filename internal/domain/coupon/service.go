@@ -0,0 +1,107 @@
+package coupon
+
+import (
+	"context"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	"github.com/nevinmanoj/hostmate/internal/domain/property"
+	"github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+type CouponService interface {
+	GetAll(ctx context.Context) ([]Coupon, error)
+	Create(ctx context.Context, coupon *Coupon) error
+	SetActive(ctx context.Context, id int64, active bool) (*Coupon, error)
+}
+
+type couponService struct {
+	repo          CouponRepository
+	propertyRepo  property.PropertyReadRepository
+	accessService access.AccessService
+	auditService  audit.AuditService
+	transactor    Transactor
+}
+
+func NewCouponService(repo CouponRepository, propertyRepo property.PropertyReadRepository, accessService access.AccessService, auditService audit.AuditService, transactor Transactor) CouponService {
+	return &couponService{repo: repo, propertyRepo: propertyRepo, accessService: accessService, auditService: auditService, transactor: transactor}
+}
+
+// GetAll is open to every member, anyone taking bookings may need to look a code up
+func (s *couponService) GetAll(ctx context.Context) ([]Coupon, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	isMember, err := s.accessService.IsMember(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrUnauthorized
+	}
+	return s.repo.GetAll(ctx)
+}
+
+// Create is for organisation admins, coupons can cover every property
+func (s *couponService) Create(ctx context.Context, coupon *Coupon) error {
+	userID, err := s.canManage(ctx)
+	if err != nil {
+		return err
+	}
+	coupon.Code = NormalizeCode(coupon.Code)
+	if err := coupon.Validate(); err != nil {
+		return err
+	}
+	if coupon.PropertyID != nil {
+		if _, err := s.propertyRepo.GetByID(ctx, *coupon.PropertyID); err != nil {
+			return err
+		}
+	}
+	coupon.Active = true
+	coupon.CreatedBy = userID
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, coupon); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, audit.EntityCoupon, coupon.ID, audit.ActionCreate, nil, coupon)
+	})
+}
+
+// SetActive retires or revives a coupon, discounts already given are kept
+func (s *couponService) SetActive(ctx context.Context, id int64, active bool) (*Coupon, error) {
+	if _, err := s.canManage(ctx); err != nil {
+		return nil, err
+	}
+	couponFromDb, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var updated *Coupon
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetActive(ctx, id, active); err != nil {
+			return err
+		}
+		updated, err = s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, audit.EntityCoupon, id, audit.ActionUpdate, couponFromDb, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *couponService) canManage(ctx context.Context) (int64, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return 0, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	isAdmin, err := s.accessService.IsAdmin(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !isAdmin {
+		return 0, ErrUnauthorized
+	}
+	return userID, nil
+}
@@ -0,0 +1,12 @@
+package idempotency
+
+import (
+	"errors"
+)
+
+var (
+	ErrInternal          = errors.New("internal error")
+	ErrInvalidKey        = errors.New("invalid idempotency key")
+	ErrKeyReused         = errors.New("idempotency key was already used for a different request")
+	ErrRequestInProgress = errors.New("a request with this idempotency key is still in progress")
+)
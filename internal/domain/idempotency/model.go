@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Record is a create request made with an Idempotency-Key, the response is kept
+// once the request completes so that retries get the same result
+type Record struct {
+	ID              int64      `db:"id"`
+	OrganisationID  int64      `db:"organisation_id"`
+	UserID          int64      `db:"user_id"`
+	Key             string     `db:"idempotency_key"`
+	Fingerprint     string     `db:"fingerprint"`
+	Response        []byte     `db:"response"`
+	ResponseHeaders Headers    `db:"response_headers"`
+	CreatedAt       time.Time  `db:"created_at"`
+	CompletedAt     *time.Time `db:"completed_at"`
+}
+
+// Headers are the response headers replayed with the body, e.g. an ETag or Content-Disposition.
+// They are stored as jsonb.
+type Headers map[string][]string
+
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *Headers) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	case nil:
+		*h = Headers{}
+		return nil
+	default:
+		return errors.New("unsupported type for response headers")
+	}
+}
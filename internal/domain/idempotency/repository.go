@@ -0,0 +1,15 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Create reserves the key, it returns false when the user holds the key already
+	// and the existing record was created after expiredBefore
+	Create(ctx context.Context, record *Record, expiredBefore time.Time) (bool, error)
+	Get(ctx context.Context, userID int64, key string) (*Record, error)
+	Complete(ctx context.Context, id int64, headers Headers, response []byte) error
+	Delete(ctx context.Context, id int64) error
+}
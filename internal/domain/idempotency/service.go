@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"log"
+	"time"
+
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+const (
+	// keys can be reused for a different request once they are this old
+	keyTTL       = 24 * time.Hour
+	maxKeyLength = 255
+)
+
+type IdempotencyService interface {
+	// Begin reserves the key for a request, when the key was used before for the same
+	// request the earlier record is returned with replay set and the request must not run again
+	Begin(ctx context.Context, key, fingerprint string) (record *Record, replay bool, err error)
+	Complete(ctx context.Context, record *Record, headers Headers, response []byte)
+	Release(ctx context.Context, record *Record)
+}
+
+type idempotencyService struct {
+	repo IdempotencyRepository
+}
+
+func NewIdempotencyService(repo IdempotencyRepository) IdempotencyService {
+	return &idempotencyService{repo: repo}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, key, fingerprint string) (*Record, bool, error) {
+	if key == "" || len(key) > maxKeyLength {
+		return nil, false, ErrInvalidKey
+	}
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return nil, false, ErrInternal
+	}
+	record := &Record{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+	}
+	created, err := s.repo.Create(ctx, record, time.Now().Add(-keyTTL))
+	if err != nil {
+		log.Println("Error reserving idempotency key:", err)
+		return nil, false, ErrInternal
+	}
+	if created {
+		return record, false, nil
+	}
+	existing, err := s.repo.Get(ctx, userID, key)
+	if err != nil {
+		log.Println("Error fetching idempotency key:", err)
+		return nil, false, ErrInternal
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, false, ErrKeyReused
+	}
+	if existing.CompletedAt == nil {
+		return nil, false, ErrRequestInProgress
+	}
+	return existing, true, nil
+}
+
+// Complete stores the response to replay, a failure only costs the replay so it is logged
+func (s *idempotencyService) Complete(ctx context.Context, record *Record, headers Headers, response []byte) {
+	if err := s.repo.Complete(ctx, record.ID, headers, response); err != nil {
+		log.Printf("Error storing response for idempotency key %s: %s", record.Key, err.Error())
+	}
+}
+
+// Release frees the key after a request failed without a result worth replaying, so it can be retried
+func (s *idempotencyService) Release(ctx context.Context, record *Record) {
+	if err := s.repo.Delete(ctx, record.ID); err != nil {
+		log.Printf("Error releasing idempotency key %s: %s", record.Key, err.Error())
+	}
+}
@@ -0,0 +1,249 @@
+package imports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
+)
+
+var (
+	bookingRequiredColumns = []string{"property_id", "guest_name", "guest_phone", "check_in_date", "check_out_date", "base_rate"}
+	paymentRequiredColumns = []string{"amount", "date", "payment_type"}
+)
+
+// csvFile reads a file by column name, cells are looked up in the current record
+type csvFile struct {
+	kind    Kind
+	reader  *csv.Reader
+	columns map[string]int
+	record  []string
+	line    int
+	errors  []RowError
+}
+
+func newCSVFile(kind Kind, r io.Reader, required []string) (*csvFile, error) {
+	f := &csvFile{kind: kind, reader: csv.NewReader(r), columns: map[string]int{}}
+	f.reader.TrimLeadingSpace = true
+	header, err := f.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	f.line = 1
+	for i, name := range header {
+		f.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := f.columns[name]; !ok {
+			f.fail(name, "missing required column")
+		}
+	}
+	return f, nil
+}
+
+// next moves to the following record, false at the end of the file or when it cannot be read on
+func (f *csvFile) next() bool {
+	record, err := f.reader.Read()
+	if err == io.EOF {
+		return false
+	}
+	line, _ := f.reader.FieldPos(0)
+	f.line = line
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			f.line = parseErr.Line
+		}
+		f.fail("", err.Error())
+		// a row with the wrong number of fields can be skipped, anything else leaves the reader lost
+		return errors.Is(err, csv.ErrFieldCount) && f.next()
+	}
+	f.record = record
+	return true
+}
+
+func (f *csvFile) fail(column, message string) {
+	f.errors = append(f.errors, RowError{File: f.kind, Line: f.line, Column: column, Message: message})
+}
+
+func (f *csvFile) text(column string) string {
+	i, ok := f.columns[column]
+	if !ok || i >= len(f.record) {
+		return ""
+	}
+	return strings.TrimSpace(f.record[i])
+}
+
+func (f *csvFile) required(column string) (string, bool) {
+	v := f.text(column)
+	if v == "" {
+		f.fail(column, "is required")
+		return "", false
+	}
+	return v, true
+}
+
+func (f *csvFile) int64(column string, def int64) (int64, bool) {
+	v := f.text(column)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		f.fail(column, "must be a whole number")
+		return 0, false
+	}
+	return n, true
+}
+
+func (f *csvFile) float(column string, def float64) (float64, bool) {
+	v := f.text(column)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		f.fail(column, "must be a non negative number")
+		return 0, false
+	}
+	return n, true
+}
+
+// date takes a plain date or a full RFC 3339 timestamp
+func (f *csvFile) date(column string) (time.Time, bool) {
+	v, ok := f.required(column)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	f.fail(column, "invalid date, expected YYYY-MM-DD")
+	return time.Time{}, false
+}
+
+// ParseBookings reads a bookings file. Every problem found is reported against its row,
+// err is only set when the file has no header to go by.
+func ParseBookings(r io.Reader) ([]BookingRow, []RowError, error) {
+	f, err := newCSVFile(KindBookings, r, bookingRequiredColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(f.errors) > 0 {
+		return nil, f.errors, nil
+	}
+	rows := []BookingRow{}
+	refs := map[string]int{}
+	for f.next() {
+		before := len(f.errors)
+		row := BookingRow{Line: f.line, Ref: f.text("ref")}
+		if line, ok := refs[row.Ref]; ok && row.Ref != "" {
+			f.fail("ref", fmt.Sprintf("already used on line %d", line))
+		}
+		b := &row.Booking
+		if _, ok := f.required("property_id"); ok {
+			if b.PropertyID, ok = f.int64("property_id", 0); ok && b.PropertyID <= 0 {
+				f.fail("property_id", "must be a positive id")
+			}
+		}
+		b.GuestName, _ = f.required("guest_name")
+		b.GuestPhone, _ = f.required("guest_phone")
+		b.CheckInDate, _ = f.date("check_in_date")
+		b.CheckOutDate, _ = f.date("check_out_date")
+		numGuests, _ := f.int64("num_guests", 1)
+		b.NumGuests = int(numGuests)
+		maxGuestsBase, _ := f.int64("max_guests_base", numGuests)
+		b.MaxGuestsBase = int(maxGuestsBase)
+		if _, ok := f.required("base_rate"); ok {
+			b.BaseRate, _ = f.float("base_rate", 0)
+		}
+		b.ExtraRatePerGuest, _ = f.float("extra_rate_per_guest", 0)
+		b.Status = booking.BookingBooked
+		if v := f.text("status"); v != "" {
+			if status, ok := parseBookingStatus(v); ok {
+				b.Status = status
+			} else {
+				f.fail("status", "must be one of booked, checkedIn, checkedOut or cancelled")
+			}
+		}
+		b.Remarks = f.text("remarks")
+		if len(f.errors) > before {
+			continue
+		}
+		if row.Ref != "" {
+			refs[row.Ref] = row.Line
+		}
+		rows = append(rows, row)
+	}
+	return rows, f.errors, nil
+}
+
+// ParsePayments reads a payments file, each row names a booking_id or the ref of a booking
+// in the same import
+func ParsePayments(r io.Reader) ([]PaymentRow, []RowError, error) {
+	f, err := newCSVFile(KindPayments, r, paymentRequiredColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, hasBookingID := f.columns["booking_id"]
+	_, hasBookingRef := f.columns["booking_ref"]
+	if !hasBookingID && !hasBookingRef {
+		f.fail("booking_id", "missing required column booking_id or booking_ref")
+	}
+	if len(f.errors) > 0 {
+		return nil, f.errors, nil
+	}
+	rows := []PaymentRow{}
+	for f.next() {
+		before := len(f.errors)
+		row := PaymentRow{Line: f.line, BookingRef: f.text("booking_ref")}
+		p := &row.Payment
+		p.BookingID, _ = f.int64("booking_id", 0)
+		if (p.BookingID == 0) == (row.BookingRef == "") {
+			f.fail("booking_id", "exactly one of booking_id or booking_ref is required")
+		}
+		if _, ok := f.required("amount"); ok {
+			p.Amount, _ = f.float("amount", 0)
+		}
+		p.Date, _ = f.date("date")
+		if v, ok := f.required("payment_type"); ok {
+			if paymentType, ok := parsePaymentType(v); ok {
+				p.PaymentType = paymentType
+			} else {
+				f.fail("payment_type", "must be one of UPI, Cash, bank-transfer or other")
+			}
+		}
+		p.Remarks = f.text("remarks")
+		if len(f.errors) > before {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, f.errors, nil
+}
+
+func parseBookingStatus(v string) (booking.BookingStatus, bool) {
+	for _, status := range []booking.BookingStatus{booking.BookingBooked, booking.BookingCheckedIn, booking.BookingCheckedOut, booking.BookingCancelled} {
+		if strings.EqualFold(v, string(status)) {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+func parsePaymentType(v string) (payment.PaymentType, bool) {
+	for _, paymentType := range []payment.PaymentType{payment.PaymentUPI, payment.PaymentCash, payment.PaymentBankTransfer, payment.PaymentOther} {
+		if strings.EqualFold(v, string(paymentType)) {
+			return paymentType, true
+		}
+	}
+	return "", false
+}
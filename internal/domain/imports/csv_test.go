@@ -0,0 +1,78 @@
+package imports
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
+)
+
+func TestParseBookings(t *testing.T) {
+	file := strings.Join([]string{
+		"Ref,Property_ID,guest_name,guest_phone,check_in_date,check_out_date,base_rate,num_guests,status,remarks",
+		"a,1,Asha Menon,+91 98470 12345,2026-12-20,2026-12-23,2500,3,checkedin, late arrival ",
+		"b,1,,98470,2026-12-20,someday,-5,,,",
+		"a,2,Ravi,98471,2026-12-24,2026-12-25,1800,,,",
+		"c,x,Ravi,98471,2026-12-24,2026-12-25,1800,,unknown,",
+	}, "\n")
+	rows, errs, err := ParseBookings(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("read %d rows, want only the valid one", len(rows))
+	}
+	b := rows[0].Booking
+	if rows[0].Ref != "a" || rows[0].Line != 2 || b.PropertyID != 1 || b.NumGuests != 3 || b.MaxGuestsBase != 3 || b.Status != booking.BookingCheckedIn || b.Remarks != "late arrival" {
+		t.Errorf("row = %+v", rows[0])
+	}
+	want := []RowError{
+		{File: KindBookings, Line: 3, Column: "guest_name", Message: "is required"},
+		{File: KindBookings, Line: 3, Column: "check_out_date", Message: "invalid date, expected YYYY-MM-DD"},
+		{File: KindBookings, Line: 3, Column: "base_rate", Message: "must be a non negative number"},
+		{File: KindBookings, Line: 4, Column: "ref", Message: "already used on line 2"},
+		{File: KindBookings, Line: 5, Column: "property_id", Message: "must be a whole number"},
+		{File: KindBookings, Line: 5, Column: "status", Message: "must be one of booked, checkedIn, checkedOut or cancelled"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %+v, want %+v", errs, want)
+	}
+}
+
+func TestParseBookingsMissingColumns(t *testing.T) {
+	rows, errs, err := ParseBookings(strings.NewReader("property_id,guest_name\n1,Asha\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 || len(errs) != 4 || errs[0].Line != 1 || errs[0].Column != "guest_phone" {
+		t.Errorf("rows = %+v, errors = %+v, want the four missing columns reported on the header", rows, errs)
+	}
+}
+
+func TestParsePayments(t *testing.T) {
+	file := strings.Join([]string{
+		"booking_id,booking_ref,amount,date,payment_type",
+		"7,,500,2026-12-20,upi",
+		",a,250.5,2026-12-21T10:00:00Z,Cash",
+		"7,a,100,2026-12-21,Cash",
+		",,100,2026-12-21,Cash",
+		"7,,100,2026-12-21,cheque",
+	}, "\n")
+	rows, errs, err := ParsePayments(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Payment.BookingID != 7 || rows[0].Payment.PaymentType != payment.PaymentUPI || rows[1].BookingRef != "a" || rows[1].Payment.Amount != 250.5 {
+		t.Errorf("rows = %+v", rows)
+	}
+	want := []RowError{
+		{File: KindPayments, Line: 4, Column: "booking_id", Message: "exactly one of booking_id or booking_ref is required"},
+		{File: KindPayments, Line: 5, Column: "booking_id", Message: "exactly one of booking_id or booking_ref is required"},
+		{File: KindPayments, Line: 6, Column: "payment_type", Message: "must be one of UPI, Cash, bank-transfer or other"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %+v, want %+v", errs, want)
+	}
+}
@@ -0,0 +1,12 @@
+package imports
+
+import (
+	"errors"
+)
+
+var (
+	ErrInternal    = errors.New("internal error")
+	ErrEmptyImport = errors.New("import has no rows")
+	ErrTooManyRows = errors.New("import has too many rows")
+	errRolledBack  = errors.New("import rolled back")
+)
@@ -0,0 +1,52 @@
+package imports
+
+import (
+	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
+)
+
+type Kind string
+
+const (
+	KindBookings Kind = "bookings"
+	KindPayments Kind = "payments"
+)
+
+// RowError points at the line of a file a problem was found on, the header is line 1
+type RowError struct {
+	File    Kind
+	Line    int
+	Column  string
+	Message string
+}
+
+// BookingRow is a booking read from a file, payments in the same import refer to it by Ref
+type BookingRow struct {
+	Line    int
+	Ref     string
+	Booking booking.Booking
+}
+
+// PaymentRow belongs to an existing booking or, through BookingRef, one from the same import
+type PaymentRow struct {
+	Line       int
+	BookingRef string
+	Payment    payment.Payment
+}
+
+// Request is what was read from the files, Errors are the rows that could not be read
+type Request struct {
+	Bookings []BookingRow
+	Payments []PaymentRow
+	Errors   []RowError
+	DryRun   bool
+}
+
+// Report is the outcome of an import, nothing is stored unless Committed
+type Report struct {
+	DryRun    bool
+	Committed bool
+	Bookings  int
+	Payments  int
+	Errors    []RowError
+}
@@ -0,0 +1,150 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
+	property "github.com/nevinmanoj/hostmate/internal/domain/property"
+)
+
+// an import runs in one transaction, this keeps it from holding locks for too long
+const MaxRows = 5000
+
+// Transactor runs fn in one transaction that the repositories called with its context join,
+// a nested call runs in a savepoint that can fail without aborting the outer transaction
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type ImportService interface {
+	Import(ctx context.Context, req Request) (*Report, error)
+}
+
+type importService struct {
+	transactor     Transactor
+	bookingService booking.BookingService
+	paymentService payment.PaymentService
+	propertyRepo   property.PropertyReadRepository
+	bookingRepo    booking.BookingReadRepository
+	accessService  access.AccessService
+}
+
+func NewImportService(transactor Transactor, bookingService booking.BookingService, paymentService payment.PaymentService, propertyRepo property.PropertyReadRepository, bookingRepo booking.BookingReadRepository, accessService access.AccessService) ImportService {
+	return &importService{
+		transactor:     transactor,
+		bookingService: bookingService,
+		paymentService: paymentService,
+		propertyRepo:   propertyRepo,
+		bookingRepo:    bookingRepo,
+		accessService:  accessService,
+	}
+}
+
+// Import creates every row through the booking and payment services, so access checks,
+// created by and auditing are the same as for a single create. All rows run in one
+// transaction, each in its own savepoint so one bad row does not hide the problems of
+// the others. The transaction is only committed when it is not a dry run and no row failed.
+func (s *importService) Import(ctx context.Context, req Request) (*Report, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	rowCount := len(req.Bookings) + len(req.Payments)
+	if rowCount == 0 && len(req.Errors) == 0 {
+		return nil, ErrEmptyImport
+	}
+	if rowCount > MaxRows {
+		return nil, ErrTooManyRows
+	}
+	report := &Report{DryRun: req.DryRun, Errors: append([]RowError{}, req.Errors...)}
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// ids of the bookings created so far by ref, a failed ref maps to 0
+		refs := map[string]int64{}
+		for i := range req.Bookings {
+			row := &req.Bookings[i]
+			if row.Ref != "" {
+				refs[row.Ref] = 0
+			}
+			err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+				// an unknown property would otherwise only show as missing access
+				if _, err := s.propertyRepo.GetByID(ctx, row.Booking.PropertyID); err != nil {
+					return err
+				}
+				return s.bookingService.Create(ctx, &row.Booking)
+			})
+			if err != nil {
+				report.Errors = append(report.Errors, RowError{File: KindBookings, Line: row.Line, Message: rowMessage(err)})
+				continue
+			}
+			if row.Ref != "" {
+				refs[row.Ref] = row.Booking.ID
+			}
+			report.Bookings++
+		}
+
+		for i := range req.Payments {
+			row := &req.Payments[i]
+			if row.BookingRef != "" {
+				id, ok := refs[row.BookingRef]
+				if !ok {
+					report.Errors = append(report.Errors, RowError{File: KindPayments, Line: row.Line, Column: "booking_ref", Message: "no booking in this import has this ref"})
+					continue
+				}
+				if id == 0 {
+					report.Errors = append(report.Errors, RowError{File: KindPayments, Line: row.Line, Column: "booking_ref", Message: "the booking with this ref could not be imported"})
+					continue
+				}
+				row.Payment.BookingID = id
+			}
+			err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+				if _, err := s.bookingRepo.GetByID(ctx, row.Payment.BookingID); err != nil {
+					return err
+				}
+				return s.paymentService.Create(ctx, &row.Payment)
+			})
+			if err != nil {
+				report.Errors = append(report.Errors, RowError{File: KindPayments, Line: row.Line, Message: rowMessage(err)})
+				continue
+			}
+			report.Payments++
+		}
+
+		if req.DryRun || len(report.Errors) > 0 {
+			return errRolledBack
+		}
+		return nil
+	})
+	if errors.Is(err, errRolledBack) {
+		return report, nil
+	}
+	if err != nil {
+		log.Println("Error running import:", err)
+		return nil, ErrInternal
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// rowMessage explains why a row was rejected in terms of the file, other errors are logged
+// rather than shown as they can carry database details
+func rowMessage(err error) string {
+	switch {
+	case errors.Is(err, property.ErrNotFound):
+		return "unknown property"
+	case errors.Is(err, booking.ErrNotFound), errors.Is(err, payment.ErrNotValidBookingId):
+		return "unknown booking"
+	case errors.Is(err, booking.ErrBookingConflict):
+		return "overlaps another booking of the property"
+	case errors.Is(err, booking.ErrInvalidDateRange):
+		return "check_out_date must be after check_in_date"
+	case errors.Is(err, booking.ErrUnauthorized), errors.Is(err, payment.ErrUnauthorized):
+		return "not allowed to add this row"
+	default:
+		log.Println("Error importing row:", err)
+		return "could not be imported"
+	}
+}
@@ -0,0 +1,219 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	payment "github.com/nevinmanoj/hostmate/internal/domain/payment"
+	property "github.com/nevinmanoj/hostmate/internal/domain/property"
+)
+
+// store stands in for the database, memoryTransactor undoes what a failed transaction wrote
+type store struct {
+	bookings []booking.Booking
+	payments []payment.Payment
+}
+
+type memoryTransactor struct {
+	store *store
+}
+
+func (t *memoryTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	bookings, payments := len(t.store.bookings), len(t.store.payments)
+	if err := fn(ctx); err != nil {
+		t.store.bookings = t.store.bookings[:bookings]
+		t.store.payments = t.store.payments[:payments]
+		return err
+	}
+	return nil
+}
+
+// fakeBookings creates bookings in the store, a guest named Conflict overlaps another booking
+// and one named Broken fails like a lost database connection
+type fakeBookings struct {
+	booking.BookingService
+	store *store
+}
+
+func (f *fakeBookings) Create(ctx context.Context, b *booking.Booking) error {
+	switch b.GuestName {
+	case "Conflict":
+		return booking.ErrBookingConflict
+	case "Broken":
+		return errors.New(`pq: relation "bookings" does not exist`)
+	}
+	b.ID = int64(100 + len(f.store.bookings))
+	f.store.bookings = append(f.store.bookings, *b)
+	return nil
+}
+
+// fakeBookingRepo knows the bookings in the store and booking 1, which existed before the import
+type fakeBookingRepo struct {
+	booking.BookingReadRepository
+	store *store
+}
+
+func (f *fakeBookingRepo) GetByID(ctx context.Context, id int64) (*booking.Booking, error) {
+	if id == 1 {
+		return &booking.Booking{ID: 1}, nil
+	}
+	for _, b := range f.store.bookings {
+		if b.ID == id {
+			return &b, nil
+		}
+	}
+	return nil, booking.ErrNotFound
+}
+
+type fakePayments struct {
+	payment.PaymentService
+	store *store
+}
+
+func (f *fakePayments) Create(ctx context.Context, p *payment.Payment) error {
+	f.store.payments = append(f.store.payments, *p)
+	return nil
+}
+
+// fakeProperties only knows property 1
+type fakeProperties struct {
+	property.PropertyReadRepository
+}
+
+func (f *fakeProperties) GetByID(ctx context.Context, id int64) (*property.Property, error) {
+	if id != 1 {
+		return nil, property.ErrNotFound
+	}
+	return &property.Property{ID: 1}, nil
+}
+
+type fakeAccess struct {
+	access.AccessService
+}
+
+func (f *fakeAccess) CanWrite(ctx context.Context) error {
+	return nil
+}
+
+func newTestService() (ImportService, *store) {
+	db := &store{}
+	s := NewImportService(&memoryTransactor{store: db}, &fakeBookings{store: db}, &fakePayments{store: db}, &fakeProperties{}, &fakeBookingRepo{store: db}, &fakeAccess{})
+	return s, db
+}
+
+func bookingRow(line int, ref, guest string, propertyID int64) BookingRow {
+	checkIn := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	return BookingRow{Line: line, Ref: ref, Booking: booking.Booking{
+		PropertyID:   propertyID,
+		GuestName:    guest,
+		CheckInDate:  checkIn,
+		CheckOutDate: checkIn.AddDate(0, 0, 2),
+	}}
+}
+
+func paymentRow(line int, bookingRef string, bookingID int64) PaymentRow {
+	return PaymentRow{Line: line, BookingRef: bookingRef, Payment: payment.Payment{BookingID: bookingID, Amount: 500}}
+}
+
+func TestImportResolvesRefs(t *testing.T) {
+	s, db := newTestService()
+	report, err := s.Import(context.Background(), Request{
+		Bookings: []BookingRow{bookingRow(2, "a", "Asha", 1), bookingRow(3, "b", "Ravi", 1)},
+		Payments: []PaymentRow{paymentRow(2, "b", 0), paymentRow(3, "a", 0), paymentRow(4, "", 1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Committed || report.Bookings != 2 || report.Payments != 3 || len(report.Errors) != 0 {
+		t.Fatalf("report = %+v, want 2 bookings and 3 payments committed", report)
+	}
+	var got []int64
+	for _, p := range db.payments {
+		got = append(got, p.BookingID)
+	}
+	if want := []int64{db.bookings[1].ID, db.bookings[0].ID, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("payments belong to bookings %v, want %v", got, want)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	s, db := newTestService()
+	report, err := s.Import(context.Background(), Request{
+		Bookings: []BookingRow{bookingRow(2, "a", "Asha", 1)},
+		Payments: []PaymentRow{paymentRow(2, "a", 0)},
+		DryRun:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Committed || !report.DryRun || report.Bookings != 1 || report.Payments != 1 {
+		t.Errorf("report = %+v, want both rows checked and nothing committed", report)
+	}
+	if len(db.bookings) != 0 || len(db.payments) != 0 {
+		t.Errorf("a dry run stored %d bookings and %d payments", len(db.bookings), len(db.payments))
+	}
+}
+
+func TestImportRollsBackWhenAnyRowFails(t *testing.T) {
+	s, db := newTestService()
+	report, err := s.Import(context.Background(), Request{
+		Bookings: []BookingRow{
+			bookingRow(2, "a", "Asha", 1),
+			bookingRow(3, "b", "Conflict", 1),
+			bookingRow(4, "c", "Ravi", 9),
+			bookingRow(5, "d", "Broken", 1),
+		},
+		Payments: []PaymentRow{
+			paymentRow(2, "a", 0),
+			paymentRow(3, "b", 0),
+			paymentRow(4, "z", 0),
+			paymentRow(5, "", 42),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Committed {
+		t.Error("an import with failed rows was committed")
+	}
+	if len(db.bookings) != 0 || len(db.payments) != 0 {
+		t.Errorf("stored %d bookings and %d payments of a failed import", len(db.bookings), len(db.payments))
+	}
+	// every problem is reported, not only the first
+	want := []RowError{
+		{File: KindBookings, Line: 3, Message: "overlaps another booking of the property"},
+		{File: KindBookings, Line: 4, Message: "unknown property"},
+		// unexpected errors are not shown, they can carry database details
+		{File: KindBookings, Line: 5, Message: "could not be imported"},
+		{File: KindPayments, Line: 3, Column: "booking_ref", Message: "the booking with this ref could not be imported"},
+		{File: KindPayments, Line: 4, Column: "booking_ref", Message: "no booking in this import has this ref"},
+		{File: KindPayments, Line: 5, Message: "unknown booking"},
+	}
+	if !reflect.DeepEqual(report.Errors, want) {
+		t.Errorf("errors = %+v, want %+v", report.Errors, want)
+	}
+}
+
+func TestImportRejectsParseErrors(t *testing.T) {
+	s, db := newTestService()
+	parseError := RowError{File: KindBookings, Line: 2, Column: "check_in_date", Message: "is required"}
+	report, err := s.Import(context.Background(), Request{
+		Bookings: []BookingRow{bookingRow(3, "", "Asha", 1)},
+		Errors:   []RowError{parseError},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Committed || len(db.bookings) != 0 || !reflect.DeepEqual(report.Errors, []RowError{parseError}) {
+		t.Errorf("report = %+v, want the parse error and nothing stored", report)
+	}
+
+	if _, err := s.Import(context.Background(), Request{}); !errors.Is(err, ErrEmptyImport) {
+		t.Errorf("empty import = %v, want %v", err, ErrEmptyImport)
+	}
+}
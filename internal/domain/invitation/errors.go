@@ -0,0 +1,17 @@
+package invitation
+
+import (
+	"errors"
+)
+
+var (
+	ErrNotFound          = errors.New("Invitation not found")
+	ErrInternal          = errors.New("Internal error")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrInvalidToken      = errors.New("invalid invitation token")
+	ErrNotPending        = errors.New("invitation is no longer pending")
+	ErrAlreadyInvited    = errors.New("a pending invitation already exists for this email")
+	ErrAlreadyManager    = errors.New("user is already a manager of this property")
+	ErrInvalidCredential = errors.New("invalid credentials for invited account")
+	ErrMissingSignup     = errors.New("name and password are required to register")
+)
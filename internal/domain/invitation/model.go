@@ -0,0 +1,37 @@
+package invitation
+
+import (
+	"time"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+type Invitation struct {
+	ID             int64            `db:"id"`
+	OrganisationID int64            `db:"organisation_id"`
+	PropertyID     int64            `db:"property_id"`
+	Email          string           `db:"email"`
+	TokenID        string           `db:"token_id"`
+	Status         InvitationStatus `db:"status"`
+	InvitedBy      int64            `db:"invited_by"`
+	AcceptedBy     *int64           `db:"accepted_by"`
+	ExpiresAt      time.Time        `db:"expires_at"`
+	AcceptedAt     *time.Time       `db:"accepted_at"`
+	CreatedAt      time.Time        `db:"created_at"`
+	UpdatedAt      time.Time        `db:"updated_at"`
+}
+
+// EffectiveStatus reports pending invitations past their expiry as expired
+func (i *Invitation) EffectiveStatus() InvitationStatus {
+	if i.Status == InvitationPending && time.Now().After(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
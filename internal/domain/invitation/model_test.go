@@ -0,0 +1,30 @@
+package invitation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffectiveStatus(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name      string
+		status    InvitationStatus
+		expiresAt time.Time
+		want      InvitationStatus
+	}{
+		{"pending", InvitationPending, future, InvitationPending},
+		{"pending past its expiry", InvitationPending, past, InvitationExpired},
+		{"accepted past its expiry", InvitationAccepted, past, InvitationAccepted},
+		{"revoked", InvitationRevoked, future, InvitationRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &Invitation{Status: tt.status, ExpiresAt: tt.expiresAt}
+			if got := i.EffectiveStatus(); got != tt.want {
+				t.Errorf("EffectiveStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
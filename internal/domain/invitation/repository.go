@@ -0,0 +1,20 @@
+package invitation
+
+import (
+	"context"
+)
+
+type InvitationReadRepository interface {
+	GetByID(ctx context.Context, id int64) (*Invitation, error)
+	GetByPropertyID(ctx context.Context, propertyID int64) ([]Invitation, error)
+	GetPendingByEmail(ctx context.Context, propertyID int64, email string) (*Invitation, error)
+}
+type InvitationWriteRepository interface {
+	InvitationReadRepository
+	Create(ctx context.Context, invitation *Invitation) error
+	Update(ctx context.Context, invitation *Invitation) error
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,334 @@
+package invitation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/organisation"
+	property "github.com/nevinmanoj/hostmate/internal/domain/property"
+	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+	"github.com/nevinmanoj/hostmate/internal/mail"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+const invitationExpiry = 7 * 24 * time.Hour
+
+type InvitationService interface {
+	Invite(ctx context.Context, propertyID int64, email string) (*Invitation, error)
+	GetByPropertyID(ctx context.Context, propertyID int64) ([]Invitation, error)
+	Resend(ctx context.Context, propertyID, invitationID int64) (*Invitation, error)
+	Revoke(ctx context.Context, propertyID, invitationID int64) (*Invitation, error)
+	Lookup(ctx context.Context, token string) (*Invitation, bool, error)
+	Accept(ctx context.Context, token, name, password string) (*Invitation, *user.User, error)
+}
+
+type invitationService struct {
+	repo          InvitationWriteRepository
+	propertyRepo  property.PropertyWriteRepository
+	userRepo      user.UserWriteRepository
+	orgRepo       organisation.OrganisationRepository
+	accessService access.AccessService
+	transactor    Transactor
+	mailer        mail.Mailer
+	keys          *auth.KeySet
+	baseURL       string
+}
+
+func NewInvitationService(
+	repo InvitationWriteRepository,
+	propertyRepo property.PropertyWriteRepository,
+	userRepo user.UserWriteRepository,
+	orgRepo organisation.OrganisationRepository,
+	accessService access.AccessService,
+	transactor Transactor,
+	mailer mail.Mailer,
+	keys *auth.KeySet,
+	baseURL string) InvitationService {
+	return &invitationService{
+		repo:          repo,
+		propertyRepo:  propertyRepo,
+		userRepo:      userRepo,
+		orgRepo:       orgRepo,
+		accessService: accessService,
+		transactor:    transactor,
+		mailer:        mailer,
+		keys:          keys,
+		baseURL:       baseURL,
+	}
+}
+
+func (s *invitationService) Invite(ctx context.Context, propertyID int64, email string) (*Invitation, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	prop, err := s.getOwnedProperty(ctx, propertyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	//invited user may already be managing the property
+	existingUser, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		return nil, ErrInternal
+	}
+	if existingUser != nil && slices.Contains(prop.Managers, existingUser.ID) {
+		return nil, ErrAlreadyManager
+	}
+
+	pending, err := s.repo.GetPendingByEmail(ctx, propertyID, email)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, ErrInternal
+	}
+	if pending != nil && pending.EffectiveStatus() == InvitationPending {
+		return nil, ErrAlreadyInvited
+	}
+
+	invitation := &Invitation{
+		PropertyID: propertyID,
+		Email:      email,
+		TokenID:    uuid.New().String(),
+		Status:     InvitationPending,
+		InvitedBy:  userID,
+		ExpiresAt:  time.Now().Add(invitationExpiry),
+	}
+	err = s.repo.Create(ctx, invitation)
+	if err != nil {
+		log.Println("Error creating invitation:", err)
+		return nil, ErrInternal
+	}
+	err = s.send(ctx, invitation, prop)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) GetByPropertyID(ctx context.Context, propertyID int64) ([]Invitation, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	_, err := s.getOwnedProperty(ctx, propertyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	invitations, err := s.repo.GetByPropertyID(ctx, propertyID)
+	if err != nil {
+		log.Printf("Error fetching invitations for property id %d: %s", propertyID, err.Error())
+		return nil, ErrInternal
+	}
+	return invitations, nil
+}
+
+func (s *invitationService) Resend(ctx context.Context, propertyID, invitationID int64) (*Invitation, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	prop, err := s.getOwnedProperty(ctx, propertyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.getInvitation(ctx, propertyID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	// expired invitations can be resent, accepted and revoked ones cannot
+	if invitation.Status != InvitationPending {
+		return nil, ErrNotPending
+	}
+	// rotating the token id invalidates every previously sent link
+	invitation.TokenID = uuid.New().String()
+	invitation.ExpiresAt = time.Now().Add(invitationExpiry)
+	err = s.repo.Update(ctx, invitation)
+	if err != nil {
+		log.Println("Error updating invitation:", err)
+		return nil, ErrInternal
+	}
+	err = s.send(ctx, invitation, prop)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) Revoke(ctx context.Context, propertyID, invitationID int64) (*Invitation, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	_, err := s.getOwnedProperty(ctx, propertyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.getInvitation(ctx, propertyID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != InvitationPending {
+		return nil, ErrNotPending
+	}
+	invitation.Status = InvitationRevoked
+	err = s.repo.Update(ctx, invitation)
+	if err != nil {
+		log.Println("Error revoking invitation:", err)
+		return nil, ErrInternal
+	}
+	return invitation, nil
+}
+
+// Lookup is what the emailed link opens, it shows the pending invitation and whether the
+// invitee already has an account, so the client knows to ask for a name as well
+func (s *invitationService) Lookup(ctx context.Context, token string) (*Invitation, bool, error) {
+	ctx, invitation, err := s.pendingInvitation(ctx, token)
+	if err != nil {
+		return nil, false, err
+	}
+	_, err = s.userRepo.GetUserByEmail(ctx, invitation.Email)
+	switch {
+	case err == nil:
+		return invitation, true, nil
+	case errors.Is(err, user.ErrNotFound):
+		return invitation, false, nil
+	default:
+		return nil, false, ErrInternal
+	}
+}
+
+func (s *invitationService) Accept(ctx context.Context, token, name, password string) (*Invitation, *user.User, error) {
+	ctx, invitation, err := s.pendingInvitation(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the invitee joins the organisation and the property together with the invitation
+	// being marked accepted, a failure part way leaves the invitation pending to retry
+	var invitee *user.User
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		//existing accounts confirm with their password, new ones are registered
+		invitee, err = s.userRepo.GetUserByEmail(ctx, invitation.Email)
+		switch {
+		case err == nil:
+			if auth.CheckPassword(password, invitee.PasswordHash) != nil {
+				return ErrInvalidCredential
+			}
+		case errors.Is(err, user.ErrNotFound):
+			if strings.TrimSpace(name) == "" || len(password) < 6 {
+				return ErrMissingSignup
+			}
+			invitee, err = s.userRepo.CreateUser(ctx, invitation.Email, password, name)
+			if err != nil {
+				return err
+			}
+		default:
+			return ErrInternal
+		}
+
+		// the token came by mail, so the invitee reads mail at the address
+		if err := s.userRepo.MarkEmailVerified(ctx, invitee.ID); err != nil {
+			return ErrInternal
+		}
+		// managing a property needs a membership in its organisation
+		if err := s.orgRepo.AddMember(ctx, invitation.OrganisationID, invitee.ID, organisation.RoleMember); err != nil {
+			return ErrInternal
+		}
+		if err := s.propertyRepo.AddManager(ctx, invitation.PropertyID, invitee.ID); err != nil {
+			log.Println("Error adding manager to property:", err)
+			return ErrInternal
+		}
+		now := time.Now()
+		invitation.Status = InvitationAccepted
+		invitation.AcceptedBy = &invitee.ID
+		invitation.AcceptedAt = &now
+		if err := s.repo.Update(ctx, invitation); err != nil {
+			log.Println("Error accepting invitation:", err)
+			return ErrInternal
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return invitation, invitee, nil
+}
+
+// helpers
+
+// pendingInvitation checks the token against the invitation it was issued for, the returned
+// context acts in the organisation of the invitation as accepting is public
+func (s *invitationService) pendingInvitation(ctx context.Context, token string) (context.Context, *Invitation, error) {
+	claims, err := auth.ParseInvitationToken(token, s.keys)
+	if err != nil {
+		return ctx, nil, ErrInvalidToken
+	}
+	ctx = middleware.WithTenant(ctx, claims.OrganisationID)
+	invitation, err := s.repo.GetByID(ctx, claims.InvitationID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ctx, nil, ErrInvalidToken
+		}
+		return ctx, nil, ErrInternal
+	}
+	if invitation.TokenID != claims.ID || invitation.Email != claims.Email {
+		return ctx, nil, ErrInvalidToken
+	}
+	if invitation.EffectiveStatus() != InvitationPending {
+		return ctx, nil, ErrNotPending
+	}
+	return ctx, invitation, nil
+}
+func (s *invitationService) getOwnedProperty(ctx context.Context, propertyID, userID int64) (*property.Property, error) {
+	prop, err := s.propertyRepo.GetByID(ctx, propertyID)
+	if err != nil {
+		if errors.Is(err, property.ErrNotFound) {
+			return nil, property.ErrNotFound
+		}
+		log.Printf("Error fetching property with id %d: %s", propertyID, err.Error())
+		return nil, ErrInternal
+	}
+	// only the owner of the property can manage its invitations
+	if prop.CreatedBy != userID {
+		return nil, ErrUnauthorized
+	}
+	return prop, nil
+}
+
+func (s *invitationService) getInvitation(ctx context.Context, propertyID, invitationID int64) (*Invitation, error) {
+	invitation, err := s.repo.GetByID(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.PropertyID != propertyID {
+		return nil, ErrNotFound
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) send(ctx context.Context, invitation *Invitation, prop *property.Property) error {
+	token, err := auth.GenerateInvitationToken(invitation.ID, invitation.OrganisationID, invitation.Email, invitation.TokenID, invitation.ExpiresAt, s.keys)
+	if err != nil {
+		log.Println("Error generating invitation token:", err)
+		return ErrInternal
+	}
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", s.baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"You have been invited to manage %s on hostmate.\n\nOpen the link below to accept the invitation before %s:\n%s\n",
+		prop.Name,
+		invitation.ExpiresAt.Format(time.RFC1123),
+		link,
+	)
+	err = s.mailer.Send(ctx, invitation.Email, "Invitation to manage "+prop.Name, body)
+	if err != nil {
+		log.Println("Error sending invitation mail:", err)
+		return ErrInternal
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package invitation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	"github.com/nevinmanoj/hostmate/internal/domain/organisation"
+	property "github.com/nevinmanoj/hostmate/internal/domain/property"
+	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+)
+
+// store stands in for the database, memoryTransactor undoes what a failed transaction wrote
+type store struct {
+	invitation Invitation
+	users      []user.User
+	members    []int64
+	managers   []int64
+}
+
+type memoryTransactor struct {
+	store *store
+}
+
+func (t *memoryTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := *t.store
+	if err := fn(ctx); err != nil {
+		*t.store = saved
+		return err
+	}
+	return nil
+}
+
+type fakeInvitationRepo struct {
+	InvitationWriteRepository
+	store *store
+}
+
+func (r *fakeInvitationRepo) GetByID(ctx context.Context, id int64) (*Invitation, error) {
+	if id != r.store.invitation.ID {
+		return nil, ErrNotFound
+	}
+	i := r.store.invitation
+	return &i, nil
+}
+
+func (r *fakeInvitationRepo) Update(ctx context.Context, invitation *Invitation) error {
+	r.store.invitation = *invitation
+	return nil
+}
+
+// fakeUserRepo registers every invitee as a new user
+type fakeUserRepo struct {
+	user.UserWriteRepository
+	store *store
+}
+
+func (r *fakeUserRepo) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
+	return nil, user.ErrNotFound
+}
+
+func (r *fakeUserRepo) CreateUser(ctx context.Context, email, password, name string) (*user.User, error) {
+	u := user.User{ID: int64(10 + len(r.store.users)), Email: email, Name: name}
+	r.store.users = append(r.store.users, u)
+	return &u, nil
+}
+
+func (r *fakeUserRepo) MarkEmailVerified(ctx context.Context, userID int64) error {
+	return nil
+}
+
+type fakeOrgRepo struct {
+	organisation.OrganisationRepository
+	store *store
+}
+
+func (r *fakeOrgRepo) AddMember(ctx context.Context, organisationID, userID int64, role organisation.Role) error {
+	r.store.members = append(r.store.members, userID)
+	return nil
+}
+
+// fakePropertyRepo fails to add a manager when err is set
+type fakePropertyRepo struct {
+	property.PropertyWriteRepository
+	store *store
+	err   error
+}
+
+func (r *fakePropertyRepo) AddManager(ctx context.Context, propertyID, userID int64) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.store.managers = append(r.store.managers, userID)
+	return nil
+}
+
+func TestAccept(t *testing.T) {
+	keys, err := auth.NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := Invitation{ID: 1, OrganisationID: 3, PropertyID: 2, Email: "asha@example.com", TokenID: "token-1",
+		Status: InvitationPending, ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := auth.GenerateInvitationToken(pending.ID, pending.OrganisationID, pending.Email, pending.TokenID, pending.ExpiresAt, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		managerErr error
+		want       error
+		wantStatus InvitationStatus
+		wantUsers  int
+	}{
+		{"accepted", nil, nil, InvitationAccepted, 1},
+		// the invitee must not end up a member without managing the property, or the invitation spent
+		{"adding the manager fails", errors.New("connection reset"), ErrInternal, InvitationPending, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &store{invitation: pending}
+			s := &invitationService{
+				repo:         &fakeInvitationRepo{store: db},
+				propertyRepo: &fakePropertyRepo{store: db, err: tt.managerErr},
+				userRepo:     &fakeUserRepo{store: db},
+				orgRepo:      &fakeOrgRepo{store: db},
+				transactor:   &memoryTransactor{store: db},
+				keys:         keys,
+			}
+			if _, _, err := s.Accept(context.Background(), token, "Asha", "secret123"); !errors.Is(err, tt.want) {
+				t.Fatalf("Accept = %v, want %v", err, tt.want)
+			}
+			if db.invitation.Status != tt.wantStatus {
+				t.Errorf("invitation is %s, want %s", db.invitation.Status, tt.wantStatus)
+			}
+			if len(db.users) != tt.wantUsers || len(db.members) != tt.wantUsers || len(db.managers) != tt.wantUsers {
+				t.Errorf("stored %d users, %d members and %d managers, want %d of each", len(db.users), len(db.members), len(db.managers), tt.wantUsers)
+			}
+		})
+	}
+}
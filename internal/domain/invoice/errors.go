@@ -0,0 +1,10 @@
+package invoice
+
+import "errors"
+
+var (
+	ErrNotFound     = errors.New("document not found")
+	ErrInternal     = errors.New("Internal error")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNoStay       = errors.New("booking has no nights to invoice")
+)
@@ -0,0 +1,190 @@
+package invoice
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/booking"
+	"github.com/nevinmanoj/hostmate/internal/domain/payment"
+	"github.com/nevinmanoj/hostmate/internal/domain/property"
+)
+
+type DocumentType string
+
+const (
+	DocumentInvoice DocumentType = "invoice"
+	DocumentReceipt DocumentType = "receipt"
+)
+
+// prefix starts the printed number, each type is numbered on its own per property
+func (t DocumentType) prefix() string {
+	if t == DocumentReceipt {
+		return "RCT"
+	}
+	return "INV"
+}
+
+// Document is an issued invoice or receipt, the rendered pdf is kept in blob storage
+type Document struct {
+	ID              int64        `db:"id"`
+	OrganisationID  int64        `db:"organisation_id"`
+	PropertyID      int64        `db:"property_id"`
+	BookingID       int64        `db:"booking_id"`
+	PaymentID       *int64       `db:"payment_id"`
+	Type            DocumentType `db:"document_type"`
+	Sequence        int64        `db:"sequence"`
+	Number          string       `db:"number"`
+	BookingRevision int          `db:"booking_revision"`
+	Total           float64      `db:"total"`
+	Balance         float64      `db:"balance"`
+	BlobName        string       `db:"blob_name"`
+	IssuedBy        int64        `db:"issued_by"`
+	IssuedAt        time.Time    `db:"issued_at"`
+}
+
+// FormatNumber prints a sequence the way it appears on the document, e.g. INV-12-000042
+func FormatNumber(t DocumentType, propertyID, sequence int64) string {
+	return fmt.Sprintf("%s-%d-%06d", t.prefix(), propertyID, sequence)
+}
+
+// Line is one charge, discount or tax on an invoice
+type Line struct {
+	Description string
+	Quantity    float64
+	UnitPrice   float64
+	Amount      float64
+}
+
+// Invoice is everything printed on an invoice, worked out from the booking and its payments.
+// With a GSTIN it is a tax invoice, TaxInclusive taxes are part of the charges and not added.
+type Invoice struct {
+	Number       string
+	IssuedAt     time.Time
+	Organisation string
+	GSTIN        string
+	Property     property.Property
+	Booking      booking.Booking
+	Nights       int
+	Charges      []Line
+	Discounts    []Line
+	Taxes        []Line
+	TaxInclusive bool
+	Subtotal     float64
+	Taxable      float64
+	Total        float64
+	Payments     []payment.Payment
+	Paid         float64
+	Balance      float64
+}
+
+// Receipt acknowledges a single payment, with what was still owed after it
+type Receipt struct {
+	Number       string
+	IssuedAt     time.Time
+	Organisation string
+	GSTIN        string
+	Property     property.Property
+	Booking      booking.Booking
+	Payment      payment.Payment
+	Total        float64
+	PaidToDate   float64
+	Balance      float64
+}
+
+// BuildInvoice prices the stay the same way the booking is priced, a night at the base rate
+// plus the extra rate for every guest over the base count, with the adjustments on top.
+// The GST is the one the booking was last saved with.
+func BuildInvoice(b *booking.Booking, p *property.Property, adjustments []booking.Adjustment, payments []payment.Payment) *Invoice {
+	nights := b.Nights()
+	inv := &Invoice{
+		Property:     *p,
+		Booking:      *b,
+		Nights:       nights,
+		Payments:     payments,
+		Taxes:        taxLines(b),
+		TaxInclusive: b.TaxInclusive,
+	}
+	inv.Charges = append(inv.Charges, Line{
+		Description: "Stay",
+		Quantity:    float64(nights),
+		UnitPrice:   b.BaseRate,
+		Amount:      round(float64(nights) * b.BaseRate),
+	})
+	if extraGuests := b.NumGuests - b.MaxGuestsBase; extraGuests > 0 && b.ExtraRatePerGuest > 0 {
+		inv.Charges = append(inv.Charges, Line{
+			Description: fmt.Sprintf("Extra guests (%d x %d nights)", extraGuests, nights),
+			Quantity:    float64(extraGuests * nights),
+			UnitPrice:   b.ExtraRatePerGuest,
+			Amount:      round(float64(extraGuests*nights) * b.ExtraRatePerGuest),
+		})
+	}
+	stay := 0.0
+	for _, line := range inv.Charges {
+		stay += line.Amount
+	}
+	for _, adjustment := range adjustments {
+		line := Line{
+			Description: adjustment.Reason,
+			Quantity:    1,
+			UnitPrice:   adjustment.AmountOn(stay),
+			Amount:      adjustment.AmountOn(stay),
+		}
+		if adjustment.Basis == booking.AdjustmentPercent {
+			line.Description += " @ " + strconv.FormatFloat(adjustment.Value, 'f', -1, 64) + "%"
+		}
+		if adjustment.Kind == booking.AdjustmentDiscount {
+			inv.Discounts = append(inv.Discounts, line)
+		} else {
+			inv.Charges = append(inv.Charges, line)
+		}
+	}
+	for _, line := range inv.Charges {
+		inv.Subtotal += line.Amount
+	}
+	// like on the booking, discounts never take the stay below zero
+	discount := 0.0
+	for _, line := range inv.Discounts {
+		discount += line.Amount
+	}
+	inv.Total = inv.Subtotal - math.Min(discount, stay)
+	inv.Taxable = inv.Total
+	for _, line := range inv.Taxes {
+		if inv.TaxInclusive {
+			inv.Taxable -= line.Amount
+		} else {
+			inv.Total += line.Amount
+		}
+	}
+	for _, pay := range payments {
+		inv.Paid += pay.Amount
+	}
+	inv.Subtotal = round(inv.Subtotal)
+	inv.Taxable = round(inv.Taxable)
+	inv.Total = round(inv.Total)
+	inv.Paid = round(inv.Paid)
+	inv.Balance = round(inv.Total - inv.Paid)
+	return inv
+}
+
+// taxLines splits the GST of the booking the way it is charged, central and state or integrated
+func taxLines(b *booking.Booking) []Line {
+	lines := []Line{}
+	halfRate := strconv.FormatFloat(b.TaxRate/2, 'f', -1, 64)
+	if b.CGST > 0 {
+		lines = append(lines, Line{Description: "CGST @ " + halfRate + "%", Amount: b.CGST})
+	}
+	if b.SGST > 0 {
+		lines = append(lines, Line{Description: "SGST @ " + halfRate + "%", Amount: b.SGST})
+	}
+	if b.IGST > 0 {
+		lines = append(lines, Line{Description: "IGST @ " + strconv.FormatFloat(b.TaxRate, 'f', -1, 64) + "%", Amount: b.IGST})
+	}
+	return lines
+}
+
+// round keeps amounts to the paisa
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
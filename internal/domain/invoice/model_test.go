@@ -0,0 +1,190 @@
+package invoice
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/booking"
+	"github.com/nevinmanoj/hostmate/internal/domain/payment"
+	"github.com/nevinmanoj/hostmate/internal/domain/property"
+	"github.com/nevinmanoj/hostmate/internal/domain/tax"
+)
+
+func stay(nights int, baseRate float64, guests int) *booking.Booking {
+	checkIn := time.Date(2026, time.March, 10, 14, 0, 0, 0, time.UTC)
+	return &booking.Booking{
+		BaseRate:          baseRate,
+		MaxGuestsBase:     2,
+		ExtraRatePerGuest: 500,
+		NumGuests:         guests,
+		CheckInDate:       checkIn,
+		CheckOutDate:      checkIn.AddDate(0, 0, nights),
+	}
+}
+
+func descriptions(lines []Line) []string {
+	out := []string{}
+	for _, line := range lines {
+		out = append(out, line.Description)
+	}
+	return out
+}
+
+func TestBuildInvoice(t *testing.T) {
+	intraState := &tax.Config{Supply: tax.SupplyIntraState, Slabs: tax.DefaultSlabs()}
+	interState := &tax.Config{Supply: tax.SupplyInterState, Slabs: tax.DefaultSlabs()}
+	inclusive := &tax.Config{Supply: tax.SupplyIntraState, Inclusive: true, Slabs: tax.DefaultSlabs()}
+
+	tests := []struct {
+		name          string
+		booking       *booking.Booking
+		adjustments   []booking.Adjustment
+		config        *tax.Config
+		payments      []payment.Payment
+		wantCharges   []string
+		wantDiscounts []string
+		wantTaxes     []string
+		subtotal      float64
+		taxable       float64
+		total         float64
+		paid          float64
+		balance       float64
+	}{
+		{
+			name:        "extra guests with GST split between centre and state",
+			booking:     stay(2, 3000, 4),
+			config:      intraState,
+			payments:    []payment.Payment{{Amount: 1000}, {Amount: 2400}},
+			wantCharges: []string{"Stay", "Extra guests (2 x 2 nights)"},
+			wantTaxes:   []string{"CGST @ 2.5%", "SGST @ 2.5%"},
+			subtotal:    8000,
+			taxable:     8000,
+			total:       8400,
+			paid:        3400,
+			balance:     5000,
+		},
+		{
+			name:    "adjustments without GST",
+			booking: stay(3, 2000, 2),
+			adjustments: []booking.Adjustment{
+				{Kind: booking.AdjustmentDiscount, Basis: booking.AdjustmentPercent, Value: 10, Reason: "Festive"},
+				{Kind: booking.AdjustmentCharge, Basis: booking.AdjustmentFlat, Value: 300, Reason: "Late checkout"},
+			},
+			wantCharges:   []string{"Stay", "Late checkout"},
+			wantDiscounts: []string{"Festive @ 10%"},
+			wantTaxes:     []string{},
+			subtotal:      6300,
+			taxable:       5700,
+			total:         5700,
+			balance:       5700,
+		},
+		{
+			name:        "inclusive GST is not added to the total",
+			booking:     stay(1, 5250, 2),
+			config:      inclusive,
+			payments:    []payment.Payment{{Amount: 5250}},
+			wantCharges: []string{"Stay"},
+			wantTaxes:   []string{"CGST @ 2.5%", "SGST @ 2.5%"},
+			subtotal:    5250,
+			taxable:     5000,
+			total:       5250,
+			paid:        5250,
+		},
+		{
+			name:        "integrated GST across states",
+			booking:     stay(1, 10000, 2),
+			config:      interState,
+			wantCharges: []string{"Stay"},
+			wantTaxes:   []string{"IGST @ 18%"},
+			subtotal:    10000,
+			taxable:     10000,
+			total:       11800,
+			balance:     11800,
+		},
+		{
+			name:    "discount is capped at the stay but charges stay on",
+			booking: stay(1, 1000, 2),
+			adjustments: []booking.Adjustment{
+				{Kind: booking.AdjustmentDiscount, Basis: booking.AdjustmentFlat, Value: 1500, Reason: "Goodwill"},
+				{Kind: booking.AdjustmentCharge, Basis: booking.AdjustmentFlat, Value: 200, Reason: "Cleaning"},
+			},
+			payments:      []payment.Payment{{Amount: 500}},
+			wantCharges:   []string{"Stay", "Cleaning"},
+			wantDiscounts: []string{"Goodwill"},
+			wantTaxes:     []string{},
+			subtotal:      1200,
+			taxable:       200,
+			total:         200,
+			paid:          500,
+			balance:       -300,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.booking
+			b.Price(tt.adjustments, tt.config)
+			inv := BuildInvoice(b, &property.Property{}, tt.adjustments, tt.payments)
+
+			if got := descriptions(inv.Charges); !slices.Equal(got, tt.wantCharges) {
+				t.Errorf("charges = %q, want %q", got, tt.wantCharges)
+			}
+			if got := descriptions(inv.Discounts); !slices.Equal(got, tt.wantDiscounts) {
+				t.Errorf("discounts = %q, want %q", got, tt.wantDiscounts)
+			}
+			if got := descriptions(inv.Taxes); !slices.Equal(got, tt.wantTaxes) {
+				t.Errorf("taxes = %q, want %q", got, tt.wantTaxes)
+			}
+			if inv.Subtotal != tt.subtotal || inv.Taxable != tt.taxable || inv.Total != tt.total {
+				t.Errorf("subtotal, taxable, total = %v, %v, %v, want %v, %v, %v",
+					inv.Subtotal, inv.Taxable, inv.Total, tt.subtotal, tt.taxable, tt.total)
+			}
+			if inv.Paid != tt.paid || inv.Balance != tt.balance {
+				t.Errorf("paid, balance = %v, %v, want %v, %v", inv.Paid, inv.Balance, tt.paid, tt.balance)
+			}
+			// the invoice has to agree with what the booking charges
+			if inv.Total != b.TotalAmount {
+				t.Errorf("invoice total %v differs from booking total %v", inv.Total, b.TotalAmount)
+			}
+		})
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	if got := FormatNumber(DocumentInvoice, 12, 42); got != "INV-12-000042" {
+		t.Errorf("FormatNumber = %q, want INV-12-000042", got)
+	}
+}
+
+func TestPaidUpTo(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 0, 0, 0, 0, time.UTC) }
+	payments := []payment.Payment{
+		{ID: 4, Date: day(1)},
+		{ID: 2, Date: day(3)},
+		{ID: 7, Date: day(3)},
+		{ID: 3, Date: day(5)},
+	}
+	tests := []struct {
+		name string
+		pay  payment.Payment
+		want []int64
+	}{
+		{"first payment", payments[0], []int64{4}},
+		{"same day payments are ordered by id", payments[1], []int64{4, 2}},
+		{"later payment on the same day", payments[2], []int64{4, 2, 7}},
+		{"last payment", payments[3], []int64{4, 2, 7, 3}},
+		{"payment not in the list", payment.Payment{ID: 1, Date: day(2)}, []int64{4}},
+		{"payment before all others", payment.Payment{ID: 9, Date: day(0)}, []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int64{}
+			for _, p := range paidUpTo(payments, &tt.pay) {
+				got = append(got, p.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("paidUpTo = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package invoice
+
+import (
+	"context"
+)
+
+type DocumentRepository interface {
+	// NextSequence hands out the next number of a property's series, it has to run in the
+	// transaction storing the document so a failed issue does not leave a gap
+	NextSequence(ctx context.Context, propertyID int64, docType DocumentType) (int64, error)
+	Create(ctx context.Context, doc *Document) error
+	GetByID(ctx context.Context, id int64) (*Document, error)
+	GetByBooking(ctx context.Context, bookingID int64) ([]Document, error)
+	// GetLatest is the last document of the type issued for the booking or, when paymentID is set, the payment
+	GetLatest(ctx context.Context, docType DocumentType, bookingID int64, paymentID *int64) (*Document, error)
+}
+
+// Renderer lays an invoice or receipt out as a pdf
+type Renderer interface {
+	RenderInvoice(inv *Invoice) ([]byte, error)
+	RenderReceipt(rec *Receipt) ([]byte, error)
+}
+
+// Transactor runs fn in one transaction, repositories called with the ctx it gets join in
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
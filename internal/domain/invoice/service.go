@@ -0,0 +1,369 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/attachment"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	"github.com/nevinmanoj/hostmate/internal/domain/booking"
+	"github.com/nevinmanoj/hostmate/internal/domain/organisation"
+	"github.com/nevinmanoj/hostmate/internal/domain/payment"
+	"github.com/nevinmanoj/hostmate/internal/domain/property"
+	"github.com/nevinmanoj/hostmate/internal/domain/tax"
+	"github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// maxPayments is more than a single booking ever collects, they are all listed on the invoice
+const maxPayments = 1000
+
+const contentType = "application/pdf"
+
+// errAlreadyIssued ends the issuing transaction when the document turned out to exist already
+var errAlreadyIssued = errors.New("document already issued")
+
+type InvoiceService interface {
+	IssueInvoice(ctx context.Context, bookingID int64) (*Document, string, error)
+	IssueReceipt(ctx context.Context, paymentID int64) (*Document, string, error)
+	GetByBooking(ctx context.Context, bookingID int64) ([]Document, error)
+	GetDownloadURL(ctx context.Context, id int64) (*Document, string, error)
+}
+
+type invoiceService struct {
+	repo          DocumentRepository
+	transactor    Transactor
+	renderer      Renderer
+	blobStorage   attachment.BlobStorage
+	bookingRepo   booking.BookingReadRepository
+	paymentRepo   payment.PaymentReadRepository
+	propertyRepo  property.PropertyReadRepository
+	orgRepo       organisation.OrganisationRepository
+	taxRepo       tax.TaxRepository
+	accessService access.AccessService
+	auditService  audit.AuditService
+}
+
+func NewInvoiceService(
+	repo DocumentRepository,
+	transactor Transactor,
+	renderer Renderer,
+	blobStorage attachment.BlobStorage,
+	bookingRepo booking.BookingReadRepository,
+	paymentRepo payment.PaymentReadRepository,
+	propertyRepo property.PropertyReadRepository,
+	orgRepo organisation.OrganisationRepository,
+	taxRepo tax.TaxRepository,
+	accessService access.AccessService,
+	auditService audit.AuditService,
+) InvoiceService {
+	return &invoiceService{
+		repo:          repo,
+		transactor:    transactor,
+		renderer:      renderer,
+		blobStorage:   blobStorage,
+		bookingRepo:   bookingRepo,
+		paymentRepo:   paymentRepo,
+		propertyRepo:  propertyRepo,
+		orgRepo:       orgRepo,
+		taxRepo:       taxRepo,
+		accessService: accessService,
+		auditService:  auditService,
+	}
+}
+
+// IssueInvoice numbers and stores a new invoice, unless the last one issued for the booking
+// still matches its current revision, total and balance, then that one is returned
+func (s *invoiceService) IssueInvoice(ctx context.Context, bookingID int64) (*Document, string, error) {
+	userID, err := s.canIssue(ctx, bookingID)
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		return nil, "", err
+	}
+	p, err := s.propertyRepo.GetByID(ctx, b.PropertyID)
+	if err != nil {
+		return nil, "", err
+	}
+	adjustments, err := s.bookingRepo.GetAdjustments(ctx, bookingID)
+	if err != nil {
+		return nil, "", err
+	}
+	payments, _, err := s.paymentRepo.GetByBookingId(ctx, bookingID, maxPayments, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	inv := BuildInvoice(b, p, adjustments, payments)
+	if inv.Nights <= 0 {
+		return nil, "", ErrNoStay
+	}
+	current := func(latest *Document) bool {
+		return latest.BookingRevision == b.Revision && latest.Total == inv.Total && latest.Balance == inv.Balance
+	}
+	latest, err := s.repo.GetLatest(ctx, DocumentInvoice, bookingID, nil)
+	if err != nil && err != ErrNotFound {
+		return nil, "", err
+	}
+	if latest != nil && current(latest) {
+		return s.withURL(latest)
+	}
+	if inv.Organisation, err = s.organisationName(ctx); err != nil {
+		return nil, "", err
+	}
+	if inv.GSTIN, err = s.gstin(ctx, p.ID); err != nil {
+		return nil, "", err
+	}
+
+	doc := &Document{
+		PropertyID:      p.ID,
+		BookingID:       b.ID,
+		Type:            DocumentInvoice,
+		BookingRevision: b.Revision,
+		Total:           inv.Total,
+		Balance:         inv.Balance,
+		IssuedBy:        userID,
+	}
+	doc, err = s.issue(ctx, doc, audit.EntityBooking, b.ID, current, func() ([]byte, error) {
+		inv.Number = doc.Number
+		inv.IssuedAt = doc.IssuedAt
+		return s.renderer.RenderInvoice(inv)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return s.withURL(doc)
+}
+
+// IssueReceipt acknowledges a payment, a payment keeps its receipt until its amount is changed
+func (s *invoiceService) IssueReceipt(ctx context.Context, paymentID int64) (*Document, string, error) {
+	pay, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, "", err
+	}
+	userID, err := s.canIssue(ctx, pay.BookingID)
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := s.bookingRepo.GetByID(ctx, pay.BookingID)
+	if err != nil {
+		return nil, "", err
+	}
+	current := func(latest *Document) bool {
+		return latest.Total == pay.Amount
+	}
+	latest, err := s.repo.GetLatest(ctx, DocumentReceipt, b.ID, &pay.ID)
+	if err != nil && err != ErrNotFound {
+		return nil, "", err
+	}
+	if latest != nil && current(latest) {
+		return s.withURL(latest)
+	}
+	p, err := s.propertyRepo.GetByID(ctx, b.PropertyID)
+	if err != nil {
+		return nil, "", err
+	}
+	adjustments, err := s.bookingRepo.GetAdjustments(ctx, b.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	payments, _, err := s.paymentRepo.GetByBookingId(ctx, b.ID, maxPayments, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	// the balance is what the guest still owed once this payment came in
+	inv := BuildInvoice(b, p, adjustments, paidUpTo(payments, pay))
+	rec := &Receipt{
+		Property:   *p,
+		Booking:    *b,
+		Payment:    *pay,
+		Total:      inv.Total,
+		PaidToDate: inv.Paid,
+		Balance:    inv.Balance,
+	}
+	if rec.Organisation, err = s.organisationName(ctx); err != nil {
+		return nil, "", err
+	}
+	if rec.GSTIN, err = s.gstin(ctx, p.ID); err != nil {
+		return nil, "", err
+	}
+
+	doc := &Document{
+		PropertyID:      p.ID,
+		BookingID:       b.ID,
+		PaymentID:       &pay.ID,
+		Type:            DocumentReceipt,
+		BookingRevision: b.Revision,
+		Total:           pay.Amount,
+		Balance:         rec.Balance,
+		IssuedBy:        userID,
+	}
+	doc, err = s.issue(ctx, doc, audit.EntityPayment, pay.ID, current, func() ([]byte, error) {
+		rec.Number = doc.Number
+		rec.IssuedAt = doc.IssuedAt
+		return s.renderer.RenderReceipt(rec)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return s.withURL(doc)
+}
+
+func (s *invoiceService) GetByBooking(ctx context.Context, bookingID int64) ([]Document, error) {
+	if err := s.canView(ctx, bookingID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByBooking(ctx, bookingID)
+}
+
+func (s *invoiceService) GetDownloadURL(ctx context.Context, id int64) (*Document, string, error) {
+	doc, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.canView(ctx, doc.BookingID); err != nil {
+		return nil, "", err
+	}
+	return s.withURL(doc)
+}
+
+// issue takes the next number and stores the rendered pdf in one transaction, a render or
+// upload failure rolls the number back so the series stays gap free. The document is
+// audited on the booking or payment it was issued for, in the same transaction.
+// Whoever held the number lock before may have issued the same document, so the latest
+// one is checked again under the lock and returned instead when current accepts it.
+func (s *invoiceService) issue(ctx context.Context, doc *Document, entityType audit.EntityType, entityID int64, current func(*Document) bool, render func() ([]byte, error)) (*Document, error) {
+	var issued *Document
+	uploaded := false
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		sequence, err := s.repo.NextSequence(ctx, doc.PropertyID, doc.Type)
+		if err != nil {
+			return err
+		}
+		latest, err := s.repo.GetLatest(ctx, doc.Type, doc.BookingID, doc.PaymentID)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if latest != nil && current(latest) {
+			issued = latest
+			// rolls the number back
+			return errAlreadyIssued
+		}
+		doc.Sequence = sequence
+		doc.Number = FormatNumber(doc.Type, doc.PropertyID, sequence)
+		doc.IssuedAt = time.Now().UTC()
+		pdf, err := render()
+		if err != nil {
+			log.Printf("Failed to render %s %s: %v", doc.Type, doc.Number, err)
+			return ErrInternal
+		}
+		doc.BlobName = fmt.Sprintf("%ss/%d/%s.pdf", doc.Type, doc.PropertyID, uuid.New().String())
+		if err := s.blobStorage.Upload(ctx, doc.BlobName, contentType, pdf); err != nil {
+			log.Printf("Failed to upload %s %s: %v", doc.Type, doc.Number, err)
+			return ErrInternal
+		}
+		uploaded = true
+		if err := s.repo.Create(ctx, doc); err != nil {
+			return err
+		}
+		return s.auditService.RecordChanges(ctx, entityType, entityID, audit.ActionIssue, audit.Changes{
+			string(doc.Type): {After: doc.Number},
+		})
+	})
+	if errors.Is(err, errAlreadyIssued) {
+		return issued, nil
+	}
+	if err != nil {
+		// the number went back to the series, the pdf carrying it must not outlive it
+		if uploaded {
+			if err := s.blobStorage.Delete(context.WithoutCancel(ctx), doc.BlobName); err != nil {
+				log.Printf("Failed to delete %s %s: %v", doc.Type, doc.BlobName, err)
+			}
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *invoiceService) withURL(doc *Document) (*Document, string, error) {
+	url, err := s.blobStorage.GenerateReadURL(doc.BlobName)
+	if err != nil {
+		log.Printf("Failed to generate read URL: %v", err)
+		return nil, "", ErrInternal
+	}
+	return doc, url, nil
+}
+
+// canIssue is for whoever takes payments on the booking
+func (s *invoiceService) canIssue(ctx context.Context, bookingID int64) (int64, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return 0, err
+	}
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return 0, ErrInternal
+	}
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapRecordPayments)
+	if err != nil {
+		return 0, err
+	}
+	if !hasAccess {
+		return 0, ErrUnauthorized
+	}
+	return userID, nil
+}
+
+func (s *invoiceService) canView(ctx context.Context, bookingID int64) error {
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return ErrInternal
+	}
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, userID, access.CapViewFinancials)
+	if err != nil {
+		return err
+	}
+	if !hasAccess {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func (s *invoiceService) organisationName(ctx context.Context) (string, error) {
+	tenantID, ok := middleware.TenantFromContext(ctx)
+	if !ok {
+		return "", ErrInternal
+	}
+	org, err := s.orgRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	return org.Name, nil
+}
+
+// gstin is the registration of the property, empty when it charges no GST
+func (s *invoiceService) gstin(ctx context.Context, propertyID int64) (string, error) {
+	config, err := s.taxRepo.GetConfig(ctx, propertyID)
+	if err == tax.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return config.GSTIN, nil
+}
+
+// paidUpTo keeps the payments made before the given one, and the payment itself
+func paidUpTo(payments []payment.Payment, pay *payment.Payment) []payment.Payment {
+	paid := []payment.Payment{}
+	for _, p := range payments {
+		if p.Date.Before(pay.Date) || (p.Date.Equal(pay.Date) && p.ID <= pay.ID) {
+			paid = append(paid, p)
+		}
+	}
+	return paid
+}
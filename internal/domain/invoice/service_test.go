@@ -0,0 +1,115 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/attachment"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+)
+
+// fakeDocumentRepo hands out numbers from next, latest is what another request already issued
+type fakeDocumentRepo struct {
+	DocumentRepository
+	next      int64
+	latest    *Document
+	createErr error
+	created   []Document
+}
+
+func (r *fakeDocumentRepo) NextSequence(ctx context.Context, propertyID int64, docType DocumentType) (int64, error) {
+	return r.next, nil
+}
+
+func (r *fakeDocumentRepo) GetLatest(ctx context.Context, docType DocumentType, bookingID int64, paymentID *int64) (*Document, error) {
+	if r.latest == nil {
+		return nil, ErrNotFound
+	}
+	return r.latest, nil
+}
+
+func (r *fakeDocumentRepo) Create(ctx context.Context, doc *Document) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.created = append(r.created, *doc)
+	return nil
+}
+
+type fakeBlobs struct {
+	attachment.BlobStorage
+	uploaded []string
+	deleted  []string
+}
+
+func (b *fakeBlobs) Upload(ctx context.Context, blobName, contentType string, data []byte) error {
+	b.uploaded = append(b.uploaded, blobName)
+	return nil
+}
+
+func (b *fakeBlobs) Delete(ctx context.Context, blobName string) error {
+	b.deleted = append(b.deleted, blobName)
+	return nil
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeAudit struct {
+	audit.AuditService
+}
+
+func (fakeAudit) RecordChanges(ctx context.Context, entityType audit.EntityType, entityID int64, action audit.Action, changes audit.Changes) error {
+	return nil
+}
+
+func TestIssue(t *testing.T) {
+	issuedMeanwhile := &Document{ID: 9, Number: "INV-12-000041", BookingRevision: 3}
+	tests := []struct {
+		name      string
+		latest    *Document
+		createErr error
+		wantErr   bool
+		number    string
+		uploads   int
+		deletes   int
+	}{
+		{name: "next number", number: "INV-12-000042", uploads: 1},
+		// a request holding the number lock before issued the same document
+		{name: "issued while waiting for the lock", latest: issuedMeanwhile, number: "INV-12-000041"},
+		{name: "older document of the booking", latest: &Document{ID: 8, BookingRevision: 2}, number: "INV-12-000042", uploads: 1},
+		// the number is rolled back, so the pdf carrying it goes as well
+		{name: "document not stored", createErr: errors.New("connection reset"), wantErr: true, uploads: 1, deletes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeDocumentRepo{next: 42, latest: tt.latest, createErr: tt.createErr}
+			blobs := &fakeBlobs{}
+			s := &invoiceService{repo: repo, transactor: fakeTransactor{}, blobStorage: blobs, auditService: fakeAudit{}}
+			current := func(latest *Document) bool { return latest.BookingRevision == 3 }
+			render := func() ([]byte, error) { return []byte("%PDF"), nil }
+
+			doc := &Document{Type: DocumentInvoice, PropertyID: 12, BookingID: 1, BookingRevision: 3}
+			issued, err := s.issue(context.Background(), doc, audit.EntityBooking, 1, current, render)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("issue = %+v, want an error", issued)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if issued.Number != tt.number {
+				t.Errorf("issued %s, want %s", issued.Number, tt.number)
+			}
+			if len(blobs.uploaded) != tt.uploads || len(blobs.deleted) != tt.deletes {
+				t.Errorf("uploaded %v and deleted %v, want %d uploads and %d deletes", blobs.uploaded, blobs.deleted, tt.uploads, tt.deletes)
+			}
+			if tt.deletes > 0 && blobs.deleted[0] != blobs.uploaded[0] {
+				t.Errorf("deleted %s, want the uploaded %s", blobs.deleted[0], blobs.uploaded[0])
+			}
+		})
+	}
+}
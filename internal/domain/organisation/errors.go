@@ -0,0 +1,15 @@
+package organisation
+
+import (
+	"errors"
+)
+
+var (
+	ErrNotFound     = errors.New("Organisation not found")
+	ErrInternal     = errors.New("Internal error")
+	ErrUnauthorized = errors.New("Unauthorized")
+	ErrNotMember    = errors.New("user is not a member of the organisation")
+	ErrInvalidRole  = errors.New("invalid organisation role")
+	ErrLastOwner    = errors.New("organisation must keep at least one owner")
+	ErrNoTenant     = errors.New("no organisation selected")
+)
@@ -0,0 +1,47 @@
+package organisation
+
+import (
+	"time"
+)
+
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+// Organisation is a tenant, a hosting business owning its properties, bookings and payments
+type Organisation struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	CreatedBy int64     `db:"created_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Membership is an organisation as seen by one of its members
+type Membership struct {
+	Organisation
+	Role Role `db:"role"`
+}
+
+type Member struct {
+	OrganisationID int64     `db:"organisation_id"`
+	UserID         int64     `db:"user_id"`
+	Role           Role      `db:"role"`
+	Name           string    `db:"name"`
+	Email          string    `db:"email"`
+	CreatedAt      time.Time `db:"created_at"`
+	// SessionGeneration of the user, a switched session has to carry the current one
+	SessionGeneration int `db:"session_generation"`
+}
+
+func (r Role) Valid() bool {
+	return r == RoleOwner || r == RoleAdmin || r == RoleMember
+}
+
+// CanManageMembers is true for owners and admins
+func (r Role) CanManageMembers() bool {
+	return r == RoleOwner || r == RoleAdmin
+}
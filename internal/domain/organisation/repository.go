@@ -0,0 +1,18 @@
+package organisation
+
+import (
+	"context"
+)
+
+// OrganisationRepository is the tenant registry itself, so it takes organisation ids explicitly
+type OrganisationRepository interface {
+	Create(ctx context.Context, org *Organisation) error
+	GetByID(ctx context.Context, id int64) (*Organisation, error)
+	GetForUser(ctx context.Context, userID int64) ([]Membership, error)
+	GetMember(ctx context.Context, organisationID, userID int64) (*Member, error)
+	GetMembers(ctx context.Context, organisationID int64) ([]Member, error)
+	AddMember(ctx context.Context, organisationID, userID int64, role Role) error
+	UpdateMemberRole(ctx context.Context, organisationID, userID int64, role Role) error
+	RemoveMember(ctx context.Context, organisationID, userID int64) error
+	CountOwners(ctx context.Context, organisationID int64) (int, error)
+}
@@ -0,0 +1,178 @@
+package organisation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+type OrganisationService interface {
+	Create(ctx context.Context, name string) (*Organisation, error)
+	GetMine(ctx context.Context) ([]Membership, error)
+	GetCurrent(ctx context.Context) (*Membership, error)
+	GetMembers(ctx context.Context) ([]Member, error)
+	UpdateMemberRole(ctx context.Context, userID int64, role Role) (*Member, error)
+	RemoveMember(ctx context.Context, userID int64) error
+	Switch(ctx context.Context, organisationID int64) (string, error)
+}
+
+type organisationService struct {
+	repo OrganisationRepository
+	keys *auth.KeySet
+}
+
+func NewOrganisationService(repo OrganisationRepository, keys *auth.KeySet) OrganisationService {
+	return &organisationService{repo: repo, keys: keys}
+}
+
+func (s *organisationService) Create(ctx context.Context, name string) (*Organisation, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	org := &Organisation{
+		Name:      strings.TrimSpace(name),
+		CreatedBy: userID,
+	}
+	// the creator becomes the first owner
+	err := s.repo.Create(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organisationService) GetMine(ctx context.Context) ([]Membership, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	return s.repo.GetForUser(ctx, userID)
+}
+
+func (s *organisationService) GetCurrent(ctx context.Context) (*Membership, error) {
+	member, err := s.currentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.repo.GetByID(ctx, member.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+	return &Membership{Organisation: *org, Role: member.Role}, nil
+}
+
+func (s *organisationService) GetMembers(ctx context.Context) ([]Member, error) {
+	member, err := s.currentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(ctx, member.OrganisationID)
+}
+
+func (s *organisationService) UpdateMemberRole(ctx context.Context, userID int64, role Role) (*Member, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	caller, err := s.currentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.repo.GetMember(ctx, caller.OrganisationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCanManage(caller, target, role); err != nil {
+		return nil, err
+	}
+	if target.Role == RoleOwner && role != RoleOwner {
+		if err := s.checkNotLastOwner(ctx, caller.OrganisationID); err != nil {
+			return nil, err
+		}
+	}
+	err = s.repo.UpdateMemberRole(ctx, caller.OrganisationID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	target.Role = role
+	return target, nil
+}
+
+// RemoveMember also takes the user off every property of the organisation
+func (s *organisationService) RemoveMember(ctx context.Context, userID int64) error {
+	caller, err := s.currentMember(ctx)
+	if err != nil {
+		return err
+	}
+	target, err := s.repo.GetMember(ctx, caller.OrganisationID, userID)
+	if err != nil {
+		return err
+	}
+	// members may always leave, removing someone else needs the manage permission
+	if caller.UserID != target.UserID {
+		if err := s.checkCanManage(caller, target, target.Role); err != nil {
+			return err
+		}
+	}
+	if target.Role == RoleOwner {
+		if err := s.checkNotLastOwner(ctx, caller.OrganisationID); err != nil {
+			return err
+		}
+	}
+	return s.repo.RemoveMember(ctx, caller.OrganisationID, userID)
+}
+
+// Switch issues a session token acting in another organisation the caller belongs to
+func (s *organisationService) Switch(ctx context.Context, organisationID int64) (string, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	member, err := s.repo.GetMember(ctx, organisationID, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotMember) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	token, err := auth.GenerateToken(userID, member.Email, organisationID, member.SessionGeneration, s.keys)
+	if err != nil {
+		log.Println("Error generating session token:", err)
+		return "", ErrInternal
+	}
+	return token, nil
+}
+
+// helpers
+func (s *organisationService) currentMember(ctx context.Context) (*Member, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	tenantID, ok := middleware.TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	member, err := s.repo.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotMember) {
+			return nil, ErrUnauthorized
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// only owners can hand out or take away ownership, admins manage everyone else
+func (s *organisationService) checkCanManage(caller, target *Member, role Role) error {
+	if !caller.Role.CanManageMembers() {
+		return ErrUnauthorized
+	}
+	if caller.Role != RoleOwner && (target.Role == RoleOwner || role == RoleOwner) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func (s *organisationService) checkNotLastOwner(ctx context.Context, organisationID int64) error {
+	owners, err := s.repo.CountOwners(ctx, organisationID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
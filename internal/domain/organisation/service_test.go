@@ -0,0 +1,126 @@
+package organisation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// fakeOrganisationRepo holds the members of organisation 1, methods the tests do not need are left to the nil interface
+type fakeOrganisationRepo struct {
+	OrganisationRepository
+	members map[int64]Role
+}
+
+func (r *fakeOrganisationRepo) GetMember(ctx context.Context, organisationID, userID int64) (*Member, error) {
+	role, ok := r.members[userID]
+	if organisationID != 1 || !ok {
+		return nil, ErrNotMember
+	}
+	return &Member{OrganisationID: organisationID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeOrganisationRepo) UpdateMemberRole(ctx context.Context, organisationID, userID int64, role Role) error {
+	r.members[userID] = role
+	return nil
+}
+
+func (r *fakeOrganisationRepo) RemoveMember(ctx context.Context, organisationID, userID int64) error {
+	delete(r.members, userID)
+	return nil
+}
+
+func (r *fakeOrganisationRepo) CountOwners(ctx context.Context, organisationID int64) (int, error) {
+	owners := 0
+	for _, role := range r.members {
+		if role == RoleOwner {
+			owners++
+		}
+	}
+	return owners, nil
+}
+
+func memberContext(userID, tenantID int64) context.Context {
+	ctx := context.WithValue(context.Background(), middleware.ContextUserKey, userID)
+	return middleware.WithTenant(ctx, tenantID)
+}
+
+func TestUpdateMemberRole(t *testing.T) {
+	// 1 owns the organisation, 2 is an admin, 3 and 4 are members
+	tests := []struct {
+		name   string
+		caller int64
+		target int64
+		role   Role
+		want   error
+	}{
+		{"owner promotes a member to admin", 1, 3, RoleAdmin, nil},
+		{"owner hands out ownership", 1, 3, RoleOwner, nil},
+		{"admin promotes a member to admin", 2, 3, RoleAdmin, nil},
+		{"admin hands out ownership", 2, 3, RoleOwner, ErrUnauthorized},
+		{"admin demotes the owner", 2, 1, RoleMember, ErrUnauthorized},
+		{"member promotes another member", 3, 4, RoleAdmin, ErrUnauthorized},
+		{"member promotes themselves", 3, 3, RoleAdmin, ErrUnauthorized},
+		{"last owner steps down", 1, 1, RoleAdmin, ErrLastOwner},
+		{"unknown role", 1, 3, Role("superuser"), ErrInvalidRole},
+		{"user outside the organisation", 1, 9, RoleAdmin, ErrNotMember},
+		{"caller outside the organisation", 9, 3, RoleAdmin, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOrganisationRepo{members: map[int64]Role{1: RoleOwner, 2: RoleAdmin, 3: RoleMember, 4: RoleMember}}
+			s := &organisationService{repo: repo}
+			_, err := s.UpdateMemberRole(memberContext(tt.caller, 1), tt.target, tt.role)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("UpdateMemberRole = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && repo.members[tt.target] != tt.role {
+				t.Errorf("role of %d = %s, want %s", tt.target, repo.members[tt.target], tt.role)
+			}
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	tests := []struct {
+		name   string
+		owners int
+		caller int64
+		target int64
+		want   error
+	}{
+		{"member leaves", 1, 3, 3, nil},
+		{"admin removes a member", 1, 2, 3, nil},
+		{"member removes another member", 1, 3, 4, ErrUnauthorized},
+		{"admin removes the owner", 2, 2, 1, ErrUnauthorized},
+		{"owner removes a co-owner", 2, 1, 5, nil},
+		{"last owner leaves", 1, 1, 1, ErrLastOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := map[int64]Role{1: RoleOwner, 2: RoleAdmin, 3: RoleMember, 4: RoleMember}
+			if tt.owners > 1 {
+				members[5] = RoleOwner
+			}
+			repo := &fakeOrganisationRepo{members: members}
+			s := &organisationService{repo: repo}
+			err := s.RemoveMember(memberContext(tt.caller, 1), tt.target)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("RemoveMember = %v, want %v", err, tt.want)
+			}
+			if _, kept := repo.members[tt.target]; kept == (tt.want == nil) {
+				t.Errorf("member %d kept = %v", tt.target, kept)
+			}
+		})
+	}
+}
+
+func TestNoTenantSelected(t *testing.T) {
+	s := &organisationService{repo: &fakeOrganisationRepo{members: map[int64]Role{1: RoleOwner}}}
+	ctx := context.WithValue(context.Background(), middleware.ContextUserKey, int64(1))
+	if _, err := s.GetCurrent(ctx); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("GetCurrent without a tenant = %v, want %v", err, ErrNoTenant)
+	}
+}
@@ -0,0 +1,119 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	byName := Sort{Field: "name"}
+	byNewest := Sort{Field: "created_at", Desc: true}
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name    string
+		cursor  string
+		sort    Sort
+		want    *Cursor
+		wantErr bool
+	}{
+		{
+			name:   "round trip",
+			cursor: Cursor{Sort: "name", Value: "Beach House", ID: 12}.Encode(),
+			sort:   byName,
+			want:   &Cursor{Sort: "name", Value: "Beach House", ID: 12},
+		},
+		{
+			name:   "backward descending cursor",
+			cursor: Cursor{Sort: "-created_at", Value: "2026-03-10T14:00:00Z", ID: 3, Backward: true}.Encode(),
+			sort:   byNewest,
+			want:   &Cursor{Sort: "-created_at", Value: "2026-03-10T14:00:00Z", ID: 3, Backward: true},
+		},
+		{
+			name:    "issued for another sort",
+			cursor:  Cursor{Sort: "name", Value: "Beach House", ID: 12}.Encode(),
+			sort:    Sort{Field: "name", Desc: true},
+			wantErr: true,
+		},
+		{
+			name:    "not base64",
+			cursor:  "not a cursor!",
+			sort:    byName,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			cursor:  encode("name:a:1"),
+			sort:    byName,
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			cursor:  encode(`{"s":"name","v":"a"}`),
+			sort:    byName,
+			wantErr: true,
+		},
+		{
+			name:    "negative id",
+			cursor:  encode(`{"s":"name","v":"a","id":-4}`),
+			sort:    byName,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			cursor:  "",
+			sort:    byName,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor, tt.sort)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("DecodeCursor error = %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeCursor returned %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("DecodeCursor = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"name", "created_at"}
+	tests := []struct {
+		value   string
+		want    Sort
+		wantErr bool
+	}{
+		{value: "name", want: Sort{Field: "name"}},
+		{value: "-created_at", want: Sort{Field: "created_at", Desc: true}},
+		{value: "price", wantErr: true},
+		{value: "--name", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSort(tt.value, allowed)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSort) {
+					t.Errorf("ParseSort error = %v, want ErrInvalidSort", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseSort = %+v, %v, want %+v", got, err, tt.want)
+			}
+			if got.String() != tt.value {
+				t.Errorf("String() = %q, want %q", got.String(), tt.value)
+			}
+		})
+	}
+}
@@ -5,8 +5,10 @@ import (
 )
 
 var (
-	ErrNotFound          = errors.New("Payment not found")
-	ErrNotValidBookingId = errors.New("Booking Id is not valid")
-	ErrInternal          = errors.New("Internal error")
-	ErrUnauthorized      = errors.New("unauthorized")
+	ErrNotFound             = errors.New("Payment not found")
+	ErrNotValidBookingId    = errors.New("Booking Id is not valid")
+	ErrInternal             = errors.New("Internal error")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrVersionConflict      = errors.New("payment was modified since it was read")
+	ErrRestoreWindowExpired = errors.New("payment was deleted too long ago to be restored")
 )
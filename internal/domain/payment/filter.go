@@ -2,13 +2,28 @@ package payment
 
 import (
 	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 )
 
+// SortFields are the fields a payment list can be sorted on
+var SortFields = []string{"created_at", "date", "amount"}
+
 type PaymentFilter struct {
-	UserID      *int64
-	FromDate    *time.Time
-	ToDate      *time.Time
-	PaymentType []PaymentType
-	Limit       int
-	Offset      int
+	UserID         *int64
+	FromDate       *time.Time
+	ToDate         *time.Time
+	PaymentType    []PaymentType
+	PropertyID     []int64
+	BookingID      []int64
+	MinAmount      *float64
+	MaxAmount      *float64
+	CreatedBy      *int64
+	Remarks        *string
+	IncludeDeleted bool
+	Sort           pagination.Sort
+	Cursor         *pagination.Cursor
+	SkipCount      bool
+	Limit          int
+	Offset         int
 }
@@ -14,14 +14,31 @@ const (
 )
 
 type Payment struct {
-	ID          int64       `db:"id"`
-	Amount      float64     `db:"amount"`
-	Date        time.Time   `db:"date"`
+	ID             int64       `db:"id"`
+	OrganisationID int64       `db:"organisation_id"`
+	Amount         float64     `db:"amount"`
+	Date           time.Time   `db:"date"`
+	PaymentType    PaymentType `db:"payment_type"`
+	BookingID      int64       `db:"booking_id"`
+	Remarks        string      `db:"remarks"`
+	CreatedAt      time.Time   `db:"created_at"`
+	UpdatedAt      time.Time   `db:"updated_at"`
+	CreatedBy      int64       `db:"created_by"`
+	UpdatedBy      int64       `db:"updated_by"`
+	Version        int         `db:"version"`
+	DeletedAt      *time.Time  `db:"deleted_at"`
+	DeletedBy      *int64      `db:"deleted_by"`
+}
+
+// PaymentTotals add up every payment matching a filter, not only the page returned
+type PaymentTotals struct {
+	Count  int
+	Amount float64
+	ByType []PaymentTypeTotal
+}
+
+type PaymentTypeTotal struct {
 	PaymentType PaymentType `db:"payment_type"`
-	BookingID   int64       `db:"booking_id"`
-	Remarks     string      `db:"remarks"`
-	CreatedAt   time.Time   `db:"created_at"`
-	UpdatedAt   time.Time   `db:"updated_at"`
-	CreatedBy   int64       `db:"created_by"`
-	UpdatedBy   int64       `db:"updated_by"`
+	Count       int         `db:"count"`
+	Amount      float64     `db:"amount"`
 }
@@ -2,18 +2,28 @@ package payment
 
 import (
 	"context"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 )
 
 type PaymentReadRepository interface {
-	GetAll(ctx context.Context, filter PaymentFilter) ([]Payment, int, error)
+	GetAll(ctx context.Context, filter PaymentFilter) ([]Payment, PaymentTotals, pagination.Cursors, error)
 	GetByBookingId(ctx context.Context, bookingID int64, limit, offset int) ([]Payment, int, error)
 	GetByPropertyId(ctx context.Context, propertyID int64, limit, offset int) ([]Payment, int, error)
 	GetByID(ctx context.Context, id int64) (*Payment, error)
 	GetBlobs(ctx context.Context, paymentID int64) ([]string, error)
+	GetDeletedByID(ctx context.Context, id int64) (*Payment, error)
 }
 type PaymentWriteRepository interface {
 	PaymentReadRepository
 	Create(ctx context.Context, property *Payment) error
 	Update(ctx context.Context, property *Payment) error
 	AppendBlobs(ctx context.Context, paymentID int64, blobName string) error
+	Delete(ctx context.Context, id, deletedBy int64) error
+	Restore(ctx context.Context, id, restoredBy int64) error
+}
+
+// Transactor runs fn in one transaction, repositories called with the ctx it gets join in
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
@@ -5,30 +5,40 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
 	booking "github.com/nevinmanoj/hostmate/internal/domain/booking"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	property "github.com/nevinmanoj/hostmate/internal/domain/property"
 	user "github.com/nevinmanoj/hostmate/internal/domain/user"
 	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
 )
 
 type PaymentService interface {
-	GetAll(ctx context.Context, filter PaymentFilter) ([]Payment, int, error)
+	GetAll(ctx context.Context, filter PaymentFilter) ([]Payment, PaymentTotals, pagination.Cursors, error)
 	GetWithBookingId(ctx context.Context, bookingID int64, page, pageSize int) ([]Payment, int, error)
 	GetById(ctx context.Context, id int64) (*Payment, error)
 	Create(ctx context.Context, property *Payment) error
 	Update(ctx context.Context, property *Payment) error
 	ConfirmBlobsUpload(ctx context.Context, paymentID int64, blobName string) error
 	GetBlobs(ctx context.Context, paymentID int64) ([]string, error)
+	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64) (*Payment, error)
 }
 
+// deleted payments can be restored for this long, afterwards they stay archived
+const restoreWindow = 30 * 24 * time.Hour
+
 type paymentService struct {
 	repo          PaymentWriteRepository
 	bookingRepo   booking.BookingReadRepository
 	propertyRepo  property.PropertyReadRepository
 	userRepo      user.UserReadRepository
 	accessService access.AccessService
+	auditService  audit.AuditService
+	transactor    Transactor
 }
 
 func NewPaymentService(
@@ -36,31 +46,44 @@ func NewPaymentService(
 	accessService access.AccessService,
 	userRepo user.UserReadRepository,
 	bookingRepo booking.BookingReadRepository,
-	propertyRepo property.PropertyReadRepository) PaymentService {
+	propertyRepo property.PropertyReadRepository,
+	auditService audit.AuditService,
+	transactor Transactor) PaymentService {
 	return &paymentService{
 		repo:          repo,
 		userRepo:      userRepo,
 		bookingRepo:   bookingRepo,
 		accessService: accessService,
 		propertyRepo:  propertyRepo,
+		auditService:  auditService,
+		transactor:    transactor,
 	}
 }
 
-func (s *paymentService) GetAll(ctx context.Context, filter PaymentFilter) ([]Payment, int, error) {
+func (s *paymentService) GetAll(ctx context.Context, filter PaymentFilter) ([]Payment, PaymentTotals, pagination.Cursors, error) {
 
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	if filter.IncludeDeleted {
+		isAdmin, err := s.accessService.IsAdmin(ctx, userID)
+		if err != nil {
+			return nil, PaymentTotals{}, pagination.Cursors{}, ErrInternal
+		}
+		if !isAdmin {
+			return nil, PaymentTotals{}, pagination.Cursors{}, ErrUnauthorized
+		}
+	}
 	// TODO setup admin bypass
 	filter.UserID = &userID
-	data, total, err := s.repo.GetAll(ctx, filter)
+	data, totals, cursors, err := s.repo.GetAll(ctx, filter)
 	if err != nil {
 		log.Println("Error fetching payments:", err)
-		return nil, 0, ErrInternal
+		return nil, PaymentTotals{}, pagination.Cursors{}, ErrInternal
 	}
-	return data, total, nil
+	return data, totals, cursors, nil
 }
 func (s *paymentService) GetWithBookingId(ctx context.Context, bookingID int64, limit, offset int) ([]Payment, int, error) {
 	user := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessBooking(ctx, bookingID, user)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, bookingID, user, access.CapViewFinancials)
 	if err != nil {
 		return nil, 0, ErrInternal
 	}
@@ -77,7 +100,7 @@ func (s *paymentService) GetWithBookingId(ctx context.Context, bookingID int64,
 func (s *paymentService) GetWithPropertyId(ctx context.Context, propertyID int64, limit, offset int) ([]Payment, int, error) {
 
 	user := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessProperty(ctx, propertyID, user)
+	hasAccess, err := s.accessService.HasPropertyCapability(ctx, propertyID, user, access.CapViewFinancials)
 	if err != nil {
 		return nil, 0, ErrInternal
 	}
@@ -95,7 +118,7 @@ func (s *paymentService) GetWithPropertyId(ctx context.Context, propertyID int64
 
 func (s *paymentService) GetById(ctx context.Context, id int64) (*Payment, error) {
 	user := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessPayment(ctx, id, user)
+	hasAccess, err := s.accessService.HasPaymentCapability(ctx, id, user, access.CapViewFinancials)
 	if err != nil {
 		return nil, ErrInternal
 	}
@@ -114,15 +137,22 @@ func (s *paymentService) GetById(ctx context.Context, id int64) (*Payment, error
 }
 
 func (s *paymentService) Create(ctx context.Context, paymentToCreate *Payment) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 
 	user := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessBooking(ctx, paymentToCreate.BookingID, user)
+	hasAccess, err := s.accessService.HasBookingCapability(ctx, paymentToCreate.BookingID, user, access.CapRecordPayments)
 	if err != nil {
 		return ErrInternal
 	}
 	if !hasAccess {
 		return ErrUnauthorized
 	}
+	// payments cannot be recorded against a deleted booking
+	if _, err := s.bookingRepo.GetByID(ctx, paymentToCreate.BookingID); err != nil {
+		return ErrNotValidBookingId
+	}
 	// Validate payment fields as needed, bookingID,images should exist
 	createdBy, ok := ctx.Value(middleware.ContextUserKey).(int64)
 	if !ok {
@@ -132,17 +162,21 @@ func (s *paymentService) Create(ctx context.Context, paymentToCreate *Payment) e
 	fmt.Printf("created by user:%d", createdBy)
 	paymentToCreate.CreatedBy = createdBy
 	paymentToCreate.UpdatedBy = createdBy
-	err = s.repo.Create(ctx, paymentToCreate)
-	if err != nil {
-		log.Println("Error creating payment:", err)
-		return ErrInternal
-	}
-	return nil
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, paymentToCreate); err != nil {
+			log.Println("Error creating payment:", err)
+			return ErrInternal
+		}
+		return s.auditService.Record(ctx, audit.EntityPayment, paymentToCreate.ID, audit.ActionCreate, nil, paymentToCreate)
+	})
 }
 
 func (s *paymentService) Update(ctx context.Context, paymentToUpdate *Payment) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 	user := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessPayment(ctx, paymentToUpdate.ID, user)
+	hasAccess, err := s.accessService.HasPaymentCapability(ctx, paymentToUpdate.ID, user, access.CapRecordPayments)
 	if err != nil {
 		return ErrInternal
 	}
@@ -155,20 +189,33 @@ func (s *paymentService) Update(ctx context.Context, paymentToUpdate *Payment) e
 		log.Printf("Error fetching payment with id %d: %s", paymentToUpdate.ID, err.Error())
 		return err
 	}
+	// the client must have seen the latest version of the payment
+	if paymentToUpdate.Version != paymentFromDb.Version {
+		return ErrVersionConflict
+	}
 
+	// the booking of a payment is fixed once recorded
+	paymentToUpdate.BookingID = paymentFromDb.BookingID
 	paymentToUpdate.CreatedBy = paymentFromDb.CreatedBy
 	paymentToUpdate.CreatedAt = paymentFromDb.CreatedAt
 	paymentToUpdate.UpdatedBy = user
-	err = s.repo.Update(ctx, paymentToUpdate)
-	if err != nil {
-		log.Println("Error updating property:", err)
-		return ErrInternal
-	}
-	return nil
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, paymentToUpdate); err != nil {
+			log.Println("Error updating property:", err)
+			if errors.Is(err, ErrVersionConflict) {
+				return ErrVersionConflict
+			}
+			return ErrInternal
+		}
+		return s.auditService.Record(ctx, audit.EntityPayment, paymentToUpdate.ID, audit.ActionUpdate, paymentFromDb, paymentToUpdate)
+	})
 }
 func (s *paymentService) ConfirmBlobsUpload(ctx context.Context, paymentID int64, blobName string) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessPayment(ctx, paymentID, userID)
+	hasAccess, err := s.accessService.HasPaymentCapability(ctx, paymentID, userID, access.CapManageAttachments)
 	if err != nil {
 		return err
 	}
@@ -183,7 +230,7 @@ func (s *paymentService) ConfirmBlobsUpload(ctx context.Context, paymentID int64
 }
 func (s *paymentService) GetBlobs(ctx context.Context, paymentID int64) ([]string, error) {
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessPayment(ctx, paymentID, userID)
+	hasAccess, err := s.accessService.HasPaymentCapability(ctx, paymentID, userID, access.CapViewFinancials)
 	if err != nil {
 		return nil, err
 	}
@@ -196,3 +243,63 @@ func (s *paymentService) GetBlobs(ctx context.Context, paymentID int64) ([]strin
 	}
 	return blobs, nil
 }
+
+// Delete archives the payment, it can be restored within the restore window
+func (s *paymentService) Delete(ctx context.Context, id int64) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
+	user := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasPaymentCapability(ctx, id, user, access.CapRecordPayments)
+	if err != nil {
+		return ErrInternal
+	}
+	if !hasAccess {
+		return ErrUnauthorized
+	}
+	paymentFromDb, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, user); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, audit.EntityPayment, id, audit.ActionDelete, paymentFromDb, nil)
+	})
+}
+
+func (s *paymentService) Restore(ctx context.Context, id int64) (*Payment, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	user := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasPaymentCapability(ctx, id, user, access.CapRecordPayments)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !hasAccess {
+		return nil, ErrUnauthorized
+	}
+	deleted, err := s.repo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(*deleted.DeletedAt) > restoreWindow {
+		return nil, ErrRestoreWindowExpired
+	}
+	// the booking has to be restored first
+	if _, err := s.bookingRepo.GetByID(ctx, deleted.BookingID); err != nil {
+		return nil, ErrNotValidBookingId
+	}
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, id, user); err != nil {
+			return err
+		}
+		return s.auditService.RecordChanges(ctx, audit.EntityPayment, id, audit.ActionRestore, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
@@ -5,8 +5,14 @@ import (
 )
 
 var (
-	ErrNotFound         = errors.New("Property Not found")
-	ErrNotValidManagers = errors.New("managers are not valid")
-	ErrInternal         = errors.New("internal error")
-	ErrUnauthorized     = errors.New("unauthorized")
+	ErrNotFound             = errors.New("Property Not found")
+	ErrNotValidManagers     = errors.New("managers are not valid")
+	ErrInternal             = errors.New("internal error")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrMemberNotFound       = errors.New("property member not found")
+	ErrNotValidMember       = errors.New("member must belong to the organisation and not manage the property")
+	ErrInvalidCapabilities  = errors.New("capabilities are not valid")
+	ErrSelfGrant            = errors.New("users cannot change their own capabilities")
+	ErrVersionConflict      = errors.New("property was modified since it was read")
+	ErrRestoreWindowExpired = errors.New("property was deleted too long ago to be restored")
 )
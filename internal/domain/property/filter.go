@@ -1,10 +1,20 @@
 package property
 
+import "github.com/nevinmanoj/hostmate/internal/domain/pagination"
+
+// SortFields are the fields a property list can be sorted on
+var SortFields = []string{"created_at", "name", "base_rate"}
+
 type PropertyFilter struct {
-	UserID    *int64
-	Type      []PropertyType
-	ManagerID *int64
-	Active    *bool
-	Limit     int
-	Offset    int
+	UserID         *int64
+	Type           []PropertyType
+	ManagerID      *int64
+	Active         *bool
+	Query          *string
+	IncludeDeleted bool
+	Sort           pagination.Sort
+	Cursor         *pagination.Cursor
+	SkipCount      bool
+	Limit          int
+	Offset         int
 }
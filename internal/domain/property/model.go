@@ -16,6 +16,7 @@ const (
 
 type Property struct {
 	ID                int64          `db:"id"`
+	OrganisationID    int64          `db:"organisation_id"`
 	Name              string         `db:"name"`
 	Address           string         `db:"address"`
 	Type              PropertyType   `db:"type"`
@@ -29,4 +30,21 @@ type Property struct {
 	UpdatedAt         time.Time      `db:"updated_at"`
 	CreatedBy         int64          `db:"created_by"`
 	UpdatedBy         int64          `db:"updated_by"`
+	Version           int            `db:"version"`
+	DeletedAt         *time.Time     `db:"deleted_at"`
+	DeletedBy         *int64         `db:"deleted_by"`
+}
+
+// PropertyMember is a user granted some capabilities on a property without managing it,
+// the capability values are defined in the access package
+type PropertyMember struct {
+	PropertyID     int64          `db:"property_id"`
+	UserID         int64          `db:"user_id"`
+	OrganisationID int64          `db:"organisation_id"`
+	Capabilities   pq.StringArray `db:"capabilities"`
+	Name           string         `db:"name"`
+	Email          string         `db:"email"`
+	CreatedBy      int64          `db:"created_by"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
 }
@@ -2,15 +2,30 @@ package property
 
 import (
 	"context"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 )
 
 type PropertyReadRepository interface {
-	GetAll(ctx context.Context, filter PropertyFilter) ([]Property, int, error)
+	GetAll(ctx context.Context, filter PropertyFilter) ([]Property, int, pagination.Cursors, error)
 	GetByID(ctx context.Context, id int64) (*Property, error)
 	HasManager(ctx context.Context, propertyID, userID int64) (bool, error)
+	GetMembers(ctx context.Context, propertyID int64) ([]PropertyMember, error)
+	GetMember(ctx context.Context, propertyID, userID int64) (*PropertyMember, error)
+	GetDeletedByID(ctx context.Context, id int64) (*Property, error)
 }
 type PropertyWriteRepository interface {
 	PropertyReadRepository
 	Create(ctx context.Context, property *Property) error
 	Update(ctx context.Context, property *Property) error
+	AddManager(ctx context.Context, propertyID, userID int64) error
+	SetMember(ctx context.Context, member *PropertyMember) error
+	RemoveMember(ctx context.Context, propertyID, userID int64) error
+	Delete(ctx context.Context, id, deletedBy int64) error
+	Restore(ctx context.Context, id, restoredBy int64) error
+}
+
+// Transactor runs fn in one transaction, repositories called with the ctx it gets join in
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
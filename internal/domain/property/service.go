@@ -6,45 +6,65 @@ import (
 	"fmt"
 	"log"
 	"slices"
+	"time"
 
 	"github.com/nevinmanoj/hostmate/internal/domain/access"
-	user "github.com/nevinmanoj/hostmate/internal/domain/user"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	"github.com/nevinmanoj/hostmate/internal/domain/pagination"
 	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
 )
 
 type PropertyService interface {
-	GetAll(ctx context.Context, filter PropertyFilter) ([]Property, int, error)
+	GetAll(ctx context.Context, filter PropertyFilter) ([]Property, int, pagination.Cursors, error)
 	GetById(ctx context.Context, id int64) (*Property, error)
 	Create(ctx context.Context, property *Property) error
 	Update(ctx context.Context, property *Property) error
+	GetMembers(ctx context.Context, propertyID int64) ([]PropertyMember, error)
+	SetMember(ctx context.Context, propertyID, userID int64, capabilities []access.Capability) (*PropertyMember, error)
+	RemoveMember(ctx context.Context, propertyID, userID int64) error
+	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64) (*Property, error)
 }
 
+// deleted properties can be restored for this long, afterwards they stay archived
+const restoreWindow = 30 * 24 * time.Hour
+
 type propertyService struct {
 	repo          PropertyWriteRepository
-	userRepo      user.UserReadRepository
 	accessService access.AccessService
+	auditService  audit.AuditService
+	transactor    Transactor
 }
 
-func NewPropertyService(repo PropertyWriteRepository, userRepo user.UserReadRepository, accessService access.AccessService) PropertyService {
-	return &propertyService{repo: repo, userRepo: userRepo, accessService: accessService}
+func NewPropertyService(repo PropertyWriteRepository, accessService access.AccessService, auditService audit.AuditService, transactor Transactor) PropertyService {
+	return &propertyService{repo: repo, accessService: accessService, auditService: auditService, transactor: transactor}
 }
 
-func (s *propertyService) GetAll(ctx context.Context, filter PropertyFilter) ([]Property, int, error) {
+func (s *propertyService) GetAll(ctx context.Context, filter PropertyFilter) ([]Property, int, pagination.Cursors, error) {
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	if filter.IncludeDeleted {
+		isAdmin, err := s.accessService.IsAdmin(ctx, userID)
+		if err != nil {
+			return nil, 0, pagination.Cursors{}, ErrInternal
+		}
+		if !isAdmin {
+			return nil, 0, pagination.Cursors{}, ErrUnauthorized
+		}
+	}
 	// TODO setup admin bypass
-	filter.ManagerID = &userID
-	data, total, err := s.repo.GetAll(ctx, filter)
+	filter.UserID = &userID
+	data, total, cursors, err := s.repo.GetAll(ctx, filter)
 	if err != nil {
 		log.Println("Error fetching properties:", err)
-		return nil, 0, ErrInternal
+		return nil, 0, pagination.Cursors{}, ErrInternal
 	}
-	return data, total, nil
+	return data, total, cursors, nil
 }
 
 func (s *propertyService) GetById(ctx context.Context, id int64) (*Property, error) {
 	//check access
 	userID := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessProperty(ctx, id, userID)
+	hasAccess, err := s.accessService.CanViewProperty(ctx, id, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -65,13 +85,17 @@ func (s *propertyService) GetById(ctx context.Context, id int64) (*Property, err
 }
 
 func (s *propertyService) Create(ctx context.Context, property *Property) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 	// Validate property fields as needed, managers,images should exist
 	if len(property.Managers) == 0 {
 		return ErrNotValidManagers
 	}
+	// managers have to belong to the organisation owning the property
 	for _, managerID := range property.Managers {
-		user, err := s.userRepo.GetUserByID(ctx, managerID)
-		if err != nil || user == nil {
+		isMember, err := s.accessService.IsMember(ctx, managerID)
+		if err != nil || !isMember {
 			return ErrNotValidManagers
 		}
 	}
@@ -84,17 +108,21 @@ func (s *propertyService) Create(ctx context.Context, property *Property) error
 	property.CreatedBy = createdBy
 	property.UpdatedBy = createdBy
 	fmt.Print(property)
-	err := s.repo.Create(ctx, property)
-	if err != nil {
-		log.Println("Error creating property:", err)
-		return ErrInternal
-	}
-	return nil
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, property); err != nil {
+			log.Println("Error creating property:", err)
+			return ErrInternal
+		}
+		return s.auditService.Record(ctx, audit.EntityProperty, property.ID, audit.ActionCreate, nil, property)
+	})
 }
 func (s *propertyService) Update(ctx context.Context, property *Property) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
 	//check access first
 	user := ctx.Value(middleware.ContextUserKey).(int64)
-	hasAccess, err := s.accessService.CanAccessProperty(ctx, property.ID, user)
+	hasAccess, err := s.accessService.HasPropertyCapability(ctx, property.ID, user, access.CapManageProperty)
 	if err != nil {
 		return err
 	}
@@ -107,18 +135,27 @@ func (s *propertyService) Update(ctx context.Context, property *Property) error
 		//no such property
 		return err
 	}
+	// the client must have seen the latest version of the property
+	if property.Version != propertyFromDB.Version {
+		return ErrVersionConflict
+	}
 
 	if len(property.Managers) == 0 {
 		return ErrNotValidManagers
 	}
-	userInManagers := slices.Contains(property.Managers, user)
-	if !userInManagers {
+	// members granted manage property can change settings but not who manages the property
+	if !slices.Contains(propertyFromDB.Managers, user) {
+		if !slices.Equal(property.Managers, propertyFromDB.Managers) {
+			return ErrUnauthorized
+		}
+	} else if !slices.Contains(property.Managers, user) {
 		log.Println("updating user must be in managers list")
 		return ErrNotValidManagers
 	}
+	// managers have to belong to the organisation owning the property
 	for _, managerID := range property.Managers {
-		user, err := s.userRepo.GetUserByID(ctx, managerID)
-		if err != nil || user == nil {
+		isMember, err := s.accessService.IsMember(ctx, managerID)
+		if err != nil || !isMember {
 			return ErrNotValidManagers
 		}
 	}
@@ -126,10 +163,228 @@ func (s *propertyService) Update(ctx context.Context, property *Property) error
 	property.CreatedBy = propertyFromDB.CreatedBy
 	property.CreatedAt = propertyFromDB.CreatedAt
 	property.UpdatedBy = user
-	err = s.repo.Update(ctx, property)
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, property); err != nil {
+			log.Println("Error updating property:", err)
+			if errors.Is(err, ErrVersionConflict) {
+				return ErrVersionConflict
+			}
+			return ErrInternal
+		}
+		return s.auditService.Record(ctx, audit.EntityProperty, property.ID, audit.ActionUpdate, propertyFromDB, property)
+	})
+}
+
+func (s *propertyService) GetMembers(ctx context.Context, propertyID int64) ([]PropertyMember, error) {
+	_, err := s.checkCanManage(ctx, propertyID)
 	if err != nil {
-		log.Println("Error updating property:", err)
-		return ErrInternal
+		return nil, err
+	}
+	members, err := s.repo.GetMembers(ctx, propertyID)
+	if err != nil {
+		log.Printf("Error fetching members of property with id %d: %s", propertyID, err.Error())
+		return nil, ErrInternal
+	}
+	return members, nil
+}
+
+// SetMember grants the user exactly the given capabilities, replacing any earlier grant.
+// Only managers and organisation admins grant, manage_property does not let a member hand
+// out capabilities, and nobody changes their own grant.
+func (s *propertyService) SetMember(ctx context.Context, propertyID, userID int64, capabilities []access.Capability) (*PropertyMember, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	grantedBy, err := s.checkManagerOrAdmin(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if grantedBy == userID {
+		return nil, ErrSelfGrant
+	}
+	if len(capabilities) == 0 {
+		return nil, ErrInvalidCapabilities
+	}
+	grants := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if !capability.Valid() {
+			return nil, ErrInvalidCapabilities
+		}
+		if !slices.Contains(grants, string(capability)) {
+			grants = append(grants, string(capability))
+		}
+	}
+	isMember, err := s.accessService.IsMember(ctx, userID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !isMember {
+		return nil, ErrNotValidMember
+	}
+	// managers already hold every capability
+	isManager, err := s.repo.HasManager(ctx, propertyID, userID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if isManager {
+		return nil, ErrNotValidMember
+	}
+	previous, err := s.getMemberCapabilities(ctx, propertyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	member := &PropertyMember{
+		PropertyID:   propertyID,
+		UserID:       userID,
+		Capabilities: grants,
+		CreatedBy:    grantedBy,
+	}
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetMember(ctx, member); err != nil {
+			log.Println("Error saving property member:", err)
+			return ErrInternal
+		}
+		return s.auditService.RecordChanges(ctx, audit.EntityProperty, propertyID, audit.ActionUpdate, audit.Changes{
+			memberAuditField(userID): {Before: previous, After: grants},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetMember(ctx, propertyID, userID)
+}
+
+func (s *propertyService) RemoveMember(ctx context.Context, propertyID, userID int64) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
+	_, err := s.checkManagerOrAdmin(ctx, propertyID)
+	if err != nil {
+		return err
 	}
-	return nil
+	previous, err := s.getMemberCapabilities(ctx, propertyID, userID)
+	if err != nil {
+		return err
+	}
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.RemoveMember(ctx, propertyID, userID); err != nil {
+			return err
+		}
+		return s.auditService.RecordChanges(ctx, audit.EntityProperty, propertyID, audit.ActionUpdate, audit.Changes{
+			memberAuditField(userID): {Before: previous},
+		})
+	})
+}
+
+// Delete archives the property, its bookings and payments are hidden along with it.
+// It is up to the managers and organisation admins, not members with manage_property.
+func (s *propertyService) Delete(ctx context.Context, id int64) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
+	userID, err := s.checkManagerOrAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+	propertyFromDB, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, userID); err != nil {
+			log.Println("Error deleting property:", err)
+			if errors.Is(err, ErrNotFound) {
+				return ErrNotFound
+			}
+			return ErrInternal
+		}
+		return s.auditService.Record(ctx, audit.EntityProperty, id, audit.ActionDelete, propertyFromDB, nil)
+	})
+}
+
+func (s *propertyService) Restore(ctx context.Context, id int64) (*Property, error) {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return nil, err
+	}
+	userID, err := s.checkManagerOrAdmin(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.repo.GetDeletedByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, ErrInternal
+	}
+	if time.Since(*deleted.DeletedAt) > restoreWindow {
+		return nil, ErrRestoreWindowExpired
+	}
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, id, userID); err != nil {
+			log.Println("Error restoring property:", err)
+			if errors.Is(err, ErrNotFound) {
+				return ErrNotFound
+			}
+			return ErrInternal
+		}
+		return s.auditService.RecordChanges(ctx, audit.EntityProperty, id, audit.ActionRestore, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// helpers
+func (s *propertyService) checkCanManage(ctx context.Context, propertyID int64) (int64, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasPropertyCapability(ctx, propertyID, userID, access.CapManageProperty)
+	if err != nil {
+		return 0, err
+	}
+	if !hasAccess {
+		return 0, ErrUnauthorized
+	}
+	return userID, nil
+}
+
+// checkManagerOrAdmin is for what a capability cannot grant, like deleting the property or
+// changing who holds capabilities on it. Managers are matched on deleted properties too.
+func (s *propertyService) checkManagerOrAdmin(ctx context.Context, propertyID int64) (int64, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	isManager, err := s.repo.HasManager(ctx, propertyID, userID)
+	if err != nil {
+		log.Println("Error checking property manager:", err)
+		return 0, ErrInternal
+	}
+	if isManager {
+		return userID, nil
+	}
+	isAdmin, err := s.accessService.IsAdmin(ctx, userID)
+	if err != nil {
+		log.Println("Error checking organisation admin:", err)
+		return 0, ErrInternal
+	}
+	if !isAdmin {
+		return 0, ErrUnauthorized
+	}
+	return userID, nil
+}
+
+// getMemberCapabilities is nil when the user has no grant on the property
+func (s *propertyService) getMemberCapabilities(ctx context.Context, propertyID, userID int64) ([]string, error) {
+	member, err := s.repo.GetMember(ctx, propertyID, userID)
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return nil, nil
+		}
+		return nil, ErrInternal
+	}
+	return member.Capabilities, nil
+}
+
+// grants are audited on the property, one field per member
+func memberAuditField(userID int64) string {
+	return fmt.Sprintf("members.%d", userID)
 }
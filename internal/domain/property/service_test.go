@@ -0,0 +1,347 @@
+package property
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// fakeAccess answers capability checks for property 1, managers hold every capability
+type fakeAccess struct {
+	access.AccessService
+	repo    *fakePropertyRepo
+	members []int64
+	admins  []int64
+}
+
+func (a *fakeAccess) HasPropertyCapability(ctx context.Context, propertyID, userID int64, capability access.Capability) (bool, error) {
+	if propertyID != 1 {
+		return false, nil
+	}
+	if slices.Contains(a.repo.property.Managers, userID) {
+		return true, nil
+	}
+	member, ok := a.repo.members[userID]
+	return ok && slices.Contains(member.Capabilities, string(capability)), nil
+}
+
+func (a *fakeAccess) IsMember(ctx context.Context, userID int64) (bool, error) {
+	return slices.Contains(a.members, userID), nil
+}
+
+func (a *fakeAccess) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	return slices.Contains(a.admins, userID), nil
+}
+
+func (a *fakeAccess) CanWrite(ctx context.Context) error {
+	return nil
+}
+
+// fakeAudit keeps the recorded changes instead of storing them, with err set it fails to record
+type fakeAudit struct {
+	audit.AuditService
+	recorded []audit.Changes
+	err      error
+}
+
+func (a *fakeAudit) Record(ctx context.Context, entityType audit.EntityType, entityID int64, action audit.Action, before, after any) error {
+	return a.RecordChanges(ctx, entityType, entityID, action, audit.Diff(before, after))
+}
+
+func (a *fakeAudit) RecordChanges(ctx context.Context, entityType audit.EntityType, entityID int64, action audit.Action, changes audit.Changes) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.recorded = append(a.recorded, changes)
+	return nil
+}
+
+// fakeTransactor runs fn as is, there is nothing to roll back in memory
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakePropertyRepo holds property 1, methods the tests do not need are left to the nil interface
+type fakePropertyRepo struct {
+	PropertyWriteRepository
+	property Property
+	members  map[int64]PropertyMember
+	updated  bool
+}
+
+func (r *fakePropertyRepo) GetByID(ctx context.Context, id int64) (*Property, error) {
+	if id != r.property.ID || r.property.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	property := r.property
+	property.Managers = slices.Clone(r.property.Managers)
+	return &property, nil
+}
+
+func (r *fakePropertyRepo) GetDeletedByID(ctx context.Context, id int64) (*Property, error) {
+	if id != r.property.ID || r.property.DeletedAt == nil {
+		return nil, ErrNotFound
+	}
+	property := r.property
+	return &property, nil
+}
+
+func (r *fakePropertyRepo) Delete(ctx context.Context, id, deletedBy int64) error {
+	if id != r.property.ID || r.property.DeletedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now()
+	r.property.DeletedAt = &now
+	r.property.DeletedBy = &deletedBy
+	return nil
+}
+
+func (r *fakePropertyRepo) Restore(ctx context.Context, id, restoredBy int64) error {
+	if id != r.property.ID || r.property.DeletedAt == nil {
+		return ErrNotFound
+	}
+	r.property.DeletedAt = nil
+	r.property.DeletedBy = nil
+	return nil
+}
+
+func (r *fakePropertyRepo) HasManager(ctx context.Context, propertyID, userID int64) (bool, error) {
+	return slices.Contains(r.property.Managers, userID), nil
+}
+
+func (r *fakePropertyRepo) Update(ctx context.Context, property *Property) error {
+	r.property = *property
+	r.updated = true
+	return nil
+}
+
+func (r *fakePropertyRepo) SetMember(ctx context.Context, member *PropertyMember) error {
+	r.members[member.UserID] = *member
+	return nil
+}
+
+func (r *fakePropertyRepo) GetMember(ctx context.Context, propertyID, userID int64) (*PropertyMember, error) {
+	member, ok := r.members[userID]
+	if !ok {
+		return nil, ErrMemberNotFound
+	}
+	return &member, nil
+}
+
+func (r *fakePropertyRepo) RemoveMember(ctx context.Context, propertyID, userID int64) error {
+	delete(r.members, userID)
+	return nil
+}
+
+// newTestService sets up property 1 managed by user 1, user 2 may manage it as a member,
+// user 3 may only view bookings, users 4 and 5 belong to the organisation without any grant
+// and user 6 administers the organisation
+func newTestService() (*propertyService, *fakePropertyRepo, *fakeAudit) {
+	repo := &fakePropertyRepo{
+		property: Property{ID: 1, Name: "Beach house", Managers: []int64{1}, Version: 2},
+		members: map[int64]PropertyMember{
+			2: {PropertyID: 1, UserID: 2, Capabilities: []string{string(access.CapManageProperty)}},
+			3: {PropertyID: 1, UserID: 3, Capabilities: []string{string(access.CapViewBookings)}},
+		},
+	}
+	accessService := &fakeAccess{repo: repo, members: []int64{1, 2, 3, 4, 5, 6}, admins: []int64{6}}
+	auditService := &fakeAudit{}
+	return &propertyService{repo: repo, accessService: accessService, auditService: auditService, transactor: fakeTransactor{}}, repo, auditService
+}
+
+func userContext(userID int64) context.Context {
+	return context.WithValue(context.Background(), middleware.ContextUserKey, userID)
+}
+
+func TestSetMember(t *testing.T) {
+	tests := []struct {
+		name         string
+		caller       int64
+		target       int64
+		capabilities []access.Capability
+		want         error
+		wantGrants   []string
+	}{
+		{
+			name: "manager grants capabilities", caller: 1, target: 4,
+			capabilities: []access.Capability{access.CapViewBookings, access.CapRecordPayments, access.CapViewBookings},
+			wantGrants:   []string{"view_bookings", "record_payments"},
+		},
+		{
+			name: "grant replaces the earlier one", caller: 1, target: 3,
+			capabilities: []access.Capability{access.CapEditBookings},
+			wantGrants:   []string{"edit_bookings"},
+		},
+		{
+			name: "organisation admin grants capabilities", caller: 6, target: 4,
+			capabilities: []access.Capability{access.CapViewFinancials},
+			wantGrants:   []string{"view_financials"},
+		},
+		// manage_property is not a licence to hand out capabilities
+		{name: "member with manage property", caller: 2, target: 4, capabilities: []access.Capability{access.CapViewBookings}, want: ErrUnauthorized},
+		{name: "member with manage property grants themselves", caller: 2, target: 2, capabilities: []access.Capability{access.CapViewFinancials}, want: ErrUnauthorized},
+		{name: "member without manage property", caller: 3, target: 4, capabilities: []access.Capability{access.CapViewBookings}, want: ErrUnauthorized},
+		{name: "organisation admin grants themselves", caller: 6, target: 6, capabilities: []access.Capability{access.CapViewBookings}, want: ErrSelfGrant},
+		{name: "no capabilities", caller: 1, target: 4, want: ErrInvalidCapabilities},
+		{name: "unknown capability", caller: 1, target: 4, capabilities: []access.Capability{"delete_everything"}, want: ErrInvalidCapabilities},
+		{name: "user outside the organisation", caller: 1, target: 9, capabilities: []access.Capability{access.CapViewBookings}, want: ErrNotValidMember},
+		{name: "manager of the property", caller: 6, target: 1, capabilities: []access.Capability{access.CapViewBookings}, want: ErrNotValidMember},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, auditService := newTestService()
+			member, err := s.SetMember(userContext(tt.caller), 1, tt.target, tt.capabilities)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("SetMember = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+			if !slices.Equal(member.Capabilities, tt.wantGrants) || member.CreatedBy != tt.caller {
+				t.Errorf("member = %+v, want grants %v by %d", member, tt.wantGrants, tt.caller)
+			}
+			if !slices.Equal(repo.members[tt.target].Capabilities, tt.wantGrants) {
+				t.Errorf("stored grants %v, want %v", repo.members[tt.target].Capabilities, tt.wantGrants)
+			}
+			if len(auditService.recorded) != 1 {
+				t.Fatalf("recorded %d audit entries, want 1", len(auditService.recorded))
+			}
+			change, ok := auditService.recorded[0][memberAuditField(tt.target)]
+			if !ok || !slices.Equal(change.After.([]string), tt.wantGrants) {
+				t.Errorf("audited %v, want the grants of member %d", auditService.recorded[0], tt.target)
+			}
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller int64
+		want   error
+	}{
+		{"manager", 1, nil},
+		{"organisation admin", 6, nil},
+		{"member with manage property", 2, ErrUnauthorized},
+		{"member without manage property", 3, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _ := newTestService()
+			err := s.RemoveMember(userContext(tt.caller), 1, 3)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("RemoveMember = %v, want %v", err, tt.want)
+			}
+			if _, kept := repo.members[3]; kept != (tt.want != nil) {
+				t.Errorf("member 3 kept = %v", kept)
+			}
+		})
+	}
+}
+
+func TestUpdateManagers(t *testing.T) {
+	tests := []struct {
+		name     string
+		caller   int64
+		managers []int64
+		version  int
+		want     error
+	}{
+		{"manager adds a co-manager", 1, []int64{1, 4}, 2, nil},
+		{"manager removes themselves", 1, []int64{4}, 2, ErrNotValidManagers},
+		{"member with manage property keeps the managers", 2, []int64{1}, 2, nil},
+		{"member with manage property adds a manager", 2, []int64{1, 2}, 2, ErrUnauthorized},
+		{"member without manage property", 3, []int64{1}, 2, ErrUnauthorized},
+		{"no managers", 1, []int64{}, 2, ErrNotValidManagers},
+		{"manager outside the organisation", 1, []int64{1, 9}, 2, ErrNotValidManagers},
+		{"stale version", 1, []int64{1, 4}, 1, ErrVersionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _ := newTestService()
+			err := s.Update(userContext(tt.caller), &Property{ID: 1, Name: "Beach house", Managers: tt.managers, Version: tt.version})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Update = %v, want %v", err, tt.want)
+			}
+			if repo.updated != (tt.want == nil) {
+				t.Errorf("property updated = %v", repo.updated)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller int64
+		want   error
+	}{
+		{"manager", 1, nil},
+		{"organisation admin", 6, nil},
+		{"member with manage property", 2, ErrUnauthorized},
+		{"member without manage property", 3, ErrUnauthorized},
+		{"user without any grant", 4, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _ := newTestService()
+			err := s.Delete(userContext(tt.caller), 1)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Delete = %v, want %v", err, tt.want)
+			}
+			if deleted := repo.property.DeletedAt != nil; deleted != (tt.want == nil) {
+				t.Errorf("property deleted = %v", deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteFailsWithoutAuditEntry(t *testing.T) {
+	s, _, auditService := newTestService()
+	auditService.err = audit.ErrInternal
+	if err := s.Delete(userContext(1), 1); !errors.Is(err, audit.ErrInternal) {
+		t.Errorf("Delete = %v, want the audit error so the transaction rolls back", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name      string
+		caller    int64
+		deletedAt *time.Time
+		want      error
+	}{
+		{"deleted yesterday", 1, ago(24 * time.Hour), nil},
+		{"deleted before the restore window", 1, ago(restoreWindow + time.Hour), ErrRestoreWindowExpired},
+		{"not deleted", 1, nil, ErrNotFound},
+		{"organisation admin", 6, ago(time.Hour), nil},
+		{"member with manage property", 2, ago(time.Hour), ErrUnauthorized},
+		{"member without manage property", 3, ago(time.Hour), ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _ := newTestService()
+			repo.property.DeletedAt = tt.deletedAt
+			_, err := s.Restore(userContext(tt.caller), 1)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Restore = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && repo.property.DeletedAt != nil {
+				t.Error("property is still deleted")
+			}
+		})
+	}
+}
+
+func ago(d time.Duration) *time.Time {
+	at := time.Now().Add(-d)
+	return &at
+}
@@ -0,0 +1,11 @@
+package report
+
+import (
+	"errors"
+)
+
+var (
+	ErrInternal      = errors.New("internal error")
+	ErrInvalidPeriod = errors.New("invalid report period")
+	ErrInvalidRange  = errors.New("invalid report range")
+)
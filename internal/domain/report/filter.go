@@ -0,0 +1,14 @@
+package report
+
+import (
+	"time"
+)
+
+// ReportFilter covers From up to but not including To
+type ReportFilter struct {
+	From       time.Time
+	To         time.Time
+	Period     Period
+	PropertyID []int64
+	UserID     *int64
+}
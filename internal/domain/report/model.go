@@ -0,0 +1,50 @@
+package report
+
+import (
+	"time"
+)
+
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+func (p Period) Valid() bool {
+	return p == PeriodDay || p == PeriodWeek || p == PeriodMonth
+}
+
+// PerformanceRow is how one property did in one period. A property is a single unit, so it
+// has one night to sell per day of the period that falls inside the requested range
+type PerformanceRow struct {
+	PropertyID       int64     `db:"property_id"`
+	PropertyName     string    `db:"property_name"`
+	PeriodStart      time.Time `db:"period_start"`
+	AvailableNights  int       `db:"available_nights"`
+	NightsSold       int       `db:"nights_sold"`
+	OccupancyRate    float64   `db:"occupancy_rate"`
+	ADR              float64   `db:"adr"`
+	RevPAR           float64   `db:"revpar"`
+	RevenueBooked    float64   `db:"revenue_booked"`
+	RevenueCollected float64   `db:"revenue_collected"`
+	Cancellations    int       `db:"cancellations"`
+}
+
+// TaxSummaryRow adds up the GST of one property for one month and rate, in the shape of
+// a GSTR-1 rate wise summary. A stay is taxed in the month the guest checks out.
+type TaxSummaryRow struct {
+	PropertyID    int64     `db:"property_id"`
+	PropertyName  string    `db:"property_name"`
+	GSTIN         string    `db:"gstin"`
+	Month         time.Time `db:"month"`
+	Rate          float64   `db:"tax_rate"`
+	Bookings      int       `db:"bookings"`
+	TaxableAmount float64   `db:"taxable_amount"`
+	CGST          float64   `db:"cgst"`
+	SGST          float64   `db:"sgst"`
+	IGST          float64   `db:"igst"`
+	TotalTax      float64   `db:"total_tax"`
+	InvoiceValue  float64   `db:"invoice_value"`
+}
@@ -0,0 +1,10 @@
+package report
+
+import (
+	"context"
+)
+
+type ReportRepository interface {
+	GetPerformance(ctx context.Context, filter ReportFilter) ([]PerformanceRow, error)
+	GetTaxSummary(ctx context.Context, filter ReportFilter) ([]TaxSummaryRow, error)
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"context"
+	"log"
+	"time"
+
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// every property gets a row per period, the range is capped to keep reports a sensible size
+var maxRange = map[Period]time.Duration{
+	PeriodDay:   366 * 24 * time.Hour,
+	PeriodWeek:  3 * 366 * 24 * time.Hour,
+	PeriodMonth: 5 * 366 * 24 * time.Hour,
+}
+
+type ReportService interface {
+	GetPerformance(ctx context.Context, filter ReportFilter) ([]PerformanceRow, error)
+	GetTaxSummary(ctx context.Context, filter ReportFilter) ([]TaxSummaryRow, error)
+}
+
+type reportService struct {
+	repo ReportRepository
+}
+
+func NewReportService(repo ReportRepository) ReportService {
+	return &reportService{repo: repo}
+}
+
+// GetPerformance reports on the properties the user can see the financials of
+func (s *reportService) GetPerformance(ctx context.Context, filter ReportFilter) ([]PerformanceRow, error) {
+	if !filter.Period.Valid() {
+		return nil, ErrInvalidPeriod
+	}
+	if !filter.To.After(filter.From) || filter.To.Sub(filter.From) > maxRange[filter.Period] {
+		return nil, ErrInvalidRange
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	filter.UserID = &userID
+	rows, err := s.repo.GetPerformance(ctx, filter)
+	if err != nil {
+		log.Println("Error fetching performance report:", err)
+		return nil, ErrInternal
+	}
+	return rows, nil
+}
+
+// GetTaxSummary is always monthly, the period of the filter is ignored
+func (s *reportService) GetTaxSummary(ctx context.Context, filter ReportFilter) ([]TaxSummaryRow, error) {
+	filter.Period = PeriodMonth
+	if !filter.To.After(filter.From) || filter.To.Sub(filter.From) > maxRange[filter.Period] {
+		return nil, ErrInvalidRange
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	filter.UserID = &userID
+	rows, err := s.repo.GetTaxSummary(ctx, filter)
+	if err != nil {
+		log.Println("Error fetching tax summary:", err)
+		return nil, ErrInternal
+	}
+	return rows, nil
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+type fakeReportRepo struct {
+	filter *ReportFilter
+}
+
+func (r *fakeReportRepo) GetPerformance(ctx context.Context, filter ReportFilter) ([]PerformanceRow, error) {
+	r.filter = &filter
+	return nil, nil
+}
+
+func (r *fakeReportRepo) GetTaxSummary(ctx context.Context, filter ReportFilter) ([]TaxSummaryRow, error) {
+	r.filter = &filter
+	return nil, nil
+}
+
+func TestGetPerformance(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		period Period
+		to     time.Time
+		want   error
+	}{
+		{"a month by day", PeriodDay, from.AddDate(0, 1, 0), nil},
+		{"a year by day", PeriodDay, from.AddDate(1, 0, 0), nil},
+		{"two years by day", PeriodDay, from.AddDate(2, 0, 0), ErrInvalidRange},
+		{"two years by week", PeriodWeek, from.AddDate(2, 0, 0), nil},
+		{"five years by month", PeriodMonth, from.AddDate(5, 0, 0), nil},
+		{"six years by month", PeriodMonth, from.AddDate(6, 0, 0), ErrInvalidRange},
+		{"empty range", PeriodMonth, from, ErrInvalidRange},
+		{"reversed range", PeriodMonth, from.AddDate(0, -1, 0), ErrInvalidRange},
+		{"by quarter", Period("quarter"), from.AddDate(1, 0, 0), ErrInvalidPeriod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeReportRepo{}
+			s := NewReportService(repo)
+			ctx := context.WithValue(context.Background(), middleware.ContextUserKey, int64(7))
+			_, err := s.GetPerformance(ctx, ReportFilter{From: from, To: tt.to, Period: tt.period})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("GetPerformance = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (repo.filter.UserID == nil || *repo.filter.UserID != 7) {
+				t.Error("the report is not limited to what the user can see")
+			}
+		})
+	}
+}
+
+func TestGetTaxSummary(t *testing.T) {
+	from := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeReportRepo{}
+	s := NewReportService(repo)
+	ctx := context.WithValue(context.Background(), middleware.ContextUserKey, int64(7))
+
+	// the summary is monthly whatever period was asked for
+	if _, err := s.GetTaxSummary(ctx, ReportFilter{From: from, To: from.AddDate(1, 0, 0), Period: PeriodDay}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.filter.Period != PeriodMonth {
+		t.Errorf("period = %q, want %q", repo.filter.Period, PeriodMonth)
+	}
+	if _, err := s.GetTaxSummary(ctx, ReportFilter{From: from, To: from.AddDate(6, 0, 0)}); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("GetTaxSummary over six years = %v, want %v", err, ErrInvalidRange)
+	}
+}
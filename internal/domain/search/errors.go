@@ -0,0 +1,10 @@
+package search
+
+import (
+	"errors"
+)
+
+var (
+	ErrInternal     = errors.New("internal error")
+	ErrInvalidQuery = errors.New("invalid search query")
+)
@@ -0,0 +1,8 @@
+package search
+
+type SearchFilter struct {
+	Query  string
+	Kinds  []Kind
+	UserID *int64
+	Limit  int
+}
@@ -0,0 +1,26 @@
+package search
+
+type Kind string
+
+const (
+	KindProperty Kind = "property"
+	KindBooking  Kind = "booking"
+	KindGuest    Kind = "guest"
+)
+
+// AllKinds are searched when the caller does not narrow it down
+var AllKinds = []Kind{KindProperty, KindBooking, KindGuest}
+
+func (k Kind) Valid() bool {
+	return k == KindProperty || k == KindBooking || k == KindGuest
+}
+
+// Result is one ranked match, a guest is identified by phone and ID is their latest booking
+type Result struct {
+	Kind       Kind    `db:"kind"`
+	ID         int64   `db:"id"`
+	PropertyID int64   `db:"property_id"`
+	Title      string  `db:"title"`
+	Subtitle   string  `db:"subtitle"`
+	Rank       float64 `db:"rank"`
+}
@@ -0,0 +1,37 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MinQueryLength = 2
+	MaxQueryLength = 100
+	// fewer digits than this match too many phone numbers to be useful
+	minPhoneDigits = 3
+)
+
+// NormalizeQuery trims and collapses whitespace, the result must be a usable search term
+func NormalizeQuery(q string) (string, error) {
+	q = strings.Join(strings.Fields(q), " ")
+	if n := utf8.RuneCountInString(q); n < MinQueryLength || n > MaxQueryLength {
+		return "", ErrInvalidQuery
+	}
+	return q, nil
+}
+
+// PhoneFragment is the digits of the query when there are enough of them to look up a phone number
+func PhoneFragment(q string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) && r < utf8.RuneSelf {
+			return r
+		}
+		return -1
+	}, q)
+	if len(digits) < minPhoneDigits {
+		return ""
+	}
+	return digits
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		q       string
+		want    string
+		wantErr error
+	}{
+		{"asha", "asha", nil},
+		{"  asha \t menon\n", "asha menon", nil},
+		{"ab", "ab", nil},
+		{"a", "", ErrInvalidQuery},
+		{"   ", "", ErrInvalidQuery},
+		{"അമ", "അമ", nil},
+		{strings.Repeat("x", MaxQueryLength), strings.Repeat("x", MaxQueryLength), nil},
+		{strings.Repeat("x", MaxQueryLength+1), "", ErrInvalidQuery},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeQuery(tt.q)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("NormalizeQuery(%q) = %q, %v, want %q, %v", tt.q, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPhoneFragment(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"+91 98470-12345", "919847012345"},
+		{"984", "984"},
+		{"room 12", ""},
+		{"asha", ""},
+		// digits of other scripts are not what phone numbers are stored with
+		{"٩٨٤٧", ""},
+	}
+	for _, tt := range tests {
+		if got := PhoneFragment(tt.q); got != tt.want {
+			t.Errorf("PhoneFragment(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
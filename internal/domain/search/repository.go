@@ -0,0 +1,9 @@
+package search
+
+import (
+	"context"
+)
+
+type SearchRepository interface {
+	Search(ctx context.Context, filter SearchFilter) ([]Result, error)
+}
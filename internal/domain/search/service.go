@@ -0,0 +1,40 @@
+package search
+
+import (
+	"context"
+	"log"
+
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, filter SearchFilter) ([]Result, error)
+}
+
+type searchService struct {
+	repo SearchRepository
+}
+
+func NewSearchService(repo SearchRepository) SearchService {
+	return &searchService{repo: repo}
+}
+
+// Search only returns properties and bookings the user can already see in the list endpoints
+func (s *searchService) Search(ctx context.Context, filter SearchFilter) ([]Result, error) {
+	query, err := NormalizeQuery(filter.Query)
+	if err != nil {
+		return nil, err
+	}
+	filter.Query = query
+	if len(filter.Kinds) == 0 {
+		filter.Kinds = AllKinds
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	filter.UserID = &userID
+	results, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		log.Println("Error searching:", err)
+		return nil, ErrInternal
+	}
+	return results, nil
+}
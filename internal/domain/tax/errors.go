@@ -0,0 +1,12 @@
+package tax
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("property has no tax configuration")
+	ErrInternal      = errors.New("Internal error")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrInvalidGSTIN  = errors.New("invalid GSTIN")
+	ErrInvalidSupply = errors.New("invalid supply type")
+	ErrInvalidSlabs  = errors.New("invalid tax slabs")
+)
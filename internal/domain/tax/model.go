@@ -0,0 +1,177 @@
+package tax
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"time"
+)
+
+// Supply decides how GST is split, within the state it is shared between the centre and
+// the state, across states it is charged in full as integrated tax
+type Supply string
+
+const (
+	SupplyIntraState Supply = "intra_state"
+	SupplyInterState Supply = "inter_state"
+)
+
+func (s Supply) Valid() bool {
+	return s == SupplyIntraState || s == SupplyInterState
+}
+
+// SACAccommodation is the services accounting code of hotel and homestay accommodation
+const SACAccommodation = "996311"
+
+// Slab is the rate for nightly tariffs up to UpTo, the last slab has no upper bound
+type Slab struct {
+	UpTo *float64 `json:"up_to"`
+	Rate float64  `json:"rate"`
+}
+
+// Slabs are ordered by tariff and stored as jsonb
+type Slabs []Slab
+
+// DefaultSlabs are the GST rates on hotel accommodation from 22 September 2025,
+// no tax for tariffs up to 1000 a night, 5% up to 7500 and 18% above
+func DefaultSlabs() Slabs {
+	upTo1000, upTo7500 := 1000.0, 7500.0
+	return Slabs{
+		{UpTo: &upTo1000, Rate: 0},
+		{UpTo: &upTo7500, Rate: 5},
+		{Rate: 18},
+	}
+}
+
+func (s Slabs) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *Slabs) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case nil:
+		*s = Slabs{}
+		return nil
+	default:
+		return errors.New("unsupported type for tax slabs")
+	}
+}
+
+// Config is the GST registration and rules of a property
+type Config struct {
+	PropertyID     int64     `db:"property_id"`
+	OrganisationID int64     `db:"organisation_id"`
+	GSTIN          string    `db:"gstin"`
+	Supply         Supply    `db:"supply"`
+	Inclusive      bool      `db:"inclusive"`
+	Slabs          Slabs     `db:"slabs"`
+	UpdatedBy      int64     `db:"updated_by"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// Breakdown is the GST on an amount, for inclusive pricing the tax is taken out of it
+type Breakdown struct {
+	Rate      float64
+	Inclusive bool
+	Taxable   float64
+	CGST      float64
+	SGST      float64
+	IGST      float64
+	Total     float64
+}
+
+func (b Breakdown) Tax() float64 {
+	return round(b.CGST + b.SGST + b.IGST)
+}
+
+// state code, PAN, entity number, a Z and a check character
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+// maxRate is the highest GST rate there is
+const maxRate = 40
+
+func (c *Config) Validate() error {
+	if !gstinPattern.MatchString(c.GSTIN) {
+		return ErrInvalidGSTIN
+	}
+	if !c.Supply.Valid() {
+		return ErrInvalidSupply
+	}
+	if len(c.Slabs) == 0 {
+		return ErrInvalidSlabs
+	}
+	previous := 0.0
+	for i, slab := range c.Slabs {
+		if slab.Rate < 0 || slab.Rate > maxRate {
+			return ErrInvalidSlabs
+		}
+		last := i == len(c.Slabs)-1
+		// every tariff has to land in a slab, so only the last one is open ended
+		if last != (slab.UpTo == nil) {
+			return ErrInvalidSlabs
+		}
+		if !last {
+			if *slab.UpTo <= previous {
+				return ErrInvalidSlabs
+			}
+			previous = *slab.UpTo
+		}
+	}
+	return nil
+}
+
+// Apply works out the GST on amount, the slab is picked by the nightly tariff before tax.
+// A nil config is a property without GST registration, it charges no tax.
+func (c *Config) Apply(tariff, amount float64) Breakdown {
+	if c == nil {
+		return Breakdown{Taxable: round(amount), Total: round(amount)}
+	}
+	rate := c.rateFor(tariff)
+	taxable := amount
+	if c.Inclusive {
+		taxable = amount / (1 + rate/100)
+	}
+	b := Breakdown{Rate: rate, Inclusive: c.Inclusive}
+	if c.Supply == SupplyInterState {
+		b.IGST = round(taxable * rate / 100)
+	} else {
+		b.CGST = round(taxable * rate / 200)
+		b.SGST = b.CGST
+	}
+	if c.Inclusive {
+		// the guest pays the quoted amount, rounding goes to the taxable value
+		b.Total = round(amount)
+		b.Taxable = round(b.Total - b.Tax())
+	} else {
+		b.Taxable = round(taxable)
+		b.Total = round(b.Taxable + b.Tax())
+	}
+	return b
+}
+
+func (c *Config) rateFor(tariff float64) float64 {
+	for _, slab := range c.Slabs {
+		value := tariff
+		if c.Inclusive {
+			value = tariff / (1 + slab.Rate/100)
+		}
+		if slab.UpTo == nil || value <= *slab.UpTo {
+			return slab.Rate
+		}
+	}
+	return 0
+}
+
+// round keeps amounts to the paisa
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,115 @@
+package tax
+
+import "testing"
+
+func config(supply Supply, inclusive bool) *Config {
+	return &Config{Supply: supply, Inclusive: inclusive, Slabs: DefaultSlabs()}
+}
+
+func TestRateFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		inclusive bool
+		tariff    float64
+		want      float64
+	}{
+		{"zero tariff", false, 0, 0},
+		{"below the first slab", false, 800, 0},
+		{"on the first bound", false, 1000, 0},
+		{"just above the first bound", false, 1000.01, 5},
+		{"middle slab", false, 5000, 5},
+		{"on the second bound", false, 7500, 5},
+		{"just above the second bound", false, 7500.01, 18},
+		{"open ended slab", false, 25000, 18},
+		{"inclusive tariff within the first slab", true, 1000, 0},
+		{"inclusive tariff taken out of the middle slab", true, 5250, 5},
+		{"inclusive tariff on the second bound after tax", true, 7875, 5},
+		{"inclusive tariff past the second bound after tax", true, 7876, 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config(SupplyIntraState, tt.inclusive)
+			if got := c.rateFor(tt.tariff); got != tt.want {
+				t.Errorf("rateFor(%v) = %v, want %v", tt.tariff, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateForCustomSlabs(t *testing.T) {
+	upTo := 2000.0
+	c := &Config{Supply: SupplyIntraState, Slabs: Slabs{{UpTo: &upTo, Rate: 12}, {Rate: 28}}}
+	if got := c.rateFor(2000); got != 12 {
+		t.Errorf("rateFor(2000) = %v, want 12", got)
+	}
+	if got := c.rateFor(2000.5); got != 28 {
+		t.Errorf("rateFor(2000.5) = %v, want 28", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		tariff float64
+		amount float64
+		want   Breakdown
+	}{
+		{
+			name:   "no registration charges no tax",
+			config: nil,
+			tariff: 5000,
+			amount: 1234.567,
+			want:   Breakdown{Taxable: 1234.57, Total: 1234.57},
+		},
+		{
+			name:   "exempt slab",
+			config: config(SupplyIntraState, false),
+			tariff: 800,
+			amount: 1600,
+			want:   Breakdown{Rate: 0, Taxable: 1600, Total: 1600},
+		},
+		{
+			name:   "intra state is split between centre and state",
+			config: config(SupplyIntraState, false),
+			tariff: 5000,
+			amount: 10000,
+			want:   Breakdown{Rate: 5, Taxable: 10000, CGST: 250, SGST: 250, Total: 10500},
+		},
+		{
+			name:   "inter state is charged as integrated tax",
+			config: config(SupplyInterState, false),
+			tariff: 10000,
+			amount: 10000,
+			want:   Breakdown{Rate: 18, Taxable: 10000, IGST: 1800, Total: 11800},
+		},
+		{
+			name:   "inclusive takes the tax out of the amount",
+			config: config(SupplyIntraState, true),
+			tariff: 5250,
+			amount: 5250,
+			want:   Breakdown{Rate: 5, Inclusive: true, Taxable: 5000, CGST: 125, SGST: 125, Total: 5250},
+		},
+		{
+			name:   "inclusive rounding goes to the taxable value",
+			config: config(SupplyInterState, true),
+			tariff: 3000,
+			amount: 3000,
+			want:   Breakdown{Rate: 5, Inclusive: true, Taxable: 2857.14, IGST: 142.86, Total: 3000},
+		},
+		{
+			name:   "exclusive amounts are rounded to the paisa",
+			config: config(SupplyIntraState, false),
+			tariff: 3333.333,
+			amount: 3333.333,
+			want:   Breakdown{Rate: 5, Taxable: 3333.33, CGST: 83.33, SGST: 83.33, Total: 3499.99},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Apply(tt.tariff, tt.amount); got != tt.want {
+				t.Errorf("Apply(%v, %v) = %+v, want %+v", tt.tariff, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package tax
+
+import (
+	"context"
+)
+
+type TaxRepository interface {
+	GetConfig(ctx context.Context, propertyID int64) (*Config, error)
+	SaveConfig(ctx context.Context, config *Config) error
+}
+
+// Transactor runs fn in one transaction, repositories called with the ctx it gets join in
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
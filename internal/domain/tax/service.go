@@ -0,0 +1,75 @@
+package tax
+
+import (
+	"context"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/audit"
+	"github.com/nevinmanoj/hostmate/internal/domain/property"
+	"github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+type TaxService interface {
+	GetConfig(ctx context.Context, propertyID int64) (*Config, error)
+	SaveConfig(ctx context.Context, config *Config) error
+}
+
+type taxService struct {
+	repo          TaxRepository
+	propertyRepo  property.PropertyReadRepository
+	accessService access.AccessService
+	auditService  audit.AuditService
+	transactor    Transactor
+}
+
+func NewTaxService(repo TaxRepository, propertyRepo property.PropertyReadRepository, accessService access.AccessService, auditService audit.AuditService, transactor Transactor) TaxService {
+	return &taxService{repo: repo, propertyRepo: propertyRepo, accessService: accessService, auditService: auditService, transactor: transactor}
+}
+
+func (s *taxService) GetConfig(ctx context.Context, propertyID int64) (*Config, error) {
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasPropertyCapability(ctx, propertyID, userID, access.CapViewFinancials)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, ErrUnauthorized
+	}
+	return s.repo.GetConfig(ctx, propertyID)
+}
+
+// SaveConfig sets the rules new and amended bookings of the property are taxed with,
+// bookings keep the tax they were last saved with
+func (s *taxService) SaveConfig(ctx context.Context, config *Config) error {
+	if err := s.accessService.CanWrite(ctx); err != nil {
+		return err
+	}
+	userID := ctx.Value(middleware.ContextUserKey).(int64)
+	hasAccess, err := s.accessService.HasPropertyCapability(ctx, config.PropertyID, userID, access.CapManageProperty)
+	if err != nil {
+		return err
+	}
+	if !hasAccess {
+		return ErrUnauthorized
+	}
+	if _, err := s.propertyRepo.GetByID(ctx, config.PropertyID); err != nil {
+		return err
+	}
+	if config.Slabs == nil {
+		config.Slabs = DefaultSlabs()
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	before, err := s.repo.GetConfig(ctx, config.PropertyID)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	config.UpdatedBy = userID
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SaveConfig(ctx, config); err != nil {
+			return err
+		}
+		return s.auditService.RecordChanges(ctx, audit.EntityProperty, config.PropertyID, audit.ActionUpdate, audit.Diff(before, config))
+	})
+}
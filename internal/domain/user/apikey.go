@@ -0,0 +1,85 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	"github.com/nevinmanoj/hostmate/internal/domain/organisation"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// CreateAPIKey returns the stored key along with the plain key, which cannot be recovered later
+func (s *userService) CreateAPIKey(ctx context.Context, name, scope string) (*APIKey, string, error) {
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return nil, "", ErrInternal
+	}
+	if !auth.ValidScope(scope) {
+		return nil, "", ErrInvalidAPIKeyScope
+	}
+	// a key acts in the organisation it was created in
+	tenantID, ok := middleware.TenantFromContext(ctx)
+	if !ok {
+		return nil, "", organisation.ErrNoTenant
+	}
+	plain, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		log.Println("Error generating api key:", err)
+		return nil, "", ErrInternal
+	}
+	key := &APIKey{
+		UserID:         userID,
+		OrganisationID: tenantID,
+		Name:           strings.TrimSpace(name),
+		Prefix:         prefix,
+		KeyHash:        auth.HashAPIKey(plain),
+		Scope:          scope,
+	}
+	err = s.apiKeyRepo.Create(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+func (s *userService) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return nil, ErrInternal
+	}
+	return s.apiKeyRepo.GetByUser(ctx, userID)
+}
+
+func (s *userService) RevokeAPIKey(ctx context.Context, id int64) error {
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return ErrInternal
+	}
+	return s.apiKeyRepo.Revoke(ctx, userID, id)
+}
+
+// VerifyAPIKey resolves a key to its owner, organisation and scope, used by the auth middleware
+func (s *userService) VerifyAPIKey(ctx context.Context, key string) (*middleware.APIKeyPrincipal, error) {
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, auth.HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	// last used is informational, a failed update should not reject the request
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, apiKey.ID); err != nil {
+		log.Println("Error updating api key last used:", err)
+	}
+	return &middleware.APIKeyPrincipal{
+		UserID:   apiKey.UserID,
+		TenantID: apiKey.OrganisationID,
+		Scope:    apiKey.Scope,
+	}, nil
+}
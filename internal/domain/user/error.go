@@ -5,8 +5,30 @@ import (
 )
 
 var (
-	ErrNotFound      = errors.New("User not found")
-	ErrInternal      = errors.New("Internal error")
-	ErrUnauthorized  = errors.New("Unauthorized")
-	ErrAlreadyExists = errors.New("User already exists")
+	ErrNotFound                = errors.New("User not found")
+	ErrInternal                = errors.New("Internal error")
+	ErrUnauthorized            = errors.New("Unauthorized")
+	ErrAlreadyExists           = errors.New("User already exists")
+	ErrInvalidCredentials      = errors.New("Invalid credentials")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two factor code")
+	ErrInvalidChallenge        = errors.New("invalid or expired login challenge")
+	ErrTwoFactorNotEnrolled    = errors.New("two factor authentication is not enrolled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two factor authentication is already enabled")
+	ErrTwoFactorMandatory      = errors.New("two factor authentication is mandatory")
+	ErrTooManyAttempts         = errors.New("too many login attempts")
+	ErrAccountLocked           = errors.New("account temporarily locked")
+	ErrSSONotConfigured        = errors.New("single sign-on is not configured")
+	ErrInvalidSSOState         = errors.New("invalid or expired sign-on state")
+	ErrSSOFailed               = errors.New("single sign-on failed")
+	ErrSSODomainNotAllowed     = errors.New("sign-on domain is not allowed")
+	ErrSSOEmailNotVerified     = errors.New("sign-on email is not verified")
+	ErrSSOAccountNotVerified   = errors.New("existing account email is not verified")
+	ErrInvalidAPIKey           = errors.New("invalid api key")
+	ErrInvalidAPIKeyScope      = errors.New("invalid api key scope")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
+	ErrInvalidTimezone         = errors.New("invalid timezone")
+	ErrInvalidEmailToken       = errors.New("invalid or expired email verification token")
+	ErrEmailUnchanged          = errors.New("new email is the current email")
+	ErrAccountDeactivated      = errors.New("account is deactivated")
+	ErrSessionRevoked          = errors.New("session has been revoked")
 )
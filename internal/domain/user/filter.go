@@ -0,0 +1,10 @@
+package user
+
+type UserFilter struct {
+	Query string
+	// VisibleTo limits results to the user and the co-managers of their properties,
+	// nil lists every member of the organisation
+	VisibleTo *int64
+	Limit     int
+	Offset    int
+}
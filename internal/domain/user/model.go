@@ -5,9 +5,122 @@ import (
 )
 
 type User struct {
-	ID           int64     `db:"id"`
-	Name         string    `db:"name"`
-	Email        string    `db:"email"`
-	PasswordHash string    `db:"password_hash"`
+	ID           int64   `db:"id"`
+	Name         string  `db:"name"`
+	Email        string  `db:"email"`
+	PasswordHash string  `db:"password_hash"`
+	TOTPSecret   *string `db:"totp_secret"`
+	TOTPEnabled  bool    `db:"totp_enabled"`
+	// TOTPLastStep is the time step of the last accepted code, codes cannot be replayed
+	TOTPLastStep *int64    `db:"totp_last_step"`
 	CreatedAt    time.Time `db:"created_at"`
+
+	Phone        *string `db:"phone"`
+	Timezone     string  `db:"timezone"`
+	Language     string  `db:"language"`
+	PendingEmail *string `db:"pending_email"`
+	// EmailVerifiedAt is set once the user proved they read mail at the address,
+	// only then may a single sign-on identity be linked to the account by email
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
+	DeactivatedAt   *time.Time `db:"deactivated_at"`
+	// SessionGeneration is carried by every token issued to the user, bumping it revokes them all
+	SessionGeneration int `db:"session_generation"`
+}
+
+// ProfileUpdate holds the self service profile fields
+type ProfileUpdate struct {
+	Name     string
+	Phone    *string
+	Timezone string
+	Language string
+}
+
+// SecurityPolicy holds the authentication settings of an organisation, managed by its owners.
+// A user is held to the strictest policy of the organisations they belong to.
+type SecurityPolicy struct {
+	OrganisationID   int64     `db:"organisation_id"`
+	RequireTwoFactor bool      `db:"require_two_factor"`
+	UpdatedAt        time.Time `db:"updated_at"`
+	UpdatedBy        *int64    `db:"updated_by"`
+}
+
+// LoginResult carries either a session token or a challenge for the next login step
+type LoginResult struct {
+	Token             string
+	User              *User
+	OrganisationID    int64
+	TwoFactorRequired bool
+	EnrolmentRequired bool
+	ChallengeToken    string
+}
+
+type TwoFactorEnrolment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type LoginOutcome string
+
+const (
+	LoginSuccess          LoginOutcome = "success"
+	LoginInvalidPassword  LoginOutcome = "invalid_password"
+	LoginInvalidTwoFactor LoginOutcome = "invalid_two_factor"
+	LoginThrottled        LoginOutcome = "throttled"
+	LoginLocked           LoginOutcome = "locked"
+)
+
+// LoginClient identifies where a login attempt came from
+type LoginClient struct {
+	IP        string
+	UserAgent string
+}
+
+type LoginAttempt struct {
+	ID        int64        `db:"id"`
+	UserID    *int64       `db:"user_id"`
+	Email     string       `db:"email"`
+	IP        string       `db:"ip"`
+	UserAgent string       `db:"user_agent"`
+	Outcome   LoginOutcome `db:"outcome"`
+	CreatedAt time.Time    `db:"created_at"`
+}
+
+// LoginFailureStats are the failed attempts since the last successful login
+type LoginFailureStats struct {
+	Failures    int        `db:"failures"`
+	LastFailure *time.Time `db:"last_failure"`
+}
+
+// OIDCLoginState is kept server side between redirecting to the issuer and the callback
+type OIDCLoginState struct {
+	State        string    `db:"state"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// UserIdentity links an external OIDC subject to a hostmate user
+type UserIdentity struct {
+	ID          int64      `db:"id"`
+	UserID      int64      `db:"user_id"`
+	Issuer      string     `db:"issuer"`
+	Subject     string     `db:"subject"`
+	Email       string     `db:"email"`
+	CreatedAt   time.Time  `db:"created_at"`
+	LastLoginAt *time.Time `db:"last_login_at"`
+}
+
+// APIKey is a personal key for integrations, the key itself is only shown once on creation
+type APIKey struct {
+	ID             int64      `db:"id"`
+	UserID         int64      `db:"user_id"`
+	OrganisationID int64      `db:"organisation_id"`
+	Name           string     `db:"name"`
+	Prefix         string     `db:"prefix"`
+	KeyHash        string     `db:"key_hash"`
+	Scope          string     `db:"scope"`
+	LastUsedAt     *time.Time `db:"last_used_at"`
+	RevokedAt      *time.Time `db:"revoked_at"`
+	CreatedAt      time.Time  `db:"created_at"`
 }
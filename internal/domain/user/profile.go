@@ -0,0 +1,164 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+)
+
+const emailChangeTokenTTL = 24 * time.Hour
+
+func (s *userService) GetProfile(ctx context.Context) (*User, error) {
+	return s.currentUser(ctx)
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, profile *ProfileUpdate) (*User, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := time.LoadLocation(profile.Timezone); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	profile.Name = strings.TrimSpace(profile.Name)
+	err = s.repo.UpdateProfile(ctx, user.ID, profile)
+	if err != nil {
+		return nil, err
+	}
+	user.Name = profile.Name
+	user.Phone = profile.Phone
+	user.Timezone = profile.Timezone
+	user.Language = profile.Language
+	return user, nil
+}
+
+// RequestEmailChange mails a verification link to the new address, the email only changes once it is confirmed
+func (s *userService) RequestEmailChange(ctx context.Context, newEmail, password string) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if auth.CheckPassword(password, user.PasswordHash) != nil {
+		return ErrInvalidCredentials
+	}
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if newEmail == strings.ToLower(user.Email) {
+		return ErrEmailUnchanged
+	}
+	_, err = s.repo.GetUserByEmail(ctx, newEmail)
+	if err == nil {
+		return ErrAlreadyExists
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	// only the latest requested address can be confirmed, older links stop working
+	err = s.repo.SetPendingEmail(ctx, user.ID, &newEmail)
+	if err != nil {
+		return err
+	}
+	token, err := auth.GeneratePurposeToken(user.ID, newEmail, auth.PurposeEmailChange, user.SessionGeneration, emailChangeTokenTTL, s.keys)
+	if err != nil {
+		log.Println("Error generating email change token:", err)
+		return ErrInternal
+	}
+	link := fmt.Sprintf("%s/users/email/confirm?token=%s", s.baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"Confirm %s as the new email for your hostmate account within 24 hours:\n%s\n\nIf you did not request this change you can ignore this mail.\n",
+		newEmail,
+		link,
+	)
+	err = s.mailer.Send(ctx, newEmail, "Confirm your new email address", body)
+	if err != nil {
+		log.Println("Error sending email change mail:", err)
+		return ErrInternal
+	}
+	// let the current address know in case the account was taken over
+	notice := fmt.Sprintf("A change of your hostmate account email to %s was requested.\n", newEmail)
+	err = s.mailer.Send(ctx, user.Email, "Email change requested", notice)
+	if err != nil {
+		log.Println("Error sending email change notice:", err)
+	}
+	return nil
+}
+
+// LookupEmailChange checks the link in the mail without confirming, the address only changes on ConfirmEmailChange
+func (s *userService) LookupEmailChange(ctx context.Context, token string) (string, error) {
+	_, email, err := s.pendingEmailChange(ctx, token)
+	return email, err
+}
+
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (*User, error) {
+	user, email, err := s.pendingEmailChange(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.UpdateEmail(ctx, user.ID, email)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = email
+	user.PendingEmail = nil
+	return user, nil
+}
+
+// pendingEmailChange is the user a change token was issued to and the address it confirms,
+// the token is only good for the latest requested address
+func (s *userService) pendingEmailChange(ctx context.Context, token string) (*User, string, error) {
+	claims, err := auth.ParseToken(token, s.keys)
+	if err != nil || claims.Purpose != auth.PurposeEmailChange {
+		return nil, "", ErrInvalidEmailToken
+	}
+	user, err := s.repo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, "", ErrInvalidEmailToken
+		}
+		return nil, "", err
+	}
+	if user.PendingEmail == nil || *user.PendingEmail != claims.Email || user.SessionGeneration != claims.Generation {
+		return nil, "", ErrInvalidEmailToken
+	}
+	return user, claims.Email, nil
+}
+
+// ChangePassword signs out every session of the user, the caller's included
+func (s *userService) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if auth.CheckPassword(currentPassword, user.PasswordHash) != nil {
+		return ErrInvalidCredentials
+	}
+	passwordHash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		log.Println("Error hashing password:", err)
+		return ErrInternal
+	}
+	return s.repo.UpdatePassword(ctx, user.ID, passwordHash)
+}
+
+// Deactivate blocks further logins and revokes the account's sessions and api keys, data is kept
+func (s *userService) Deactivate(ctx context.Context, password string) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	// accounts created through single sign-on have no password to confirm with
+	if user.PasswordHash != "" && auth.CheckPassword(password, user.PasswordHash) != nil {
+		return ErrInvalidCredentials
+	}
+	err = s.apiKeyRepo.RevokeAllForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Deactivate(ctx, user.ID)
+}
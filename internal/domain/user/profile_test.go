@@ -0,0 +1,145 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// fakeUserRepo keeps a single user, methods the tests do not need are left to the nil interface
+type fakeUserRepo struct {
+	UserWriteRepository
+	user    User
+	updated *ProfileUpdate
+}
+
+func (r *fakeUserRepo) GetUserByID(ctx context.Context, id int64) (*User, error) {
+	if id != r.user.ID {
+		return nil, ErrNotFound
+	}
+	user := r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepo) UpdateProfile(ctx context.Context, userID int64, profile *ProfileUpdate) error {
+	r.updated = profile
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateEmail(ctx context.Context, userID int64, email string) error {
+	r.user.Email = email
+	r.user.PendingEmail = nil
+	return nil
+}
+
+func TestUpdateProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		want     error
+	}{
+		{"utc", "UTC", nil},
+		{"region", "Asia/Kolkata", nil},
+		{"unknown zone", "Mars/Olympus_Mons", ErrInvalidTimezone},
+		{"offset", "+05:30", ErrInvalidTimezone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepo{user: User{ID: 7, Name: "Asha"}}
+			s := &userService{repo: repo}
+			ctx := context.WithValue(context.Background(), middleware.ContextUserKey, int64(7))
+			user, err := s.UpdateProfile(ctx, &ProfileUpdate{Name: "  Asha Menon ", Timezone: tt.timezone, Language: "en"})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("UpdateProfile = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if repo.updated != nil {
+					t.Error("an invalid profile was stored")
+				}
+				return
+			}
+			if repo.updated == nil || user.Name != "Asha Menon" || user.Timezone != tt.timezone {
+				t.Errorf("user = %+v, want the trimmed name and timezone %s", user, tt.timezone)
+			}
+		})
+	}
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	keys, err := auth.NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := "new@example.com"
+	token := func(userID int64, email, purpose string, ttl time.Duration) string {
+		t.Helper()
+		token, err := auth.GeneratePurposeToken(userID, email, purpose, 0, ttl, keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		pending *string
+		want    error
+	}{
+		{"pending address", token(7, pending, auth.PurposeEmailChange, time.Hour), &pending, nil},
+		{"superseded address", token(7, "older@example.com", auth.PurposeEmailChange, time.Hour), &pending, ErrInvalidEmailToken},
+		{"no change pending", token(7, pending, auth.PurposeEmailChange, time.Hour), nil, ErrInvalidEmailToken},
+		{"expired", token(7, pending, auth.PurposeEmailChange, -time.Minute), &pending, ErrInvalidEmailToken},
+		{"token of another purpose", token(7, pending, auth.PurposeTwoFactorChallenge, time.Hour), &pending, ErrInvalidEmailToken},
+		{"unknown user", token(8, pending, auth.PurposeEmailChange, time.Hour), &pending, ErrInvalidEmailToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepo{user: User{ID: 7, Email: "old@example.com", PendingEmail: tt.pending}}
+			s := &userService{repo: repo, keys: keys}
+			user, err := s.ConfirmEmailChange(context.Background(), tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("ConfirmEmailChange = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if repo.user.Email != "old@example.com" {
+					t.Errorf("email changed to %s", repo.user.Email)
+				}
+				return
+			}
+			if user.Email != pending || repo.user.Email != pending {
+				t.Errorf("email = %s, stored %s, want %s", user.Email, repo.user.Email, pending)
+			}
+		})
+	}
+}
+
+func TestLookupEmailChange(t *testing.T) {
+	keys, err := auth.NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := "new@example.com"
+	token, err := auth.GeneratePurposeToken(7, pending, auth.PurposeEmailChange, 0, time.Hour, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeUserRepo{user: User{ID: 7, Email: "old@example.com", PendingEmail: &pending}}
+	s := &userService{repo: repo, keys: keys}
+
+	// following the link, as mail scanners do, must not confirm the change
+	email, err := s.LookupEmailChange(context.Background(), token)
+	if err != nil || email != pending {
+		t.Fatalf("LookupEmailChange = %q, %v, want %s", email, err, pending)
+	}
+	if repo.user.Email != "old@example.com" || repo.user.PendingEmail == nil {
+		t.Errorf("looking up changed the user to %s, pending %v", repo.user.Email, repo.user.PendingEmail)
+	}
+	if _, err := s.LookupEmailChange(context.Background(), "not-a-token"); !errors.Is(err, ErrInvalidEmailToken) {
+		t.Errorf("LookupEmailChange of a bad token = %v, want %v", err, ErrInvalidEmailToken)
+	}
+}
@@ -2,13 +2,53 @@ package user
 
 import (
 	"context"
+	"time"
 )
 
 type UserWriteRepository interface {
 	UserReadRepository
 	CreateUser(ctx context.Context, email, password, name string) (*User, error)
+	UpdateTwoFactor(ctx context.Context, userID int64, secret *string, enabled bool) error
+	UseTOTPStep(ctx context.Context, userID, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	UpdateSecurityPolicy(ctx context.Context, policy *SecurityPolicy) error
+	UpdateProfile(ctx context.Context, userID int64, profile *ProfileUpdate) error
+	SetPendingEmail(ctx context.Context, userID int64, email *string) error
+	UpdateEmail(ctx context.Context, userID int64, email string) error
+	MarkEmailVerified(ctx context.Context, userID int64) error
+	UpdatePassword(ctx context.Context, userID int64, passwordHash string) error
+	Deactivate(ctx context.Context, userID int64) error
 }
 type UserReadRepository interface {
+	GetAll(ctx context.Context, filter UserFilter) ([]User, int, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetUserByID(ctx context.Context, id int64) (*User, error)
+	GetSecurityPolicy(ctx context.Context, organisationID int64) (*SecurityPolicy, error)
+	RequiresTwoFactor(ctx context.Context, userID int64) (bool, error)
+}
+
+type LoginAttemptRepository interface {
+	Record(ctx context.Context, attempt *LoginAttempt) error
+	GetFailureStats(ctx context.Context, email string, since time.Time) (*LoginFailureStats, error)
+	CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int, error)
+	GetByUser(ctx context.Context, userID int64, email string, limit, offset int) ([]LoginAttempt, int, error)
+}
+
+type IdentityRepository interface {
+	SaveLoginState(ctx context.Context, state *OIDCLoginState) error
+	ConsumeLoginState(ctx context.Context, state string) (*OIDCLoginState, error)
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (*User, error)
+	LinkIdentity(ctx context.Context, identity *UserIdentity) error
+	TouchIdentity(ctx context.Context, issuer, subject string) error
+	CreateExternalUser(ctx context.Context, email, name string) (*User, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	GetByUser(ctx context.Context, userID int64) ([]APIKey, error)
+	Revoke(ctx context.Context, userID, id int64) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+	TouchLastUsed(ctx context.Context, id int64) error
 }
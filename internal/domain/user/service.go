@@ -2,53 +2,538 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/nevinmanoj/hostmate/internal/auth"
+	"github.com/nevinmanoj/hostmate/internal/domain/access"
+	"github.com/nevinmanoj/hostmate/internal/domain/organisation"
+	"github.com/nevinmanoj/hostmate/internal/mail"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+const (
+	totpIssuer        = "hostmate"
+	recoveryCodeCount = 10
+	challengeTokenTTL = 5 * time.Minute
+	enrolmentTokenTTL = 15 * time.Minute
+
+	// failures are counted per account since its last successful login
+	failureWindow = 24 * time.Hour
+	// from the 3rd consecutive failure every attempt waits 1s, 2s, 4s... since the last one
+	backoffThreshold = 3
+	backoffBase      = time.Second
+	backoffMax       = 5 * time.Minute
+	// from the 10th consecutive failure the account is locked after every failure
+	lockoutThreshold = 10
+	lockoutDuration  = 15 * time.Minute
+	// a single client address failing against any accounts is throttled as well
+	ipFailureWindow = 15 * time.Minute
+	ipFailureLimit  = 30
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, email, password, name string) (*User, error)
-	LoginUser(ctx context.Context, email, password string) (string, *User, error)
+	LoginUser(ctx context.Context, email, password string, client LoginClient) (*LoginResult, error)
+	VerifyTwoFactor(ctx context.Context, challengeToken, code string, client LoginClient) (*LoginResult, error)
+	GetLoginHistory(ctx context.Context, limit, offset int) ([]LoginAttempt, int, error)
+	BeginSSOLogin(ctx context.Context) (string, string, error)
+	CompleteSSOLogin(ctx context.Context, state, browserState, code string, client LoginClient) (*LoginResult, error)
 	GetUserByID(ctx context.Context, id int64) (*User, error)
+	SearchUsers(ctx context.Context, filter UserFilter) ([]User, int, error)
+	BeginTwoFactorEnrolment(ctx context.Context) (*TwoFactorEnrolment, error)
+	ConfirmTwoFactorEnrolment(ctx context.Context, code string) (*LoginResult, []string, error)
+	DisableTwoFactor(ctx context.Context, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error)
+	GetSecurityPolicy(ctx context.Context) (*SecurityPolicy, error)
+	UpdateSecurityPolicy(ctx context.Context, policy *SecurityPolicy) error
+	CreateAPIKey(ctx context.Context, name, scope string) (*APIKey, string, error)
+	GetAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	VerifyAPIKey(ctx context.Context, key string) (*middleware.APIKeyPrincipal, error)
+	VerifySession(ctx context.Context, userID int64, generation int) error
+	GetProfile(ctx context.Context) (*User, error)
+	UpdateProfile(ctx context.Context, profile *ProfileUpdate) (*User, error)
+	RequestEmailChange(ctx context.Context, newEmail, password string) error
+	LookupEmailChange(ctx context.Context, token string) (string, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*User, error)
+	ChangePassword(ctx context.Context, currentPassword, newPassword string) error
+	Deactivate(ctx context.Context, password string) error
 }
 
 type userService struct {
-	repo      UserWriteRepository
-	jwtSecret []byte
+	repo          UserWriteRepository
+	attemptRepo   LoginAttemptRepository
+	identityRepo  IdentityRepository
+	apiKeyRepo    APIKeyRepository
+	accessService access.AccessService
+	orgRepo       organisation.OrganisationRepository
+	mailer        mail.Mailer
+	keys          *auth.KeySet
+	sso           SSOConfig
+	baseURL       string
 }
 
-func NewUserService(repo UserWriteRepository, jwtSecret []byte) UserService {
-	return &userService{repo: repo, jwtSecret: jwtSecret}
+func NewUserService(
+	repo UserWriteRepository,
+	attemptRepo LoginAttemptRepository,
+	identityRepo IdentityRepository,
+	apiKeyRepo APIKeyRepository,
+	accessService access.AccessService,
+	orgRepo organisation.OrganisationRepository,
+	mailer mail.Mailer,
+	keys *auth.KeySet,
+	sso SSOConfig,
+	baseURL string) UserService {
+	return &userService{
+		repo:          repo,
+		attemptRepo:   attemptRepo,
+		identityRepo:  identityRepo,
+		apiKeyRepo:    apiKeyRepo,
+		accessService: accessService,
+		orgRepo:       orgRepo,
+		mailer:        mailer,
+		keys:          keys,
+		sso:           sso,
+		baseURL:       baseURL,
+	}
 }
 
 func (s *userService) CreateUser(ctx context.Context, email, password, name string) (*User, error) {
 	return s.repo.CreateUser(ctx, email, password, name)
 }
-func (s *userService) LoginUser(ctx context.Context, email, password string) (string, *User, error) {
-	// Implementation for user login
+func (s *userService) LoginUser(ctx context.Context, email, password string, client LoginClient) (*LoginResult, error) {
+	attemptKey := strings.ToLower(strings.TrimSpace(email))
+	if err := s.checkThrottle(ctx, attemptKey, client); err != nil {
+		return nil, err
+	}
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", nil, err
+		if errors.Is(err, ErrNotFound) {
+			// unknown emails are tracked like real accounts so lockouts do not reveal which exist
+			s.recordAttempt(ctx, nil, attemptKey, client, LoginInvalidPassword)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
 	}
 	err = auth.CheckPassword(password, user.PasswordHash)
 	if err != nil {
-		return "", nil, fmt.Errorf("Invalid credentials")
+		s.recordAttempt(ctx, &user.ID, attemptKey, client, LoginInvalidPassword)
+		return nil, ErrInvalidCredentials
+	}
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+	//password is correct, second factor or enrolment may still be required
+	return s.afterFirstFactor(ctx, user, attemptKey, client)
+}
+
+// afterFirstFactor asks for the second factor when the user has one and for enrolment when
+// one of their organisations makes two factor mandatory, otherwise the session is issued
+func (s *userService) afterFirstFactor(ctx context.Context, user *User, attemptKey string, client LoginClient) (*LoginResult, error) {
+	if user.TOTPEnabled {
+		challenge, err := auth.GeneratePurposeToken(user.ID, user.Email, auth.PurposeTwoFactorChallenge, user.SessionGeneration, challengeTokenTTL, s.keys)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+	s.recordAttempt(ctx, &user.ID, attemptKey, client, LoginSuccess)
+	requireTwoFactor, err := s.repo.RequiresTwoFactor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if requireTwoFactor {
+		challenge, err := auth.GeneratePurposeToken(user.ID, user.Email, auth.PurposeTwoFactorEnrolment, user.SessionGeneration, enrolmentTokenTTL, s.keys)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, EnrolmentRequired: true, ChallengeToken: challenge}, nil
 	}
 
-	// create and issue JWT token
-	token, err := auth.GenerateToken(user.ID, user.Email, s.jwtSecret)
+	return s.issueSession(ctx, user)
+}
+
+func (s *userService) VerifyTwoFactor(ctx context.Context, challengeToken, code string, client LoginClient) (*LoginResult, error) {
+	claims, err := auth.ParseToken(challengeToken, s.keys)
+	if err != nil || claims.Purpose != auth.PurposeTwoFactorChallenge {
+		return nil, ErrInvalidChallenge
+	}
+	email := strings.ToLower(claims.Email)
+	if err := s.checkThrottle(ctx, email, client); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(ctx, claims.UserID)
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	if user.SessionGeneration != claims.Generation {
+		return nil, ErrInvalidChallenge
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.recordAttempt(ctx, &user.ID, email, client, LoginInvalidTwoFactor)
+		}
+		return nil, err
 	}
+	s.recordAttempt(ctx, &user.ID, email, client, LoginSuccess)
+	return s.issueSession(ctx, user)
+}
 
-	return token, user, nil
+func (s *userService) GetLoginHistory(ctx context.Context, limit, offset int) ([]LoginAttempt, int, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.attemptRepo.GetByUser(ctx, user.ID, strings.ToLower(user.Email), limit, offset)
 }
 
 func (s *userService) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	return s.repo.GetUserByEmail(ctx, email)
 }
 
+// GetUserByID returns the caller, a co-manager of one of their properties or,
+// for organisation owners and admins, any member of the organisation
 func (s *userService) GetUserByID(ctx context.Context, id int64) (*User, error) {
+	viewer, err := s.currentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var canAccess bool
+	if viewer.Role.CanManageMembers() {
+		canAccess, err = s.accessService.IsMember(ctx, id)
+	} else {
+		canAccess, err = s.accessService.CanAccessUser(ctx, id, viewer.UserID)
+	}
+	if err != nil {
+		log.Printf("Error checking access to user with id %d: %s", id, err.Error())
+		return nil, ErrInternal
+	}
+	// hidden users look like missing ones so ids cannot be probed
+	if !canAccess && id != viewer.UserID {
+		return nil, ErrNotFound
+	}
 	return s.repo.GetUserByID(ctx, id)
 }
+
+func (s *userService) SearchUsers(ctx context.Context, filter UserFilter) ([]User, int, error) {
+	viewer, err := s.currentMember(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !viewer.Role.CanManageMembers() {
+		filter.VisibleTo = &viewer.UserID
+	}
+	return s.repo.GetAll(ctx, filter)
+}
+
+func (s *userService) BeginTwoFactorEnrolment(ctx context.Context) (*TwoFactorEnrolment, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	// secret is stored disabled until the user proves the authenticator works
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		log.Println("Error generating totp secret:", err)
+		return nil, ErrInternal
+	}
+	err = s.repo.UpdateTwoFactor(ctx, user.ID, &secret, false)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrolment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *userService) ConfirmTwoFactorEnrolment(ctx context.Context, code string) (*LoginResult, []string, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, nil, ErrTwoFactorNotEnrolled
+	}
+	if err := s.useTOTP(ctx, user, code); err != nil {
+		return nil, nil, err
+	}
+	err = s.repo.UpdateTwoFactor(ctx, user.ID, user.TOTPSecret, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	user.TOTPEnabled = true
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// enrolment may have been started with an enrolment token, hand out a full session
+	result, err := s.issueSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, codes, nil
+}
+
+func (s *userService) DisableTwoFactor(ctx context.Context, code string) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnrolled
+	}
+	requireTwoFactor, err := s.repo.RequiresTwoFactor(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if requireTwoFactor {
+		return ErrTwoFactorMandatory
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+	err = s.repo.UpdateTwoFactor(ctx, user.ID, nil, false)
+	if err != nil {
+		return err
+	}
+	return s.repo.ReplaceRecoveryCodes(ctx, user.ID, nil)
+}
+
+func (s *userService) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err := s.useTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// GetSecurityPolicy is the policy of the organisation the request acts in, for its owners and admins
+func (s *userService) GetSecurityPolicy(ctx context.Context) (*SecurityPolicy, error) {
+	member, err := s.currentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !member.Role.CanManageMembers() {
+		return nil, ErrUnauthorized
+	}
+	return s.repo.GetSecurityPolicy(ctx, member.OrganisationID)
+}
+
+// UpdateSecurityPolicy is reserved to owners, it decides how every member has to sign in
+func (s *userService) UpdateSecurityPolicy(ctx context.Context, policy *SecurityPolicy) error {
+	member, err := s.currentMember(ctx)
+	if err != nil {
+		return err
+	}
+	if member.Role != organisation.RoleOwner {
+		return ErrUnauthorized
+	}
+	policy.OrganisationID = member.OrganisationID
+	policy.UpdatedBy = &member.UserID
+	return s.repo.UpdateSecurityPolicy(ctx, policy)
+}
+
+// helpers
+func (s *userService) currentUser(ctx context.Context) (*User, error) {
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return nil, ErrInternal
+	}
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+// currentMember is the caller's membership in the organisation the request acts in
+func (s *userService) currentMember(ctx context.Context) (*organisation.Member, error) {
+	userID, ok := ctx.Value(middleware.ContextUserKey).(int64)
+	if !ok {
+		return nil, ErrInternal
+	}
+	tenantID, ok := middleware.TenantFromContext(ctx)
+	if !ok {
+		return nil, organisation.ErrNoTenant
+	}
+	member, err := s.orgRepo.GetMember(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, organisation.ErrNotMember) {
+			return nil, ErrUnauthorized
+		}
+		return nil, ErrInternal
+	}
+	return member, nil
+}
+
+// checkSecondFactor accepts a current totp code or consumes an unused recovery code
+func (s *userService) checkSecondFactor(ctx context.Context, user *User, code string) error {
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return ErrTwoFactorNotEnrolled
+	}
+	if step, ok := auth.ValidateTOTP(*user.TOTPSecret, code, time.Now()); ok {
+		return s.useTOTPStep(ctx, user, step)
+	}
+	consumed, err := s.repo.ConsumeRecoveryCode(ctx, user.ID, auth.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// useTOTP accepts a current totp code that has not been used before
+func (s *userService) useTOTP(ctx context.Context, user *User, code string) error {
+	if user.TOTPSecret == nil {
+		return ErrInvalidTwoFactorCode
+	}
+	step, ok := auth.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return s.useTOTPStep(ctx, user, step)
+}
+
+func (s *userService) useTOTPStep(ctx context.Context, user *User, step int64) error {
+	used, err := s.repo.UseTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *userService) replaceRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Println("Error generating recovery codes:", err)
+		return nil, ErrInternal
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, auth.HashRecoveryCode(code))
+	}
+	err = s.repo.ReplaceRecoveryCodes(ctx, userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkThrottle rejects attempts for locked accounts, accounts in backoff and noisy addresses
+func (s *userService) checkThrottle(ctx context.Context, email string, client LoginClient) error {
+	now := time.Now()
+	stats, err := s.attemptRepo.GetFailureStats(ctx, email, now.Add(-failureWindow))
+	if err != nil {
+		return err
+	}
+	if stats.LastFailure != nil {
+		if stats.Failures >= lockoutThreshold && now.Before(stats.LastFailure.Add(lockoutDuration)) {
+			s.recordAttempt(ctx, nil, email, client, LoginLocked)
+			return ErrAccountLocked
+		}
+		if stats.Failures >= backoffThreshold {
+			wait := backoffMax
+			if shift := stats.Failures - backoffThreshold; shift < 16 {
+				wait = min(backoffBase<<shift, backoffMax)
+			}
+			if now.Before(stats.LastFailure.Add(wait)) {
+				s.recordAttempt(ctx, nil, email, client, LoginThrottled)
+				return ErrTooManyAttempts
+			}
+		}
+	}
+	if client.IP != "" {
+		ipFailures, err := s.attemptRepo.CountFailuresByIP(ctx, client.IP, now.Add(-ipFailureWindow))
+		if err != nil {
+			return err
+		}
+		if ipFailures >= ipFailureLimit {
+			s.recordAttempt(ctx, nil, email, client, LoginThrottled)
+			return ErrTooManyAttempts
+		}
+	}
+	return nil
+}
+
+// recordAttempt is best effort, a failing audit insert must not block logins
+func (s *userService) recordAttempt(ctx context.Context, userID *int64, email string, client LoginClient, outcome LoginOutcome) {
+	err := s.attemptRepo.Record(ctx, &LoginAttempt{
+		UserID:    userID,
+		Email:     email,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Outcome:   outcome,
+	})
+	if err != nil {
+		log.Println("Error recording login attempt:", err)
+	}
+}
+
+// VerifySession is used by the auth middleware, a token stops working once the account is
+// deactivated or the session generation it was issued in has moved on
+func (s *userService) VerifySession(ctx context.Context, userID int64, generation int) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeactivatedAt != nil {
+		return ErrAccountDeactivated
+	}
+	if user.SessionGeneration != generation {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *userService) issueSession(ctx context.Context, user *User) (*LoginResult, error) {
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+	organisationID, err := s.defaultOrganisation(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	token, err := auth.GenerateToken(user.ID, user.Email, organisationID, user.SessionGeneration, s.keys)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Token: token, User: user, OrganisationID: organisationID}, nil
+}
+
+// defaultOrganisation is the user's oldest membership, users without one get a personal organisation
+func (s *userService) defaultOrganisation(ctx context.Context, user *User) (int64, error) {
+	memberships, err := s.orgRepo.GetForUser(ctx, user.ID)
+	if err != nil {
+		return 0, ErrInternal
+	}
+	if len(memberships) > 0 {
+		return memberships[0].ID, nil
+	}
+	org := &organisation.Organisation{
+		Name:      fmt.Sprintf("%s's organisation", user.Name),
+		CreatedBy: user.ID,
+	}
+	err = s.orgRepo.Create(ctx, org)
+	if err != nil {
+		return 0, ErrInternal
+	}
+	return org.ID, nil
+}
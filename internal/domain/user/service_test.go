@@ -0,0 +1,180 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+	"github.com/nevinmanoj/hostmate/internal/domain/organisation"
+	middleware "github.com/nevinmanoj/hostmate/internal/middleware"
+)
+
+// fakeOrgRepo holds the roles of the members of organisation 3
+type fakeOrgRepo struct {
+	organisation.OrganisationRepository
+	roles map[int64]organisation.Role
+}
+
+func (r *fakeOrgRepo) GetMember(ctx context.Context, organisationID, userID int64) (*organisation.Member, error) {
+	role, ok := r.roles[userID]
+	if organisationID != 3 || !ok {
+		return nil, organisation.ErrNotMember
+	}
+	return &organisation.Member{OrganisationID: organisationID, UserID: userID, Role: role}, nil
+}
+
+// policyRepo records the policy that was saved
+type policyRepo struct {
+	fakeUserRepo
+	saved *SecurityPolicy
+}
+
+func (r *policyRepo) UpdateSecurityPolicy(ctx context.Context, policy *SecurityPolicy) error {
+	r.saved = policy
+	return nil
+}
+
+func TestVerifySession(t *testing.T) {
+	deactivated := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name       string
+		user       User
+		userID     int64
+		generation int
+		want       error
+	}{
+		{"current generation", User{ID: 7, SessionGeneration: 2}, 7, 2, nil},
+		{"issued before a password change", User{ID: 7, SessionGeneration: 2}, 7, 1, ErrSessionRevoked},
+		{"deactivated account", User{ID: 7, SessionGeneration: 2, DeactivatedAt: &deactivated}, 7, 2, ErrAccountDeactivated},
+		{"unknown user", User{ID: 7}, 8, 0, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &userService{repo: &fakeUserRepo{user: tt.user}}
+			if err := s.VerifySession(context.Background(), tt.userID, tt.generation); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySession = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSecurityPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID int64
+		want   error
+	}{
+		{"owner", 1, nil},
+		{"admin", 2, ErrUnauthorized},
+		{"member", 3, ErrUnauthorized},
+		{"another organisation", 4, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &policyRepo{}
+			s := &userService{repo: repo, orgRepo: &fakeOrgRepo{roles: map[int64]organisation.Role{
+				1: organisation.RoleOwner,
+				2: organisation.RoleAdmin,
+				3: organisation.RoleMember,
+			}}}
+			ctx := middleware.WithTenant(context.WithValue(context.Background(), middleware.ContextUserKey, tt.userID), 3)
+			// the organisation comes from the session, whatever the request names
+			err := s.UpdateSecurityPolicy(ctx, &SecurityPolicy{OrganisationID: 9, RequireTwoFactor: true})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("UpdateSecurityPolicy = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if repo.saved != nil {
+					t.Error("the policy was saved")
+				}
+				return
+			}
+			if repo.saved.OrganisationID != 3 || repo.saved.UpdatedBy == nil || *repo.saved.UpdatedBy != 1 {
+				t.Errorf("saved policy of organisation %d by %v, want organisation 3 by user 1", repo.saved.OrganisationID, repo.saved.UpdatedBy)
+			}
+		})
+	}
+}
+
+type fakeProvider struct {
+	IdentityProvider
+	identity auth.OIDCIdentity
+}
+
+func (p *fakeProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*auth.OIDCIdentity, error) {
+	identity := p.identity
+	return &identity, nil
+}
+
+// fakeIdentityRepo has a pending login for state-1 and no linked identities
+type fakeIdentityRepo struct {
+	IdentityRepository
+	linked bool
+}
+
+func (r *fakeIdentityRepo) ConsumeLoginState(ctx context.Context, state string) (*OIDCLoginState, error) {
+	if state != "state-1" {
+		return nil, ErrInvalidSSOState
+	}
+	return &OIDCLoginState{State: state}, nil
+}
+
+func (r *fakeIdentityRepo) GetUserByIdentity(ctx context.Context, issuer, subject string) (*User, error) {
+	return nil, ErrNotFound
+}
+
+func (r *fakeIdentityRepo) LinkIdentity(ctx context.Context, identity *UserIdentity) error {
+	r.linked = true
+	return nil
+}
+
+// emailRepo finds the user it holds by email
+type emailRepo struct {
+	fakeUserRepo
+}
+
+func (r *emailRepo) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	if email != r.user.Email {
+		return nil, ErrNotFound
+	}
+	user := r.user
+	return &user, nil
+}
+
+func TestCompleteSSOLoginRefusesUnsafeLinks(t *testing.T) {
+	identity := auth.OIDCIdentity{Issuer: "https://id.example.com", Subject: "subject-1", Email: "asha@example.com", EmailVerified: true}
+	tests := []struct {
+		name         string
+		browserState string
+		verified     bool
+		want         error
+	}{
+		{"callback without the browser state", "", true, ErrInvalidSSOState},
+		{"callback from another browser", "state-2", true, ErrInvalidSSOState},
+		{"account whose email was never verified", "state-1", false, ErrSSOAccountNotVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{ID: 7, Email: "asha@example.com"}
+			if tt.verified {
+				verifiedAt := time.Now()
+				user.EmailVerifiedAt = &verifiedAt
+			}
+			identityRepo := &fakeIdentityRepo{}
+			s := &userService{
+				repo:         &emailRepo{fakeUserRepo{user: user}},
+				identityRepo: identityRepo,
+				sso:          SSOConfig{Provider: &fakeProvider{identity: identity}},
+			}
+			_, err := s.CompleteSSOLogin(context.Background(), "state-1", tt.browserState, "code", LoginClient{})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("CompleteSSOLogin = %v, want %v", err, tt.want)
+			}
+			if identityRepo.linked {
+				t.Error("the identity was linked")
+			}
+		})
+	}
+}
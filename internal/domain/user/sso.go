@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+)
+
+const ssoStateTTL = 10 * time.Minute
+
+type IdentityProvider interface {
+	Issuer() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*auth.OIDCIdentity, error)
+}
+
+// SSOConfig is optional, a nil Provider disables single sign-on
+type SSOConfig struct {
+	Provider      IdentityProvider
+	AllowedDomain string
+}
+
+// BeginSSOLogin returns the authorization url and the state, the state has to be kept in the
+// browser that started the login and handed back with the callback
+func (s *userService) BeginSSOLogin(ctx context.Context) (string, string, error) {
+	if s.sso.Provider == nil {
+		return "", "", ErrSSONotConfigured
+	}
+	state, err := auth.RandomToken(32)
+	if err != nil {
+		return "", "", ErrInternal
+	}
+	nonce, err := auth.RandomToken(32)
+	if err != nil {
+		return "", "", ErrInternal
+	}
+	verifier, challenge, err := auth.NewPKCEVerifier()
+	if err != nil {
+		return "", "", ErrInternal
+	}
+	err = s.identityRepo.SaveLoginState(ctx, &OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(ssoStateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	authURL, err := s.sso.Provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Println("Error building sign-on url:", err)
+		return "", "", ErrSSOFailed
+	}
+	return authURL, state, nil
+}
+
+// CompleteSSOLogin links the issuer subject to a user, matching verified emails to existing
+// accounts with a verified email and creating password-less accounts otherwise. The callback
+// has to come from the browser that began the login, so a login cannot be planted in another.
+// The user's second factor and the organisation policies apply unless the provider asserts MFA.
+func (s *userService) CompleteSSOLogin(ctx context.Context, state, browserState, code string, client LoginClient) (*LoginResult, error) {
+	if s.sso.Provider == nil {
+		return nil, ErrSSONotConfigured
+	}
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, ErrInvalidSSOState
+	}
+	loginState, err := s.identityRepo.ConsumeLoginState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := s.sso.Provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Println("Error completing sign-on:", err)
+		return nil, ErrSSOFailed
+	}
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if s.sso.AllowedDomain != "" && !domainAllowed(identity, email, s.sso.AllowedDomain) {
+		return nil, ErrSSODomainNotAllowed
+	}
+
+	user, err := s.identityRepo.GetUserByIdentity(ctx, identity.Issuer, identity.Subject)
+	switch {
+	case err == nil:
+		err = s.identityRepo.TouchIdentity(ctx, identity.Issuer, identity.Subject)
+		if err != nil {
+			return nil, err
+		}
+	case errors.Is(err, ErrNotFound):
+		user, err = s.linkOrCreate(ctx, identity, email)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+	if !identity.MFA {
+		return s.afterFirstFactor(ctx, user, email, client)
+	}
+	s.recordAttempt(ctx, &user.ID, email, client, LoginSuccess)
+	return s.issueSession(ctx, user)
+}
+
+// helpers
+func (s *userService) linkOrCreate(ctx context.Context, identity *auth.OIDCIdentity, email string) (*User, error) {
+	// linking by email is only safe when the issuer vouches for the address
+	if email == "" || !identity.EmailVerified {
+		return nil, ErrSSOEmailNotVerified
+	}
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	// an address nobody proved to read may have been registered by someone else
+	if err == nil && user.EmailVerifiedAt == nil {
+		return nil, ErrSSOAccountNotVerified
+	}
+	if errors.Is(err, ErrNotFound) {
+		name := identity.Name
+		if name == "" {
+			name = email
+		}
+		user, err = s.identityRepo.CreateExternalUser(ctx, email, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = s.identityRepo.LinkIdentity(ctx, &UserIdentity{
+		UserID:  user.ID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func domainAllowed(identity *auth.OIDCIdentity, email, domain string) bool {
+	if identity.HostedDomain != "" {
+		return strings.EqualFold(identity.HostedDomain, domain)
+	}
+	return strings.HasSuffix(email, "@"+strings.ToLower(domain))
+}
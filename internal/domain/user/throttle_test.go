@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeAttemptRepo struct {
+	stats      LoginFailureStats
+	ipFailures int
+	recorded   []LoginOutcome
+}
+
+func (r *fakeAttemptRepo) Record(ctx context.Context, attempt *LoginAttempt) error {
+	r.recorded = append(r.recorded, attempt.Outcome)
+	return nil
+}
+
+func (r *fakeAttemptRepo) GetFailureStats(ctx context.Context, email string, since time.Time) (*LoginFailureStats, error) {
+	return &r.stats, nil
+}
+
+func (r *fakeAttemptRepo) CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int, error) {
+	return r.ipFailures, nil
+}
+
+func (r *fakeAttemptRepo) GetByUser(ctx context.Context, userID int64, email string, limit, offset int) ([]LoginAttempt, int, error) {
+	return nil, 0, nil
+}
+
+func TestCheckThrottle(t *testing.T) {
+	ago := func(d time.Duration) *time.Time {
+		at := time.Now().Add(-d)
+		return &at
+	}
+	tests := []struct {
+		name        string
+		failures    int
+		lastFailure *time.Time
+		ipFailures  int
+		want        error
+		wantOutcome LoginOutcome
+	}{
+		{name: "no failures"},
+		{name: "below the backoff threshold", failures: 2, lastFailure: ago(0)},
+		{name: "third failure waits a second", failures: 3, lastFailure: ago(500 * time.Millisecond), want: ErrTooManyAttempts, wantOutcome: LoginThrottled},
+		{name: "third failure after a second", failures: 3, lastFailure: ago(2 * time.Second)},
+		{name: "backoff doubles", failures: 5, lastFailure: ago(3 * time.Second), want: ErrTooManyAttempts, wantOutcome: LoginThrottled},
+		{name: "ninth failure waits a minute", failures: 9, lastFailure: ago(50 * time.Second), want: ErrTooManyAttempts, wantOutcome: LoginThrottled},
+		{name: "ninth failure after a minute", failures: 9, lastFailure: ago(70 * time.Second)},
+		{name: "locked", failures: 10, lastFailure: ago(time.Minute), want: ErrAccountLocked, wantOutcome: LoginLocked},
+		{name: "lock expired", failures: 10, lastFailure: ago(16 * time.Minute)},
+		{name: "noisy address", ipFailures: ipFailureLimit, want: ErrTooManyAttempts, wantOutcome: LoginThrottled},
+		{name: "address below the limit", ipFailures: ipFailureLimit - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAttemptRepo{
+				stats:      LoginFailureStats{Failures: tt.failures, LastFailure: tt.lastFailure},
+				ipFailures: tt.ipFailures,
+			}
+			s := &userService{attemptRepo: repo}
+			err := s.checkThrottle(context.Background(), "guest@example.com", LoginClient{IP: "203.0.113.7"})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("checkThrottle = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil {
+				if len(repo.recorded) != 0 {
+					t.Errorf("recorded %v for an allowed attempt", repo.recorded)
+				}
+				return
+			}
+			if len(repo.recorded) != 1 || repo.recorded[0] != tt.wantOutcome {
+				t.Errorf("recorded %v, want [%s]", repo.recorded, tt.wantOutcome)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"regexp"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// tokenParam matches the bearer tokens links in mails carry
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+// logMailer writes outgoing mail to the service log until a real provider is wired in,
+// tokens in links are redacted so the log never holds a usable link
+type logMailer struct{}
+
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("Mail to:%s subject:%q\n%s", to, subject, redact(body))
+	return nil
+}
+
+// redact replaces the token of every link in the text
+func redact(text string) string {
+	return tokenParam.ReplaceAllString(text, "${1}[redacted]")
+}
@@ -0,0 +1,34 @@
+package mail
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "token is the only parameter",
+			text: "Accept at https://app.example.com/invitations?token=eyJhbGciOi.abc-def_1",
+			want: "Accept at https://app.example.com/invitations?token=[redacted]",
+		},
+		{
+			name: "token among other parameters",
+			text: "https://app.example.com/users/email/confirm?lang=en&token=abc&next=/home\nThanks",
+			want: "https://app.example.com/users/email/confirm?lang=en&token=[redacted]&next=/home\nThanks",
+		},
+		{
+			name: "text without links",
+			text: "Your token expires tomorrow",
+			want: "Your token expires tomorrow",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redact(tt.text); got != tt.want {
+				t.Errorf("redact = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
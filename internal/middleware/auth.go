@@ -2,25 +2,102 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/nevinmanoj/hostmate/internal/auth"
 )
 
-func Authorization(jwtSecret []byte) func(next http.Handler) http.Handler {
+// APIKeyPrincipal is who a personal API key acts as
+type APIKeyPrincipal struct {
+	UserID   int64
+	TenantID int64
+	Scope    string
+}
+
+// APIKeyVerifier resolves a personal API key to its owner, organisation and scope
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+// SessionVerifier checks a JWT has not been revoked since it was issued, e.g. by a password
+// change or the account being deactivated
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, userID int64, generation int) error
+}
+
+// MembershipVerifier checks the user still belongs to the organisation set on the context,
+// a token or key naming an organisation is not proof of membership once it has been revoked
+type MembershipVerifier interface {
+	IsMember(ctx context.Context, userID int64) (bool, error)
+}
+
+// Authorization accepts a session JWT or, when apiKeys is set, a personal API key
+func Authorization(keys *auth.KeySet, apiKeys APIKeyVerifier, sessions SessionVerifier, members MembershipVerifier) func(next http.Handler) http.Handler {
+	sessionAuth := AuthorizationForPurpose(keys, sessions, members, "")
 	return func(next http.Handler) http.Handler {
+		sessionHandler := sessionAuth(next)
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-			var token = r.Header.Get("Authorization")
-			fmt.Println("Token in middleware:", token)
-			claims, err := auth.ParseToken(token, jwtSecret)
+			var token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if apiKeys == nil || !auth.IsAPIKey(token) {
+				sessionHandler.ServeHTTP(w, r)
+				return
+			}
+			principal, err := apiKeys.VerifyAPIKey(r.Context(), token)
 			if err != nil {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
+			ctx := context.WithValue(r.Context(), ContextUserKey, principal.UserID)
+			ctx = context.WithValue(ctx, ContextScopeKey, principal.Scope)
+			ctx = WithTenant(ctx, principal.TenantID)
+			if !isMember(ctx, members, principal.UserID) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AuthorizationForPurpose only accepts JWTs of a user, including purpose scoped tokens, e.g. for
+// two factor enrolment. Any other kind of token, like an invitation, is refused.
+func AuthorizationForPurpose(keys *auth.KeySet, sessions SessionVerifier, members MembershipVerifier, purposes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			var token = r.Header.Get("Authorization")
+			claims, err := auth.ParseToken(strings.TrimPrefix(token, "Bearer "), keys)
+			if err != nil || claims.UserID <= 0 || !slices.Contains(purposes, claims.Purpose) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if err := sessions.VerifySession(r.Context(), claims.UserID, claims.Generation); err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
 			ctx := context.WithValue(r.Context(), ContextUserKey, claims.UserID)
+			if claims.OrganisationID != 0 {
+				ctx = WithTenant(ctx, claims.OrganisationID)
+				if !isMember(ctx, members, claims.UserID) {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// isMember fails closed, a request is only let through on a confirmed membership
+func isMember(ctx context.Context, members MembershipVerifier, userID int64) bool {
+	isMember, err := members.IsMember(ctx, userID)
+	if err != nil {
+		log.Println("Error checking organisation membership:", err)
+		return false
+	}
+	return isMember
+}
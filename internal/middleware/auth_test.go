@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/auth"
+)
+
+type fakeAPIKeys map[string]string
+
+func (f fakeAPIKeys) VerifyAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	scope, ok := f[key]
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+	return &APIKeyPrincipal{UserID: 9, TenantID: 4, Scope: scope}, nil
+}
+
+// fakeSessions holds the current session generation of every user
+type fakeSessions map[int64]int
+
+func (f fakeSessions) VerifySession(ctx context.Context, userID int64, generation int) error {
+	if current, ok := f[userID]; !ok || current != generation {
+		return errors.New("session revoked")
+	}
+	return nil
+}
+
+// fakeMembers lists the users of every organisation
+type fakeMembers map[int64][]int64
+
+func (f fakeMembers) IsMember(ctx context.Context, userID int64) (bool, error) {
+	tenantID, _ := TenantFromContext(ctx)
+	return slices.Contains(f[tenantID], userID), nil
+}
+
+func TestAuthorization(t *testing.T) {
+	keys, err := auth.NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _ := auth.GenerateToken(7, "owner@example.com", 3, 1, keys)
+	revoked, _ := auth.GenerateToken(7, "owner@example.com", 3, 0, keys)
+	challenge, _ := auth.GeneratePurposeToken(7, "owner@example.com", auth.PurposeTwoFactorChallenge, 1, time.Minute, keys)
+	removed, _ := auth.GenerateToken(8, "former@example.com", 3, 0, keys)
+	sessions := fakeSessions{7: 1, 8: 0}
+	apiKeys := fakeAPIKeys{"hm_readonly": auth.ScopeRead}
+	members := fakeMembers{3: {7}, 4: {9}}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantUser   int64
+		wantTenant int64
+		wantScope  string
+	}{
+		{name: "session token", header: "Bearer " + session, wantStatus: http.StatusOK, wantUser: 7, wantTenant: 3},
+		{name: "revoked session", header: "Bearer " + revoked, wantStatus: http.StatusUnauthorized},
+		{name: "removed from the organisation", header: "Bearer " + removed, wantStatus: http.StatusUnauthorized},
+		{name: "api key", header: "Bearer hm_readonly", wantStatus: http.StatusOK, wantUser: 9, wantTenant: 4, wantScope: auth.ScopeRead},
+		{name: "unknown api key", header: "Bearer hm_unknown", wantStatus: http.StatusUnauthorized},
+		{name: "two factor challenge", header: "Bearer " + challenge, wantStatus: http.StatusUnauthorized},
+		{name: "no token", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser, gotTenant int64
+			var gotScope string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser, _ = r.Context().Value(ContextUserKey).(int64)
+				gotTenant, _ = TenantFromContext(r.Context())
+				gotScope, _ = r.Context().Value(ContextScopeKey).(string)
+			})
+			r := httptest.NewRequest(http.MethodGet, "/properties", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			Authorization(keys, apiKeys, sessions, members)(next).ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if gotUser != tt.wantUser || gotTenant != tt.wantTenant || gotScope != tt.wantScope {
+				t.Errorf("context user %d tenant %d scope %q, want %d %d %q", gotUser, gotTenant, gotScope, tt.wantUser, tt.wantTenant, tt.wantScope)
+			}
+		})
+	}
+}
@@ -1,8 +1,22 @@
 package middleware
 
+import "context"
+
 type contextKey string
 
 const (
-	ContextUserKey   contextKey = "userID"
-	ContextjwtSecret contextKey = "jwtSecret"
+	ContextUserKey contextKey = "userID"
+	// ContextScopeKey is only set for API key requests, sessions carry no scope
+	ContextScopeKey contextKey = "scope"
+	// ContextTenantKey is the organisation the request acts in
+	ContextTenantKey contextKey = "tenantID"
 )
+
+func TenantFromContext(ctx context.Context) (int64, bool) {
+	tenantID, ok := ctx.Value(ContextTenantKey).(int64)
+	return tenantID, ok && tenantID != 0
+}
+
+func WithTenant(ctx context.Context, tenantID int64) context.Context {
+	return context.WithValue(ctx, ContextTenantKey, tenantID)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(" 10.0.0.0/8, 192.168.1.5 ,::1,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.5/32", "::1/128"}
+	if len(proxies) != len(want) {
+		t.Fatalf("got %d proxies, want %d", len(proxies), len(want))
+	}
+	for i, network := range proxies {
+		if network.String() != want[i] {
+			t.Errorf("proxy %d = %s, want %s", i, network, want[i])
+		}
+	}
+	for _, list := range []string{"10.0.0.300", "10.0.0.0/33", "proxy.internal"} {
+		if _, err := ParseTrustedProxies(list); err == nil {
+			t.Errorf("ParseTrustedProxies accepted %q", list)
+		}
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "", "203.0.113.7:5000"},
+		{"untrusted peer cannot pick its address", "203.0.113.7:5000", "198.51.100.1", "198.51.100.2", "203.0.113.7:5000"},
+		{"client behind a trusted proxy", "10.0.0.2:443", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed hops before the client are ignored", "10.0.0.2:443", "1.2.3.4, 198.51.100.1, 10.0.0.3", "", "198.51.100.1"},
+		{"every hop a proxy", "10.0.0.2:443", "10.0.0.4, 10.0.0.3", "", "10.0.0.4"},
+		{"garbage in the chain", "10.0.0.2:443", "198.51.100.1, not-an-ip", "", "10.0.0.2:443"},
+		{"real ip header of a trusted proxy", "10.0.0.2:443", "", "198.51.100.1", "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})
+			r := httptest.NewRequest(http.MethodPost, "/users/login", nil)
+			r.RemoteAddr = tt.peer
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			RealIP(trusted)(next).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("remote address = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nevinmanoj/hostmate/internal/domain/invoice"
+	"github.com/nevinmanoj/hostmate/internal/domain/tax"
+)
+
+const (
+	margin    = 50.0
+	lineGap   = 15.0
+	colQty    = 330.0
+	colRate   = 440.0
+	colAmount = PageWidth - margin
+)
+
+type renderer struct{}
+
+func NewRenderer() invoice.Renderer {
+	return &renderer{}
+}
+
+// layout writes top down and starts a new page when the next row does not fit
+type layout struct {
+	doc *Document
+	y   float64
+}
+
+func newLayout() *layout {
+	return &layout{doc: New(), y: PageHeight - margin}
+}
+
+func (l *layout) next(height float64) float64 {
+	if l.y-height < margin {
+		l.doc.AddPage()
+		l.y = PageHeight - margin
+	}
+	l.y -= height
+	return l.y
+}
+
+func (l *layout) rule() {
+	y := l.next(6)
+	l.doc.Line(margin, y, PageWidth-margin, y, 0.5)
+	l.y -= 6
+}
+
+// field writes a label with its value next to it
+func (l *layout) field(label, value string) {
+	y := l.next(lineGap)
+	l.doc.Text(margin, y, 9, true, label)
+	l.doc.Text(margin+110, y, 9, false, Fit(value, PageWidth-2*margin-110, 9, false))
+}
+
+// total writes a label and an amount aligned with the amount column
+func (l *layout) total(label string, amount float64, bold bool) {
+	y := l.next(lineGap)
+	l.doc.TextRight(colRate, y, 9, bold, label)
+	l.doc.TextRight(colAmount, y, 9, bold, money(amount))
+}
+
+func (l *layout) header(title, number string, issuedAt time.Time, organisation string, inv invoiceParty) {
+	if inv.gstin != "" {
+		title = "TAX " + title
+	}
+	y := l.next(20)
+	l.doc.Text(margin, y, 20, true, title)
+	l.doc.TextRight(colAmount, y+6, 9, true, number)
+	l.doc.TextRight(colAmount, y-6, 9, false, "Date: "+date(issuedAt))
+	l.y -= 12
+	y = l.next(lineGap)
+	l.doc.Text(margin, y, 12, true, Fit(organisation, PageWidth-2*margin, 12, true))
+	y = l.next(lineGap)
+	l.doc.Text(margin, y, 9, false, Fit(inv.propertyName, PageWidth-2*margin, 9, false))
+	y = l.next(12)
+	l.doc.Text(margin, y, 9, false, Fit(inv.propertyAddress, PageWidth-2*margin, 9, false))
+	if inv.gstin != "" {
+		y = l.next(12)
+		l.doc.Text(margin, y, 9, false, "GSTIN: "+inv.gstin+"    SAC: "+tax.SACAccommodation)
+	}
+	l.y -= 8
+	l.rule()
+	l.field("Guest", inv.guestName)
+	l.field("Phone", inv.guestPhone)
+	l.field("Booking", "#"+strconv.FormatInt(inv.bookingID, 10))
+	l.field("Stay", fmt.Sprintf("%s to %s", date(inv.checkIn), date(inv.checkOut)))
+	l.field("Guests", strconv.Itoa(inv.guests))
+	l.y -= 8
+}
+
+type invoiceParty struct {
+	gstin           string
+	propertyName    string
+	propertyAddress string
+	guestName       string
+	guestPhone      string
+	bookingID       int64
+	checkIn         time.Time
+	checkOut        time.Time
+	guests          int
+}
+
+func (r *renderer) RenderInvoice(inv *invoice.Invoice) ([]byte, error) {
+	l := newLayout()
+	l.header("INVOICE", inv.Number, inv.IssuedAt, inv.Organisation, invoiceParty{
+		gstin:           inv.GSTIN,
+		propertyName:    inv.Property.Name,
+		propertyAddress: inv.Property.Address,
+		guestName:       inv.Booking.GuestName,
+		guestPhone:      inv.Booking.GuestPhone,
+		bookingID:       inv.Booking.ID,
+		checkIn:         inv.Booking.CheckInDate,
+		checkOut:        inv.Booking.CheckOutDate,
+		guests:          inv.Booking.NumGuests,
+	})
+
+	y := l.next(lineGap)
+	l.doc.Text(margin, y, 9, true, "Description")
+	l.doc.TextRight(colQty, y, 9, true, "Qty")
+	l.doc.TextRight(colRate, y, 9, true, "Rate")
+	l.doc.TextRight(colAmount, y, 9, true, "Amount")
+	l.rule()
+	for _, line := range inv.Charges {
+		writeLine(l, line, 1)
+	}
+	l.rule()
+	l.total("Subtotal", inv.Subtotal, false)
+	for _, line := range inv.Discounts {
+		writeLine(l, line, -1)
+	}
+	if len(inv.Taxes) > 0 {
+		l.total("Taxable value", inv.Taxable, false)
+	}
+	for _, line := range inv.Taxes {
+		// inclusive taxes are already in the charges, they are shown but not added again
+		if inv.TaxInclusive {
+			line.Description += " (included)"
+		}
+		writeLine(l, line, 1)
+	}
+	l.total("Total", inv.Total, true)
+	l.y -= 12
+
+	if len(inv.Payments) > 0 {
+		y = l.next(lineGap)
+		l.doc.Text(margin, y, 9, true, "Payments received")
+		l.rule()
+		for _, pay := range inv.Payments {
+			y = l.next(lineGap)
+			l.doc.Text(margin, y, 9, false, date(pay.Date))
+			l.doc.Text(margin+80, y, 9, false, string(pay.PaymentType))
+			l.doc.Text(margin+170, y, 9, false, Fit(pay.Remarks, colRate-margin-170, 9, false))
+			l.doc.TextRight(colAmount, y, 9, false, money(pay.Amount))
+		}
+		l.rule()
+	}
+	l.total("Paid", inv.Paid, false)
+	l.total("Balance due", inv.Balance, true)
+	footer(l, "This is a computer generated invoice.")
+	return l.doc.Bytes(), nil
+}
+
+func (r *renderer) RenderReceipt(rec *invoice.Receipt) ([]byte, error) {
+	l := newLayout()
+	l.header("RECEIPT", rec.Number, rec.IssuedAt, rec.Organisation, invoiceParty{
+		gstin:           rec.GSTIN,
+		propertyName:    rec.Property.Name,
+		propertyAddress: rec.Property.Address,
+		guestName:       rec.Booking.GuestName,
+		guestPhone:      rec.Booking.GuestPhone,
+		bookingID:       rec.Booking.ID,
+		checkIn:         rec.Booking.CheckInDate,
+		checkOut:        rec.Booking.CheckOutDate,
+		guests:          rec.Booking.NumGuests,
+	})
+
+	y := l.next(22)
+	l.doc.Text(margin, y, 12, true, "Amount received")
+	l.doc.TextRight(colAmount, y, 12, true, "Rs. "+money(rec.Payment.Amount))
+	l.rule()
+	l.field("Paid on", date(rec.Payment.Date))
+	l.field("Method", string(rec.Payment.PaymentType))
+	if rec.Payment.Remarks != "" {
+		l.field("Remarks", rec.Payment.Remarks)
+	}
+	l.y -= 8
+	l.rule()
+	l.total("Booking total", rec.Total, false)
+	l.total("Paid to date", rec.PaidToDate, false)
+	l.total("Balance due", rec.Balance, true)
+	footer(l, "This is a computer generated receipt.")
+	return l.doc.Bytes(), nil
+}
+
+// writeLine prints a charge, or with sign -1 a deduction
+func writeLine(l *layout, line invoice.Line, sign float64) {
+	y := l.next(lineGap)
+	l.doc.Text(margin, y, 9, false, Fit(line.Description, colQty-margin-50, 9, false))
+	if line.Quantity != 0 {
+		l.doc.TextRight(colQty, y, 9, false, strconv.FormatFloat(line.Quantity, 'f', -1, 64))
+	}
+	if line.UnitPrice != 0 {
+		l.doc.TextRight(colRate, y, 9, false, money(line.UnitPrice))
+	}
+	l.doc.TextRight(colAmount, y, 9, false, money(sign*line.Amount))
+}
+
+func footer(l *layout, note string) {
+	l.y -= 24
+	y := l.next(lineGap)
+	l.doc.Text(margin, y, 8, false, note)
+}
+
+func date(t time.Time) string {
+	return t.Format("02 Jan 2006")
+}
+
+// money prints an amount with Indian digit grouping, 12,34,567.50
+func money(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	paise := int64(math.Round(amount * 100))
+	rupees := strconv.FormatInt(paise/100, 10)
+	if len(rupees) > 3 {
+		head, tail := rupees[:len(rupees)-3], rupees[len(rupees)-3:]
+		groups := []string{}
+		for len(head) > 2 {
+			groups = append([]string{head[len(head)-2:]}, groups...)
+			head = head[:len(head)-2]
+		}
+		groups = append([]string{head}, groups...)
+		rupees = strings.Join(groups, ",") + "," + tail
+	}
+	return fmt.Sprintf("%s%s.%02d", sign, rupees, paise%100)
+}
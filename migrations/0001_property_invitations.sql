@@ -0,0 +1,17 @@
+-- Invitations of managers to a property, the token id is the lookup key of the mailed link
+CREATE TABLE IF NOT EXISTS property_invitations (
+    id          BIGSERIAL PRIMARY KEY,
+    property_id BIGINT NOT NULL REFERENCES properties (id),
+    email       TEXT NOT NULL,
+    token_id    TEXT NOT NULL,
+    status      TEXT NOT NULL DEFAULT 'pending',
+    invited_by  BIGINT NOT NULL REFERENCES users (id),
+    accepted_by BIGINT REFERENCES users (id),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS property_invitations_token_id_idx ON property_invitations (token_id);
+CREATE INDEX IF NOT EXISTS property_invitations_property_email_idx ON property_invitations (property_id, email);
@@ -0,0 +1,22 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret TEXT,
+    ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users (id),
+    code_hash  TEXT NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_codes_user_idx ON user_recovery_codes (user_id, code_hash);
+
+-- The deployment wide policy set by admins, a single row with id 1
+CREATE TABLE IF NOT EXISTS security_policy (
+    id                 INTEGER PRIMARY KEY CHECK (id = 1),
+    require_two_factor BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by         BIGINT REFERENCES users (id),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,76 @@
+CREATE TABLE IF NOT EXISTS organisations (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_by BIGINT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS organisation_members (
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    user_id         BIGINT NOT NULL REFERENCES users (id),
+    role            TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organisation_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organisation_members_user_idx ON organisation_members (user_id, created_at);
+
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS organisation_id BIGINT REFERENCES organisations (id);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS organisation_id BIGINT REFERENCES organisations (id);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS organisation_id BIGINT REFERENCES organisations (id);
+ALTER TABLE property_invitations ADD COLUMN IF NOT EXISTS organisation_id BIGINT REFERENCES organisations (id);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organisation_id BIGINT REFERENCES organisations (id);
+
+-- Every existing user gets the personal organisation login would create for them,
+-- properties move into the one of whoever created them and their managers join it
+INSERT INTO organisations (name, created_by)
+SELECT u.name || '''s organisation', u.id
+FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM organisation_members m WHERE m.user_id = u.id);
+
+INSERT INTO organisation_members (organisation_id, user_id, role)
+SELECT o.id, o.created_by, 'owner'
+FROM organisations o
+ON CONFLICT (organisation_id, user_id) DO NOTHING;
+
+UPDATE properties p
+SET organisation_id = (SELECT MIN(o.id) FROM organisations o WHERE o.created_by = p.created_by)
+WHERE p.organisation_id IS NULL;
+
+INSERT INTO organisation_members (organisation_id, user_id, role)
+SELECT DISTINCT p.organisation_id, m.user_id, 'member'
+FROM properties p, UNNEST(p.managers) AS m (user_id)
+WHERE EXISTS (SELECT 1 FROM users u WHERE u.id = m.user_id)
+ON CONFLICT (organisation_id, user_id) DO NOTHING;
+
+UPDATE bookings b
+SET organisation_id = p.organisation_id
+FROM properties p
+WHERE p.id = b.property_id
+  AND b.organisation_id IS NULL;
+
+UPDATE payments pm
+SET organisation_id = b.organisation_id
+FROM bookings b
+WHERE b.id = pm.booking_id
+  AND pm.organisation_id IS NULL;
+
+UPDATE property_invitations i
+SET organisation_id = p.organisation_id
+FROM properties p
+WHERE p.id = i.property_id
+  AND i.organisation_id IS NULL;
+
+UPDATE api_keys k
+SET organisation_id = (SELECT MIN(o.id) FROM organisations o WHERE o.created_by = k.user_id)
+WHERE k.organisation_id IS NULL;
+
+ALTER TABLE properties ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE bookings ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE property_invitations ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE api_keys ALTER COLUMN organisation_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS properties_organisation_idx ON properties (organisation_id);
+CREATE INDEX IF NOT EXISTS bookings_organisation_property_idx ON bookings (organisation_id, property_id);
+CREATE INDEX IF NOT EXISTS payments_organisation_booking_idx ON payments (organisation_id, booking_id);
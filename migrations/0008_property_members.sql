@@ -0,0 +1,13 @@
+-- Capabilities granted on a property to users who do not manage it
+CREATE TABLE IF NOT EXISTS property_members (
+    property_id     BIGINT NOT NULL REFERENCES properties (id),
+    user_id         BIGINT NOT NULL REFERENCES users (id),
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    capabilities    TEXT[] NOT NULL DEFAULT '{}',
+    created_by      BIGINT NOT NULL REFERENCES users (id),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (property_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS property_members_user_idx ON property_members (user_id);
@@ -0,0 +1,14 @@
+-- Append-only, entries are written in the transaction of the change they record
+CREATE TABLE IF NOT EXISTS audit_log (
+    id              BIGSERIAL PRIMARY KEY,
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    actor_id        BIGINT NOT NULL REFERENCES users (id),
+    entity_type     TEXT NOT NULL,
+    entity_id       BIGINT NOT NULL,
+    action          TEXT NOT NULL,
+    changes         JSONB NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (organisation_id, entity_type, entity_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (organisation_id, actor_id, created_at);
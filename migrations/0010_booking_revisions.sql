@@ -0,0 +1,27 @@
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS remarks TEXT NOT NULL DEFAULT '';
+
+-- A copy of the booking terms at every revision, with why and by whom it was amended
+CREATE TABLE IF NOT EXISTS booking_revisions (
+    id                   BIGSERIAL PRIMARY KEY,
+    booking_id           BIGINT NOT NULL REFERENCES bookings (id),
+    organisation_id      BIGINT NOT NULL REFERENCES organisations (id),
+    revision             INTEGER NOT NULL,
+    property_id          BIGINT NOT NULL,
+    manager_id           BIGINT NOT NULL,
+    guest_phone          TEXT NOT NULL,
+    guest_name           TEXT NOT NULL,
+    base_rate            NUMERIC(12, 2) NOT NULL,
+    max_guests_base      INTEGER NOT NULL,
+    extra_rate_per_guest NUMERIC(12, 2) NOT NULL,
+    num_guests           INTEGER NOT NULL,
+    status               TEXT NOT NULL,
+    check_in_date        DATE NOT NULL,
+    check_out_date       DATE NOT NULL,
+    remarks              TEXT NOT NULL DEFAULT '',
+    reason               TEXT NOT NULL DEFAULT '',
+    changed_by           BIGINT NOT NULL REFERENCES users (id),
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (booking_id, revision)
+);
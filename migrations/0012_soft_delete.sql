@@ -0,0 +1,25 @@
+ALTER TABLE properties
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_by BIGINT REFERENCES users (id);
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_by BIGINT REFERENCES users (id);
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_by BIGINT REFERENCES users (id);
+
+-- A deleted booking no longer holds its dates, restoring it fails on the constraint
+-- when the dates were booked again meanwhile
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS no_overlapping_bookings;
+ALTER TABLE bookings ADD CONSTRAINT no_overlapping_bookings
+    EXCLUDE USING gist (
+        property_id WITH =,
+        daterange(check_in_date, check_out_date, '[)') WITH &&
+    )
+    WHERE (deleted_at IS NULL AND status IN ('booked', 'checkedIn'));
+
+CREATE INDEX IF NOT EXISTS properties_live_idx ON properties (organisation_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS bookings_live_idx ON bookings (organisation_id, property_id, check_in_date) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS payments_live_idx ON payments (organisation_id, booking_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- The response of a create request, replayed when the same key is sent again
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id              BIGSERIAL PRIMARY KEY,
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    user_id         BIGINT NOT NULL REFERENCES users (id),
+    idempotency_key TEXT NOT NULL,
+    fingerprint     TEXT NOT NULL,
+    response        BYTEA,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMPTZ,
+    UNIQUE (organisation_id, user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
@@ -0,0 +1,8 @@
+-- The expressions match those of internal/db/postgres/search.go, keep them identical
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS bookings_search_idx ON bookings USING GIN (to_tsvector('simple', guest_name || ' ' || remarks));
+CREATE INDEX IF NOT EXISTS bookings_guest_name_trgm_idx ON bookings USING GIN (guest_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS bookings_guest_phone_trgm_idx ON bookings USING GIN ((regexp_replace(guest_phone, '\D', '', 'g')) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS properties_search_idx ON properties USING GIN (to_tsvector('simple', name || ' ' || address));
+CREATE INDEX IF NOT EXISTS properties_name_trgm_idx ON properties USING GIN (name gin_trgm_ops, address gin_trgm_ops);
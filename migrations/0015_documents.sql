@@ -0,0 +1,28 @@
+-- Numbers run per property and document type, taken under the row lock of the sequence
+CREATE TABLE IF NOT EXISTS document_sequences (
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    property_id     BIGINT NOT NULL REFERENCES properties (id),
+    document_type   TEXT NOT NULL,
+    last_sequence   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (organisation_id, property_id, document_type)
+);
+
+CREATE TABLE IF NOT EXISTS documents (
+    id               BIGSERIAL PRIMARY KEY,
+    organisation_id  BIGINT NOT NULL REFERENCES organisations (id),
+    property_id      BIGINT NOT NULL REFERENCES properties (id),
+    booking_id       BIGINT NOT NULL REFERENCES bookings (id),
+    payment_id       BIGINT REFERENCES payments (id),
+    document_type    TEXT NOT NULL,
+    sequence         BIGINT NOT NULL,
+    number           TEXT NOT NULL,
+    booking_revision INTEGER NOT NULL,
+    total            NUMERIC(12, 2) NOT NULL,
+    balance          NUMERIC(12, 2) NOT NULL,
+    blob_name        TEXT NOT NULL,
+    issued_by        BIGINT NOT NULL REFERENCES users (id),
+    issued_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (organisation_id, property_id, document_type, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS documents_booking_idx ON documents (organisation_id, booking_id, document_type, issued_at);
@@ -0,0 +1,28 @@
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS taxable_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cgst NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS sgst NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS igst NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+-- Bookings made before GST was configured owe their stay without tax
+UPDATE bookings
+SET taxable_amount = (check_out_date - check_in_date)
+        * (base_rate + GREATEST(num_guests - max_guests_base, 0) * extra_rate_per_guest),
+    total_amount = (check_out_date - check_in_date)
+        * (base_rate + GREATEST(num_guests - max_guests_base, 0) * extra_rate_per_guest)
+WHERE total_amount = 0;
+
+-- The GST registration of a property, slabs hold the rate by tariff band
+CREATE TABLE IF NOT EXISTS property_tax_configs (
+    property_id     BIGINT PRIMARY KEY REFERENCES properties (id),
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    gstin           TEXT NOT NULL DEFAULT '',
+    supply          TEXT NOT NULL,
+    inclusive       BOOLEAN NOT NULL DEFAULT FALSE,
+    slabs           JSONB NOT NULL DEFAULT '[]',
+    updated_by      BIGINT NOT NULL REFERENCES users (id),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
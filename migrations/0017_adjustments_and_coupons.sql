@@ -0,0 +1,40 @@
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS charge_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+-- Uses of a coupon are counted from the live adjustments redeeming it, not stored
+CREATE TABLE IF NOT EXISTS coupons (
+    id              BIGSERIAL PRIMARY KEY,
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    code            TEXT NOT NULL,
+    percent         BOOLEAN NOT NULL,
+    value           NUMERIC(12, 2) NOT NULL,
+    property_id     BIGINT REFERENCES properties (id),
+    valid_from      TIMESTAMPTZ,
+    valid_to        TIMESTAMPTZ,
+    max_uses        INTEGER,
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by      BIGINT NOT NULL REFERENCES users (id),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (organisation_id, code)
+);
+
+CREATE TABLE IF NOT EXISTS booking_adjustments (
+    id              BIGSERIAL PRIMARY KEY,
+    organisation_id BIGINT NOT NULL REFERENCES organisations (id),
+    booking_id      BIGINT NOT NULL REFERENCES bookings (id),
+    kind            TEXT NOT NULL,
+    basis           TEXT NOT NULL,
+    value           NUMERIC(12, 2) NOT NULL,
+    coupon_id       BIGINT REFERENCES coupons (id),
+    coupon_code     TEXT,
+    reason          TEXT NOT NULL DEFAULT '',
+    created_by      BIGINT NOT NULL REFERENCES users (id),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at      TIMESTAMPTZ,
+    deleted_by      BIGINT REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS booking_adjustments_booking_idx ON booking_adjustments (organisation_id, booking_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS booking_adjustments_coupon_idx ON booking_adjustments (coupon_id) WHERE deleted_at IS NULL;
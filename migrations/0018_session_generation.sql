@@ -0,0 +1,2 @@
+-- Carried by every token issued to the user, bumping it revokes them all
+ALTER TABLE users ADD COLUMN IF NOT EXISTS session_generation INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+-- The two factor policy is set per organisation by its owners, the deployment wide
+-- policy carries over to every organisation
+CREATE TABLE IF NOT EXISTS organisation_security_policies (
+    organisation_id    BIGINT PRIMARY KEY REFERENCES organisations (id),
+    require_two_factor BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by         BIGINT REFERENCES users (id),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO organisation_security_policies (organisation_id, require_two_factor, updated_by, updated_at)
+SELECT o.id, p.require_two_factor, p.updated_by, p.updated_at
+FROM organisations o
+CROSS JOIN security_policy p
+ON CONFLICT (organisation_id) DO NOTHING;
+
+DROP TABLE IF EXISTS security_policy;
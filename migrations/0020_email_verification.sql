@@ -0,0 +1,3 @@
+-- Set once the user proved they read mail at the address, by confirming an email change
+-- or accepting an invitation, single sign-on only links accounts that have it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- The headers replayed with the stored response
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers JSONB;
@@ -0,0 +1,2 @@
+-- Admins are the owners and admins of an organisation, the deployment wide flag was never read
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;